@@ -15,9 +15,9 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/wallets/{id}/balance": {
-            "get": {
-                "description": "get user balance by id",
+        "/batches": {
+            "post": {
+                "description": "execute a list of operations. In the atomic mode all of them are committed in one transaction or none of them,\noperations requiring manual review fail the atomic batch. In the best_effort mode every operation is committed separately.\nBatches larger than BATCH_MAX_SIZE must be async: they are executed as a job, the report is the artifact of the job by the Location",
                 "consumes": [
                     "application/json"
                 ],
@@ -25,23 +25,43 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "info"
+                    "changing"
                 ],
-                "summary": "Get user balance",
+                "summary": "Execute batch",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "user id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "operations",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.BatchRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "client API key, required if authentication is enabled",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "end user who initiated the batch, stored in the audit log",
+                        "name": "X-User-ID",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "all the operations of the atomic batch are committed, or the best effort batch is executed",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/batch.Report"
+                        }
+                    },
+                    "202": {
+                        "description": "async batch is queued",
+                        "schema": {
+                            "$ref": "#/definitions/jobs.Job"
                         }
                     },
                     "400": {
@@ -50,6 +70,30 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "atomic batch is rolled back, the failed operation has the error",
+                        "schema": {
+                            "$ref": "#/definitions/batch.Report"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -59,45 +103,114 @@ const docTemplate = `{
                 }
             }
         },
-        "/wallets/{id}/history": {
-            "get": {
-                "description": "get user transaction history by id",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+        "/deliveries/{id}/replay": {
+            "post": {
+                "description": "send the delivery again with a new set of attempts, whatever its status is",
                 "tags": [
-                    "info"
+                    "webhooks"
                 ],
-                "summary": "Get user balance history",
+                "summary": "Replay delivery",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "user id",
+                        "description": "delivery id",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "enum": [
-                            "date",
-                            "amount"
-                        ],
                         "type": "string",
-                        "description": "string enums, default: date",
-                        "name": "orderBy",
-                        "in": "query"
+                        "description": "admin API key",
+                        "name": "X-API-Key",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "reports that the process is alive",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs": {
+            "get": {
+                "description": "get the jobs of the client by status, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Get jobs",
+                "parameters": [
                     {
                         "enum": [
-                            "DESC",
-                            "ASC"
+                            "queued",
+                            "running",
+                            "succeeded",
+                            "failed",
+                            "canceled"
                         ],
                         "type": "string",
-                        "description": "string enums, default: DESC",
-                        "name": "order",
+                        "description": "string enums, default: running",
+                        "name": "status",
                         "in": "query"
                     },
                     {
@@ -105,6 +218,12 @@ const docTemplate = `{
                         "description": "default: 100",
                         "name": "limit",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "client API key, required if authentication is enabled",
+                        "name": "X-API-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -113,7 +232,7 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/wallet.HistoryChange"
+                                "$ref": "#/definitions/jobs.Job"
                             }
                         }
                     },
@@ -123,6 +242,18 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -132,37 +263,37 @@ const docTemplate = `{
                 }
             }
         },
-        "/wallets/{id}/transaction": {
-            "patch": {
-                "description": "produce transaction to change user balance. Support replenishment, withdrawal and transfer between users",
-                "consumes": [
+        "/jobs/{id}": {
+            "get": {
+                "description": "get the status of the job, the result is available as the artifact when artifact_type is set. The jobs of the other clients are not found",
+                "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "changing"
+                    "jobs"
                 ],
-                "summary": "Change user balance",
+                "summary": "Get job",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "user id",
+                        "description": "job id",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "info about transaction",
-                        "name": "input",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/api.ChangingBalanceRequest"
-                        }
+                        "type": "string",
+                        "description": "client API key, required if authentication is enabled",
+                        "name": "X-API-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/jobs.Job"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -170,6 +301,24 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -178,51 +327,4147 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "api.ChangingBalanceRequest": {
-            "type": "object",
-            "properties": {
-                "amount": {
-                    "description": "for a transfer must be a positive number, for a not transfer transaction reports whether the operation is a replenishment (positive amount) or withdrawal (negative)",
-                    "type": "number"
-                },
-                "description": {
-                    "description": "required for a not transfer transactions",
-                    "type": "string"
-                },
-                "is_transfer": {
-                    "description": "reports whether transaction is a transfer or not, default false",
-                    "type": "boolean"
-                },
-                "to": {
-                    "description": "required for a transfer",
-                    "type": "integer"
+        },
+        "/jobs/{id}/artifact": {
+            "get": {
+                "description": "download the result of the job, the content type is the artifact_type of the job. The failed or canceled job can have the partial result",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Get job artifact",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "job id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "client API key, required if authentication is enabled",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
                 }
             }
         },
-        "wallet.HistoryChange": {
-            "type": "object",
-            "properties": {
-                "Operation": {
-                    "type": "string",
-                    "enum": [
-                        "replenishment",
-                        "withdrawal"
-                    ],
-                    "x-enum-varnames": [
-                        "Replenishment",
-                        "Withdrawal"
-                    ]
-                },
-                "amount": {
-                    "type": "number"
-                },
-                "date": {
-                    "type": "integer"
-                },
-                "description": {
+        "/jobs/{id}/cancel": {
+            "post": {
+                "description": "cancel the queued job at once, the running one is stopped within JOBS_LEASE and gets the canceled status",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Cancel job",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "job id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "client API key, required if authentication is enabled",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/jobs.Job"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/plans": {
+            "get": {
+                "description": "get the plans, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Get plans",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "default: 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "client API key, required if authentication is enabled",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/subscription.Plan"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "create the plan charging the price at the start of every period. The failed charge is retried at the retry_schedule offsets\nand at the end of the grace period from the start of the unpaid period, then the subscription is suspended. Requires an admin API key",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Create plan",
+                "parameters": [
+                    {
+                        "description": "plan",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.PlanRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "admin API key",
+                        "name": "X-API-Key",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.Plan"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/plans/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Get plan",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "plan id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "client API key, required if authentication is enabled",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.Plan"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "reports whether the database is reachable, its schema is at the expected version and the server is not shutting down",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.HealthResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/api.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/recurring/{id}": {
+            "get": {
+                "description": "get the recurring payment with its progress, scheduled_id is the scheduled operation of the pending occurrence. The payments of the other clients are not found",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scheduled"
+                ],
+                "summary": "Get recurring payment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "recurring payment id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "client API key, required if authentication is enabled",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/recurring.Payment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/recurring/{id}/cancel": {
+            "post": {
+                "description": "cancel the recurring payment and its pending occurrence, the occurrence that is being executed is finished. The payments of the other clients are not found",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scheduled"
+                ],
+                "summary": "Cancel recurring payment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "recurring payment id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "client API key, required if authentication is enabled",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/recurring.Payment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/recurring/{id}/upcoming": {
+            "get": {
+                "description": "get the times of the next executions of the active recurring payment, the pending one first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scheduled"
+                ],
+                "summary": "Get upcoming executions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "recurring payment id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "default: 10, max: 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "client API key, required if authentication is enabled",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.UpcomingExecution"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/reviews": {
+            "get": {
+                "description": "get operations held by risk rules",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reviews"
+                ],
+                "summary": "Get operations held for review",
+                "parameters": [
+                    {
+                        "enum": [
+                            "pending",
+                            "approved",
+                            "rejected"
+                        ],
+                        "type": "string",
+                        "description": "string enums, default: pending",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "default: 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "admin API key",
+                        "name": "X-API-Key",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/risk.Review"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/reviews/{id}/approve": {
+            "post": {
+                "description": "execute the operation held for review, limits and funds are checked again. Requires an admin API key,\nthe operation can not be approved with the API key it was requested with, whatever X-User-ID is sent",
+                "tags": [
+                    "reviews"
+                ],
+                "summary": "Approve operation",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "review id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "admin API key",
+                        "name": "X-API-Key",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "limit_exceeded error or plain text description of other errors",
+                        "schema": {
+                            "$ref": "#/definitions/api.LimitExceededResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/reviews/{id}/reject": {
+            "post": {
+                "description": "reject the operation held for review",
+                "tags": [
+                    "reviews"
+                ],
+                "summary": "Reject operation",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "review id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "admin API key",
+                        "name": "X-API-Key",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/scheduled/{id}": {
+            "get": {
+                "description": "get the operation with its status, the failed one has the reason in the error. The operations of the other clients are not found",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scheduled"
+                ],
+                "summary": "Get scheduled operation",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "scheduled operation id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "client API key, required if authentication is enabled",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/schedule.Operation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/scheduled/{id}/cancel": {
+            "post": {
+                "description": "cancel the operation that is not executed yet. The operations of the other clients are not found",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scheduled"
+                ],
+                "summary": "Cancel scheduled operation",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "scheduled operation id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "client API key, required if authentication is enabled",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/schedule.Operation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}": {
+            "get": {
+                "description": "get the subscription with the paid period and the state of the charge attempts. The subscriptions of the other clients are not found",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Get subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "subscription id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "client API key, required if authentication is enabled",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}/cancel": {
+            "post": {
+                "description": "stop charging the subscription, the paid period is kept. The subscriptions of the other clients are not found",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Cancel subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "subscription id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "client API key, required if authentication is enabled",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/wallets/{id}/audit": {
+            "get": {
+                "description": "check that the user transaction history was not modified or partially deleted",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "info"
+                ],
+                "summary": "Verify user history",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "client API key, required if authentication is enabled",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/audit.Report"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/wallets/{id}/balance": {
+            "get": {
+                "description": "get user balance by id. With details the balance is returned with the credit limit and the funds available for withdrawals",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "info"
+                ],
+                "summary": "Get user balance",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "return api.BalanceResponse instead of the balance string",
+                        "name": "details",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "client API key, required if authentication is enabled",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/wallets/{id}/credit": {
+            "get": {
+                "description": "get the credit limit and the annual overdraft interest rate of the user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "credit"
+                ],
+                "summary": "Get user credit line",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "client API key, required if authentication is enabled",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/credit.Line"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "set the credit limit and the annual overdraft interest rate of the user. Withdrawals are allowed down to the negative limit,\nthe interest for the negative balance at the end of the day is charged for every day from the day it is enabled, since is set by the service. Requires an admin API key",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "credit"
+                ],
+                "summary": "Set user credit line",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "credit line, zero limit disables the overdraft",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/credit.Line"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "admin API key",
+                        "name": "X-API-Key",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/wallets/{id}/events": {
+            "get": {
+                "description": "stream of the wallet changes as Server-Sent Events \"change\" with notify.Event in data, the event ID is the ID of the history record.\nWithout Last-Event-ID the stream starts from the last change with the current balance, with Last-Event-ID the changes made after it are replayed from the history first",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "info"
+                ],
+                "summary": "Subscribe to balance changes",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the last received event, set by the browser on reconnection",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "client API key, required if authentication is enabled",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/notify.Event"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/wallets/{id}/fee": {
+            "get": {
+                "description": "get the fee the withdrawal or transfer from the user would be charged now, nothing is executed",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "fees"
+                ],
+                "summary": "Preview fee",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "withdrawal",
+                            "transfer"
+                        ],
+                        "type": "string",
+                        "description": "string enums",
+                        "name": "operation",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "positive amount of the operation",
+                        "name": "amount",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "client API key, required if authentication is enabled",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/fees.Quote"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/wallets/{id}/history": {
+            "get": {
+                "description": "get user transaction history by id",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "info"
+                ],
+                "summary": "Get user balance history",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "date",
+                            "amount"
+                        ],
+                        "type": "string",
+                        "description": "string enums, default: date",
+                        "name": "orderBy",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "DESC",
+                            "ASC"
+                        ],
+                        "type": "string",
+                        "description": "string enums, default: DESC",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "default: 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "client API key, required if authentication is enabled",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/wallet.HistoryChange"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/wallets/{id}/interest": {
+            "get": {
+                "description": "get the interest accrued for the days of the period and posted to the balance for its months",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "savings"
+                ],
+                "summary": "Get interest report",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "unix time, default: start of the current month",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "unix time, default: now",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "client API key, required if authentication is enabled",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/savings.Report"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/wallets/{id}/limits": {
+            "get": {
+                "description": "get transaction limits set for the user and the limits in effect",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "limits"
+                ],
+                "summary": "Get user limits",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "client API key, required if authentication is enabled",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.LimitsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "set transaction limits of the user, null values are inherited from the global limits. Requires an admin API key",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "limits"
+                ],
+                "summary": "Set user limits",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "user limits",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/limits.Limits"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "admin API key",
+                        "name": "X-API-Key",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/wallets/{id}/recurring": {
+            "get": {
+                "description": "get the recurring payments from the wallet created by the client by status, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scheduled"
+                ],
+                "summary": "Get recurring payments",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "wallet id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "active",
+                            "completed",
+                            "canceled"
+                        ],
+                        "type": "string",
+                        "description": "string enums, default: active",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "default: 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "client API key, required if authentication is enabled",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/recurring.Payment"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "create a transfer from the wallet repeated daily, weekly, monthly or by a cron expression in UTC from start_at until end_at or max_occurrences.\nEvery occurrence is executed as a scheduled operation. A failed occurrence is skipped, or retried with the retry policy",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scheduled"
+                ],
+                "summary": "Create recurring payment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "wallet id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "recurring payment",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.RecurringRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "client API key, required if authentication is enabled",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "end user who created the payment, stored in the audit log",
+                        "name": "X-User-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/recurring.Payment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/wallets/{id}/savings": {
+            "get": {
+                "description": "get the savings product and the annual interest rate of the user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "savings"
+                ],
+                "summary": "Get user savings",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "client API key, required if authentication is enabled",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/savings.Account"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "set the savings product or the annual interest rate of the user, the rate of the user overrides the rate of the product.\nThe interest is accrued daily from the day the savings are enabled and posted to the balance monthly, empty product and null rate disable the savings.\nThe rate must not be greater than 100. Requires an admin API key",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "savings"
+                ],
+                "summary": "Set user savings",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "savings, since is set by the service",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/savings.Account"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "admin API key",
+                        "name": "X-API-Key",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/savings.Account"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/wallets/{id}/scheduled": {
+            "get": {
+                "description": "get the operations of the wallet scheduled by the client by status in the order of execution",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scheduled"
+                ],
+                "summary": "Get scheduled operations",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "wallet id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "scheduled",
+                            "completed",
+                            "pending_review",
+                            "failed",
+                            "canceled"
+                        ],
+                        "type": "string",
+                        "description": "string enums, default: scheduled",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "default: 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "client API key, required if authentication is enabled",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/schedule.Operation"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "schedule a replenishment, withdrawal or transfer from the wallet. It is executed once at execute_at on behalf of the client,\nlimits, funds and risk rules are checked at the execution time. The result is the status of the operation and the scheduled events",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scheduled"
+                ],
+                "summary": "Schedule operation",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "wallet id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "operation",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.ScheduleRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "client API key, required if authentication is enabled",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "end user who scheduled the operation, stored in the audit log",
+                        "name": "X-User-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/schedule.Operation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/wallets/{id}/subscriptions": {
+            "get": {
+                "description": "get the subscriptions of the wallet created by the client by status, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Get subscriptions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "wallet id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "active",
+                            "past_due",
+                            "suspended",
+                            "canceled"
+                        ],
+                        "type": "string",
+                        "description": "string enums, default: active",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "default: 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "client API key, required if authentication is enabled",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/subscription.Subscription"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "subscribe the wallet to the plan, the price is withdrawn at the start of every period from start_at on behalf of the client",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Subscribe",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "wallet id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "subscription",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.SubscriptionRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "client API key, required if authentication is enabled",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "end user who subscribed, stored in the audit log",
+                        "name": "X-User-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/subscription.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/wallets/{id}/tier": {
+            "put": {
+                "description": "set the tier of the user, the fee rules of the tier are applied to the next operations. Requires an admin API key",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "fees"
+                ],
+                "summary": "Set user tier",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "user tier",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.TierRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "admin API key",
+                        "name": "X-API-Key",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/wallets/{id}/transaction": {
+            "patch": {
+                "description": "produce transaction to change user balance. Support replenishment, withdrawal and transfer between users.\nWith dry_run the operation is checked by limits, fees, funds and risk rules in a transaction that is rolled back, the would-be result is returned",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "changing"
+                ],
+                "summary": "Change user balance",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "info about transaction",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.ChangingBalanceRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "client API key, required if authentication is enabled",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "end user who initiated the transaction, stored in the audit log",
+                        "name": "X-User-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "would-be result of the dry run, empty body otherwise",
+                        "schema": {
+                            "$ref": "#/definitions/wallet.Preview"
+                        }
+                    },
+                    "202": {
+                        "description": "operation is held for manual review",
+                        "schema": {
+                            "$ref": "#/definitions/api.ReviewRequiredResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "limit_exceeded error or plain text description of other errors",
+                        "schema": {
+                            "$ref": "#/definitions/api.LimitExceededResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "description": "get the webhooks without secrets",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Get webhooks",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "admin API key",
+                        "name": "X-API-Key",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/webhook.Webhook"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "subscribe the URL to the events of committed operations of all the wallets, so it requires an admin API key.\nThe URL must not point to a private, loopback or link-local address. The secret of the signatures is returned only in this response",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Create webhook",
+                "parameters": [
+                    {
+                        "description": "webhook URL",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.CreateWebhookRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "admin API key",
+                        "name": "X-API-Key",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/webhook.Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}": {
+            "delete": {
+                "description": "unsubscribe the webhook, its pending deliveries are dropped",
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Delete webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "webhook id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "admin API key",
+                        "name": "X-API-Key",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries": {
+            "get": {
+                "description": "get the deliveries of the webhook by status, newest first. Dead deliveries are the dead letters: all the attempts failed",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Get webhook deliveries",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "webhook id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "delivered",
+                            "dead"
+                        ],
+                        "type": "string",
+                        "description": "string enums, default: dead",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "default: 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "admin API key",
+                        "name": "X-API-Key",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/webhook.Delivery"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/replay": {
+            "post": {
+                "description": "send again all the dead deliveries of the webhook with a new set of attempts",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Replay dead deliveries",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "webhook id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "admin API key",
+                        "name": "X-API-Key",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.ReplayResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "api.BatchRequest": {
+            "type": "object",
+            "properties": {
+                "async": {
+                    "description": "execute as a job, required for batches larger than BATCH_MAX_SIZE",
+                    "type": "boolean"
+                },
+                "mode": {
+                    "description": "atomic or best_effort",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/batch.Mode"
+                        }
+                    ]
+                },
+                "operations": {
+                    "description": "executed in order",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/batch.Item"
+                    }
+                }
+            }
+        },
+        "api.ChangingBalanceRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "for a transfer must be a positive number, for a not transfer transaction reports whether the operation is a replenishment (positive amount) or withdrawal (negative)",
+                    "type": "number"
+                },
+                "description": {
+                    "description": "required for a not transfer transactions",
+                    "type": "string"
+                },
+                "dry_run": {
+                    "description": "check the transaction and return the would-be result without changing anything, default false",
+                    "type": "boolean"
+                },
+                "is_transfer": {
+                    "description": "reports whether transaction is a transfer or not, default false",
+                    "type": "boolean"
+                },
+                "to": {
+                    "description": "required for a transfer",
+                    "type": "integer"
+                }
+            }
+        },
+        "api.CreateWebhookRequest": {
+            "type": "object",
+            "properties": {
+                "url": {
+                    "description": "absolute http or https URL receiving POST requests with the events",
+                    "type": "string"
+                }
+            }
+        },
+        "api.HealthResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "reason of unavailability",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "ok or unavailable",
+                    "type": "string"
+                }
+            }
+        },
+        "api.LimitExceededResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "always limit_exceeded",
+                    "type": "string"
+                },
+                "limit": {
+                    "type": "string"
+                },
+                "max": {
+                    "type": "number"
+                },
+                "remaining": {
+                    "type": "number"
+                }
+            }
+        },
+        "api.LimitsResponse": {
+            "type": "object",
+            "properties": {
+                "effective": {
+                    "description": "limits in effect, null values mean no limit",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/limits.Limits"
+                        }
+                    ]
+                },
+                "wallet": {
+                    "description": "limits set for the wallet, null values are inherited from the global limits",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/limits.Limits"
+                        }
+                    ]
+                }
+            }
+        },
+        "api.PlanRequest": {
+            "type": "object",
+            "properties": {
+                "frequency": {
+                    "description": "daily, weekly or monthly",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/recurring.Frequency"
+                        }
+                    ]
+                },
+                "grace_period": {
+                    "description": "seconds after the start of the unpaid period until the last charge attempt",
+                    "type": "integer"
+                },
+                "interval": {
+                    "description": "period is interval days, weeks or months, default 1",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "price": {
+                    "description": "positive number",
+                    "type": "number"
+                },
+                "retry_schedule": {
+                    "description": "seconds after the start of the unpaid period to retry the failed charge at, increasing, within the grace period",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "api.RecurringRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "positive number",
+                    "type": "number"
+                },
+                "cron": {
+                    "description": "\"minute hour day-of-month month day-of-week\" in UTC, required for the cron frequency",
+                    "type": "string"
+                },
+                "end_at": {
+                    "description": "no occurrences after it",
+                    "type": "integer"
+                },
+                "frequency": {
+                    "description": "daily, weekly, monthly or cron",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/recurring.Frequency"
+                        }
+                    ]
+                },
+                "interval": {
+                    "description": "every interval days, weeks or months, default 1",
+                    "type": "integer"
+                },
+                "max_occurrences": {
+                    "description": "0 means no limit",
+                    "type": "integer"
+                },
+                "max_retries": {
+                    "description": "retries of the failed occurrence with the retry policy",
+                    "type": "integer"
+                },
+                "on_failure": {
+                    "description": "skip or retry, default skip",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/recurring.Policy"
+                        }
+                    ]
+                },
+                "retry_interval": {
+                    "description": "seconds between the retries, default 3600",
+                    "type": "integer"
+                },
+                "start_at": {
+                    "description": "Unix timestamp in the future",
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.ReplayResponse": {
+            "type": "object",
+            "properties": {
+                "replayed": {
+                    "description": "number of deliveries queued again",
+                    "type": "integer"
+                }
+            }
+        },
+        "api.ReviewRequiredResponse": {
+            "type": "object",
+            "properties": {
+                "review_id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "always pending",
+                    "type": "string"
+                }
+            }
+        },
+        "api.ScheduleRequest": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "positive number",
+                    "type": "number"
+                },
+                "description": {
+                    "description": "required for replenishment and withdrawal",
+                    "type": "string"
+                },
+                "execute_at": {
+                    "description": "Unix timestamp in the future",
+                    "type": "integer"
+                },
+                "to": {
+                    "description": "required for a transfer",
+                    "type": "integer"
+                },
+                "type": {
+                    "description": "replenishment, withdrawal or transfer",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/risk.Kind"
+                        }
+                    ]
+                }
+            }
+        },
+        "api.SubscriptionRequest": {
+            "type": "object",
+            "properties": {
+                "plan_id": {
+                    "type": "integer"
+                },
+                "start_at": {
+                    "description": "Unix timestamp of the first charge, default now",
+                    "type": "integer"
+                }
+            }
+        },
+        "api.TierRequest": {
+            "type": "object",
+            "properties": {
+                "tier": {
+                    "description": "empty value means the fee rules for any tier",
+                    "type": "string"
+                }
+            }
+        },
+        "api.UpcomingExecution": {
+            "type": "object",
+            "properties": {
+                "execute_at": {
+                    "type": "integer"
+                },
+                "occurrence": {
+                    "description": "number of the occurrence from 1",
+                    "type": "integer"
+                }
+            }
+        },
+        "audit.Report": {
+            "type": "object",
+            "properties": {
+                "broken_at": {
+                    "type": "integer"
+                },
+                "checked": {
+                    "description": "number of sealed records",
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "unsealed": {
+                    "description": "records created before the chain was introduced",
+                    "type": "integer"
+                },
+                "valid": {
+                    "type": "boolean"
+                },
+                "wallet_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "batch.Item": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "positive number",
+                    "type": "number"
+                },
+                "description": {
+                    "description": "required for replenishment and withdrawal",
+                    "type": "string"
+                },
+                "to": {
+                    "description": "recipient of a transfer",
+                    "type": "integer"
+                },
+                "type": {
+                    "description": "replenishment, withdrawal or transfer",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/risk.Kind"
+                        }
+                    ]
+                },
+                "wallet_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "batch.ItemStatus": {
+            "type": "string",
+            "enum": [
+                "completed",
+                "pending_review",
+                "failed",
+                "rolled_back",
+                "canceled"
+            ],
+            "x-enum-comments": {
+                "ItemCanceled": "operation of the best effort batch is not executed as the batch is canceled",
+                "ItemRolledBack": "operation of the failed atomic batch, nothing is changed by it"
+            },
+            "x-enum-varnames": [
+                "ItemCompleted",
+                "ItemPendingReview",
+                "ItemFailed",
+                "ItemRolledBack",
+                "ItemCanceled"
+            ]
+        },
+        "batch.Mode": {
+            "type": "string",
+            "enum": [
+                "atomic",
+                "best_effort"
+            ],
+            "x-enum-comments": {
+                "Atomic": "all the operations are committed in one transaction or none of them",
+                "BestEffort": "every operation is committed separately"
+            },
+            "x-enum-varnames": [
+                "Atomic",
+                "BestEffort"
+            ]
+        },
+        "batch.Report": {
+            "type": "object",
+            "properties": {
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/batch.Result"
+                    }
+                },
+                "status": {
+                    "description": "completed, partial or failed",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/batch.Status"
+                        }
+                    ]
+                }
+            }
+        },
+        "batch.Result": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "review_id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/batch.ItemStatus"
+                }
+            }
+        },
+        "batch.Status": {
+            "type": "string",
+            "enum": [
+                "completed",
+                "partial",
+                "failed"
+            ],
+            "x-enum-comments": {
+                "Completed": "all the operations are committed or held for review",
+                "Failed": "atomic batch is rolled back or no operation of a best effort batch is committed",
+                "Partial": "some operations of a best effort batch failed"
+            },
+            "x-enum-varnames": [
+                "Completed",
+                "Partial",
+                "Failed"
+            ]
+        },
+        "credit.Line": {
+            "type": "object",
+            "properties": {
+                "interest_rate": {
+                    "description": "annual percent charged on the negative balance, 0 means no interest",
+                    "type": "number"
+                },
+                "limit": {
+                    "description": "the lowest allowed balance is -limit, 0 means no overdraft",
+                    "type": "number"
+                },
+                "since": {
+                    "description": "start of the first day the interest is charged for, set when the interest is enabled",
+                    "type": "integer"
+                }
+            }
+        },
+        "fees.Quote": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "amount of the operation",
+                    "type": "number"
+                },
+                "fee": {
+                    "description": "withdrawn from the wallet in addition to the amount",
+                    "type": "number"
+                },
+                "operation": {
+                    "$ref": "#/definitions/risk.Kind"
+                },
+                "rule": {
+                    "description": "rule that set the fee, empty if the operation has no fee",
+                    "type": "string"
+                },
+                "tier": {
+                    "description": "tier of the wallet",
+                    "type": "string"
+                },
+                "total": {
+                    "description": "amount and fee",
+                    "type": "number"
+                }
+            }
+        },
+        "jobs.Job": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "type": "string"
+                },
+                "artifact_type": {
+                    "description": "content type of the artifact, empty if there is none",
+                    "type": "string"
+                },
+                "attempts": {
+                    "type": "integer"
+                },
+                "cancel_requested": {
+                    "description": "running job is stopping",
+                    "type": "boolean"
+                },
+                "created": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "max_attempts": {
+                    "type": "integer"
+                },
+                "run_at": {
+                    "description": "time of the next attempt of the queued job",
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/jobs.Status"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "updated": {
+                    "type": "integer"
+                }
+            }
+        },
+        "jobs.Status": {
+            "type": "string",
+            "enum": [
+                "queued",
+                "running",
+                "succeeded",
+                "failed",
+                "canceled"
+            ],
+            "x-enum-comments": {
+                "Canceled": "canceled before it was finished, the artifact can hold the partial result",
+                "Failed": "all attempts failed"
+            },
+            "x-enum-varnames": [
+                "Queued",
+                "Running",
+                "Succeeded",
+                "Failed",
+                "Canceled"
+            ]
+        },
+        "limits.Limits": {
+            "type": "object",
+            "properties": {
+                "daily_withdrawal": {
+                    "description": "withdrawals and outgoing transfers per calendar day",
+                    "type": "number"
+                },
+                "max_transfer": {
+                    "description": "single transfer",
+                    "type": "number"
+                },
+                "max_withdrawal": {
+                    "description": "single withdrawal",
+                    "type": "number"
+                },
+                "monthly_withdrawal": {
+                    "description": "withdrawals and outgoing transfers per calendar month",
+                    "type": "number"
+                }
+            }
+        },
+        "notify.Event": {
+            "type": "object",
+            "properties": {
+                "balance": {
+                    "description": "balance after the change",
+                    "type": "number"
+                },
+                "change": {
+                    "$ref": "#/definitions/wallet.HistoryChange"
+                },
+                "id": {
+                    "description": "ID of the history record, increases with every change of the wallet",
+                    "type": "integer"
+                },
+                "wallet_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "recurring.Frequency": {
+            "type": "string",
+            "enum": [
+                "daily",
+                "weekly",
+                "monthly",
+                "cron"
+            ],
+            "x-enum-comments": {
+                "Monthly": "on the day of the start date, on the last day of shorter months"
+            },
+            "x-enum-varnames": [
+                "Daily",
+                "Weekly",
+                "Monthly",
+                "Cron"
+            ]
+        },
+        "recurring.Payment": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "description": "client who created the payment, it is the actor of the occurrences",
+                    "type": "string"
+                },
+                "amount": {
+                    "type": "number"
+                },
+                "created": {
+                    "type": "integer"
+                },
+                "cron": {
+                    "description": "\"minute hour day-of-month month day-of-week\", required for the cron frequency",
+                    "type": "string"
+                },
+                "end_at": {
+                    "description": "no occurrences after it, 0 means no end date",
+                    "type": "integer"
+                },
+                "frequency": {
+                    "description": "daily, weekly, monthly or cron",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/recurring.Frequency"
+                        }
+                    ]
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "interval": {
+                    "description": "every interval days, weeks or months, default 1",
+                    "type": "integer"
+                },
+                "max_occurrences": {
+                    "description": "0 means no limit",
+                    "type": "integer"
+                },
+                "max_retries": {
+                    "description": "retries of the failed occurrence with the retry policy",
+                    "type": "integer"
+                },
+                "next_at": {
+                    "description": "time of the pending occurrence",
+                    "type": "integer"
+                },
+                "occurrences": {
+                    "description": "finished occurrences, the failed and skipped ones included",
+                    "type": "integer"
+                },
+                "on_failure": {
+                    "description": "skip or retry, default skip",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/recurring.Policy"
+                        }
+                    ]
+                },
+                "retries": {
+                    "description": "retries of the pending occurrence",
+                    "type": "integer"
+                },
+                "retry_at": {
+                    "description": "time of the retry of the pending occurrence",
+                    "type": "integer"
+                },
+                "retry_interval": {
+                    "description": "seconds between the retries, default 1 hour",
+                    "type": "integer"
+                },
+                "scheduled_id": {
+                    "description": "scheduled operation executing the pending occurrence",
+                    "type": "integer"
+                },
+                "start_at": {
+                    "description": "Unix timestamp of the first occurrence or after it",
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/recurring.Status"
+                },
+                "to": {
+                    "type": "integer"
+                },
+                "updated": {
+                    "type": "integer"
+                },
+                "wallet_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "recurring.Policy": {
+            "type": "string",
+            "enum": [
+                "skip",
+                "retry"
+            ],
+            "x-enum-comments": {
+                "Retry": "failed occurrence is retried after the retry interval up to max retries, then skipped",
+                "Skip": "failed occurrence is skipped, the payment continues with the next one"
+            },
+            "x-enum-varnames": [
+                "Skip",
+                "Retry"
+            ]
+        },
+        "recurring.Rule": {
+            "type": "object",
+            "properties": {
+                "cron": {
+                    "description": "\"minute hour day-of-month month day-of-week\", required for the cron frequency",
+                    "type": "string"
+                },
+                "frequency": {
+                    "description": "daily, weekly, monthly or cron",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/recurring.Frequency"
+                        }
+                    ]
+                },
+                "interval": {
+                    "description": "every interval days, weeks or months, default 1",
+                    "type": "integer"
+                }
+            }
+        },
+        "recurring.Status": {
+            "type": "string",
+            "enum": [
+                "active",
+                "completed",
+                "canceled"
+            ],
+            "x-enum-comments": {
+                "Completed": "end date or max occurrences is reached"
+            },
+            "x-enum-varnames": [
+                "Active",
+                "Completed",
+                "Canceled"
+            ]
+        },
+        "risk.Decision": {
+            "type": "string",
+            "enum": [
+                "allow",
+                "review",
+                "deny"
+            ],
+            "x-enum-varnames": [
+                "Allow",
+                "ManualReview",
+                "Deny"
+            ]
+        },
+        "risk.Hit": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "$ref": "#/definitions/risk.Decision"
+                },
+                "rule": {
+                    "type": "string"
+                }
+            }
+        },
+        "risk.Kind": {
+            "type": "string",
+            "enum": [
+                "replenishment",
+                "withdrawal",
+                "transfer"
+            ],
+            "x-enum-varnames": [
+                "KindReplenishment",
+                "KindWithdrawal",
+                "KindTransfer"
+            ]
+        },
+        "risk.Operation": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "kind": {
+                    "$ref": "#/definitions/risk.Kind"
+                },
+                "to": {
+                    "description": "recipient of a transfer",
+                    "type": "integer"
+                },
+                "wallet_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "risk.Review": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "description": "client who requested the operation",
+                    "type": "string"
+                },
+                "created": {
+                    "type": "integer"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "hits": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/risk.Hit"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "operation": {
+                    "$ref": "#/definitions/risk.Operation"
+                },
+                "resolved": {
+                    "type": "integer"
+                },
+                "resolved_by": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/risk.ReviewStatus"
+                }
+            }
+        },
+        "risk.ReviewStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "approved",
+                "rejected"
+            ],
+            "x-enum-varnames": [
+                "Pending",
+                "Approved",
+                "Rejected"
+            ]
+        },
+        "savings.Account": {
+            "type": "object",
+            "properties": {
+                "product": {
+                    "description": "empty value means no product",
+                    "type": "string"
+                },
+                "rate": {
+                    "description": "annual percent of the wallet, null value means the rate of the product",
+                    "type": "number"
+                },
+                "since": {
+                    "description": "start of the first day the interest is accrued for, set when the savings are enabled",
+                    "type": "integer"
+                }
+            }
+        },
+        "savings.Accrual": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "kept with Precision decimal places",
+                    "type": "number"
+                },
+                "balance": {
+                    "description": "balance at the end of the day",
+                    "type": "number"
+                },
+                "day": {
+                    "description": "start of the day",
+                    "type": "integer"
+                },
+                "rate": {
+                    "description": "annual percent",
+                    "type": "number"
+                }
+            }
+        },
+        "savings.Capitalization": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "date": {
+                    "type": "integer"
+                },
+                "history_id": {
+                    "description": "history entry of the replenishment",
+                    "type": "integer"
+                },
+                "month": {
+                    "description": "YYYY-MM",
+                    "type": "string"
+                }
+            }
+        },
+        "savings.Report": {
+            "type": "object",
+            "properties": {
+                "accruals": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/savings.Accrual"
+                    }
+                },
+                "accrued": {
+                    "description": "interest accrued for the days of the period",
+                    "type": "number"
+                },
+                "capitalizations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/savings.Capitalization"
+                    }
+                },
+                "capitalized": {
+                    "description": "interest posted to the balance for the months of the period",
+                    "type": "number"
+                },
+                "from": {
+                    "type": "integer"
+                },
+                "pending": {
+                    "description": "interest accrued and not posted yet, including the previous periods",
+                    "type": "number"
+                },
+                "to": {
+                    "type": "integer"
+                },
+                "wallet_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "schedule.Operation": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "description": "client who scheduled the operation, it is the actor of the history records",
+                    "type": "string"
+                },
+                "amount": {
+                    "description": "positive number",
+                    "type": "number"
+                },
+                "created": {
+                    "type": "integer"
+                },
+                "description": {
+                    "description": "required for replenishment and withdrawal",
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "execute_at": {
+                    "description": "Unix timestamp, the operation is executed at or shortly after it",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "recurring_id": {
+                    "description": "recurring payment the operation is an occurrence of",
+                    "type": "integer"
+                },
+                "review_id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/schedule.Status"
+                },
+                "to": {
+                    "description": "recipient of a transfer",
+                    "type": "integer"
+                },
+                "type": {
+                    "description": "replenishment, withdrawal or transfer",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/risk.Kind"
+                        }
+                    ]
+                },
+                "updated": {
+                    "type": "integer"
+                },
+                "wallet_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "schedule.Status": {
+            "type": "string",
+            "enum": [
+                "scheduled",
+                "completed",
+                "pending_review",
+                "failed",
+                "canceled"
+            ],
+            "x-enum-comments": {
+                "Failed": "rejected at the execution time, e.g. insufficient funds, the reason is in the error",
+                "PendingReview": "held for manual review at the execution time"
+            },
+            "x-enum-varnames": [
+                "Scheduled",
+                "Completed",
+                "PendingReview",
+                "Failed",
+                "Canceled"
+            ]
+        },
+        "subscription.Plan": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "grace_period": {
+                    "description": "seconds after the start of the unpaid period until the last charge attempt, the subscription is past due meanwhile",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "period": {
+                    "description": "daily, weekly or monthly with the interval",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/recurring.Rule"
+                        }
+                    ]
+                },
+                "price": {
+                    "description": "positive number",
+                    "type": "number"
+                },
+                "retry_schedule": {
+                    "description": "seconds after the start of the unpaid period the failed charge is retried at, within the grace period",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "subscription.Status": {
+            "type": "string",
+            "enum": [
+                "active",
+                "past_due",
+                "suspended",
+                "canceled"
+            ],
+            "x-enum-comments": {
+                "PastDue": "the charge failed, it is retried within the grace period",
+                "Suspended": "the charge failed after all the retries, the subscription is not charged anymore"
+            },
+            "x-enum-varnames": [
+                "Active",
+                "PastDue",
+                "Suspended",
+                "Canceled"
+            ]
+        },
+        "subscription.Subscription": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "description": "client who created the subscription, it is the actor of the charges",
+                    "type": "string"
+                },
+                "attempts": {
+                    "description": "failed attempts to charge the due period",
+                    "type": "integer"
+                },
+                "created": {
+                    "type": "integer"
+                },
+                "due_at": {
+                    "description": "start of the period to charge",
+                    "type": "integer"
+                },
+                "grace_until": {
+                    "description": "end of the grace period of the past due subscription",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "description": "reason of the last failed attempt",
+                    "type": "string"
+                },
+                "next_charge_at": {
+                    "description": "time of the next charge attempt",
+                    "type": "integer"
+                },
+                "period_end": {
+                    "description": "end of the paid period",
+                    "type": "integer"
+                },
+                "period_start": {
+                    "description": "start of the paid period",
+                    "type": "integer"
+                },
+                "plan_id": {
+                    "type": "integer"
+                },
+                "start_at": {
+                    "description": "start of the first period, the periods are counted from it",
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/subscription.Status"
+                },
+                "updated": {
+                    "type": "integer"
+                },
+                "wallet_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "wallet.HistoryChange": {
+            "type": "object",
+            "properties": {
+                "Operation": {
+                    "type": "string",
+                    "enum": [
+                        "replenishment",
+                        "withdrawal",
+                        "fee",
+                        "overdraft_interest"
+                    ],
+                    "x-enum-comments": {
+                        "Fee": "withdrawal of the fee for an operation, not counted in the limits and risk rules",
+                        "OverdraftInterest": "charge of the interest for the negative balance, not counted in the limits and risk rules"
+                    },
+                    "x-enum-varnames": [
+                        "Replenishment",
+                        "Withdrawal",
+                        "Fee",
+                        "OverdraftInterest"
+                    ]
+                },
+                "actor": {
+                    "description": "client who triggered the operation",
+                    "type": "string"
+                },
+                "amount": {
+                    "type": "number"
+                },
+                "counterparty": {
+                    "description": "other wallet of a transfer, 0 for replenishment and withdrawal",
+                    "type": "integer"
+                },
+                "date": {
+                    "type": "integer"
+                },
+                "description": {
+                    "type": "string"
+                }
+            }
+        },
+        "wallet.Preview": {
+            "type": "object",
+            "properties": {
+                "balance": {
+                    "description": "balance of the wallet after the operation and the fee",
+                    "type": "number"
+                },
+                "fee": {
+                    "description": "withdrawn in addition to the amount",
+                    "type": "number"
+                },
+                "fee_rule": {
+                    "description": "rule that set the fee",
+                    "type": "string"
+                },
+                "review_required": {
+                    "description": "operation would be held for manual review instead of being executed",
+                    "type": "boolean"
+                }
+            }
+        },
+        "webhook.Delivery": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "event": {
+                    "$ref": "#/definitions/webhook.Event"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "next_attempt": {
+                    "type": "integer"
+                },
+                "status": {
+                    "$ref": "#/definitions/webhook.Status"
+                },
+                "updated": {
+                    "type": "integer"
+                },
+                "webhook_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "webhook.Event": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "data": {
+                    "type": "object"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "webhook.Status": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "delivered",
+                "dead"
+            ],
+            "x-enum-comments": {
+                "Dead": "all attempts failed, the delivery is sent again only on replay"
+            },
+            "x-enum-varnames": [
+                "Pending",
+                "Delivered",
+                "Dead"
+            ]
+        },
+        "webhook.Webhook": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "secret": {
+                    "description": "key of the signature, returned only on creation",
+                    "type": "string"
+                },
+                "url": {
                     "type": "string"
                 }
             }
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/KseniiaSalmina/Balance/internal/batch"
+)
+
+const batchItemBytes = 1024 //max size of an operation in the request body
+
+// @Summary Execute batch
+// @Tags changing
+// @Description execute a list of operations. In the atomic mode all of them are committed in one transaction or none of them,
+// @Description operations requiring manual review fail the atomic batch. In the best_effort mode every operation is committed separately.
+// @Description Batches larger than BATCH_MAX_SIZE must be async: they are executed as a job, the report is the artifact of the job by the Location
+// @Accept json
+// @Produce json
+// @Param input body api.BatchRequest true "operations"
+// @Param X-API-Key header string false "client API key, required if authentication is enabled"
+// @Param X-User-ID header string false "end user who initiated the batch, stored in the audit log"
+// @Success 200 {object} batch.Report "all the operations of the atomic batch are committed, or the best effort batch is executed"
+// @Success 202 {object} jobs.Job "async batch is queued"
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 413 {string} string
+// @Failure 422 {object} batch.Report "atomic batch is rolled back, the failed operation has the error"
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /batches [post]
+func (s *Server) batchHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := s.cfg.Load().Batch
+	r.Body = http.MaxBytesReader(w, r.Body, int64(cfg.MaxAsyncSize)*batchItemBytes)
+
+	var req BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "batch is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "incorrect batch: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch size := len(req.Operations); {
+	case size > cfg.MaxAsyncSize:
+		http.Error(w, fmt.Sprintf("batch is too large, max %d operations", cfg.MaxAsyncSize), http.StatusRequestEntityTooLarge)
+		return
+	case size > cfg.MaxSize && !req.Async:
+		http.Error(w, fmt.Sprintf("batch is too large, max %d operations, use async for larger batches", cfg.MaxSize), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if err := batch.Validate(req.Mode, req.Operations); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if req.Async {
+		job, err := s.bill.SubmitBatch(r.Context(), req.Mode, req.Operations)
+		if err != nil {
+			writeInternalError(w, r, err)
+			return
+		}
+
+		w.Header().Set("Location", fmt.Sprintf("/jobs/%d", job.ID))
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(job)
+		return
+	}
+
+	results, err := s.bill.ExecuteBatch(r.Context(), req.Mode, req.Operations)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	report := batch.NewReport(results)
+	if req.Mode == batch.Atomic && report.Status != batch.Completed {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/KseniiaSalmina/Balance/internal/batch"
+	"github.com/KseniiaSalmina/Balance/internal/config"
+	"github.com/KseniiaSalmina/Balance/internal/jobs"
+	"github.com/KseniiaSalmina/Balance/internal/ratelimit"
+)
+
+// batchBilling fails the operations of wallet 13, the atomic batch is rolled back then
+type batchBilling struct {
+	BillingManager
+}
+
+func (b *batchBilling) ExecuteBatch(ctx context.Context, mode batch.Mode, items []batch.Item) ([]batch.Result, error) {
+	results := make([]batch.Result, len(items))
+	failed := false
+	for i, it := range items {
+		results[i] = batch.Result{Index: i, Status: batch.ItemCompleted}
+		if it.WalletID == 13 {
+			results[i] = batch.Result{Index: i, Status: batch.ItemFailed, Error: "insufficient funds"}
+			failed = true
+		}
+	}
+	for i := range results {
+		if failed && mode == batch.Atomic && results[i].Status == batch.ItemCompleted {
+			results[i].Status = batch.ItemRolledBack
+		}
+	}
+	return results, nil
+}
+
+func (b *batchBilling) SubmitBatch(ctx context.Context, mode batch.Mode, items []batch.Item) (jobs.Job, error) {
+	return jobs.Job{ID: 1, Type: batch.JobType, Status: jobs.Queued, MaxAttempts: 1}, nil
+}
+
+func batchBody(mode batch.Mode, async bool, wallets ...int) string {
+	ops := make([]string, len(wallets))
+	for i, id := range wallets {
+		ops[i] = fmt.Sprintf(`{"type":"replenishment","wallet_id":%d,"amount":"10","description":"salary"}`, id)
+	}
+	return fmt.Sprintf(`{"mode":%q,"async":%t,"operations":[%s]}`, mode, async, strings.Join(ops, ","))
+}
+
+func TestBatch(t *testing.T) {
+	cfg := config.Server{Batch: config.Batch{MaxSize: 2, MaxAsyncSize: 3}}
+	s, err := NewServer(cfg, &batchBilling{}, ratelimit.NewMemory())
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		body         string
+		wantStatus   int
+		wantBatch    string //status of the batch report or the job
+		wantLocation string
+	}{
+		{name: "atomic batch is committed", body: batchBody(batch.Atomic, false, 1, 2), wantStatus: http.StatusOK, wantBatch: string(batch.Completed)},
+		{name: "atomic batch is rolled back", body: batchBody(batch.Atomic, false, 1, 13), wantStatus: http.StatusUnprocessableEntity, wantBatch: string(batch.Failed)},
+		{name: "best effort batch is partial", body: batchBody(batch.BestEffort, false, 1, 13), wantStatus: http.StatusOK, wantBatch: string(batch.Partial)},
+		{name: "large batch must be async", body: batchBody(batch.Atomic, false, 1, 2, 3), wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "async batch", body: batchBody(batch.Atomic, true, 1, 2, 3), wantStatus: http.StatusAccepted, wantBatch: string(jobs.Queued), wantLocation: "/jobs/1"},
+		{name: "too large async batch", body: batchBody(batch.Atomic, true, 1, 2, 3, 4), wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "invalid operation", body: batchBody(batch.Atomic, false, 1, -1), wantStatus: http.StatusBadRequest},
+		{name: "incorrect body", body: `{"mode":`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.httpServer.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/batches", strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantLocation, rec.Header().Get("Location"))
+			if tt.wantBatch == "" {
+				return
+			}
+			var resp struct {
+				Status string `json:"status"`
+			}
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+			assert.Equal(t, tt.wantBatch, resp.Status)
+		})
+	}
+}
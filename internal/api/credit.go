@@ -0,0 +1,85 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/KseniiaSalmina/Balance/internal/credit"
+	"github.com/KseniiaSalmina/Balance/internal/database"
+)
+
+// @Summary Get user credit line
+// @Tags credit
+// @Description get the credit limit and the annual overdraft interest rate of the user
+// @Produce json
+// @Param id path int true "user id"
+// @Param X-API-Key header string false "client API key, required if authentication is enabled"
+// @Success 200 {object} credit.Line
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /wallets/{id}/credit [get]
+func (s *Server) getCreditHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parceID(r)
+	if err != nil {
+		http.Error(w, "incorrect wallet ID: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	l, err := s.bill.CreditLine(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, database.UserDoesNotExistErr) {
+			http.Error(w, database.UserDoesNotExistErr.Error(), http.StatusBadRequest)
+			return
+		}
+		writeInternalError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(l)
+}
+
+// @Summary Set user credit line
+// @Tags credit
+// @Description set the credit limit and the annual overdraft interest rate of the user. Withdrawals are allowed down to the negative limit,
+// @Description the interest for the negative balance at the end of the day is charged for every day from the day it is enabled, since is set by the service. Requires an admin API key
+// @Accept json
+// @Param id path int true "user id"
+// @Param input body credit.Line true "credit line, zero limit disables the overdraft"
+// @Param X-API-Key header string true "admin API key"
+// @Success 200
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 403 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /wallets/{id}/credit [put]
+func (s *Server) setCreditHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parceID(r)
+	if err != nil {
+		http.Error(w, "incorrect wallet ID: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var l credit.Line
+	if err = json.NewDecoder(r.Body).Decode(&l); err != nil {
+		http.Error(w, "incorrect credit line: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = s.bill.SetCreditLine(r.Context(), id, l); err != nil {
+		switch {
+		case errors.Is(err, credit.InvalidLineErr):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, database.UserDoesNotExistErr):
+			http.Error(w, database.UserDoesNotExistErr.Error(), http.StatusBadRequest)
+		default:
+			writeInternalError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"context"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/KseniiaSalmina/Balance/internal/config"
+	"github.com/KseniiaSalmina/Balance/internal/credit"
+	"github.com/KseniiaSalmina/Balance/internal/database"
+	"github.com/KseniiaSalmina/Balance/internal/ratelimit"
+	"github.com/KseniiaSalmina/Balance/internal/wallet"
+)
+
+// creditBilling knows wallet 1 that used 100 of its credit line of 1000
+type creditBilling struct {
+	BillingManager
+}
+
+func (b *creditBilling) WalletBalance(ctx context.Context, id int) (*wallet.Wallet, error) {
+	if id != 1 {
+		return nil, database.UserDoesNotExistErr
+	}
+	return &wallet.Wallet{ID: id, Balance: decimal.NewFromInt(-100), CreditLimit: decimal.NewFromInt(1000)}, nil
+}
+
+func (b *creditBilling) CreditLine(ctx context.Context, id int) (credit.Line, error) {
+	if id != 1 {
+		return credit.Line{}, database.UserDoesNotExistErr
+	}
+	return credit.Line{Limit: decimal.NewFromInt(1000), InterestRate: decimal.NewFromInt(20)}, nil
+}
+
+func (b *creditBilling) SetCreditLine(ctx context.Context, id int, l credit.Line) error {
+	if err := l.Validate(); err != nil {
+		return err
+	}
+	if id != 1 {
+		return database.UserDoesNotExistErr
+	}
+	return nil
+}
+
+func TestCredit(t *testing.T) {
+	s, err := NewServer(config.Server{Auth: config.Auth{AdminKeys: config.APIKeys{"root": "admin"}}}, &creditBilling{}, ratelimit.NewMemory())
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		key        string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "balance details", method: http.MethodGet, path: "/wallets/1/balance?details=true", wantStatus: http.StatusOK, wantBody: `"available_credit":"900","available":"900"`},
+		{name: "balance details of unknown wallet", method: http.MethodGet, path: "/wallets/2/balance?details=true", wantStatus: http.StatusBadRequest},
+		{name: "get credit line", method: http.MethodGet, path: "/wallets/1/credit", wantStatus: http.StatusOK, wantBody: `"interest_rate":"20"`},
+		{name: "set credit line", method: http.MethodPut, path: "/wallets/1/credit", key: "root", body: `{"limit":"500","interest_rate":"18.5"}`, wantStatus: http.StatusOK},
+		{name: "client sets credit line", method: http.MethodPut, path: "/wallets/1/credit", body: `{"limit":"1000000"}`, wantStatus: http.StatusForbidden},
+		{name: "set negative limit", method: http.MethodPut, path: "/wallets/1/credit", key: "root", body: `{"limit":"-500"}`, wantStatus: http.StatusBadRequest},
+		{name: "set credit line of unknown wallet", method: http.MethodPut, path: "/wallets/2/credit", key: "root", body: `{"limit":"500"}`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set(apiKeyHeader, tt.key)
+			rec := httptest.NewRecorder()
+			s.httpServer.Handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.wantBody)
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/KseniiaSalmina/Balance/internal/notify"
+)
+
+const (
+	eventsReplayBatch = 500              //number of missed events read from the history at once
+	eventsHeartbeat   = 15 * time.Second //interval of comments keeping idle connections open through proxies
+	lastEventIDHeader = "Last-Event-ID"
+)
+
+// @Summary Subscribe to balance changes
+// @Tags info
+// @Description stream of the wallet changes as Server-Sent Events "change" with notify.Event in data, the event ID is the ID of the history record.
+// @Description Without Last-Event-ID the stream starts from the last change with the current balance, with Last-Event-ID the changes made after it are replayed from the history first
+// @Produce text/event-stream
+// @Param id path int true "user id"
+// @Param Last-Event-ID header int false "ID of the last received event, set by the browser on reconnection"
+// @Param X-API-Key header string false "client API key, required if authentication is enabled"
+// @Success 200 {object} notify.Event
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /wallets/{id}/events [get]
+func (s *Server) eventsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parceID(r)
+	if err != nil {
+		http.Error(w, "incorrect wallet ID: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	after := int64(-1)
+	if lastID := r.Header.Get(lastEventIDHeader); lastID != "" {
+		after, err = strconv.ParseInt(lastID, 10, 64)
+		if err != nil || after < 0 {
+			http.Error(w, "incorrect Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+	}
+
+	//subscribe before reading the history so that no change is lost between them, repeated ones are skipped by ID
+	sub := s.bill.Subscribe(id)
+	defer sub.Close()
+
+	var missed []notify.Event
+	if after < 0 {
+		last, err := s.bill.LastEvent(r.Context(), id)
+		if err != nil {
+			writeInternalError(w, r, err)
+			return
+		}
+		if last != nil {
+			missed = append(missed, *last)
+		}
+	} else {
+		missed, err = s.bill.Events(r.Context(), id, after, eventsReplayBatch)
+		if err != nil {
+			writeInternalError(w, r, err)
+			return
+		}
+	}
+
+	//the stream is not limited by SERVER_WRITE_TIMEOUT
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	stream := &eventStream{w: w, rc: rc, last: after}
+	for {
+		for _, e := range missed {
+			if err = stream.send(e); err != nil {
+				return
+			}
+		}
+		if len(missed) < eventsReplayBatch {
+			break
+		}
+
+		missed, err = s.bill.Events(r.Context(), id, stream.last, eventsReplayBatch)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "events are not replayed", "error", err)
+			return
+		}
+	}
+	if err = rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(eventsHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case e, ok := <-sub.C:
+			if !ok {
+				//the client is too slow, it reconnects and replays the missed events
+				return
+			}
+			err = stream.send(e)
+		case <-heartbeat.C:
+			err = stream.comment()
+		case <-r.Context().Done():
+			return
+		case <-s.closing:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+type eventStream struct {
+	w    http.ResponseWriter
+	rc   *http.ResponseController
+	last int64 //ID of the last sent event
+}
+
+// send writes the event if it was not sent yet
+func (s *eventStream) send(e notify.Event) error {
+	if e.ID <= s.last {
+		return nil
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("send -> %w", err)
+	}
+	if _, err = fmt.Fprintf(s.w, "id: %d\nevent: change\ndata: %s\n\n", e.ID, data); err != nil {
+		return fmt.Errorf("send -> %w", err)
+	}
+	s.last = e.ID
+	return s.rc.Flush()
+}
+
+func (s *eventStream) comment() error {
+	if _, err := fmt.Fprint(s.w, ":\n\n"); err != nil {
+		return fmt.Errorf("comment -> %w", err)
+	}
+	return s.rc.Flush()
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KseniiaSalmina/Balance/internal/config"
+	"github.com/KseniiaSalmina/Balance/internal/notify"
+	"github.com/KseniiaSalmina/Balance/internal/ratelimit"
+)
+
+// eventsBilling has the history of wallet 1 with changes 1-5
+type eventsBilling struct {
+	BillingManager
+	hub *notify.Hub
+}
+
+func testEvent(id int64) notify.Event {
+	return notify.Event{ID: id, WalletID: 1, Balance: decimal.NewFromInt(id * 10)}
+}
+
+func (b *eventsBilling) Subscribe(id int) *notify.Subscription {
+	return b.hub.Subscribe(id)
+}
+
+func (b *eventsBilling) Events(ctx context.Context, id int, after int64, limit int) ([]notify.Event, error) {
+	events := make([]notify.Event, 0)
+	for i := after + 1; i <= 5 && len(events) < limit; i++ {
+		events = append(events, testEvent(i))
+	}
+	return events, nil
+}
+
+func (b *eventsBilling) LastEvent(ctx context.Context, id int) (*notify.Event, error) {
+	e := testEvent(5)
+	return &e, nil
+}
+
+func TestEvents(t *testing.T) {
+	bill := &eventsBilling{hub: notify.NewHub()}
+	s, err := NewServer(config.Server{}, bill, ratelimit.NewMemory())
+	assert.NoError(t, err)
+	srv := httptest.NewServer(s.httpServer.Handler)
+	defer srv.Close()
+
+	tests := []struct {
+		name        string
+		lastEventID string
+		wantStatus  int
+		wantIDs     []int64
+	}{
+		{name: "from the last change", wantStatus: http.StatusOK, wantIDs: []int64{5, 6}},
+		{name: "replay of the missed changes", lastEventID: "3", wantStatus: http.StatusOK, wantIDs: []int64{4, 5, 6}},
+		{name: "incorrect Last-Event-ID", lastEventID: "last", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/wallets/1/events", nil)
+			assert.NoError(t, err)
+			if tt.lastEventID != "" {
+				req.Header.Set(lastEventIDHeader, tt.lastEventID)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+			//the repeated change is skipped
+			bill.hub.Publish(testEvent(5), testEvent(6))
+
+			ids := make([]int64, 0)
+			scanner := bufio.NewScanner(resp.Body)
+			for len(ids) < len(tt.wantIDs) && scanner.Scan() {
+				data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+				if !ok {
+					continue
+				}
+				var e notify.Event
+				assert.NoError(t, json.Unmarshal([]byte(data), &e))
+				ids = append(ids, e.ID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+		})
+	}
+}
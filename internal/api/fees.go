@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/shopspring/decimal"
+	"net/http"
+
+	"github.com/KseniiaSalmina/Balance/internal/database"
+	"github.com/KseniiaSalmina/Balance/internal/risk"
+)
+
+// @Summary Preview fee
+// @Tags fees
+// @Description get the fee the withdrawal or transfer from the user would be charged now, nothing is executed
+// @Produce json
+// @Param id path int true "user id"
+// @Param operation query string true "string enums" Enums(withdrawal, transfer)
+// @Param amount query number true "positive amount of the operation"
+// @Param X-API-Key header string false "client API key, required if authentication is enabled"
+// @Success 200 {object} fees.Quote
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /wallets/{id}/fee [get]
+func (s *Server) getFeeHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parceID(r)
+	if err != nil {
+		http.Error(w, "incorrect wallet ID: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	kind := risk.Kind(r.FormValue("operation"))
+	if kind != risk.KindWithdrawal && kind != risk.KindTransfer {
+		http.Error(w, "incorrect operation: must be withdrawal or transfer", http.StatusBadRequest)
+		return
+	}
+
+	amount, err := decimal.NewFromString(r.FormValue("amount"))
+	if err != nil || !amount.IsPositive() {
+		http.Error(w, "incorrect amount: must be a positive number", http.StatusBadRequest)
+		return
+	}
+
+	q, err := s.bill.QuoteFee(r.Context(), id, kind, amount)
+	if err != nil {
+		if errors.Is(err, database.UserDoesNotExistErr) {
+			http.Error(w, database.UserDoesNotExistErr.Error(), http.StatusBadRequest)
+			return
+		}
+		writeInternalError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(q)
+}
+
+// @Summary Set user tier
+// @Tags fees
+// @Description set the tier of the user, the fee rules of the tier are applied to the next operations. Requires an admin API key
+// @Accept json
+// @Param id path int true "user id"
+// @Param input body api.TierRequest true "user tier"
+// @Param X-API-Key header string true "admin API key"
+// @Success 200
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 403 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /wallets/{id}/tier [put]
+func (s *Server) setTierHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parceID(r)
+	if err != nil {
+		http.Error(w, "incorrect wallet ID: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req TierRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "incorrect tier: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = s.bill.SetWalletTier(r.Context(), id, req.Tier); err != nil {
+		if errors.Is(err, database.UserDoesNotExistErr) {
+			http.Error(w, database.UserDoesNotExistErr.Error(), http.StatusBadRequest)
+			return
+		}
+		writeInternalError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"context"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/KseniiaSalmina/Balance/internal/config"
+	"github.com/KseniiaSalmina/Balance/internal/database"
+	"github.com/KseniiaSalmina/Balance/internal/fees"
+	"github.com/KseniiaSalmina/Balance/internal/ratelimit"
+	"github.com/KseniiaSalmina/Balance/internal/risk"
+)
+
+// feeBilling knows wallet 1 and charges the fee of 1 for every operation
+type feeBilling struct {
+	BillingManager
+}
+
+func (b *feeBilling) QuoteFee(ctx context.Context, id int, kind risk.Kind, amount decimal.Decimal) (fees.Quote, error) {
+	if id != 1 {
+		return fees.Quote{}, database.UserDoesNotExistErr
+	}
+	fee := decimal.NewFromInt(1)
+	return fees.Quote{Operation: kind, Amount: amount, Fee: fee, Total: amount.Add(fee)}, nil
+}
+
+func (b *feeBilling) SetWalletTier(ctx context.Context, id int, tier string) error {
+	if id != 1 {
+		return database.UserDoesNotExistErr
+	}
+	return nil
+}
+
+func TestFees(t *testing.T) {
+	s, err := NewServer(config.Server{Auth: config.Auth{AdminKeys: config.APIKeys{"root": "admin"}}}, &feeBilling{}, ratelimit.NewMemory())
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		key        string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "preview fee", method: http.MethodGet, path: "/wallets/1/fee?operation=transfer&amount=10.5", wantStatus: http.StatusOK, wantBody: `"total":"11.5"`},
+		{name: "preview fee of replenishment", method: http.MethodGet, path: "/wallets/1/fee?operation=replenishment&amount=10", wantStatus: http.StatusBadRequest},
+		{name: "preview fee of negative amount", method: http.MethodGet, path: "/wallets/1/fee?operation=withdrawal&amount=-10", wantStatus: http.StatusBadRequest},
+		{name: "preview fee of unknown wallet", method: http.MethodGet, path: "/wallets/2/fee?operation=withdrawal&amount=10", wantStatus: http.StatusBadRequest},
+		{name: "set tier", method: http.MethodPut, path: "/wallets/1/tier", key: "root", body: `{"tier":"business"}`, wantStatus: http.StatusOK},
+		{name: "client sets tier", method: http.MethodPut, path: "/wallets/1/tier", body: `{"tier":"business"}`, wantStatus: http.StatusForbidden},
+		{name: "set tier of unknown wallet", method: http.MethodPut, path: "/wallets/2/tier", key: "root", body: `{"tier":"business"}`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set(apiKeyHeader, tt.key)
+			rec := httptest.NewRecorder()
+			s.httpServer.Handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.wantBody)
+		})
+	}
+}
@@ -1,27 +1,35 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/gorilla/mux"
 	"github.com/jackc/pgx"
 	"github.com/shopspring/decimal"
+	"log/slog"
 	"net/http"
 	"strconv"
 
 	"github.com/KseniiaSalmina/Balance/internal/database"
+	"github.com/KseniiaSalmina/Balance/internal/limits"
+	"github.com/KseniiaSalmina/Balance/internal/risk"
 	"github.com/KseniiaSalmina/Balance/internal/wallet"
 )
 
 // @Summary Get user balance
 // @Tags info
-// @Description get user balance by id
+// @Description get user balance by id. With details the balance is returned with the credit limit and the funds available for withdrawals
 // @Accept json
 // @Produce json
 // @Param id path int true "user id"
+// @Param details query bool false "return api.BalanceResponse instead of the balance string"
+// @Param X-API-Key header string false "client API key, required if authentication is enabled"
 // @Success 200 {string} string
 // @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 429 {string} string
 // @Failure 500	{string} string
 // @Router /wallets/{id}/balance [get]
 func (s *Server) getBalanceHandler(w http.ResponseWriter, r *http.Request) {
@@ -31,19 +39,43 @@ func (s *Server) getBalanceHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	balance, err := s.bill.CheckBalance(id)
+	if r.FormValue("details") == "true" {
+		s.getBalanceDetails(w, r, id)
+		return
+	}
+
+	balance, err := s.bill.CheckBalance(r.Context(), id)
 	if err != nil {
 		if errors.Is(err, database.UserDoesNotExistErr) {
 			http.Error(w, database.UserDoesNotExistErr.Error(), http.StatusBadRequest)
 			return
 		}
-		http.Error(w, "internal server error, try again", http.StatusInternalServerError)
+		writeInternalError(w, r, err)
 		return
 	}
 
 	json.NewEncoder(w).Encode(balance)
 }
 
+func (s *Server) getBalanceDetails(w http.ResponseWriter, r *http.Request, id int) {
+	wal, err := s.bill.WalletBalance(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, database.UserDoesNotExistErr) {
+			http.Error(w, database.UserDoesNotExistErr.Error(), http.StatusBadRequest)
+			return
+		}
+		writeInternalError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(BalanceResponse{
+		Balance:         wal.Balance,
+		CreditLimit:     wal.CreditLimit,
+		AvailableCredit: wal.AvailableCredit(),
+		Available:       wal.Available(),
+	})
+}
+
 func parceID(r *http.Request) (int, error) {
 	idStr := mux.Vars(r)["id"]
 	id, err := strconv.Atoi(idStr)
@@ -65,8 +97,11 @@ func parceID(r *http.Request) (int, error) {
 // @Param orderBy query string false "string enums, default: date" Enums(date, amount)
 // @Param order query string false "string enums, default: DESC" Enums(DESC, ASC)
 // @Param limit query int false "default: 100"
+// @Param X-API-Key header string false "client API key, required if authentication is enabled"
 // @Success 200 {array} wallet.HistoryChange
 // @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 429 {string} string
 // @Failure 500	{string} string
 // @Router /wallets/{id}/history [get]
 func (s *Server) getHistoryHandler(w http.ResponseWriter, r *http.Request) {
@@ -96,13 +131,13 @@ func (s *Server) getHistoryHandler(w http.ResponseWriter, r *http.Request) {
 		limit = 100
 	}
 
-	history, err := s.bill.CheckHistory(id, database.OrderBy(orderBy), database.Order(order), limit)
+	history, err := s.bill.CheckHistory(r.Context(), id, database.OrderBy(orderBy), database.Order(order), limit)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			http.Error(w, database.UserDoesNotExistErr.Error(), http.StatusBadRequest)
 			return
 		}
-		http.Error(w, "internal server error, try again", http.StatusInternalServerError)
+		writeInternalError(w, r, err)
 		return
 	}
 
@@ -111,12 +146,20 @@ func (s *Server) getHistoryHandler(w http.ResponseWriter, r *http.Request) {
 
 // @Summary Change user balance
 // @Tags changing
-// @Description produce transaction to change user balance. Support replenishment, withdrawal and transfer between users
+// @Description produce transaction to change user balance. Support replenishment, withdrawal and transfer between users.
+// @Description With dry_run the operation is checked by limits, fees, funds and risk rules in a transaction that is rolled back, the would-be result is returned
 // @Accept json
+// @Produce json
 // @Param id path int true "user id"
 // @Param input body api.ChangingBalanceRequest true "info about transaction"
-// @Success 200
-// @Failure 400 {string} string
+// @Param X-API-Key header string false "client API key, required if authentication is enabled"
+// @Param X-User-ID header string false "end user who initiated the transaction, stored in the audit log"
+// @Success 200 {object} wallet.Preview "would-be result of the dry run, empty body otherwise"
+// @Success 202 {object} api.ReviewRequiredResponse "operation is held for manual review"
+// @Failure 400 {object} api.LimitExceededResponse "limit_exceeded error or plain text description of other errors"
+// @Failure 401 {string} string
+// @Failure 403 {string} string
+// @Failure 429 {string} string
 // @Failure 500	{string} string
 // @Router /wallets/{id}/transaction [patch]
 func (s *Server) moneyTransactionHandler(w http.ResponseWriter, r *http.Request) {
@@ -153,25 +196,277 @@ func (s *Server) moneyTransactionHandler(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
+	if !changing.IsTransfer && changing.Description == "" {
+		http.Error(w, "required description", http.StatusBadRequest)
+		return
+	}
+
+	if changing.DryRun {
+		op := risk.Operation{Kind: risk.Kind(operation), WalletID: id, Amount: changing.Amount}
+		if changing.IsTransfer {
+			op.Kind, op.To = risk.KindTransfer, changing.To
+		}
+
+		preview, err := s.bill.PreviewOperation(r.Context(), op, changing.Description)
+		if err != nil {
+			writeTransactionError(w, r, err)
+			return
+		}
+		json.NewEncoder(w).Encode(preview)
+		return
+	}
+
 	switch changing.IsTransfer {
 	case true:
-		err = s.bill.Transfer(id, changing.To, changing.Amount)
+		err = s.bill.Transfer(r.Context(), id, changing.To, changing.Amount)
 	case false:
-		if changing.Description == "" {
-			http.Error(w, "required description", http.StatusBadRequest)
+		err = s.bill.MoneyTransaction(r.Context(), id, operation, changing.Amount, changing.Description)
+	}
+
+	if err != nil {
+		writeTransactionError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func writeInternalError(w http.ResponseWriter, r *http.Request, err error) {
+	slog.ErrorContext(r.Context(), "request failed", "error", err)
+	http.Error(w, "internal server error, try again", http.StatusInternalServerError)
+}
+
+func writeTransactionError(w http.ResponseWriter, r *http.Request, err error) {
+	var exceeded *limits.ExceededError
+	var review *risk.ReviewRequiredError
+
+	switch {
+	case errors.As(err, &exceeded):
+		writeLimitExceeded(w, exceeded)
+	case errors.As(err, &review):
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(ReviewRequiredResponse{ReviewID: review.ReviewID, Status: string(risk.Pending)})
+	case errors.Is(err, risk.DeniedErr):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, database.UserDoesNotExistErr) || errors.Is(err, wallet.InsufficientFundsErr):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		slog.ErrorContext(r.Context(), "transaction failed", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeLimitExceeded(w http.ResponseWriter, e *limits.ExceededError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(LimitExceededResponse{Error: limits.LimitExceededErr.Error(), Limit: e.Limit, Max: e.Max, Remaining: e.Remaining})
+}
+
+// @Summary Verify user history
+// @Tags info
+// @Description check that the user transaction history was not modified or partially deleted
+// @Produce json
+// @Param id path int true "user id"
+// @Param X-API-Key header string false "client API key, required if authentication is enabled"
+// @Success 200 {object} audit.Report
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /wallets/{id}/audit [get]
+func (s *Server) verifyHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parceID(r)
+	if err != nil {
+		http.Error(w, "incorrect wallet ID: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := s.bill.VerifyHistory(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, database.UserDoesNotExistErr) {
+			http.Error(w, database.UserDoesNotExistErr.Error(), http.StatusBadRequest)
 			return
 		}
-		err = s.bill.MoneyTransaction(id, operation, changing.Amount, changing.Description)
+		writeInternalError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(report)
+}
+
+// @Summary Get user limits
+// @Tags limits
+// @Description get transaction limits set for the user and the limits in effect
+// @Produce json
+// @Param id path int true "user id"
+// @Param X-API-Key header string false "client API key, required if authentication is enabled"
+// @Success 200 {object} api.LimitsResponse
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /wallets/{id}/limits [get]
+func (s *Server) getLimitsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parceID(r)
+	if err != nil {
+		http.Error(w, "incorrect wallet ID: "+err.Error(), http.StatusBadRequest)
+		return
 	}
 
+	own, effective, err := s.bill.WalletLimits(r.Context(), id)
 	if err != nil {
-		if errors.Is(err, database.UserDoesNotExistErr) || errors.Is(err, wallet.InsufficientFundsErr) {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+		if errors.Is(err, database.UserDoesNotExistErr) {
+			http.Error(w, database.UserDoesNotExistErr.Error(), http.StatusBadRequest)
 			return
 		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeInternalError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(LimitsResponse{Wallet: own, Effective: effective})
+}
+
+// @Summary Set user limits
+// @Tags limits
+// @Description set transaction limits of the user, null values are inherited from the global limits. Requires an admin API key
+// @Accept json
+// @Param id path int true "user id"
+// @Param input body limits.Limits true "user limits"
+// @Param X-API-Key header string true "admin API key"
+// @Success 200
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 403 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /wallets/{id}/limits [put]
+func (s *Server) setLimitsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parceID(r)
+	if err != nil {
+		http.Error(w, "incorrect wallet ID: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var l limits.Limits
+	if err = json.NewDecoder(r.Body).Decode(&l); err != nil {
+		http.Error(w, "incorrect limits: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for _, v := range []decimal.NullDecimal{l.MaxWithdrawal, l.MaxTransfer, l.DailyWithdrawal, l.MonthlyWithdrawal} {
+		if v.Valid && v.Decimal.IsNegative() {
+			http.Error(w, "incorrect limits: must not be negative", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err = s.bill.SetWalletLimits(r.Context(), id, l); err != nil {
+		if errors.Is(err, database.UserDoesNotExistErr) {
+			http.Error(w, database.UserDoesNotExistErr.Error(), http.StatusBadRequest)
+			return
+		}
+		writeInternalError(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 }
+
+// @Summary Get operations held for review
+// @Tags reviews
+// @Description get operations held by risk rules
+// @Produce json
+// @Param status query string false "string enums, default: pending" Enums(pending, approved, rejected)
+// @Param limit query int false "default: 100"
+// @Param X-API-Key header string true "admin API key"
+// @Success 200 {array} risk.Review
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 403 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /reviews [get]
+func (s *Server) getReviewsHandler(w http.ResponseWriter, r *http.Request) {
+	status := risk.ReviewStatus(r.FormValue("status"))
+	if status != risk.Approved && status != risk.Rejected {
+		status = risk.Pending
+	}
+
+	limitStr := r.FormValue("limit")
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil && limitStr != "" {
+		http.Error(w, "incorrect limit", http.StatusBadRequest)
+		return
+	}
+	if limitStr == "" {
+		limit = 100
+	}
+
+	reviews, err := s.bill.Reviews(r.Context(), status, limit)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(reviews)
+}
+
+// @Summary Approve operation
+// @Tags reviews
+// @Description execute the operation held for review, limits and funds are checked again. Requires an admin API key,
+// @Description the operation can not be approved with the API key it was requested with, whatever X-User-ID is sent
+// @Param id path int true "review id"
+// @Param X-API-Key header string true "admin API key"
+// @Success 200
+// @Failure 400 {object} api.LimitExceededResponse "limit_exceeded error or plain text description of other errors"
+// @Failure 401 {string} string
+// @Failure 403 {string} string
+// @Failure 404 {string} string
+// @Failure 409 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /reviews/{id}/approve [post]
+func (s *Server) approveReviewHandler(w http.ResponseWriter, r *http.Request) {
+	s.resolveReview(w, r, s.bill.ApproveReview)
+}
+
+// @Summary Reject operation
+// @Tags reviews
+// @Description reject the operation held for review
+// @Param id path int true "review id"
+// @Param X-API-Key header string true "admin API key"
+// @Success 200
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 403 {string} string
+// @Failure 404 {string} string
+// @Failure 409 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /reviews/{id}/reject [post]
+func (s *Server) rejectReviewHandler(w http.ResponseWriter, r *http.Request) {
+	s.resolveReview(w, r, s.bill.RejectReview)
+}
+
+func (s *Server) resolveReview(w http.ResponseWriter, r *http.Request, resolve func(ctx context.Context, id int64) error) {
+	id, err := parceID(r)
+	if err != nil {
+		http.Error(w, "incorrect review ID: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = resolve(r.Context(), int64(id))
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusOK)
+	case errors.Is(err, database.ReviewDoesNotExistErr):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, risk.ReviewResolvedErr):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, risk.SelfApprovalErr):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		writeTransactionError(w, r, err)
+	}
+}
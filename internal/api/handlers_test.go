@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/KseniiaSalmina/Balance/internal/config"
+	"github.com/KseniiaSalmina/Balance/internal/ratelimit"
+	"github.com/KseniiaSalmina/Balance/internal/risk"
+	"github.com/KseniiaSalmina/Balance/internal/wallet"
+)
+
+// previewBilling has the balance of 300 in every wallet and fails every operation that is not a dry run
+type previewBilling struct {
+	BillingManager
+}
+
+func (b *previewBilling) PreviewOperation(ctx context.Context, op risk.Operation, desc string) (wallet.Preview, error) {
+	w := &wallet.Wallet{Balance: decimal.NewFromInt(300)}
+	opt := wallet.Withdrawal
+	if op.Kind == risk.KindReplenishment {
+		opt = wallet.Replenishment
+	}
+	err := w.ChangeBalance(op.Amount, opt)
+	return wallet.Preview{Balance: w.Balance, Fee: decimal.Zero}, err
+}
+
+func (b *previewBilling) MoneyTransaction(ctx context.Context, id int, opt wallet.Operation, amount decimal.Decimal, desc string) error {
+	return wallet.InsufficientFundsErr
+}
+
+func (b *previewBilling) Transfer(ctx context.Context, from, to int, amount decimal.Decimal) error {
+	return wallet.InsufficientFundsErr
+}
+
+func TestMoneyTransaction_dryRun(t *testing.T) {
+	s, err := NewServer(config.Server{}, &previewBilling{}, ratelimit.NewMemory())
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "withdrawal", body: `{"to":1,"amount":"-100","description":"test","dry_run":true}`, wantStatus: http.StatusOK, wantBody: `"balance":"200"`},
+		{name: "replenishment", body: `{"to":1,"amount":"100","description":"test","dry_run":true}`, wantStatus: http.StatusOK, wantBody: `"balance":"400"`},
+		{name: "transfer", body: `{"is_transfer":true,"to":2,"amount":"300","dry_run":true}`, wantStatus: http.StatusOK, wantBody: `"balance":"0"`},
+		{name: "insufficient funds", body: `{"is_transfer":true,"to":2,"amount":"301","dry_run":true}`, wantStatus: http.StatusBadRequest, wantBody: "insufficient funds"},
+		{name: "without description", body: `{"to":1,"amount":"-100","dry_run":true}`, wantStatus: http.StatusBadRequest},
+		{name: "not a dry run", body: `{"to":1,"amount":"-100","description":"test"}`, wantStatus: http.StatusBadRequest, wantBody: "insufficient funds"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.httpServer.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, "/wallets/1/transaction", strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.wantBody)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const readinessTimeout = 2 * time.Second
+
+var ShuttingDownErr = errors.New("server is shutting down")
+
+// ReadinessChecker reports whether a dependency is ready to serve traffic
+type ReadinessChecker interface {
+	Ready(ctx context.Context) error
+}
+
+// SetNotReady makes the readiness probe fail so the orchestrator stops routing traffic before the server is shut down
+func (s *Server) SetNotReady() {
+	s.shuttingDown.Store(true)
+}
+
+// @Summary Liveness probe
+// @Tags health
+// @Description reports that the process is alive
+// @Produce json
+// @Success 200 {object} HealthResponse
+// @Router /healthz [get]
+func (s *Server) livenessHandler(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, http.StatusOK, HealthResponse{Status: "ok"})
+}
+
+// @Summary Readiness probe
+// @Tags health
+// @Description reports whether the database is reachable, its schema is at the expected version and the server is not shutting down
+// @Produce json
+// @Success 200 {object} HealthResponse
+// @Failure 503 {object} HealthResponse
+// @Router /readyz [get]
+func (s *Server) readinessHandler(w http.ResponseWriter, r *http.Request) {
+	if err := s.ready(r.Context()); err != nil {
+		slog.WarnContext(r.Context(), "service is not ready", "error", err)
+		writeHealth(w, http.StatusServiceUnavailable, HealthResponse{Status: "unavailable", Error: err.Error()})
+		return
+	}
+
+	writeHealth(w, http.StatusOK, HealthResponse{Status: "ok"})
+}
+
+func (s *Server) ready(ctx context.Context) error {
+	if s.shuttingDown.Load() {
+		return ShuttingDownErr
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	for _, c := range s.checks {
+		if err := c.Ready(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeHealth(w http.ResponseWriter, status int, resp HealthResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
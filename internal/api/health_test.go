@@ -0,0 +1,58 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/KseniiaSalmina/Balance/internal/config"
+	"github.com/KseniiaSalmina/Balance/internal/ratelimit"
+)
+
+type checkerFunc func(ctx context.Context) error
+
+func (f checkerFunc) Ready(ctx context.Context) error {
+	return f(ctx)
+}
+
+func TestHealth(t *testing.T) {
+	failing := checkerFunc(func(ctx context.Context) error { return errors.New("database is unreachable") })
+	ok := checkerFunc(func(ctx context.Context) error { return nil })
+
+	tests := []struct {
+		name         string
+		checks       []ReadinessChecker
+		shuttingDown bool
+		path         string
+		wantStatus   int
+		wantBody     HealthResponse
+	}{
+		{name: "alive", checks: []ReadinessChecker{failing}, path: "/healthz", wantStatus: http.StatusOK, wantBody: HealthResponse{Status: "ok"}},
+		{name: "ready", checks: []ReadinessChecker{ok}, path: "/readyz", wantStatus: http.StatusOK, wantBody: HealthResponse{Status: "ok"}},
+		{name: "dependency failed", checks: []ReadinessChecker{ok, failing}, path: "/readyz", wantStatus: http.StatusServiceUnavailable, wantBody: HealthResponse{Status: "unavailable", Error: "database is unreachable"}},
+		{name: "shutting down", checks: []ReadinessChecker{ok}, shuttingDown: true, path: "/readyz", wantStatus: http.StatusServiceUnavailable, wantBody: HealthResponse{Status: "unavailable", Error: ShuttingDownErr.Error()}},
+		{name: "alive while shutting down", shuttingDown: true, path: "/healthz", wantStatus: http.StatusOK, wantBody: HealthResponse{Status: "ok"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewServer(config.Server{}, nil, ratelimit.NewMemory(), tt.checks...)
+			assert.NoError(t, err)
+			if tt.shuttingDown {
+				s.SetNotReady()
+			}
+
+			rec := httptest.NewRecorder()
+			s.httpServer.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			var body HealthResponse
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+			assert.Equal(t, tt.wantBody, body)
+		})
+	}
+}
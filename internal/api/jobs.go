@@ -0,0 +1,156 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/KseniiaSalmina/Balance/internal/database"
+	"github.com/KseniiaSalmina/Balance/internal/jobs"
+)
+
+// @Summary Get jobs
+// @Tags jobs
+// @Description get the jobs of the client by status, newest first
+// @Produce json
+// @Param status query string false "string enums, default: running" Enums(queued, running, succeeded, failed, canceled)
+// @Param limit query int false "default: 100"
+// @Param X-API-Key header string false "client API key, required if authentication is enabled"
+// @Success 200 {array} jobs.Job
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /jobs [get]
+func (s *Server) getJobsHandler(w http.ResponseWriter, r *http.Request) {
+	status := jobs.Status(r.FormValue("status"))
+	switch status {
+	case jobs.Queued, jobs.Succeeded, jobs.Failed, jobs.Canceled:
+	default:
+		status = jobs.Running
+	}
+
+	limitStr := r.FormValue("limit")
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil && limitStr != "" {
+		http.Error(w, "incorrect limit", http.StatusBadRequest)
+		return
+	}
+	if limitStr == "" {
+		limit = 100
+	}
+
+	list, err := s.bill.Jobs(r.Context(), status, limit)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(list)
+}
+
+// @Summary Get job
+// @Tags jobs
+// @Description get the status of the job, the result is available as the artifact when artifact_type is set. The jobs of the other clients are not found
+// @Produce json
+// @Param id path int true "job id"
+// @Param X-API-Key header string false "client API key, required if authentication is enabled"
+// @Success 200 {object} jobs.Job
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 404 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /jobs/{id} [get]
+func (s *Server) getJobHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parceID(r)
+	if err != nil {
+		http.Error(w, "incorrect job ID: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	job, err := s.bill.Job(r.Context(), int64(id))
+	if err != nil {
+		writeJobError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(job)
+}
+
+// @Summary Get job artifact
+// @Tags jobs
+// @Description download the result of the job, the content type is the artifact_type of the job. The failed or canceled job can have the partial result
+// @Produce json
+// @Param id path int true "job id"
+// @Param X-API-Key header string false "client API key, required if authentication is enabled"
+// @Success 200 {file} file
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 404 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /jobs/{id}/artifact [get]
+func (s *Server) getJobArtifactHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parceID(r)
+	if err != nil {
+		http.Error(w, "incorrect job ID: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	artifact, err := s.bill.JobArtifact(r.Context(), int64(id))
+	if err != nil {
+		writeJobError(w, r, err)
+		return
+	}
+	if artifact == nil {
+		http.Error(w, "job has no artifact", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", artifact.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(artifact.Data)))
+	w.Write(artifact.Data)
+}
+
+// @Summary Cancel job
+// @Tags jobs
+// @Description cancel the queued job at once, the running one is stopped within JOBS_LEASE and gets the canceled status
+// @Produce json
+// @Param id path int true "job id"
+// @Param X-API-Key header string false "client API key, required if authentication is enabled"
+// @Success 200 {object} jobs.Job
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 404 {string} string
+// @Failure 409 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /jobs/{id}/cancel [post]
+func (s *Server) cancelJobHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parceID(r)
+	if err != nil {
+		http.Error(w, "incorrect job ID: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	job, err := s.bill.CancelJob(r.Context(), int64(id))
+	if err != nil {
+		writeJobError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(job)
+}
+
+func writeJobError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, database.JobDoesNotExistErr):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, jobs.FinishedErr):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		writeInternalError(w, r, err)
+	}
+}
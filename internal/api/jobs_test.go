@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/KseniiaSalmina/Balance/internal/config"
+	"github.com/KseniiaSalmina/Balance/internal/database"
+	"github.com/KseniiaSalmina/Balance/internal/jobs"
+	"github.com/KseniiaSalmina/Balance/internal/ratelimit"
+)
+
+// jobsBilling knows job 1 with an artifact and job 2 that is finished without it
+type jobsBilling struct {
+	BillingManager
+}
+
+func (b *jobsBilling) Job(ctx context.Context, id int64) (*jobs.Job, error) {
+	switch id {
+	case 1:
+		return &jobs.Job{ID: 1, Status: jobs.Running}, nil
+	case 2:
+		return &jobs.Job{ID: 2, Status: jobs.Failed}, nil
+	}
+	return nil, database.JobDoesNotExistErr
+}
+
+func (b *jobsBilling) JobArtifact(ctx context.Context, id int64) (*jobs.Artifact, error) {
+	if _, err := b.Job(ctx, id); err != nil {
+		return nil, err
+	}
+	if id == 1 {
+		return &jobs.Artifact{ContentType: "text/csv", Data: []byte("id,amount\n")}, nil
+	}
+	return nil, nil
+}
+
+func (b *jobsBilling) CancelJob(ctx context.Context, id int64) (*jobs.Job, error) {
+	j, err := b.Job(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if j.Finished() {
+		return nil, jobs.FinishedErr
+	}
+	j.CancelRequested = true
+	return j, nil
+}
+
+func TestJobs(t *testing.T) {
+	s, err := NewServer(config.Server{}, &jobsBilling{}, ratelimit.NewMemory())
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		wantStatus  int
+		wantType    string
+		wantContent string
+	}{
+		{name: "job", method: http.MethodGet, path: "/jobs/1", wantStatus: http.StatusOK},
+		{name: "unknown job", method: http.MethodGet, path: "/jobs/3", wantStatus: http.StatusNotFound},
+		{name: "incorrect ID", method: http.MethodGet, path: "/jobs/one", wantStatus: http.StatusBadRequest},
+		{name: "artifact", method: http.MethodGet, path: "/jobs/1/artifact", wantStatus: http.StatusOK, wantType: "text/csv", wantContent: "id,amount\n"},
+		{name: "no artifact", method: http.MethodGet, path: "/jobs/2/artifact", wantStatus: http.StatusNotFound},
+		{name: "cancel running job", method: http.MethodPost, path: "/jobs/1/cancel", wantStatus: http.StatusOK},
+		{name: "cancel finished job", method: http.MethodPost, path: "/jobs/2/cancel", wantStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.httpServer.Handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantType != "" {
+				assert.Equal(t, tt.wantType, rec.Header().Get("Content-Type"))
+				assert.Equal(t, tt.wantContent, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/KseniiaSalmina/Balance/internal/audit"
+	"github.com/KseniiaSalmina/Balance/internal/config"
+	"github.com/KseniiaSalmina/Balance/internal/logger"
+	"github.com/KseniiaSalmina/Balance/internal/metrics"
+	"github.com/KseniiaSalmina/Balance/internal/ratelimit"
+)
+
+const (
+	apiKeyHeader = "X-API-Key"
+	userIDHeader = "X-User-ID"
+
+	requestIDHeader    = "X-Request-ID"
+	maxRequestIDLength = 64
+)
+
+// requestIDMiddleware takes the request ID from the X-Request-ID header or generates a new one,
+// stores it in the request context for logging and returns it in the response header
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if id == "" || len(id) > maxRequestIDLength {
+			id = logger.NewRequestID()
+		}
+
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logger.WithRequestID(r.Context(), id)))
+	})
+}
+
+// accessLogMiddleware logs every request with its status and duration
+func accessLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		slog.InfoContext(r.Context(), "http request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"duration", time.Since(start),
+			"remote_addr", r.RemoteAddr,
+		)
+	})
+}
+
+var InvalidAPIKeyErr = errors.New("invalid API key")
+
+// Authenticate returns the actor for the audit log: the client or the administrator name by API key and the end user if it is set.
+// If no client keys are configured every client without an admin key is accepted as anonymous
+func Authenticate(auth config.Auth, apiKey, user string) (string, error) {
+	actor := audit.Anonymous
+	if admin, ok := auth.AdminKeys[apiKey]; ok {
+		actor = admin
+	} else if len(auth.APIKeys) != 0 {
+		client, ok := auth.APIKeys[apiKey]
+		if !ok {
+			return "", InvalidAPIKeyErr
+		}
+		actor = client
+	}
+
+	if user != "" {
+		actor += "/" + user
+	}
+	return actor, nil
+}
+
+// addressRateLimitMiddleware limits the requests without a valid API key by IP address before the key is checked,
+// so the keys can not be guessed at full speed
+func addressRateLimitMiddleware(auth func() config.Auth, rateLimit func() config.RateLimit, limiter ratelimit.Backend) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cfg := rateLimit()
+			limit := ratelimit.Limit{Rate: cfg.AddressRate, Burst: cfg.AddressBurst}
+			if !limit.Disabled() && !KnownKey(auth(), r.Header.Get(apiKeyHeader)) {
+				if !allow(w, r, limiter, "address:"+RemoteIP(r.RemoteAddr), limit) {
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// KnownKey reports whether the API key is a client or an admin key. If no client keys are configured the requests without a key are known
+func KnownKey(auth config.Auth, apiKey string) bool {
+	_, admin := auth.AdminKeys[apiKey]
+	_, client := auth.APIKeys[apiKey]
+	return admin || client || len(auth.APIKeys) == 0 && apiKey == ""
+}
+
+// authMiddleware identifies the client by API key and stores it in the request context as an actor for the audit log.
+// If no client keys are configured every request without an admin key is accepted as anonymous
+func authMiddleware(auth func() config.Auth) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			actor, err := Authenticate(auth(), r.Header.Get(apiKeyHeader), r.Header.Get(userIDHeader))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(audit.WithActor(r.Context(), actor)))
+		})
+	}
+}
+
+// adminMiddleware allows only the requests with an admin API key, if no admin keys are configured every request is forbidden.
+// Must be used after authMiddleware
+func adminMiddleware(auth func() config.Auth) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := auth().AdminKeys[r.Header.Get(apiKeyHeader)]; !ok {
+				http.Error(w, "admin API key is required", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rateLimitMiddleware limits requests per API client and per wallet. Anonymous clients are limited by IP address.
+// Must be used after authMiddleware
+func rateLimitMiddleware(rateLimit func() config.RateLimit, limiter ratelimit.Backend) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cfg := rateLimit()
+			clientLimit := ratelimit.Limit{Rate: cfg.ClientRate, Burst: cfg.ClientBurst}
+			walletLimit := ratelimit.Limit{Rate: cfg.WalletRate, Burst: cfg.WalletBurst}
+
+			if !clientLimit.Disabled() {
+				if !allow(w, r, limiter, "client:"+clientKey(r), clientLimit) {
+					return
+				}
+			}
+
+			if id, ok := mux.Vars(r)["id"]; ok && !walletLimit.Disabled() {
+				if !allow(w, r, limiter, "wallet:"+id, walletLimit) {
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// allow takes a token from the bucket by key and responds with 429 if it is empty.
+// If the backend is unavailable the request is allowed
+func allow(w http.ResponseWriter, r *http.Request, limiter ratelimit.Backend, key string, limit ratelimit.Limit) bool {
+	ok, wait, err := limiter.Take(key, limit)
+	if err != nil {
+		slog.WarnContext(r.Context(), "rate limiter is unavailable", "error", err)
+		return true
+	}
+	if ok {
+		return true
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	http.Error(w, "too many requests, retry after "+wait.Round(time.Millisecond).String(), http.StatusTooManyRequests)
+	return false
+}
+
+func clientKey(r *http.Request) string {
+	return ClientKey(r.Context(), r.RemoteAddr)
+}
+
+// ClientKey returns the rate limit key of the client: its name for authenticated clients and the IP address for anonymous ones.
+// ctx must contain the actor
+func ClientKey(ctx context.Context, remoteAddr string) string {
+	client, _, _ := strings.Cut(audit.Actor(ctx), "/")
+	if client != audit.Anonymous {
+		return client
+	}
+	return RemoteIP(remoteAddr)
+}
+
+// RemoteIP returns the IP address of the remote address with the port
+func RemoteIP(remoteAddr string) string {
+	ip, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return ip
+}
+
+// metricsMiddleware counts requests and their latency by route name
+func metricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := "unnamed"
+		if current := mux.CurrentRoute(r); current != nil && current.GetName() != "" {
+			route = current.GetName()
+		}
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		metrics.HTTPRequest(route, r.Method, rec.status, time.Since(start))
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController reach the original writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/KseniiaSalmina/Balance/internal/config"
+	"github.com/KseniiaSalmina/Balance/internal/limits"
+	"github.com/KseniiaSalmina/Balance/internal/ratelimit"
+	"github.com/KseniiaSalmina/Balance/internal/webhook"
+)
+
+// authBilling has the balance of 300 in every wallet
+type authBilling struct {
+	BillingManager
+}
+
+func (b *authBilling) CheckBalance(ctx context.Context, id int) (string, error) {
+	return "300", nil
+}
+
+func (b *authBilling) WalletLimits(ctx context.Context, id int) (limits.Limits, limits.Limits, error) {
+	return limits.Limits{}, limits.Limits{}, nil
+}
+
+func (b *authBilling) SetWalletLimits(ctx context.Context, id int, l limits.Limits) error {
+	return nil
+}
+
+func (b *authBilling) ApproveReview(ctx context.Context, id int64) error {
+	return nil
+}
+
+func (b *authBilling) Webhooks(ctx context.Context) ([]webhook.Webhook, error) {
+	return nil, nil
+}
+
+// serve sends the request with the API key to the server and returns the response status
+func serve(s *Server, method, path, key, body string) int {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(apiKeyHeader, key)
+	rec := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestAddressRateLimit(t *testing.T) {
+	cfg := config.Server{
+		Auth:      config.Auth{APIKeys: config.APIKeys{"secret": "shop"}},
+		RateLimit: config.RateLimit{AddressRate: 0.001, AddressBurst: 2},
+	}
+	s, err := NewServer(cfg, &authBilling{}, ratelimit.NewMemory())
+	assert.NoError(t, err)
+
+	get := func(key string) int {
+		return serve(s, http.MethodGet, "/wallets/1/balance", key, "")
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, get("guess1"))
+	assert.Equal(t, http.StatusUnauthorized, get(""))
+	assert.Equal(t, http.StatusTooManyRequests, get("guess2"), "key guessing must be throttled by address")
+	assert.Equal(t, http.StatusOK, get("secret"), "valid key must not be limited by address")
+}
+
+func TestAdminEndpoints(t *testing.T) {
+	cfg := config.Server{Auth: config.Auth{APIKeys: config.APIKeys{"secret": "shop"}, AdminKeys: config.APIKeys{"root": "admin"}}}
+	s, err := NewServer(cfg, &authBilling{}, ratelimit.NewMemory())
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		key        string
+		body       string
+		wantStatus int
+	}{
+		{name: "client sets limits", method: http.MethodPut, path: "/wallets/1/limits", key: "secret", body: `{}`, wantStatus: http.StatusForbidden},
+		{name: "admin sets limits", method: http.MethodPut, path: "/wallets/1/limits", key: "root", body: `{}`, wantStatus: http.StatusOK},
+		{name: "unknown key sets limits", method: http.MethodPut, path: "/wallets/1/limits", key: "guess", body: `{}`, wantStatus: http.StatusUnauthorized},
+		{name: "client approves review", method: http.MethodPost, path: "/reviews/1/approve", key: "secret", wantStatus: http.StatusForbidden},
+		{name: "admin approves review", method: http.MethodPost, path: "/reviews/1/approve", key: "root", wantStatus: http.StatusOK},
+		{name: "client gets webhooks", method: http.MethodGet, path: "/webhooks", key: "secret", wantStatus: http.StatusForbidden},
+		{name: "admin gets webhooks", method: http.MethodGet, path: "/webhooks", key: "root", wantStatus: http.StatusOK},
+		{name: "client gets limits", method: http.MethodGet, path: "/wallets/1/limits", key: "secret", wantStatus: http.StatusOK},
+		{name: "admin uses client endpoints", method: http.MethodGet, path: "/wallets/1/balance", key: "root", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantStatus, serve(s, tt.method, tt.path, tt.key, tt.body))
+		})
+	}
+
+	noAdmins, err := NewServer(config.Server{}, &authBilling{}, ratelimit.NewMemory())
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, serve(noAdmins, http.MethodPut, "/wallets/1/limits", "", `{}`), "admin endpoints must be disabled without admin keys")
+}
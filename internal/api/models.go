@@ -1,10 +1,106 @@
 package api
 
-import "github.com/shopspring/decimal"
+import (
+	"github.com/shopspring/decimal"
+
+	"github.com/KseniiaSalmina/Balance/internal/batch"
+	"github.com/KseniiaSalmina/Balance/internal/limits"
+	"github.com/KseniiaSalmina/Balance/internal/recurring"
+	"github.com/KseniiaSalmina/Balance/internal/risk"
+)
 
 type ChangingBalanceRequest struct {
 	IsTransfer  bool            `json:"is_transfer"` //reports whether transaction is a transfer or not, default false
 	To          int             `json:"to"`          //required for a transfer
 	Amount      decimal.Decimal `json:"amount"`      //for a transfer must be a positive number, for a not transfer transaction reports whether the operation is a replenishment (positive amount) or withdrawal (negative)
 	Description string          `json:"description"` //required for a not transfer transactions
+	DryRun      bool            `json:"dry_run"`     //check the transaction and return the would-be result without changing anything, default false
+}
+
+type LimitsResponse struct {
+	Wallet    limits.Limits `json:"wallet"`    //limits set for the wallet, null values are inherited from the global limits
+	Effective limits.Limits `json:"effective"` //limits in effect, null values mean no limit
+}
+
+type BalanceResponse struct {
+	Balance         decimal.Decimal `json:"balance"`          //negative if the credit line is used
+	CreditLimit     decimal.Decimal `json:"credit_limit"`     //withdrawals are allowed down to the negative limit
+	AvailableCredit decimal.Decimal `json:"available_credit"` //unused part of the credit line
+	Available       decimal.Decimal `json:"available"`        //funds available for withdrawals
+}
+
+type TierRequest struct {
+	Tier string `json:"tier"` //empty value means the fee rules for any tier
+}
+
+type LimitExceededResponse struct {
+	Error     string          `json:"error"` //always limit_exceeded
+	Limit     string          `json:"limit"`
+	Max       decimal.Decimal `json:"max"`
+	Remaining decimal.Decimal `json:"remaining"`
+}
+
+type ReviewRequiredResponse struct {
+	ReviewID int64  `json:"review_id"`
+	Status   string `json:"status"` //always pending
+}
+
+type HealthResponse struct {
+	Status string `json:"status"`          //ok or unavailable
+	Error  string `json:"error,omitempty"` //reason of unavailability
+}
+
+type CreateWebhookRequest struct {
+	URL string `json:"url"` //absolute http or https URL receiving POST requests with the events
+}
+
+type ReplayResponse struct {
+	Replayed int64 `json:"replayed"` //number of deliveries queued again
+}
+
+type BatchRequest struct {
+	Mode       batch.Mode   `json:"mode"`       //atomic or best_effort
+	Async      bool         `json:"async"`      //execute as a job, required for batches larger than BATCH_MAX_SIZE
+	Operations []batch.Item `json:"operations"` //executed in order
+}
+
+type ScheduleRequest struct {
+	Type        risk.Kind       `json:"type"`        //replenishment, withdrawal or transfer
+	To          int             `json:"to"`          //required for a transfer
+	Amount      decimal.Decimal `json:"amount"`      //positive number
+	Description string          `json:"description"` //required for replenishment and withdrawal
+	ExecuteAt   int64           `json:"execute_at"`  //Unix timestamp in the future
+}
+
+type RecurringRequest struct {
+	To             int                 `json:"to"`
+	Amount         decimal.Decimal     `json:"amount"`                    //positive number
+	Frequency      recurring.Frequency `json:"frequency"`                 //daily, weekly, monthly or cron
+	Interval       int                 `json:"interval,omitempty"`        //every interval days, weeks or months, default 1
+	Cron           string              `json:"cron,omitempty"`            //"minute hour day-of-month month day-of-week" in UTC, required for the cron frequency
+	StartAt        int64               `json:"start_at"`                  //Unix timestamp in the future
+	EndAt          int64               `json:"end_at,omitempty"`          //no occurrences after it
+	MaxOccurrences int                 `json:"max_occurrences,omitempty"` //0 means no limit
+	OnFailure      recurring.Policy    `json:"on_failure,omitempty"`      //skip or retry, default skip
+	MaxRetries     int                 `json:"max_retries,omitempty"`     //retries of the failed occurrence with the retry policy
+	RetryInterval  int64               `json:"retry_interval,omitempty"`  //seconds between the retries, default 3600
+}
+
+type UpcomingExecution struct {
+	Occurrence int   `json:"occurrence"` //number of the occurrence from 1
+	ExecuteAt  int64 `json:"execute_at"`
+}
+
+type PlanRequest struct {
+	Name          string              `json:"name"`
+	Price         decimal.Decimal     `json:"price"`          //positive number
+	Frequency     recurring.Frequency `json:"frequency"`      //daily, weekly or monthly
+	Interval      int                 `json:"interval"`       //period is interval days, weeks or months, default 1
+	GracePeriod   int64               `json:"grace_period"`   //seconds after the start of the unpaid period until the last charge attempt
+	RetrySchedule []int64             `json:"retry_schedule"` //seconds after the start of the unpaid period to retry the failed charge at, increasing, within the grace period
+}
+
+type SubscriptionRequest struct {
+	PlanID  int64 `json:"plan_id"`
+	StartAt int64 `json:"start_at"` //Unix timestamp of the first charge, default now
 }
@@ -0,0 +1,227 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/KseniiaSalmina/Balance/internal/database"
+	"github.com/KseniiaSalmina/Balance/internal/recurring"
+)
+
+const maxUpcoming = 100 //max number of the upcoming executions in the response
+
+// @Summary Create recurring payment
+// @Tags scheduled
+// @Description create a transfer from the wallet repeated daily, weekly, monthly or by a cron expression in UTC from start_at until end_at or max_occurrences.
+// @Description Every occurrence is executed as a scheduled operation. A failed occurrence is skipped, or retried with the retry policy
+// @Accept json
+// @Produce json
+// @Param id path int true "wallet id"
+// @Param input body api.RecurringRequest true "recurring payment"
+// @Param X-API-Key header string false "client API key, required if authentication is enabled"
+// @Param X-User-ID header string false "end user who created the payment, stored in the audit log"
+// @Success 201 {object} recurring.Payment
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /wallets/{id}/recurring [post]
+func (s *Server) createRecurringHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parceID(r)
+	if err != nil {
+		http.Error(w, "incorrect wallet ID: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req RecurringRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "incorrect request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	p, err := s.bill.CreateRecurring(r.Context(), recurring.Payment{
+		WalletID:       id,
+		To:             req.To,
+		Amount:         req.Amount,
+		Rule:           recurring.Rule{Frequency: req.Frequency, Interval: req.Interval, Cron: req.Cron},
+		StartAt:        req.StartAt,
+		EndAt:          req.EndAt,
+		MaxOccurrences: req.MaxOccurrences,
+		OnFailure:      req.OnFailure,
+		MaxRetries:     req.MaxRetries,
+		RetryInterval:  req.RetryInterval,
+	})
+	if err != nil {
+		if errors.Is(err, recurring.InvalidPaymentErr) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeInternalError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(p)
+}
+
+// @Summary Get recurring payments
+// @Tags scheduled
+// @Description get the recurring payments from the wallet created by the client by status, newest first
+// @Produce json
+// @Param id path int true "wallet id"
+// @Param status query string false "string enums, default: active" Enums(active, completed, canceled)
+// @Param limit query int false "default: 100"
+// @Param X-API-Key header string false "client API key, required if authentication is enabled"
+// @Success 200 {array} recurring.Payment
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /wallets/{id}/recurring [get]
+func (s *Server) getRecurringListHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parceID(r)
+	if err != nil {
+		http.Error(w, "incorrect wallet ID: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	status := recurring.Status(r.FormValue("status"))
+	switch status {
+	case recurring.Completed, recurring.Canceled:
+	default:
+		status = recurring.Active
+	}
+
+	limitStr := r.FormValue("limit")
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil && limitStr != "" {
+		http.Error(w, "incorrect limit", http.StatusBadRequest)
+		return
+	}
+	if limitStr == "" {
+		limit = 100
+	}
+
+	list, err := s.bill.RecurringPayments(r.Context(), id, status, limit)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(list)
+}
+
+// @Summary Get recurring payment
+// @Tags scheduled
+// @Description get the recurring payment with its progress, scheduled_id is the scheduled operation of the pending occurrence. The payments of the other clients are not found
+// @Produce json
+// @Param id path int true "recurring payment id"
+// @Param X-API-Key header string false "client API key, required if authentication is enabled"
+// @Success 200 {object} recurring.Payment
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 404 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /recurring/{id} [get]
+func (s *Server) getRecurringHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parceID(r)
+	if err != nil {
+		http.Error(w, "incorrect recurring payment ID: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	p, err := s.bill.RecurringPayment(r.Context(), int64(id))
+	if err != nil {
+		writeRecurringError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(p)
+}
+
+// @Summary Get upcoming executions
+// @Tags scheduled
+// @Description get the times of the next executions of the active recurring payment, the pending one first
+// @Produce json
+// @Param id path int true "recurring payment id"
+// @Param limit query int false "default: 10, max: 100"
+// @Param X-API-Key header string false "client API key, required if authentication is enabled"
+// @Success 200 {array} api.UpcomingExecution
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 404 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /recurring/{id}/upcoming [get]
+func (s *Server) getUpcomingHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parceID(r)
+	if err != nil {
+		http.Error(w, "incorrect recurring payment ID: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limit := 10
+	if limitStr := r.FormValue("limit"); limitStr != "" {
+		if limit, err = strconv.Atoi(limitStr); err != nil || limit <= 0 || limit > maxUpcoming {
+			http.Error(w, "incorrect limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	p, err := s.bill.RecurringPayment(r.Context(), int64(id))
+	if err != nil {
+		writeRecurringError(w, r, err)
+		return
+	}
+
+	upcoming := make([]UpcomingExecution, 0, limit)
+	for i, at := range p.Upcoming(limit) {
+		upcoming = append(upcoming, UpcomingExecution{Occurrence: p.Occurrences + i + 1, ExecuteAt: at})
+	}
+	json.NewEncoder(w).Encode(upcoming)
+}
+
+// @Summary Cancel recurring payment
+// @Tags scheduled
+// @Description cancel the recurring payment and its pending occurrence, the occurrence that is being executed is finished. The payments of the other clients are not found
+// @Produce json
+// @Param id path int true "recurring payment id"
+// @Param X-API-Key header string false "client API key, required if authentication is enabled"
+// @Success 200 {object} recurring.Payment
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 404 {string} string
+// @Failure 409 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /recurring/{id}/cancel [post]
+func (s *Server) cancelRecurringHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parceID(r)
+	if err != nil {
+		http.Error(w, "incorrect recurring payment ID: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	p, err := s.bill.CancelRecurring(r.Context(), int64(id))
+	if err != nil {
+		writeRecurringError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(p)
+}
+
+func writeRecurringError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, database.RecurringDoesNotExistErr):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, recurring.NotActiveErr):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		writeInternalError(w, r, err)
+	}
+}
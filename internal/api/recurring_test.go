@@ -0,0 +1,91 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/KseniiaSalmina/Balance/internal/config"
+	"github.com/KseniiaSalmina/Balance/internal/database"
+	"github.com/KseniiaSalmina/Balance/internal/ratelimit"
+	"github.com/KseniiaSalmina/Balance/internal/recurring"
+)
+
+// recurringBilling knows daily payment 1 with 3 occurrences left and canceled payment 2
+type recurringBilling struct {
+	BillingManager
+}
+
+func (b *recurringBilling) CreateRecurring(ctx context.Context, p recurring.Payment) (recurring.Payment, error) {
+	return recurring.New(p, "test", 1)
+}
+
+func (b *recurringBilling) RecurringPayment(ctx context.Context, id int64) (*recurring.Payment, error) {
+	p := recurring.Payment{ID: id, Rule: recurring.Rule{Frequency: recurring.Daily, Interval: 1}, StartAt: 86400, MaxOccurrences: 5,
+		Status: recurring.Active, Occurrences: 2, NextAt: 3 * 86400}
+	switch id {
+	case 1:
+	case 2:
+		p.Status = recurring.Canceled
+	default:
+		return nil, database.RecurringDoesNotExistErr
+	}
+	return &p, nil
+}
+
+func (b *recurringBilling) CancelRecurring(ctx context.Context, id int64) (*recurring.Payment, error) {
+	p, err := b.RecurringPayment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status != recurring.Active {
+		return nil, recurring.NotActiveErr
+	}
+	p.Status = recurring.Canceled
+	return p, nil
+}
+
+func TestRecurring(t *testing.T) {
+	s, err := NewServer(config.Server{}, &recurringBilling{}, ratelimit.NewMemory())
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{name: "create monthly payment", method: http.MethodPost, path: "/wallets/1/recurring", body: `{"to":2,"amount":"10","frequency":"monthly","start_at":2}`, wantStatus: http.StatusCreated},
+		{name: "create cron payment", method: http.MethodPost, path: "/wallets/1/recurring", body: `{"to":2,"amount":"10","frequency":"cron","cron":"0 9 * * 5","start_at":2}`, wantStatus: http.StatusCreated},
+		{name: "invalid cron", method: http.MethodPost, path: "/wallets/1/recurring", body: `{"to":2,"amount":"10","frequency":"cron","cron":"every friday","start_at":2}`, wantStatus: http.StatusBadRequest},
+		{name: "recurring payment", method: http.MethodGet, path: "/recurring/1", wantStatus: http.StatusOK},
+		{name: "unknown recurring payment", method: http.MethodGet, path: "/recurring/3", wantStatus: http.StatusNotFound},
+		{name: "too many upcoming", method: http.MethodGet, path: "/recurring/1/upcoming?limit=101", wantStatus: http.StatusBadRequest},
+		{name: "cancel active payment", method: http.MethodPost, path: "/recurring/1/cancel", wantStatus: http.StatusOK},
+		{name: "cancel canceled payment", method: http.MethodPost, path: "/recurring/2/cancel", wantStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.httpServer.Handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+
+	t.Run("upcoming executions", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/recurring/1/upcoming", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var got []UpcomingExecution
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+		assert.Equal(t, []UpcomingExecution{{Occurrence: 3, ExecuteAt: 3 * 86400}, {Occurrence: 4, ExecuteAt: 4 * 86400}, {Occurrence: 5, ExecuteAt: 5 * 86400}}, got)
+	})
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/KseniiaSalmina/Balance/internal/database"
+	"github.com/KseniiaSalmina/Balance/internal/limits"
+	"github.com/KseniiaSalmina/Balance/internal/savings"
+)
+
+// @Summary Get user savings
+// @Tags savings
+// @Description get the savings product and the annual interest rate of the user
+// @Produce json
+// @Param id path int true "user id"
+// @Param X-API-Key header string false "client API key, required if authentication is enabled"
+// @Success 200 {object} savings.Account
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /wallets/{id}/savings [get]
+func (s *Server) getSavingsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parceID(r)
+	if err != nil {
+		http.Error(w, "incorrect wallet ID: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	a, err := s.bill.Savings(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, database.UserDoesNotExistErr) {
+			http.Error(w, database.UserDoesNotExistErr.Error(), http.StatusBadRequest)
+			return
+		}
+		writeInternalError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(a)
+}
+
+// @Summary Set user savings
+// @Tags savings
+// @Description set the savings product or the annual interest rate of the user, the rate of the user overrides the rate of the product.
+// @Description The interest is accrued daily from the day the savings are enabled and posted to the balance monthly, empty product and null rate disable the savings.
+// @Description The rate must not be greater than 100. Requires an admin API key
+// @Accept json
+// @Produce json
+// @Param id path int true "user id"
+// @Param input body savings.Account true "savings, since is set by the service"
+// @Param X-API-Key header string true "admin API key"
+// @Success 200 {object} savings.Account
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 403 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /wallets/{id}/savings [put]
+func (s *Server) setSavingsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parceID(r)
+	if err != nil {
+		http.Error(w, "incorrect wallet ID: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var a savings.Account
+	if err = json.NewDecoder(r.Body).Decode(&a); err != nil {
+		http.Error(w, "incorrect savings: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	a, err = s.bill.SetSavings(r.Context(), id, a)
+	if err != nil {
+		switch {
+		case errors.Is(err, savings.InvalidAccountErr):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, database.UserDoesNotExistErr):
+			http.Error(w, database.UserDoesNotExistErr.Error(), http.StatusBadRequest)
+		default:
+			writeInternalError(w, r, err)
+		}
+		return
+	}
+
+	json.NewEncoder(w).Encode(a)
+}
+
+// @Summary Get interest report
+// @Tags savings
+// @Description get the interest accrued for the days of the period and posted to the balance for its months
+// @Produce json
+// @Param id path int true "user id"
+// @Param from query int false "unix time, default: start of the current month"
+// @Param to query int false "unix time, default: now"
+// @Param X-API-Key header string false "client API key, required if authentication is enabled"
+// @Success 200 {object} savings.Report
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /wallets/{id}/interest [get]
+func (s *Server) getInterestHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parceID(r)
+	if err != nil {
+		http.Error(w, "incorrect wallet ID: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	from, err := parseUnix(r.FormValue("from"), limits.MonthStart(now).Unix())
+	if err != nil {
+		http.Error(w, "incorrect from", http.StatusBadRequest)
+		return
+	}
+	to, err := parseUnix(r.FormValue("to"), now.Unix())
+	if err != nil || to <= from {
+		http.Error(w, "incorrect to: must be greater than from", http.StatusBadRequest)
+		return
+	}
+
+	report, err := s.bill.InterestReport(r.Context(), id, from, to)
+	if err != nil {
+		if errors.Is(err, database.UserDoesNotExistErr) {
+			http.Error(w, database.UserDoesNotExistErr.Error(), http.StatusBadRequest)
+			return
+		}
+		writeInternalError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(report)
+}
+
+// parseUnix parses the unix time, empty value means the default
+func parseUnix(s string, def int64) (int64, error) {
+	if s == "" {
+		return def, nil
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
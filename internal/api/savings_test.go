@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/KseniiaSalmina/Balance/internal/config"
+	"github.com/KseniiaSalmina/Balance/internal/database"
+	"github.com/KseniiaSalmina/Balance/internal/ratelimit"
+	"github.com/KseniiaSalmina/Balance/internal/savings"
+)
+
+// savingsBilling knows wallet 1 with the basic product, the only product
+type savingsBilling struct {
+	BillingManager
+}
+
+func (b *savingsBilling) Savings(ctx context.Context, id int) (savings.Account, error) {
+	if id != 1 {
+		return savings.Account{}, database.UserDoesNotExistErr
+	}
+	return savings.Account{Product: "basic", Since: 1700000000}, nil
+}
+
+func (b *savingsBilling) SetSavings(ctx context.Context, id int, a savings.Account) (savings.Account, error) {
+	products, _ := savings.NewProducts(savings.Product{Name: "basic", Rate: decimal.NewFromInt(3)})
+	if err := a.Validate(products); err != nil {
+		return savings.Account{}, err
+	}
+	if id != 1 {
+		return savings.Account{}, database.UserDoesNotExistErr
+	}
+	a.Since = 1700000000
+	return a, nil
+}
+
+func (b *savingsBilling) InterestReport(ctx context.Context, id int, from, to int64) (savings.Report, error) {
+	if id != 1 {
+		return savings.Report{}, database.UserDoesNotExistErr
+	}
+	return savings.NewReport(id, from, to, nil, nil, decimal.Zero), nil
+}
+
+func TestSavings(t *testing.T) {
+	s, err := NewServer(config.Server{Auth: config.Auth{AdminKeys: config.APIKeys{"root": "admin"}}}, &savingsBilling{}, ratelimit.NewMemory())
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		key        string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "get savings", method: http.MethodGet, path: "/wallets/1/savings", wantStatus: http.StatusOK, wantBody: `"product":"basic"`},
+		{name: "get savings of unknown wallet", method: http.MethodGet, path: "/wallets/2/savings", wantStatus: http.StatusBadRequest},
+		{name: "set savings", method: http.MethodPut, path: "/wallets/1/savings", key: "root", body: `{"product":"basic","rate":"4.5"}`, wantStatus: http.StatusOK, wantBody: `"since":1700000000`},
+		{name: "client sets savings", method: http.MethodPut, path: "/wallets/1/savings", body: `{"rate":"50"}`, wantStatus: http.StatusForbidden},
+		{name: "set too high rate", method: http.MethodPut, path: "/wallets/1/savings", key: "root", body: `{"rate":"1000"}`, wantStatus: http.StatusBadRequest},
+		{name: "set unknown product", method: http.MethodPut, path: "/wallets/1/savings", key: "root", body: `{"product":"gold"}`, wantStatus: http.StatusBadRequest},
+		{name: "set savings of unknown wallet", method: http.MethodPut, path: "/wallets/2/savings", key: "root", body: `{"product":"basic"}`, wantStatus: http.StatusBadRequest},
+		{name: "interest report", method: http.MethodGet, path: "/wallets/1/interest?from=1700000000&to=1700086400", wantStatus: http.StatusOK, wantBody: `"from":1700000000,"to":1700086400`},
+		{name: "interest report by default", method: http.MethodGet, path: "/wallets/1/interest", wantStatus: http.StatusOK, wantBody: `"wallet_id":1`},
+		{name: "interest report of empty period", method: http.MethodGet, path: "/wallets/1/interest?from=1700000000&to=1700000000", wantStatus: http.StatusBadRequest},
+		{name: "interest report of unknown wallet", method: http.MethodGet, path: "/wallets/2/interest", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set(apiKeyHeader, tt.key)
+			rec := httptest.NewRecorder()
+			s.httpServer.Handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.wantBody)
+		})
+	}
+}
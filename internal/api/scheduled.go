@@ -0,0 +1,174 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/KseniiaSalmina/Balance/internal/batch"
+	"github.com/KseniiaSalmina/Balance/internal/database"
+	"github.com/KseniiaSalmina/Balance/internal/schedule"
+)
+
+// @Summary Schedule operation
+// @Tags scheduled
+// @Description schedule a replenishment, withdrawal or transfer from the wallet. It is executed once at execute_at on behalf of the client,
+// @Description limits, funds and risk rules are checked at the execution time. The result is the status of the operation and the scheduled events
+// @Accept json
+// @Produce json
+// @Param id path int true "wallet id"
+// @Param input body api.ScheduleRequest true "operation"
+// @Param X-API-Key header string false "client API key, required if authentication is enabled"
+// @Param X-User-ID header string false "end user who scheduled the operation, stored in the audit log"
+// @Success 201 {object} schedule.Operation
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /wallets/{id}/scheduled [post]
+func (s *Server) scheduleHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parceID(r)
+	if err != nil {
+		http.Error(w, "incorrect wallet ID: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req ScheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "incorrect request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	item := batch.Item{Type: req.Type, WalletID: id, To: req.To, Amount: req.Amount, Description: req.Description}
+	op, err := s.bill.ScheduleOperation(r.Context(), item, req.ExecuteAt)
+	if err != nil {
+		if errors.Is(err, schedule.InvalidOperationErr) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeInternalError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(op)
+}
+
+// @Summary Get scheduled operations
+// @Tags scheduled
+// @Description get the operations of the wallet scheduled by the client by status in the order of execution
+// @Produce json
+// @Param id path int true "wallet id"
+// @Param status query string false "string enums, default: scheduled" Enums(scheduled, completed, pending_review, failed, canceled)
+// @Param limit query int false "default: 100"
+// @Param X-API-Key header string false "client API key, required if authentication is enabled"
+// @Success 200 {array} schedule.Operation
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /wallets/{id}/scheduled [get]
+func (s *Server) getScheduledListHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parceID(r)
+	if err != nil {
+		http.Error(w, "incorrect wallet ID: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	status := schedule.Status(r.FormValue("status"))
+	switch status {
+	case schedule.Completed, schedule.PendingReview, schedule.Failed, schedule.Canceled:
+	default:
+		status = schedule.Scheduled
+	}
+
+	limitStr := r.FormValue("limit")
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil && limitStr != "" {
+		http.Error(w, "incorrect limit", http.StatusBadRequest)
+		return
+	}
+	if limitStr == "" {
+		limit = 100
+	}
+
+	list, err := s.bill.ScheduledOperations(r.Context(), id, status, limit)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(list)
+}
+
+// @Summary Get scheduled operation
+// @Tags scheduled
+// @Description get the operation with its status, the failed one has the reason in the error. The operations of the other clients are not found
+// @Produce json
+// @Param id path int true "scheduled operation id"
+// @Param X-API-Key header string false "client API key, required if authentication is enabled"
+// @Success 200 {object} schedule.Operation
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 404 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /scheduled/{id} [get]
+func (s *Server) getScheduledHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parceID(r)
+	if err != nil {
+		http.Error(w, "incorrect scheduled operation ID: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	op, err := s.bill.ScheduledOperation(r.Context(), int64(id))
+	if err != nil {
+		writeScheduledError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(op)
+}
+
+// @Summary Cancel scheduled operation
+// @Tags scheduled
+// @Description cancel the operation that is not executed yet. The operations of the other clients are not found
+// @Produce json
+// @Param id path int true "scheduled operation id"
+// @Param X-API-Key header string false "client API key, required if authentication is enabled"
+// @Success 200 {object} schedule.Operation
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 404 {string} string
+// @Failure 409 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /scheduled/{id}/cancel [post]
+func (s *Server) cancelScheduledHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parceID(r)
+	if err != nil {
+		http.Error(w, "incorrect scheduled operation ID: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	op, err := s.bill.CancelScheduled(r.Context(), int64(id))
+	if err != nil {
+		writeScheduledError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(op)
+}
+
+func writeScheduledError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, database.ScheduledDoesNotExistErr):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, schedule.NotScheduledErr):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		writeInternalError(w, r, err)
+	}
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/KseniiaSalmina/Balance/internal/batch"
+	"github.com/KseniiaSalmina/Balance/internal/config"
+	"github.com/KseniiaSalmina/Balance/internal/database"
+	"github.com/KseniiaSalmina/Balance/internal/ratelimit"
+	"github.com/KseniiaSalmina/Balance/internal/schedule"
+)
+
+// scheduledBilling knows scheduled operation 1 and executed operation 2
+type scheduledBilling struct {
+	BillingManager
+}
+
+func (b *scheduledBilling) ScheduleOperation(ctx context.Context, item batch.Item, executeAt int64) (schedule.Operation, error) {
+	op, err := schedule.New(item, executeAt, "test", 1)
+	op.ID = 1
+	return op, err
+}
+
+func (b *scheduledBilling) ScheduledOperation(ctx context.Context, id int64) (*schedule.Operation, error) {
+	switch id {
+	case 1:
+		return &schedule.Operation{ID: 1, Status: schedule.Scheduled}, nil
+	case 2:
+		return &schedule.Operation{ID: 2, Status: schedule.Completed}, nil
+	}
+	return nil, database.ScheduledDoesNotExistErr
+}
+
+func (b *scheduledBilling) CancelScheduled(ctx context.Context, id int64) (*schedule.Operation, error) {
+	op, err := b.ScheduledOperation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if op.Status != schedule.Scheduled {
+		return nil, schedule.NotScheduledErr
+	}
+	op.Status = schedule.Canceled
+	return op, nil
+}
+
+func TestScheduled(t *testing.T) {
+	s, err := NewServer(config.Server{}, &scheduledBilling{}, ratelimit.NewMemory())
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{name: "schedule transfer", method: http.MethodPost, path: "/wallets/1/scheduled", body: `{"type":"transfer","to":2,"amount":"10","execute_at":2}`, wantStatus: http.StatusCreated},
+		{name: "schedule in the past", method: http.MethodPost, path: "/wallets/1/scheduled", body: `{"type":"transfer","to":2,"amount":"10","execute_at":1}`, wantStatus: http.StatusBadRequest},
+		{name: "schedule without description", method: http.MethodPost, path: "/wallets/1/scheduled", body: `{"type":"withdrawal","amount":"10","execute_at":2}`, wantStatus: http.StatusBadRequest},
+		{name: "incorrect request", method: http.MethodPost, path: "/wallets/1/scheduled", body: `{`, wantStatus: http.StatusBadRequest},
+		{name: "scheduled operation", method: http.MethodGet, path: "/scheduled/1", wantStatus: http.StatusOK},
+		{name: "unknown scheduled operation", method: http.MethodGet, path: "/scheduled/3", wantStatus: http.StatusNotFound},
+		{name: "cancel scheduled operation", method: http.MethodPost, path: "/scheduled/1/cancel", wantStatus: http.StatusOK},
+		{name: "cancel executed operation", method: http.MethodPost, path: "/scheduled/2/cancel", wantStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.httpServer.Handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
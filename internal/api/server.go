@@ -2,44 +2,170 @@ package api
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/gorilla/mux"
 	"github.com/shopspring/decimal"
 	httpSwagger "github.com/swaggo/http-swagger"
-	"log"
+	"log/slog"
 	"net/http"
+	"sync/atomic"
 
+	"github.com/KseniiaSalmina/Balance/internal/audit"
+	"github.com/KseniiaSalmina/Balance/internal/batch"
 	"github.com/KseniiaSalmina/Balance/internal/config"
+	"github.com/KseniiaSalmina/Balance/internal/credit"
 	"github.com/KseniiaSalmina/Balance/internal/database"
+	"github.com/KseniiaSalmina/Balance/internal/fees"
+	"github.com/KseniiaSalmina/Balance/internal/jobs"
+	"github.com/KseniiaSalmina/Balance/internal/limits"
+	"github.com/KseniiaSalmina/Balance/internal/metrics"
+	"github.com/KseniiaSalmina/Balance/internal/notify"
+	"github.com/KseniiaSalmina/Balance/internal/ratelimit"
+	"github.com/KseniiaSalmina/Balance/internal/recurring"
+	"github.com/KseniiaSalmina/Balance/internal/risk"
+	"github.com/KseniiaSalmina/Balance/internal/savings"
+	"github.com/KseniiaSalmina/Balance/internal/schedule"
+	"github.com/KseniiaSalmina/Balance/internal/subscription"
+	"github.com/KseniiaSalmina/Balance/internal/tracing"
 	"github.com/KseniiaSalmina/Balance/internal/wallet"
+	"github.com/KseniiaSalmina/Balance/internal/webhook"
 )
 
 type BillingManager interface {
-	MoneyTransaction(id int, opt wallet.Operation, amount decimal.Decimal, desc string) error
-	Transfer(from, to int, amount decimal.Decimal) error
-	CheckBalance(id int) (string, error)
-	CheckHistory(id int, orderBy database.OrderBy, order database.Order, limit int) ([]wallet.HistoryChange, error)
+	MoneyTransaction(ctx context.Context, id int, opt wallet.Operation, amount decimal.Decimal, desc string) error
+	Transfer(ctx context.Context, from, to int, amount decimal.Decimal) error
+	CheckBalance(ctx context.Context, id int) (string, error)
+	CheckHistory(ctx context.Context, id int, orderBy database.OrderBy, order database.Order, limit int) ([]wallet.HistoryChange, error)
+	VerifyHistory(ctx context.Context, id int) (audit.Report, error)
+	WalletLimits(ctx context.Context, id int) (limits.Limits, limits.Limits, error)
+	SetWalletLimits(ctx context.Context, id int, l limits.Limits) error
+	QuoteFee(ctx context.Context, id int, kind risk.Kind, amount decimal.Decimal) (fees.Quote, error)
+	PreviewOperation(ctx context.Context, op risk.Operation, desc string) (wallet.Preview, error)
+	SetWalletTier(ctx context.Context, id int, tier string) error
+	WalletBalance(ctx context.Context, id int) (*wallet.Wallet, error)
+	CreditLine(ctx context.Context, id int) (credit.Line, error)
+	SetCreditLine(ctx context.Context, id int, l credit.Line) error
+	Savings(ctx context.Context, id int) (savings.Account, error)
+	SetSavings(ctx context.Context, id int, a savings.Account) (savings.Account, error)
+	InterestReport(ctx context.Context, id int, from, to int64) (savings.Report, error)
+	Reviews(ctx context.Context, status risk.ReviewStatus, limit int) ([]risk.Review, error)
+	ApproveReview(ctx context.Context, id int64) error
+	RejectReview(ctx context.Context, id int64) error
+	Subscribe(id int) *notify.Subscription
+	Events(ctx context.Context, id int, after int64, limit int) ([]notify.Event, error)
+	LastEvent(ctx context.Context, id int) (*notify.Event, error)
+	CreateWebhook(ctx context.Context, url string) (webhook.Webhook, error)
+	Webhooks(ctx context.Context) ([]webhook.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int64) error
+	Deliveries(ctx context.Context, webhookID int64, status webhook.Status, limit int) ([]webhook.Delivery, error)
+	ReplayDelivery(ctx context.Context, id int64) error
+	ReplayDeadDeliveries(ctx context.Context, webhookID int64) (int64, error)
+	ExecuteBatch(ctx context.Context, mode batch.Mode, items []batch.Item) ([]batch.Result, error)
+	SubmitBatch(ctx context.Context, mode batch.Mode, items []batch.Item) (jobs.Job, error)
+	Job(ctx context.Context, id int64) (*jobs.Job, error)
+	Jobs(ctx context.Context, status jobs.Status, limit int) ([]jobs.Job, error)
+	JobArtifact(ctx context.Context, id int64) (*jobs.Artifact, error)
+	CancelJob(ctx context.Context, id int64) (*jobs.Job, error)
+	ScheduleOperation(ctx context.Context, item batch.Item, executeAt int64) (schedule.Operation, error)
+	ScheduledOperation(ctx context.Context, id int64) (*schedule.Operation, error)
+	ScheduledOperations(ctx context.Context, walletID int, status schedule.Status, limit int) ([]schedule.Operation, error)
+	CancelScheduled(ctx context.Context, id int64) (*schedule.Operation, error)
+	CreateRecurring(ctx context.Context, p recurring.Payment) (recurring.Payment, error)
+	RecurringPayment(ctx context.Context, id int64) (*recurring.Payment, error)
+	RecurringPayments(ctx context.Context, walletID int, status recurring.Status, limit int) ([]recurring.Payment, error)
+	CancelRecurring(ctx context.Context, id int64) (*recurring.Payment, error)
+	CreatePlan(ctx context.Context, p subscription.Plan) (subscription.Plan, error)
+	Plan(ctx context.Context, id int64) (*subscription.Plan, error)
+	Plans(ctx context.Context, limit int) ([]subscription.Plan, error)
+	CreateSubscription(ctx context.Context, planID int64, walletID int, startAt int64) (subscription.Subscription, error)
+	Subscription(ctx context.Context, id int64) (*subscription.Subscription, error)
+	Subscriptions(ctx context.Context, walletID int, status subscription.Status, limit int) ([]subscription.Subscription, error)
+	CancelSubscription(ctx context.Context, id int64) (*subscription.Subscription, error)
 }
 
 type Server struct {
-	bill       BillingManager
-	httpServer *http.Server
+	bill         BillingManager
+	cfg          atomic.Pointer[config.Server]
+	checks       []ReadinessChecker
+	shuttingDown atomic.Bool
+	closing      chan struct{} //closed on shutdown to end the event streams
+	httpServer   *http.Server
 }
 
-func NewServer(cfg config.Server, bill BillingManager) (*Server, error) {
+// NewServer creates the HTTP server, checks are used by the readiness probe
+func NewServer(cfg config.Server, bill BillingManager, limiter ratelimit.Backend, checks ...ReadinessChecker) (*Server, error) {
 	s := &Server{
-		bill: bill,
+		bill:    bill,
+		checks:  checks,
+		closing: make(chan struct{}),
 	}
+	s.cfg.Store(&cfg)
 
 	router := mux.NewRouter()
-	router.Name("get_balance").Methods(http.MethodGet).Path("/wallets/{id}/balance").HandlerFunc(s.getBalanceHandler)
-	router.Name("get_history").Methods(http.MethodGet).Path("/wallets/{id}/history").HandlerFunc(s.getHistoryHandler)
-	router.Name("transaction").Methods(http.MethodPatch).Path("/wallets/{id}/transaction").HandlerFunc(s.moneyTransactionHandler)
+	router.Use(requestIDMiddleware, tracing.Middleware, accessLogMiddleware, metricsMiddleware)
+	router.Name("metrics").Methods(http.MethodGet).Path("/metrics").Handler(metrics.Handler())
+	router.Name("healthz").Methods(http.MethodGet).Path("/healthz").HandlerFunc(s.livenessHandler)
+	router.Name("readyz").Methods(http.MethodGet).Path("/readyz").HandlerFunc(s.readinessHandler)
 
 	swagHandler := httpSwagger.Handler(
 		httpSwagger.URL("/swagger/doc.json"),
 	)
 	router.Methods(http.MethodGet).PathPrefix("/swagger").HandlerFunc(swagHandler)
 
+	private := router.NewRoute().Subrouter()
+	private.Use(addressRateLimitMiddleware(s.auth, s.rateLimit, limiter), authMiddleware(s.auth), rateLimitMiddleware(s.rateLimit, limiter))
+
+	//admin endpoints change the settings that bound the clients or give access to the operations of all the wallets,
+	//so the clients can not use them
+	admin := private.NewRoute().Subrouter()
+	admin.Use(adminMiddleware(s.auth))
+	admin.Name("set_limits").Methods(http.MethodPut).Path("/wallets/{id}/limits").HandlerFunc(s.setLimitsHandler)
+	admin.Name("get_reviews").Methods(http.MethodGet).Path("/reviews").HandlerFunc(s.getReviewsHandler)
+	admin.Name("approve_review").Methods(http.MethodPost).Path("/reviews/{id}/approve").HandlerFunc(s.approveReviewHandler)
+	admin.Name("reject_review").Methods(http.MethodPost).Path("/reviews/{id}/reject").HandlerFunc(s.rejectReviewHandler)
+	admin.Name("create_webhook").Methods(http.MethodPost).Path("/webhooks").HandlerFunc(s.createWebhookHandler)
+	admin.Name("get_webhooks").Methods(http.MethodGet).Path("/webhooks").HandlerFunc(s.getWebhooksHandler)
+	admin.Name("delete_webhook").Methods(http.MethodDelete).Path("/webhooks/{id}").HandlerFunc(s.deleteWebhookHandler)
+	admin.Name("get_deliveries").Methods(http.MethodGet).Path("/webhooks/{id}/deliveries").HandlerFunc(s.getDeliveriesHandler)
+	admin.Name("replay_dead_deliveries").Methods(http.MethodPost).Path("/webhooks/{id}/replay").HandlerFunc(s.replayDeadDeliveriesHandler)
+	admin.Name("replay_delivery").Methods(http.MethodPost).Path("/deliveries/{id}/replay").HandlerFunc(s.replayDeliveryHandler)
+	admin.Name("create_plan").Methods(http.MethodPost).Path("/plans").HandlerFunc(s.createPlanHandler)
+	admin.Name("set_savings").Methods(http.MethodPut).Path("/wallets/{id}/savings").HandlerFunc(s.setSavingsHandler)
+	admin.Name("set_credit").Methods(http.MethodPut).Path("/wallets/{id}/credit").HandlerFunc(s.setCreditHandler)
+	admin.Name("set_tier").Methods(http.MethodPut).Path("/wallets/{id}/tier").HandlerFunc(s.setTierHandler)
+
+	private.Name("get_balance").Methods(http.MethodGet).Path("/wallets/{id}/balance").HandlerFunc(s.getBalanceHandler)
+	private.Name("get_history").Methods(http.MethodGet).Path("/wallets/{id}/history").HandlerFunc(s.getHistoryHandler)
+	private.Name("transaction").Methods(http.MethodPatch).Path("/wallets/{id}/transaction").HandlerFunc(s.moneyTransactionHandler)
+	private.Name("events").Methods(http.MethodGet).Path("/wallets/{id}/events").HandlerFunc(s.eventsHandler)
+	private.Name("batch").Methods(http.MethodPost).Path("/batches").HandlerFunc(s.batchHandler)
+	private.Name("schedule").Methods(http.MethodPost).Path("/wallets/{id}/scheduled").HandlerFunc(s.scheduleHandler)
+	private.Name("get_scheduled_list").Methods(http.MethodGet).Path("/wallets/{id}/scheduled").HandlerFunc(s.getScheduledListHandler)
+	private.Name("get_scheduled").Methods(http.MethodGet).Path("/scheduled/{id}").HandlerFunc(s.getScheduledHandler)
+	private.Name("cancel_scheduled").Methods(http.MethodPost).Path("/scheduled/{id}/cancel").HandlerFunc(s.cancelScheduledHandler)
+	private.Name("create_recurring").Methods(http.MethodPost).Path("/wallets/{id}/recurring").HandlerFunc(s.createRecurringHandler)
+	private.Name("get_recurring_list").Methods(http.MethodGet).Path("/wallets/{id}/recurring").HandlerFunc(s.getRecurringListHandler)
+	private.Name("get_recurring").Methods(http.MethodGet).Path("/recurring/{id}").HandlerFunc(s.getRecurringHandler)
+	private.Name("get_upcoming").Methods(http.MethodGet).Path("/recurring/{id}/upcoming").HandlerFunc(s.getUpcomingHandler)
+	private.Name("cancel_recurring").Methods(http.MethodPost).Path("/recurring/{id}/cancel").HandlerFunc(s.cancelRecurringHandler)
+	private.Name("create_subscription").Methods(http.MethodPost).Path("/wallets/{id}/subscriptions").HandlerFunc(s.createSubscriptionHandler)
+	private.Name("get_subscriptions").Methods(http.MethodGet).Path("/wallets/{id}/subscriptions").HandlerFunc(s.getSubscriptionsHandler)
+	private.Name("get_subscription").Methods(http.MethodGet).Path("/subscriptions/{id}").HandlerFunc(s.getSubscriptionHandler)
+	private.Name("cancel_subscription").Methods(http.MethodPost).Path("/subscriptions/{id}/cancel").HandlerFunc(s.cancelSubscriptionHandler)
+	private.Name("get_plans").Methods(http.MethodGet).Path("/plans").HandlerFunc(s.getPlansHandler)
+	private.Name("get_plan").Methods(http.MethodGet).Path("/plans/{id}").HandlerFunc(s.getPlanHandler)
+	private.Name("verify_history").Methods(http.MethodGet).Path("/wallets/{id}/audit").HandlerFunc(s.verifyHistoryHandler)
+	private.Name("get_limits").Methods(http.MethodGet).Path("/wallets/{id}/limits").HandlerFunc(s.getLimitsHandler)
+	private.Name("get_fee").Methods(http.MethodGet).Path("/wallets/{id}/fee").HandlerFunc(s.getFeeHandler)
+	private.Name("get_credit").Methods(http.MethodGet).Path("/wallets/{id}/credit").HandlerFunc(s.getCreditHandler)
+	private.Name("get_savings").Methods(http.MethodGet).Path("/wallets/{id}/savings").HandlerFunc(s.getSavingsHandler)
+	private.Name("get_interest").Methods(http.MethodGet).Path("/wallets/{id}/interest").HandlerFunc(s.getInterestHandler)
+	private.Name("get_jobs").Methods(http.MethodGet).Path("/jobs").HandlerFunc(s.getJobsHandler)
+	private.Name("get_job").Methods(http.MethodGet).Path("/jobs/{id}").HandlerFunc(s.getJobHandler)
+	private.Name("get_job_artifact").Methods(http.MethodGet).Path("/jobs/{id}/artifact").HandlerFunc(s.getJobArtifactHandler)
+	private.Name("cancel_job").Methods(http.MethodPost).Path("/jobs/{id}/cancel").HandlerFunc(s.cancelJobHandler)
+
 	s.httpServer = &http.Server{
 		Addr:         cfg.Listen,
 		Handler:      router,
@@ -47,18 +173,54 @@ func NewServer(cfg config.Server, bill BillingManager) (*Server, error) {
 		WriteTimeout: cfg.WriteTimeout,
 		IdleTimeout:  cfg.IdleTimeout,
 	}
+	s.httpServer.RegisterOnShutdown(func() { close(s.closing) })
+
+	if cfg.TLS.Enabled() {
+		tlsConfig, err := TLSConfig(cfg.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("NewServer -> %w", err)
+		}
+		s.httpServer.TLSConfig = tlsConfig
+	}
 	return s, nil
 }
 
-func (s *Server) Run() {
-	log.Println("server started")
+// Reconfigure replaces the API keys and the rate limits, listening, timeouts and TLS settings are applied only on restart.
+// Changed certificate files are reloaded without restart
+func (s *Server) Reconfigure(cfg config.Server) {
+	s.cfg.Store(&cfg)
+}
+
+func (s *Server) auth() config.Auth {
+	return s.cfg.Load().Auth
+}
+
+func (s *Server) rateLimit() config.RateLimit {
+	return s.cfg.Load().RateLimit
+}
+
+// Run starts listening in the background. The returned channel receives an error if the server stops unexpectedly
+func (s *Server) Run() <-chan error {
+	tls := s.httpServer.TLSConfig != nil
+	slog.Info("server started", "listen", s.httpServer.Addr, "tls", tls)
 
+	errs := make(chan error, 1)
 	go func() {
-		err := s.httpServer.ListenAndServe()
-		log.Printf("http server stopped: %s", err.Error())
+		var err error
+		if tls {
+			//the certificate is taken from TLSConfig
+			err = s.httpServer.ListenAndServeTLS("", "")
+		} else {
+			err = s.httpServer.ListenAndServe()
+		}
+		if !errors.Is(err, http.ErrServerClosed) {
+			errs <- fmt.Errorf("Server.Run -> %w", err)
+		}
 	}()
+	return errs
 }
 
-func (s *Server) Shutdown() error {
-	return s.httpServer.Shutdown(context.Background())
+// Shutdown stops accepting connections and waits for the requests in progress until ctx is done
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.httpServer.Shutdown(ctx)
 }
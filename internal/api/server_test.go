@@ -0,0 +1,43 @@
+package api
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/KseniiaSalmina/Balance/internal/config"
+	"github.com/KseniiaSalmina/Balance/internal/ratelimit"
+)
+
+func TestServer_Run(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer busy.Close()
+
+	s, err := NewServer(config.Server{Listen: busy.Addr().String()}, nil, ratelimit.NewMemory())
+	assert.NoError(t, err)
+
+	select {
+	case err = <-s.Run():
+		assert.Error(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("expected an error from the server listening on a busy address")
+	}
+}
+
+func TestServer_Shutdown(t *testing.T) {
+	s, err := NewServer(config.Server{Listen: "127.0.0.1:0"}, nil, ratelimit.NewMemory())
+	assert.NoError(t, err)
+
+	errs := s.Run()
+	time.Sleep(50 * time.Millisecond)
+	assert.NoError(t, s.Shutdown(context.Background()))
+
+	select {
+	case err = <-errs:
+		t.Fatalf("unexpected error after shutdown: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,275 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/KseniiaSalmina/Balance/internal/database"
+	"github.com/KseniiaSalmina/Balance/internal/recurring"
+	"github.com/KseniiaSalmina/Balance/internal/subscription"
+)
+
+// @Summary Create plan
+// @Tags subscriptions
+// @Description create the plan charging the price at the start of every period. The failed charge is retried at the retry_schedule offsets
+// @Description and at the end of the grace period from the start of the unpaid period, then the subscription is suspended. Requires an admin API key
+// @Accept json
+// @Produce json
+// @Param input body api.PlanRequest true "plan"
+// @Param X-API-Key header string true "admin API key"
+// @Success 201 {object} subscription.Plan
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 403 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /plans [post]
+func (s *Server) createPlanHandler(w http.ResponseWriter, r *http.Request) {
+	var req PlanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "incorrect request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	p, err := s.bill.CreatePlan(r.Context(), subscription.Plan{
+		Name:          req.Name,
+		Price:         req.Price,
+		Period:        recurring.Rule{Frequency: req.Frequency, Interval: req.Interval},
+		GracePeriod:   req.GracePeriod,
+		RetrySchedule: req.RetrySchedule,
+	})
+	if err != nil {
+		if errors.Is(err, subscription.InvalidPlanErr) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeInternalError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(p)
+}
+
+// @Summary Get plans
+// @Tags subscriptions
+// @Description get the plans, newest first
+// @Produce json
+// @Param limit query int false "default: 100"
+// @Param X-API-Key header string false "client API key, required if authentication is enabled"
+// @Success 200 {array} subscription.Plan
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /plans [get]
+func (s *Server) getPlansHandler(w http.ResponseWriter, r *http.Request) {
+	limitStr := r.FormValue("limit")
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil && limitStr != "" {
+		http.Error(w, "incorrect limit", http.StatusBadRequest)
+		return
+	}
+	if limitStr == "" {
+		limit = 100
+	}
+
+	plans, err := s.bill.Plans(r.Context(), limit)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(plans)
+}
+
+// @Summary Get plan
+// @Tags subscriptions
+// @Produce json
+// @Param id path int true "plan id"
+// @Param X-API-Key header string false "client API key, required if authentication is enabled"
+// @Success 200 {object} subscription.Plan
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 404 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /plans/{id} [get]
+func (s *Server) getPlanHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parceID(r)
+	if err != nil {
+		http.Error(w, "incorrect plan ID: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	p, err := s.bill.Plan(r.Context(), int64(id))
+	if err != nil {
+		writeSubscriptionError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(p)
+}
+
+// @Summary Subscribe
+// @Tags subscriptions
+// @Description subscribe the wallet to the plan, the price is withdrawn at the start of every period from start_at on behalf of the client
+// @Accept json
+// @Produce json
+// @Param id path int true "wallet id"
+// @Param input body api.SubscriptionRequest true "subscription"
+// @Param X-API-Key header string false "client API key, required if authentication is enabled"
+// @Param X-User-ID header string false "end user who subscribed, stored in the audit log"
+// @Success 201 {object} subscription.Subscription
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /wallets/{id}/subscriptions [post]
+func (s *Server) createSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parceID(r)
+	if err != nil {
+		http.Error(w, "incorrect wallet ID: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req SubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "incorrect request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sub, err := s.bill.CreateSubscription(r.Context(), req.PlanID, id, req.StartAt)
+	if err != nil {
+		if errors.Is(err, subscription.InvalidSubscriptionErr) || errors.Is(err, database.PlanDoesNotExistErr) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeInternalError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(sub)
+}
+
+// @Summary Get subscriptions
+// @Tags subscriptions
+// @Description get the subscriptions of the wallet created by the client by status, newest first
+// @Produce json
+// @Param id path int true "wallet id"
+// @Param status query string false "string enums, default: active" Enums(active, past_due, suspended, canceled)
+// @Param limit query int false "default: 100"
+// @Param X-API-Key header string false "client API key, required if authentication is enabled"
+// @Success 200 {array} subscription.Subscription
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /wallets/{id}/subscriptions [get]
+func (s *Server) getSubscriptionsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parceID(r)
+	if err != nil {
+		http.Error(w, "incorrect wallet ID: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	status := subscription.Status(r.FormValue("status"))
+	switch status {
+	case subscription.PastDue, subscription.Suspended, subscription.Canceled:
+	default:
+		status = subscription.Active
+	}
+
+	limitStr := r.FormValue("limit")
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil && limitStr != "" {
+		http.Error(w, "incorrect limit", http.StatusBadRequest)
+		return
+	}
+	if limitStr == "" {
+		limit = 100
+	}
+
+	list, err := s.bill.Subscriptions(r.Context(), id, status, limit)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(list)
+}
+
+// @Summary Get subscription
+// @Tags subscriptions
+// @Description get the subscription with the paid period and the state of the charge attempts. The subscriptions of the other clients are not found
+// @Produce json
+// @Param id path int true "subscription id"
+// @Param X-API-Key header string false "client API key, required if authentication is enabled"
+// @Success 200 {object} subscription.Subscription
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 404 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /subscriptions/{id} [get]
+func (s *Server) getSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parceID(r)
+	if err != nil {
+		http.Error(w, "incorrect subscription ID: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sub, err := s.bill.Subscription(r.Context(), int64(id))
+	if err != nil {
+		writeSubscriptionError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(sub)
+}
+
+// @Summary Cancel subscription
+// @Tags subscriptions
+// @Description stop charging the subscription, the paid period is kept. The subscriptions of the other clients are not found
+// @Produce json
+// @Param id path int true "subscription id"
+// @Param X-API-Key header string false "client API key, required if authentication is enabled"
+// @Success 200 {object} subscription.Subscription
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 404 {string} string
+// @Failure 409 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /subscriptions/{id}/cancel [post]
+func (s *Server) cancelSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parceID(r)
+	if err != nil {
+		http.Error(w, "incorrect subscription ID: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sub, err := s.bill.CancelSubscription(r.Context(), int64(id))
+	if err != nil {
+		writeSubscriptionError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(sub)
+}
+
+func writeSubscriptionError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, database.PlanDoesNotExistErr) || errors.Is(err, database.SubscriptionDoesNotExistErr):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, subscription.CanceledErr):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		writeInternalError(w, r, err)
+	}
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+
+	"github.com/KseniiaSalmina/Balance/internal/config"
+	"github.com/KseniiaSalmina/Balance/internal/database"
+	"github.com/KseniiaSalmina/Balance/internal/ratelimit"
+	"github.com/KseniiaSalmina/Balance/internal/subscription"
+)
+
+// subscriptionBilling knows plan 1, active subscription 1 and canceled subscription 2
+type subscriptionBilling struct {
+	BillingManager
+}
+
+func (b *subscriptionBilling) CreatePlan(ctx context.Context, p subscription.Plan) (subscription.Plan, error) {
+	p, err := subscription.NewPlan(p, 1)
+	p.ID = 1
+	return p, err
+}
+
+func (b *subscriptionBilling) Plan(ctx context.Context, id int64) (*subscription.Plan, error) {
+	if id != 1 {
+		return nil, database.PlanDoesNotExistErr
+	}
+	return &subscription.Plan{ID: 1}, nil
+}
+
+func (b *subscriptionBilling) CreateSubscription(ctx context.Context, planID int64, walletID int, startAt int64) (subscription.Subscription, error) {
+	plan, err := b.Plan(ctx, planID)
+	if err != nil {
+		return subscription.Subscription{}, err
+	}
+	return subscription.New(*plan, walletID, startAt, "test", 1)
+}
+
+func (b *subscriptionBilling) Subscription(ctx context.Context, id int64) (*subscription.Subscription, error) {
+	switch id {
+	case 1:
+		return &subscription.Subscription{ID: 1, Status: subscription.Active}, nil
+	case 2:
+		return &subscription.Subscription{ID: 2, Status: subscription.Canceled}, nil
+	}
+	return nil, database.SubscriptionDoesNotExistErr
+}
+
+func (b *subscriptionBilling) CancelSubscription(ctx context.Context, id int64) (*subscription.Subscription, error) {
+	sub, err := b.Subscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sub.Status == subscription.Canceled {
+		return nil, subscription.CanceledErr
+	}
+	sub.Status = subscription.Canceled
+	return sub, nil
+}
+
+func TestSubscriptions(t *testing.T) {
+	s, err := NewServer(config.Server{Auth: config.Auth{AdminKeys: config.APIKeys{"root": "admin"}}}, &subscriptionBilling{}, ratelimit.NewMemory())
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		key        string
+		body       string
+		wantStatus int
+	}{
+		{name: "create plan", method: http.MethodPost, path: "/plans", key: "root", body: `{"name":"pro","price":"100","frequency":"monthly","grace_period":259200,"retry_schedule":[86400]}`, wantStatus: http.StatusCreated},
+		{name: "create plan without price", method: http.MethodPost, path: "/plans", key: "root", body: `{"name":"pro","frequency":"monthly"}`, wantStatus: http.StatusBadRequest},
+		{name: "client creates plan", method: http.MethodPost, path: "/plans", body: `{"name":"pro","price":"0.01","frequency":"monthly"}`, wantStatus: http.StatusForbidden},
+		{name: "plan", method: http.MethodGet, path: "/plans/1", wantStatus: http.StatusOK},
+		{name: "unknown plan", method: http.MethodGet, path: "/plans/2", wantStatus: http.StatusNotFound},
+		{name: "subscribe", method: http.MethodPost, path: "/wallets/1/subscriptions", body: `{"plan_id":1}`, wantStatus: http.StatusCreated},
+		{name: "subscribe to unknown plan", method: http.MethodPost, path: "/wallets/1/subscriptions", body: `{"plan_id":2}`, wantStatus: http.StatusBadRequest},
+		{name: "subscribe in the past", method: http.MethodPost, path: "/wallets/1/subscriptions", body: `{"plan_id":1,"start_at":-1}`, wantStatus: http.StatusBadRequest},
+		{name: "subscription", method: http.MethodGet, path: "/subscriptions/1", wantStatus: http.StatusOK},
+		{name: "unknown subscription", method: http.MethodGet, path: "/subscriptions/3", wantStatus: http.StatusNotFound},
+		{name: "cancel subscription", method: http.MethodPost, path: "/subscriptions/1/cancel", wantStatus: http.StatusOK},
+		{name: "cancel canceled subscription", method: http.MethodPost, path: "/subscriptions/2/cancel", wantStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantStatus, serve(s, tt.method, tt.path, tt.key, tt.body))
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/KseniiaSalmina/Balance/internal/config"
+)
+
+// certCheckInterval limits how often the certificate files are checked for changes
+const certCheckInterval = 5 * time.Second
+
+// certReloader serves the certificate and the client CA from the files and reloads them when the files change,
+// so rotated certificates are used without restart
+type certReloader struct {
+	cfg config.TLS
+
+	mu      sync.Mutex
+	checked time.Time
+	modTime time.Time
+	config  *tls.Config
+}
+
+// TLSConfig returns the server TLS config with the certificate reloaded when its files change
+func TLSConfig(cfg config.TLS) (*tls.Config, error) {
+	r, err := newCertReloader(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return r.tlsConfig(), nil
+}
+
+func newCertReloader(cfg config.TLS) (*certReloader, error) {
+	r := &certReloader{cfg: cfg}
+	modTime, err := r.lastModified()
+	if err != nil {
+		return nil, err
+	}
+	if err = r.load(modTime); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// tlsConfig returns the server TLS config, every handshake takes the current certificate from the reloader
+func (r *certReloader) tlsConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return &r.current().Certificates[0], nil
+		},
+		GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.current(), nil
+		},
+	}
+}
+
+func (r *certReloader) current() *tls.Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.checked) < certCheckInterval {
+		return r.config
+	}
+	r.checked = time.Now()
+
+	modTime, err := r.lastModified()
+	if err == nil && modTime.After(r.modTime) {
+		err = r.load(modTime)
+		if err == nil {
+			slog.Info("TLS certificate reloaded")
+		}
+	}
+	if err != nil {
+		slog.Error("TLS certificate is not reloaded, the current one is used", "error", err)
+	}
+	return r.config
+}
+
+// load reads the files, must be called with the lock held or before the reloader is used
+func (r *certReloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("load -> %w", err)
+	}
+
+	c := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+
+	if r.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("load -> %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in %s", r.cfg.ClientCAFile)
+		}
+		c.ClientCAs = pool
+		c.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	r.config = c
+	r.modTime = modTime
+	return nil
+}
+
+func (r *certReloader) lastModified() (time.Time, error) {
+	var last time.Time
+	for _, file := range []string{r.cfg.CertFile, r.cfg.KeyFile, r.cfg.ClientCAFile} {
+		if file == "" {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("lastModified -> %w", err)
+		}
+		if info.ModTime().After(last) {
+			last = info.ModTime()
+		}
+	}
+	return last, nil
+}
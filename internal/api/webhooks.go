@@ -0,0 +1,208 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/KseniiaSalmina/Balance/internal/database"
+	"github.com/KseniiaSalmina/Balance/internal/webhook"
+)
+
+// @Summary Create webhook
+// @Tags webhooks
+// @Description subscribe the URL to the events of committed operations of all the wallets, so it requires an admin API key.
+// @Description The URL must not point to a private, loopback or link-local address. The secret of the signatures is returned only in this response
+// @Accept json
+// @Produce json
+// @Param webhook body api.CreateWebhookRequest true "webhook URL"
+// @Param X-API-Key header string true "admin API key"
+// @Success 201 {object} webhook.Webhook
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 403 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /webhooks [post]
+func (s *Server) createWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	var req CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "incorrect request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	wh, err := s.bill.CreateWebhook(r.Context(), req.URL)
+	if err != nil {
+		if errors.Is(err, webhook.InvalidURLErr) || errors.Is(err, webhook.PrivateTargetErr) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeInternalError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(wh)
+}
+
+// @Summary Get webhooks
+// @Tags webhooks
+// @Description get the webhooks without secrets
+// @Produce json
+// @Param X-API-Key header string true "admin API key"
+// @Success 200 {array} webhook.Webhook
+// @Failure 401 {string} string
+// @Failure 403 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /webhooks [get]
+func (s *Server) getWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := s.bill.Webhooks(r.Context())
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(webhooks)
+}
+
+// @Summary Delete webhook
+// @Tags webhooks
+// @Description unsubscribe the webhook, its pending deliveries are dropped
+// @Param id path int true "webhook id"
+// @Param X-API-Key header string true "admin API key"
+// @Success 200
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 403 {string} string
+// @Failure 404 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /webhooks/{id} [delete]
+func (s *Server) deleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parceID(r)
+	if err != nil {
+		http.Error(w, "incorrect webhook ID: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = s.bill.DeleteWebhook(r.Context(), int64(id)); err != nil {
+		writeWebhookError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// @Summary Get webhook deliveries
+// @Tags webhooks
+// @Description get the deliveries of the webhook by status, newest first. Dead deliveries are the dead letters: all the attempts failed
+// @Produce json
+// @Param id path int true "webhook id"
+// @Param status query string false "string enums, default: dead" Enums(pending, delivered, dead)
+// @Param limit query int false "default: 100"
+// @Param X-API-Key header string true "admin API key"
+// @Success 200 {array} webhook.Delivery
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 403 {string} string
+// @Failure 404 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /webhooks/{id}/deliveries [get]
+func (s *Server) getDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parceID(r)
+	if err != nil {
+		http.Error(w, "incorrect webhook ID: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	status := webhook.Status(r.FormValue("status"))
+	if status != webhook.Pending && status != webhook.Delivered {
+		status = webhook.Dead
+	}
+
+	limitStr := r.FormValue("limit")
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil && limitStr != "" {
+		http.Error(w, "incorrect limit", http.StatusBadRequest)
+		return
+	}
+	if limitStr == "" {
+		limit = 100
+	}
+
+	deliveries, err := s.bill.Deliveries(r.Context(), int64(id), status, limit)
+	if err != nil {
+		writeWebhookError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// @Summary Replay dead deliveries
+// @Tags webhooks
+// @Description send again all the dead deliveries of the webhook with a new set of attempts
+// @Produce json
+// @Param id path int true "webhook id"
+// @Param X-API-Key header string true "admin API key"
+// @Success 200 {object} api.ReplayResponse
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 403 {string} string
+// @Failure 404 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /webhooks/{id}/replay [post]
+func (s *Server) replayDeadDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parceID(r)
+	if err != nil {
+		http.Error(w, "incorrect webhook ID: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	n, err := s.bill.ReplayDeadDeliveries(r.Context(), int64(id))
+	if err != nil {
+		writeWebhookError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(ReplayResponse{Replayed: n})
+}
+
+// @Summary Replay delivery
+// @Tags webhooks
+// @Description send the delivery again with a new set of attempts, whatever its status is
+// @Param id path int true "delivery id"
+// @Param X-API-Key header string true "admin API key"
+// @Success 200
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 403 {string} string
+// @Failure 404 {string} string
+// @Failure 429 {string} string
+// @Failure 500	{string} string
+// @Router /deliveries/{id}/replay [post]
+func (s *Server) replayDeliveryHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := parceID(r)
+	if err != nil {
+		http.Error(w, "incorrect delivery ID: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = s.bill.ReplayDelivery(r.Context(), int64(id)); err != nil {
+		writeWebhookError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func writeWebhookError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, database.WebhookDoesNotExistErr) || errors.Is(err, database.DeliveryDoesNotExistErr) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeInternalError(w, r, err)
+}
@@ -1,28 +1,66 @@
 package app
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"os/signal"
+	"reflect"
 	"syscall"
+	"time"
 
 	"github.com/KseniiaSalmina/Balance/internal/api"
+	"github.com/KseniiaSalmina/Balance/internal/batch"
 	"github.com/KseniiaSalmina/Balance/internal/billing"
 	"github.com/KseniiaSalmina/Balance/internal/config"
 	"github.com/KseniiaSalmina/Balance/internal/database"
+	"github.com/KseniiaSalmina/Balance/internal/events"
+	"github.com/KseniiaSalmina/Balance/internal/fees"
+	"github.com/KseniiaSalmina/Balance/internal/grpcapi"
+	"github.com/KseniiaSalmina/Balance/internal/jobs"
+	"github.com/KseniiaSalmina/Balance/internal/limits"
+	"github.com/KseniiaSalmina/Balance/internal/logger"
+	"github.com/KseniiaSalmina/Balance/internal/metrics"
+	"github.com/KseniiaSalmina/Balance/internal/ratelimit"
+	"github.com/KseniiaSalmina/Balance/internal/risk"
+	"github.com/KseniiaSalmina/Balance/internal/savings"
+	"github.com/KseniiaSalmina/Balance/internal/schedule"
+	"github.com/KseniiaSalmina/Balance/internal/tracing"
+	"github.com/KseniiaSalmina/Balance/internal/webhook"
 )
 
 type Application struct {
-	cfg    config.Application
-	close  chan os.Signal
-	server *api.Server
-	db     *database.DB
-	bill   *billing.Billing
+	loader    config.Loader
+	cfg       config.Application
+	close     chan os.Signal
+	reload    chan os.Signal
+	server    *api.Server
+	grpc      *grpcapi.Server
+	db        *database.DB
+	bill      *billing.Billing
+	limiter   ratelimit.Backend
+	hooks     *webhook.Dispatcher
+	jobs      *jobs.Queue
+	scheduler *schedule.Scheduler
+	charges   *schedule.Scheduler //charges the due subscriptions
+	overdraft *schedule.Scheduler //charges the overdraft interest for the finished days
+	interest  *schedule.Scheduler //accrues the interest of the savings for the finished days
+	events    events.Publisher
+	tracing   func(context.Context) error
 }
 
-func NewApplication(cfg config.Application) (*Application, error) {
+func NewApplication(loader config.Loader) (*Application, error) {
+	cfg, err := loader.Load()
+	if err != nil {
+		return nil, err
+	}
+
 	app := Application{
-		cfg: cfg,
+		loader: loader,
+		cfg:    cfg,
 	}
 
 	if err := app.bootstrap(); err != nil {
@@ -36,18 +74,62 @@ func NewApplication(cfg config.Application) (*Application, error) {
 
 func (a *Application) bootstrap() error {
 	//init dependencies
+	if err := a.initLogger(); err != nil {
+		return err
+	}
+	if err := a.initTracing(); err != nil {
+		return err
+	}
 	if err := a.initDatabase(); err != nil {
 		return err
 	}
 
 	//init services
-	a.initBilling()
+	if err := a.initEvents(); err != nil {
+		return err
+	}
+	if err := a.initBilling(); err != nil {
+		return err
+	}
+	if err := a.initRateLimiter(); err != nil {
+		return err
+	}
+	a.hooks = webhook.NewDispatcher(a.db, a.cfg.Webhooks)
+	a.jobs = jobs.NewQueue(a.db, a.cfg.Jobs)
+	a.jobs.Register(batch.JobType, a.bill.BatchJob)
+	a.scheduler = schedule.NewScheduler(a.db, a.bill.ExecuteScheduled, a.cfg.Scheduler)
+	a.charges = schedule.NewScheduler(schedule.StoreFunc(a.db.ClaimSubscriptions), a.bill.ChargeSubscription, a.cfg.Scheduler)
+	a.overdraft = schedule.NewScheduler(schedule.StoreFunc(a.db.ClaimOverdraftInterest), a.bill.ChargeOverdraftInterest, a.cfg.Scheduler)
+	a.interest = schedule.NewScheduler(schedule.StoreFunc(a.db.ClaimInterestAccruals), a.bill.AccrueInterest, a.cfg.Scheduler)
 
 	//init controllers
 	if err := a.initServer(); err != nil {
 		return err
 	}
+	if err := a.initGRPCServer(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (a *Application) initLogger() error {
+	l, err := logger.New(a.cfg.Log, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	slog.SetDefault(l)
+	return nil
+}
+
+func (a *Application) initTracing() error {
+	shutdown, err := tracing.Init(a.cfg.Tracing)
+	if err != nil {
+		return err
+	}
 
+	a.tracing = shutdown
 	return nil
 }
 
@@ -58,15 +140,92 @@ func (a *Application) initDatabase() error {
 	}
 
 	a.db = db
+
+	if err = metrics.RegisterPool(db.Stat); err != nil {
+		return err
+	}
 	return nil
 }
 
-func (a *Application) initBilling() {
-	a.bill = billing.NewBilling(a.db)
+func (a *Application) initBilling() error {
+	engine, err := loadRiskEngine(a.cfg.Risk)
+	if err != nil {
+		return err
+	}
+	feeSchedule, err := loadFees(a.cfg.Fees)
+	if err != nil {
+		return err
+	}
+	products, err := loadSavings(a.cfg.Savings)
+	if err != nil {
+		return err
+	}
+
+	var emitter *events.Emitter
+	if a.events != nil {
+		emitter = events.NewEmitter(a.cfg.Events.Source, a.events)
+	}
+
+	a.bill = billing.NewBilling(a.db, limits.Limits(a.cfg.Limits), engine, feeSchedule, products, emitter)
+	return nil
+}
+
+func (a *Application) initEvents() error {
+	cfg := a.cfg.Events
+	switch cfg.Publisher {
+	case "none":
+	case "file":
+		f, err := events.NewFile(cfg.File)
+		if err != nil {
+			return err
+		}
+		a.events = f
+	case "http":
+		a.events = events.NewHTTP(cfg.URL, cfg.Timeout)
+	default:
+		return fmt.Errorf("unknown events publisher %q", cfg.Publisher)
+	}
+	return nil
+}
+
+// loadRiskEngine loads the fraud rules, nil engine means the checks are disabled
+func loadRiskEngine(cfg config.Risk) (*risk.Engine, error) {
+	if cfg.RulesFile == "" {
+		return nil, nil
+	}
+	return risk.Load(cfg.RulesFile)
+}
+
+// loadFees loads the fee schedule, nil schedule means no fees are charged
+func loadFees(cfg config.Fees) (*fees.Schedule, error) {
+	if cfg.File == "" {
+		return nil, nil
+	}
+	return fees.Load(cfg.File)
+}
+
+// loadSavings loads the savings products, nil products mean only the wallet interest rates are applied
+func loadSavings(cfg config.Savings) (*savings.Products, error) {
+	if cfg.File == "" {
+		return nil, nil
+	}
+	return savings.Load(cfg.File)
+}
+
+func (a *Application) initRateLimiter() error {
+	switch a.cfg.Server.RateLimit.Backend {
+	case "memory":
+		a.limiter = ratelimit.NewMemory()
+	case "postgres":
+		a.limiter = ratelimit.NewPostgres(a.db)
+	default:
+		return fmt.Errorf("unknown rate limit backend %q", a.cfg.Server.RateLimit.Backend)
+	}
+	return nil
 }
 
 func (a *Application) initServer() error {
-	s, err := api.NewServer(a.cfg.Server, a.bill)
+	s, err := api.NewServer(a.cfg.Server, a.bill, a.limiter, a.db)
 	if err != nil {
 		return err
 	}
@@ -75,30 +234,192 @@ func (a *Application) initServer() error {
 	return nil
 }
 
-func (a *Application) Run() {
-	defer a.stop()
+// initGRPCServer creates the gRPC server if GRPC_LISTEN is set
+func (a *Application) initGRPCServer() error {
+	if a.cfg.GRPC.Listen == "" {
+		return nil
+	}
 
-	a.server.Run()
+	s, err := grpcapi.NewServer(a.cfg.GRPC, a.cfg.Server, a.bill, a.limiter)
+	if err != nil {
+		return err
+	}
 
-	<-a.close
+	a.grpc = s
+	return nil
 }
 
-func (a *Application) stop() {
-	if err := a.db.Close(); err != nil {
-		log.Printf("incorrect closing of database: %s", err.Error())
-	} else {
-		log.Print("database closed")
+// Run serves until a termination signal or a server failure, then shuts the application down.
+// SIGHUP reloads the configuration
+func (a *Application) Run() error {
+	errs := a.server.Run()
+	a.hooks.Run()
+	a.jobs.Run()
+	a.scheduler.Run()
+	a.charges.Run()
+	a.overdraft.Run()
+	a.interest.Run()
+	var grpcErrs <-chan error
+	if a.grpc != nil {
+		grpcErrs = a.grpc.Run()
+	}
+
+	var runErr error
+	for running := true; running; {
+		select {
+		case <-a.reload:
+			if err := a.reloadConfig(); err != nil {
+				slog.Error("configuration is not reloaded, the current one is kept", "error", err)
+			} else {
+				slog.Info("configuration reloaded")
+			}
+		case sig := <-a.close:
+			slog.Info("shutdown signal received, waiting for traffic to drain", "signal", sig.String(), "delay", a.cfg.Server.ShutdownDelay)
+			a.server.SetNotReady()
+			time.Sleep(a.cfg.Server.ShutdownDelay)
+			running = false
+		case runErr = <-errs:
+			slog.Error("server failed", "error", runErr)
+			running = false
+		case runErr = <-grpcErrs:
+			slog.Error("gRPC server failed", "error", runErr)
+			running = false
+		}
+	}
+
+	if err := a.stop(); err != nil && runErr == nil {
+		runErr = err
+	}
+	return runErr
+}
+
+// reloadConfig applies the log level, limits, fraud rules, fees, savings products, API keys, rate limits and batch sizes without dropping connections.
+// Other settings are kept until restart. If the new configuration is invalid nothing is changed
+func (a *Application) reloadConfig() error {
+	cfg, err := a.loader.Load()
+	if err != nil {
+		return err
+	}
+
+	engine, err := loadRiskEngine(cfg.Risk)
+	if err != nil {
+		return err
+	}
+	feeSchedule, err := loadFees(cfg.Fees)
+	if err != nil {
+		return err
+	}
+	products, err := loadSavings(cfg.Savings)
+	if err != nil {
+		return err
 	}
 
-	if err := a.server.Shutdown(); err != nil {
-		log.Printf("incorrect closing of server: %s", err.Error())
+	next := a.cfg
+	next.Log.Level = cfg.Log.Level
+	next.Limits = cfg.Limits
+	next.Risk = cfg.Risk
+	next.Fees = cfg.Fees
+	next.Savings = cfg.Savings
+	next.Server.Auth = cfg.Server.Auth
+	next.Server.RateLimit = cfg.Server.RateLimit
+	next.Server.RateLimit.Backend = a.cfg.Server.RateLimit.Backend
+	next.Server.Batch = cfg.Server.Batch
+	if !reflect.DeepEqual(next, cfg) {
+		slog.Warn("some changed settings are applied only on restart")
+	}
+
+	logger.SetLevel(next.Log.Level)
+	a.bill.Reconfigure(limits.Limits(next.Limits), engine, feeSchedule, products)
+	a.server.Reconfigure(next.Server)
+	if a.grpc != nil {
+		a.grpc.Reconfigure(next.Server)
+	}
+	a.cfg = next
+	return nil
+}
+
+// stop shuts down in order: stops accepting requests and waits for those in progress, waits for the scheduled operation in progress,
+// the running jobs, the webhook deliveries and the database work to finish, closes the database pool and flushes the traces.
+// The whole sequence is limited by SERVER_SHUTDOWN_TIMEOUT
+func (a *Application) stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), a.cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	var errs []error
+	if err := a.server.Shutdown(ctx); err != nil {
+		slog.Error("incorrect closing of server", "error", err)
+		errs = append(errs, err)
 	} else {
-		log.Print("server closed")
+		slog.Info("server closed")
+	}
+
+	if a.grpc != nil {
+		if err := a.grpc.Shutdown(ctx); err != nil {
+			slog.Error("incorrect closing of gRPC server", "error", err)
+			errs = append(errs, err)
+		} else {
+			slog.Info("gRPC server closed")
+		}
+	}
+
+	if err := a.scheduler.Shutdown(ctx); err != nil {
+		slog.Error("scheduled operation was not finished", "error", err)
+		errs = append(errs, err)
+	}
+
+	if err := a.charges.Shutdown(ctx); err != nil {
+		slog.Error("subscription charge was not finished", "error", err)
+		errs = append(errs, err)
+	}
+
+	if err := a.overdraft.Shutdown(ctx); err != nil {
+		slog.Error("overdraft interest charge was not finished", "error", err)
+		errs = append(errs, err)
 	}
+
+	if err := a.interest.Shutdown(ctx); err != nil {
+		slog.Error("interest accrual was not finished", "error", err)
+		errs = append(errs, err)
+	}
+
+	if err := a.jobs.Shutdown(ctx); err != nil {
+		slog.Error("running jobs were not finished", "error", err)
+		errs = append(errs, err)
+	}
+
+	if err := a.hooks.Shutdown(ctx); err != nil {
+		slog.Error("webhook deliveries were not finished", "error", err)
+		errs = append(errs, err)
+	}
+
+	if err := a.db.Drain(ctx); err != nil {
+		slog.Error("database work was not finished", "error", err)
+		errs = append(errs, err)
+	}
+	a.db.Close()
+	slog.Info("database closed")
+
+	if closer, ok := a.events.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			slog.Error("incorrect closing of events publisher", "error", err)
+			errs = append(errs, err)
+		}
+	}
+
+	if err := a.tracing(ctx); err != nil {
+		slog.Error("incorrect flushing of traces", "error", err)
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
 }
 
 func (a *Application) readyToShutdown() {
 	ch := make(chan os.Signal, 1)
-	signal.Notify(ch, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 	a.close = ch
+
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	a.reload = reload
 }
@@ -0,0 +1,122 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/KseniiaSalmina/Balance/internal/wallet"
+)
+
+// Anonymous is used as an actor when the request was not authenticated
+const Anonymous = "anonymous"
+
+// System is the actor of the operations the service makes on its own, such as the interest charges
+const System = "system"
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx which carries the name of the client who triggered the operation
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// Actor returns the client stored in ctx or Anonymous
+func Actor(ctx context.Context) string {
+	actor, ok := ctx.Value(actorKey{}).(string)
+	if !ok || actor == "" {
+		return Anonymous
+	}
+	return actor
+}
+
+// Client returns the name of the client or administrator authenticated by API key without the end user from the X-User-ID header,
+// which is set by the client itself and can not be trusted
+func Client(actor string) string {
+	client, _, _ := strings.Cut(actor, "/")
+	return client
+}
+
+// Record is a stored history change with its position in the wallet hash chain
+type Record struct {
+	ID       int64
+	Change   wallet.HistoryChange
+	PrevHash string
+	Hash     string
+}
+
+type sealedChange struct {
+	PrevHash     string `json:"prev_hash"`
+	WalletID     int    `json:"wallet_id"`
+	Date         int64  `json:"date"`
+	Operation    string `json:"operation"`
+	Amount       string `json:"amount"`
+	Description  string `json:"description"`
+	Actor        string `json:"actor"`
+	Counterparty int    `json:"counterparty,omitempty"` //omitted when empty, so records sealed before it was added keep their hashes
+}
+
+// Hash seals the change of the wallet together with the hash of the previous change
+func Hash(prevHash string, walletID int, ch wallet.HistoryChange) string {
+	data, _ := json.Marshal(sealedChange{
+		PrevHash:     prevHash,
+		WalletID:     walletID,
+		Date:         ch.Date,
+		Operation:    string(ch.Operation),
+		Amount:       ch.Amount.String(),
+		Description:  ch.Description,
+		Actor:        ch.Actor,
+		Counterparty: ch.Counterparty,
+	})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+type Report struct {
+	WalletID int    `json:"wallet_id"`
+	Valid    bool   `json:"valid"`
+	Checked  int    `json:"checked"`  //number of sealed records
+	Unsealed int    `json:"unsealed"` //records created before the chain was introduced
+	BrokenAt int64  `json:"broken_at,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// Verify walks the records ordered by id and reports the first record that was modified, inserted or deleted.
+// head is the hash of the last change stored with the wallet balance, it reveals deletion of the latest records
+func Verify(walletID int, records []Record, head string) Report {
+	r := Report{WalletID: walletID, Valid: true}
+	prev := ""
+
+	for _, rec := range records {
+		if rec.Hash == "" && r.Checked == 0 {
+			r.Unsealed++
+			continue
+		}
+
+		switch {
+		case rec.PrevHash != prev:
+			return r.broken(rec.ID, "previous record is missing or was modified")
+		case rec.Hash != Hash(rec.PrevHash, walletID, rec.Change):
+			return r.broken(rec.ID, "record was modified")
+		}
+
+		prev = rec.Hash
+		r.Checked++
+	}
+
+	if prev != head {
+		return r.broken(0, fmt.Sprintf("chain ends with %q, but wallet head is %q: latest records were deleted", prev, head))
+	}
+
+	return r
+}
+
+func (r Report) broken(id int64, reason string) Report {
+	r.Valid = false
+	r.BrokenAt = id
+	r.Reason = reason
+	return r
+}
@@ -0,0 +1,85 @@
+package audit
+
+import (
+	"context"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"testing"
+
+	"github.com/KseniiaSalmina/Balance/internal/wallet"
+)
+
+func chain(walletID int, changes ...wallet.HistoryChange) ([]Record, string) {
+	records := make([]Record, 0, len(changes))
+	prev := ""
+	for i, ch := range changes {
+		hash := Hash(prev, walletID, ch)
+		records = append(records, Record{ID: int64(i + 1), Change: ch, PrevHash: prev, Hash: hash})
+		prev = hash
+	}
+	return records, prev
+}
+
+func TestActor(t *testing.T) {
+	assert.Equal(t, Anonymous, Actor(context.Background()))
+	assert.Equal(t, "shop", Actor(WithActor(context.Background(), "shop")))
+}
+
+func TestClient(t *testing.T) {
+	assert.Equal(t, "shop", Client("shop"))
+	assert.Equal(t, "shop", Client("shop/alice"))
+	assert.Equal(t, "shop", Client("shop/alice/bob"))
+	assert.Equal(t, Anonymous, Client(Anonymous))
+}
+
+func TestHash(t *testing.T) {
+	ch := wallet.HistoryChange{Date: 100, Operation: wallet.Replenishment, Amount: decimal.NewFromInt(10), Description: "salary", Actor: "shop"}
+	changed := ch
+	changed.Amount = decimal.NewFromInt(11)
+
+	assert.Equal(t, Hash("", 1, ch), Hash("", 1, ch))
+	assert.NotEqual(t, Hash("", 1, ch), Hash("", 2, ch))
+	assert.NotEqual(t, Hash("", 1, ch), Hash("prev", 1, ch))
+	assert.NotEqual(t, Hash("", 1, ch), Hash("", 1, changed))
+}
+
+func TestVerify(t *testing.T) {
+	ch1 := wallet.HistoryChange{Date: 100, Operation: wallet.Replenishment, Amount: decimal.NewFromInt(1000), Description: "salary", Actor: "shop"}
+	ch2 := wallet.HistoryChange{Date: 101, Operation: wallet.Withdrawal, Amount: decimal.NewFromInt(300), Description: "rent", Actor: "shop"}
+	ch3 := wallet.HistoryChange{Date: 102, Operation: wallet.Withdrawal, Amount: decimal.NewFromInt(50), Description: "coffee", Actor: "shop/42"}
+
+	valid, head := chain(7, ch1, ch2, ch3)
+
+	modified, _ := chain(7, ch1, ch2, ch3)
+	modified[1].Change.Amount = decimal.NewFromInt(30)
+
+	deletedMiddle := []Record{valid[0], valid[2]}
+	deletedLast := valid[:2]
+
+	legacy := append([]Record{{ID: 0, Change: ch1}}, valid...)
+
+	tests := []struct {
+		name     string
+		records  []Record
+		head     string
+		want     bool
+		brokenAt int64
+		unsealed int
+	}{
+		{name: "valid chain", records: valid, head: head, want: true},
+		{name: "empty history", records: nil, head: "", want: true},
+		{name: "modified record", records: modified, head: head, want: false, brokenAt: 2},
+		{name: "deleted record in the middle", records: deletedMiddle, head: head, want: false, brokenAt: 3},
+		{name: "deleted last record", records: deletedLast, head: head, want: false},
+		{name: "records created before the chain", records: legacy, head: head, want: true, unsealed: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Verify(7, tt.records, tt.head)
+			assert.Equal(t, tt.want, got.Valid)
+			assert.Equal(t, tt.brokenAt, got.BrokenAt)
+			assert.Equal(t, tt.unsealed, got.Unsealed)
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package batch
+
+import (
+	"errors"
+	"fmt"
+	"github.com/shopspring/decimal"
+
+	"github.com/KseniiaSalmina/Balance/internal/risk"
+)
+
+var InvalidBatchErr = errors.New("invalid batch")
+
+// JobType is the type of the job executing the async batch, its payload is Request and its artifact is Report
+const JobType = "batch"
+
+type Mode string
+
+const (
+	Atomic     Mode = "atomic"      //all the operations are committed in one transaction or none of them
+	BestEffort Mode = "best_effort" //every operation is committed separately
+)
+
+type Status string
+
+const (
+	Completed Status = "completed" //all the operations are committed or held for review
+	Partial   Status = "partial"   //some operations of a best effort batch failed
+	Failed    Status = "failed"    //atomic batch is rolled back or no operation of a best effort batch is committed
+)
+
+type ItemStatus string
+
+const (
+	ItemCompleted     ItemStatus = "completed"
+	ItemPendingReview ItemStatus = "pending_review"
+	ItemFailed        ItemStatus = "failed"
+	ItemRolledBack    ItemStatus = "rolled_back" //operation of the failed atomic batch, nothing is changed by it
+	ItemCanceled      ItemStatus = "canceled"    //operation of the best effort batch is not executed as the batch is canceled
+)
+
+// Item is an operation of the batch
+type Item struct {
+	Type        risk.Kind       `json:"type"` //replenishment, withdrawal or transfer
+	WalletID    int             `json:"wallet_id"`
+	To          int             `json:"to,omitempty"` //recipient of a transfer
+	Amount      decimal.Decimal `json:"amount"`       //positive number
+	Description string          `json:"description"`  //required for replenishment and withdrawal
+}
+
+type Result struct {
+	Index    int        `json:"index"`
+	Status   ItemStatus `json:"status"`
+	ReviewID int64      `json:"review_id,omitempty"`
+	Error    string     `json:"error,omitempty"`
+}
+
+// Request is the payload of the batch job
+type Request struct {
+	Mode       Mode   `json:"mode"`
+	Operations []Item `json:"operations"`
+}
+
+// Report is the result of the executed batch
+type Report struct {
+	Status  Status   `json:"status"` //completed, partial or failed
+	Results []Result `json:"results"`
+}
+
+// Validate checks the mode and the operations before any of them is executed
+func Validate(mode Mode, items []Item) error {
+	if mode != Atomic && mode != BestEffort {
+		return fmt.Errorf("%w: unknown mode %q", InvalidBatchErr, mode)
+	}
+	if len(items) == 0 {
+		return fmt.Errorf("%w: no operations", InvalidBatchErr)
+	}
+
+	for i, it := range items {
+		if err := it.Validate(); err != nil {
+			return fmt.Errorf("%w: operation %d: %v", InvalidBatchErr, i, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks the operation, the error is the reason
+func (it Item) Validate() error {
+	switch {
+	case it.Type != risk.KindReplenishment && it.Type != risk.KindWithdrawal && it.Type != risk.KindTransfer:
+		return fmt.Errorf("unknown type %q", it.Type)
+	case it.WalletID <= 0:
+		return errors.New("invalid wallet ID")
+	case !it.Amount.IsPositive():
+		return errors.New("amount must be positive")
+	case it.Type == risk.KindTransfer && (it.To <= 0 || it.To == it.WalletID):
+		return errors.New("invalid recipient")
+	case it.Type != risk.KindTransfer && it.Description == "":
+		return errors.New("required description")
+	}
+	return nil
+}
+
+// NewReport returns the report of the finished batch with the status set by the results of its operations
+func NewReport(results []Result) Report {
+	return Report{Status: summarize(results), Results: results}
+}
+
+func summarize(results []Result) Status {
+	failed := 0
+	for _, r := range results {
+		if r.Status != ItemCompleted && r.Status != ItemPendingReview {
+			failed++
+		}
+	}
+
+	switch failed {
+	case 0:
+		return Completed
+	case len(results):
+		return Failed
+	default:
+		return Partial
+	}
+}
+
+func (it Item) Operation() risk.Operation {
+	return risk.Operation{Kind: it.Type, WalletID: it.WalletID, To: it.To, Amount: it.Amount}
+}
@@ -0,0 +1,61 @@
+package batch
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"testing"
+
+	"github.com/KseniiaSalmina/Balance/internal/risk"
+)
+
+func TestValidate(t *testing.T) {
+	ten := decimal.NewFromInt(10)
+
+	tests := []struct {
+		name    string
+		mode    Mode
+		items   []Item
+		wantErr bool
+	}{
+		{name: "valid", mode: Atomic, items: []Item{
+			{Type: risk.KindReplenishment, WalletID: 1, Amount: ten, Description: "salary"},
+			{Type: risk.KindTransfer, WalletID: 1, To: 2, Amount: ten},
+		}},
+		{name: "unknown mode", mode: "all", items: []Item{{Type: risk.KindReplenishment, WalletID: 1, Amount: ten, Description: "salary"}}, wantErr: true},
+		{name: "empty", mode: BestEffort, wantErr: true},
+		{name: "unknown type", mode: Atomic, items: []Item{{Type: "refund", WalletID: 1, Amount: ten, Description: "refund"}}, wantErr: true},
+		{name: "invalid wallet", mode: Atomic, items: []Item{{Type: risk.KindWithdrawal, Amount: ten, Description: "rent"}}, wantErr: true},
+		{name: "negative amount", mode: Atomic, items: []Item{{Type: risk.KindWithdrawal, WalletID: 1, Amount: ten.Neg(), Description: "rent"}}, wantErr: true},
+		{name: "transfer to itself", mode: Atomic, items: []Item{{Type: risk.KindTransfer, WalletID: 1, To: 1, Amount: ten}}, wantErr: true},
+		{name: "without description", mode: Atomic, items: []Item{{Type: risk.KindWithdrawal, WalletID: 1, Amount: ten}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.mode, tt.items)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, InvalidBatchErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestNewReport(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []Result
+		want    Status
+	}{
+		{name: "completed", results: []Result{{Status: ItemCompleted}, {Status: ItemPendingReview}}, want: Completed},
+		{name: "partial", results: []Result{{Status: ItemCompleted}, {Status: ItemFailed}}, want: Partial},
+		{name: "rolled back", results: []Result{{Status: ItemFailed}, {Status: ItemRolledBack}}, want: Failed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NewReport(tt.results).Status)
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package billing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/KseniiaSalmina/Balance/internal/audit"
+	"github.com/KseniiaSalmina/Balance/internal/batch"
+	"github.com/KseniiaSalmina/Balance/internal/database"
+	"github.com/KseniiaSalmina/Balance/internal/jobs"
+	"github.com/KseniiaSalmina/Balance/internal/limits"
+	"github.com/KseniiaSalmina/Balance/internal/metrics"
+	"github.com/KseniiaSalmina/Balance/internal/risk"
+	"github.com/KseniiaSalmina/Balance/internal/tracing"
+	"github.com/KseniiaSalmina/Balance/internal/wallet"
+)
+
+// ExecuteBatch executes the valid batch and returns the result of every operation. Errors caused by the operations
+// are reported in the results, the returned error means the batch was not executed
+func (b *Billing) ExecuteBatch(ctx context.Context, mode batch.Mode, items []batch.Item) (_ []batch.Result, err error) {
+	ctx, span := tracing.Start(ctx, "billing.ExecuteBatch", attribute.String("mode", string(mode)), attribute.Int("size", len(items)))
+	defer tracing.End(span, &err)
+
+	if mode == batch.Atomic {
+		return b.executeAtomic(ctx, items)
+	}
+	return b.executeBestEffort(ctx, items), nil
+}
+
+// executeAtomic executes the operations in one transaction. Operations that require manual review fail the batch,
+// as the review can not hold a part of the transaction
+func (b *Billing) executeAtomic(ctx context.Context, items []batch.Item) ([]batch.Result, error) {
+	tx, err := b.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("executeAtomic -> %w", err)
+	}
+	defer tx.Rollback()
+
+	results := make([]batch.Result, len(items))
+	for i := range results {
+		results[i] = batch.Result{Index: i, Status: batch.ItemRolledBack}
+	}
+
+	now := time.Now()
+	for i, it := range items {
+		op := it.Operation()
+		op.Time = now
+
+		if err = b.assessAtomic(ctx, tx, op); err == nil {
+			err = b.execute(ctx, tx, op, it.Description)
+		}
+		if err != nil {
+			msg, ok := itemError(err)
+			if !ok {
+				return nil, fmt.Errorf("executeAtomic -> operation %d: %w", i, err)
+			}
+			results[i] = batch.Result{Index: i, Status: batch.ItemFailed, Error: msg}
+			return results, nil
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("executeAtomic -> %w", err)
+	}
+
+	for i, it := range items {
+		results[i].Status = batch.ItemCompleted
+		metrics.Operation(string(it.Type), it.Amount)
+	}
+	return results, nil
+}
+
+func (b *Billing) assessAtomic(ctx context.Context, s Storage, op risk.Operation) error {
+	res, err := b.riskEngine().Evaluate(ctx, op, s)
+	if err != nil {
+		return fmt.Errorf("assessAtomic -> %w", err)
+	}
+
+	switch res.Decision {
+	case risk.Deny:
+		return risk.DeniedErr
+	case risk.ManualReview:
+		return fmt.Errorf("%w, not allowed in an atomic batch", risk.ReviewRequiredErr)
+	}
+	return nil
+}
+
+// executeBestEffort executes every operation in its own transaction, failed operations do not stop the batch
+func (b *Billing) executeBestEffort(ctx context.Context, items []batch.Item) []batch.Result {
+	results := make([]batch.Result, len(items))
+	for i, it := range items {
+		if ctx.Err() != nil {
+			results[i] = batch.Result{Index: i, Status: batch.ItemCanceled}
+			continue
+		}
+
+		var err error
+		if it.Type == risk.KindTransfer {
+			err = b.Transfer(ctx, it.WalletID, it.To, it.Amount)
+		} else {
+			err = b.MoneyTransaction(ctx, it.WalletID, wallet.Operation(it.Type), it.Amount, it.Description)
+		}
+
+		var review *risk.ReviewRequiredError
+		switch {
+		case err == nil:
+			results[i] = batch.Result{Index: i, Status: batch.ItemCompleted}
+		case errors.As(err, &review):
+			results[i] = batch.Result{Index: i, Status: batch.ItemPendingReview, ReviewID: review.ReviewID}
+		default:
+			msg, ok := itemError(err)
+			if !ok {
+				slog.ErrorContext(ctx, "batch operation failed", "index", i, "error", err)
+			}
+			results[i] = batch.Result{Index: i, Status: batch.ItemFailed, Error: msg}
+		}
+	}
+	return results
+}
+
+// itemError returns the message of the error caused by the operation itself, false for internal errors
+func itemError(err error) (string, bool) {
+	var exceeded *limits.ExceededError
+	switch {
+	case errors.As(err, &exceeded):
+		return exceeded.Error(), true
+	case errors.Is(err, pgx.ErrNoRows) || errors.Is(err, database.UserDoesNotExistErr):
+		return database.UserDoesNotExistErr.Error(), true
+	case errors.Is(err, wallet.InsufficientFundsErr):
+		return wallet.InsufficientFundsErr.Error(), true
+	case errors.Is(err, risk.DeniedErr) || errors.Is(err, risk.ReviewRequiredErr):
+		return err.Error(), true
+	}
+	return "internal error", false
+}
+
+// SubmitBatch queues the valid batch as a job, its report is the artifact of the job. The batch is not retried,
+// as the best effort batch could repeat the committed operations
+func (b *Billing) SubmitBatch(ctx context.Context, mode batch.Mode, items []batch.Item) (_ jobs.Job, err error) {
+	ctx, span := tracing.Start(ctx, "billing.SubmitBatch", attribute.String("mode", string(mode)), attribute.Int("size", len(items)))
+	defer tracing.End(span, &err)
+
+	j, err := jobs.New(batch.JobType, batch.Request{Mode: mode, Operations: items}, 1, audit.Actor(ctx), time.Now().Unix())
+	if err != nil {
+		return jobs.Job{}, fmt.Errorf("SubmitBatch -> %w", err)
+	}
+	return b.enqueue(ctx, j)
+}
+
+// BatchJob is the handler of the batch jobs. The canceled best effort batch is stopped before the next operation,
+// the report of the committed ones is kept
+func (b *Billing) BatchJob(ctx context.Context, j jobs.Job) (*jobs.Artifact, error) {
+	var req batch.Request
+	if err := json.Unmarshal(j.Payload, &req); err != nil {
+		return nil, fmt.Errorf("%w: BatchJob -> %w", jobs.PermanentErr, err)
+	}
+
+	results, err := b.ExecuteBatch(ctx, req.Mode, req.Operations)
+	if err != nil {
+		return nil, fmt.Errorf("BatchJob -> %w", err)
+	}
+
+	artifact, err := jobs.JSONArtifact(batch.NewReport(results))
+	if err != nil {
+		return nil, fmt.Errorf("BatchJob -> %w", err)
+	}
+	for _, r := range results {
+		if r.Status == batch.ItemCanceled {
+			return artifact, fmt.Errorf("BatchJob -> %w", ctx.Err())
+		}
+	}
+	return artifact, nil
+}
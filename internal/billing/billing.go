@@ -1,102 +1,265 @@
 package billing
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/shopspring/decimal"
+	"log/slog"
+	"sync"
+	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/KseniiaSalmina/Balance/internal/audit"
+	"github.com/KseniiaSalmina/Balance/internal/credit"
 	"github.com/KseniiaSalmina/Balance/internal/database"
 	"github.com/KseniiaSalmina/Balance/internal/database/mockdb"
+	"github.com/KseniiaSalmina/Balance/internal/events"
+	"github.com/KseniiaSalmina/Balance/internal/fees"
+	"github.com/KseniiaSalmina/Balance/internal/jobs"
+	"github.com/KseniiaSalmina/Balance/internal/limits"
+	"github.com/KseniiaSalmina/Balance/internal/metrics"
+	"github.com/KseniiaSalmina/Balance/internal/notify"
+	"github.com/KseniiaSalmina/Balance/internal/recurring"
+	"github.com/KseniiaSalmina/Balance/internal/risk"
+	"github.com/KseniiaSalmina/Balance/internal/savings"
+	"github.com/KseniiaSalmina/Balance/internal/schedule"
+	"github.com/KseniiaSalmina/Balance/internal/subscription"
+	"github.com/KseniiaSalmina/Balance/internal/tracing"
 	"github.com/KseniiaSalmina/Balance/internal/wallet"
+	"github.com/KseniiaSalmina/Balance/internal/webhook"
 )
 
 type Storage interface {
 	GetBalance(id int) (*wallet.Wallet, error)
 	GetHistory(id int, orderBy database.OrderBy, order database.Order, limit int) (*wallet.Wallet, error)
-	CommitChanges(id int, balance decimal.Decimal, ch wallet.HistoryChange) error
+	CommitChanges(id int, balance decimal.Decimal, ch wallet.HistoryChange) (int64, error)
 	NewUser(id int) error
+	GetHistoryChain(id int) ([]audit.Record, string, error)
+	GetEvents(id int, after int64, limit int) ([]notify.Event, error)
+	GetLastEvent(id int) (*notify.Event, error)
+	LockWallet(id int) error
+	GetLimits(id int) (limits.Limits, error)
+	SetLimits(id int, l limits.Limits) error
+	SumWithdrawals(id int, since int64) (decimal.Decimal, error)
+	GetTier(id int) (string, error)
+	SetTier(id int, tier string) error
+	GetCreditLine(id int) (credit.Line, error)
+	SetCreditLine(id int, l credit.Line) error
+	NetChangeSince(id int, since int64) (decimal.Decimal, error)
+	LastOverdraftCharge(id int) (int64, bool, error)
+	AddOverdraftCharge(id int, day int64, balance, amount decimal.Decimal) (bool, error)
+	GetSavings(id int) (savings.Account, error)
+	SetSavings(id int, a savings.Account) error
+	LastInterestAccrual(id int) (int64, bool, error)
+	AddInterestAccrual(id int, a savings.Accrual) (bool, error)
+	InterestTotals(id int) (decimal.Decimal, decimal.Decimal, error)
+	AddCapitalization(id int, c savings.Capitalization) error
+	ListAccruals(id int, from, to int64) ([]savings.Accrual, error)
+	ListCapitalizations(id int, fromMonth, toMonth string) ([]savings.Capitalization, error)
+	risk.Facts
+	CreateReview(r risk.Review) (int64, error)
+	GetReview(id int64) (*risk.Review, error)
+	ListReviews(status risk.ReviewStatus, limit int) ([]risk.Review, error)
+	ResolveReview(id int64, status risk.ReviewStatus, by string, at int64) error
+	CreateJob(j jobs.Job) (int64, error)
+	GetJob(id int64) (*jobs.Job, error)
+	ListJobs(client string, status jobs.Status, limit int) ([]jobs.Job, error)
+	GetJobArtifact(id int64) (*jobs.Artifact, error)
+	CancelJob(id int64, at int64) (*jobs.Job, error)
+	CreateScheduled(op schedule.Operation) (int64, error)
+	GetScheduled(id int64) (*schedule.Operation, error)
+	ListScheduled(client string, walletID int, status schedule.Status, limit int) ([]schedule.Operation, error)
+	CancelScheduled(id int64, at int64) (*schedule.Operation, error)
+	LockScheduled(id int64) (*schedule.Operation, error)
+	FinishScheduled(op schedule.Operation) error
+	CreateRecurring(p recurring.Payment) (int64, error)
+	GetRecurring(id int64) (*recurring.Payment, error)
+	LockRecurring(id int64) (*recurring.Payment, error)
+	ListRecurring(client string, walletID int, status recurring.Status, limit int) ([]recurring.Payment, error)
+	UpdateRecurring(p recurring.Payment) error
+	CancelRecurring(id int64, at int64) (*recurring.Payment, error)
+	CreatePlan(p subscription.Plan) (int64, error)
+	GetPlan(id int64) (*subscription.Plan, error)
+	ListPlans(limit int) ([]subscription.Plan, error)
+	CreateSubscription(s subscription.Subscription) (int64, error)
+	GetSubscription(id int64) (*subscription.Subscription, error)
+	ListSubscriptions(client string, walletID int, status subscription.Status, limit int) ([]subscription.Subscription, error)
+	LockSubscription(id int64) (*subscription.Subscription, error)
+	SaveCharge(s subscription.Subscription) error
+	CancelSubscription(id int64, at int64) (*subscription.Subscription, error)
+	CreateWebhook(w webhook.Webhook) (int64, error)
+	ListWebhooks() ([]webhook.Webhook, error)
+	DeleteWebhook(id int64) error
+	ListDeliveries(webhookID int64, status webhook.Status, limit int) ([]webhook.Delivery, error)
+	ReplayDelivery(id int64, at int64) error
+	ReplayDeadDeliveries(webhookID int64, at int64) (int64, error)
 	Rollback()
 	Commit() error
 }
 
 type Billing struct {
-	db *database.DB
+	db       *database.DB
+	mu       sync.RWMutex
+	limits   limits.Limits
+	risk     *risk.Engine
+	fees     *fees.Schedule
+	products *savings.Products
+	notify   *notify.Hub
+	events   *events.Emitter
 }
 
-func NewBilling(db *database.DB) *Billing {
+// NewBilling creates billing, nil risk engine allows every operation, nil fee schedule charges no fees, nil products leave only
+// the wallet interest rates, nil emitter drops the domain events
+func NewBilling(db *database.DB, lim limits.Limits, riskEngine *risk.Engine, feeSchedule *fees.Schedule, products *savings.Products, emitter *events.Emitter) *Billing {
 	return &Billing{
-		db: db,
+		db:       db,
+		limits:   lim,
+		risk:     riskEngine,
+		fees:     feeSchedule,
+		products: products,
+		notify:   notify.NewHub(),
+		events:   emitter,
 	}
 }
 
-func (b *Billing) MoneyTransaction(id int, opt wallet.Operation, amount decimal.Decimal, desc string) error {
-	tx, err := b.beginTx()
+// Reconfigure replaces the global limits, the risk engine, the fee schedule and the savings products, operations in progress keep the old ones
+func (b *Billing) Reconfigure(lim limits.Limits, riskEngine *risk.Engine, feeSchedule *fees.Schedule, products *savings.Products) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.limits = lim
+	b.risk = riskEngine
+	b.fees = feeSchedule
+	b.products = products
+}
+
+func (b *Billing) globalLimits() limits.Limits {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.limits
+}
+
+func (b *Billing) riskEngine() *risk.Engine {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.risk
+}
+
+func (b *Billing) feeSchedule() *fees.Schedule {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.fees
+}
+
+func (b *Billing) savingsProducts() *savings.Products {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.products
+}
+
+func (b *Billing) MoneyTransaction(ctx context.Context, id int, opt wallet.Operation, amount decimal.Decimal, desc string) (err error) {
+	ctx, span := tracing.Start(ctx, "billing.MoneyTransaction", attribute.Int("wallet.id", id), attribute.String("operation", string(opt)))
+	defer tracing.End(span, &err)
+
+	tx, err := b.beginTx(ctx)
 	if err != nil {
 		return fmt.Errorf("MoneyTransaction -> %w", err)
 	}
 	defer tx.Rollback()
 
-	w, err := tx.GetBalance(id)
-	if err != nil {
-		if errors.Is(err, database.UserDoesNotExistErr) {
-			switch opt {
-			case wallet.Withdrawal:
-				return fmt.Errorf("problem with getting balance: %w", err)
-			case wallet.Replenishment:
-				err = tx.NewUser(id)
-				fmt.Println(err)
-				if err != nil {
-					return fmt.Errorf("problem with creating a new user: %w", err)
-				}
-				w = &wallet.Wallet{ID: id, Balance: decimal.NewFromInt(0)}
-			}
-		} else {
-			return fmt.Errorf("problem with getting balance: %w", err)
-		}
+	op := risk.Operation{Kind: risk.Kind(opt), WalletID: id, Amount: amount, Time: time.Now()}
+	if err = b.assess(ctx, tx, op, desc); err != nil {
+		return err
 	}
 
-	if err = w.ChangeBalance(amount, opt); err != nil {
-		return fmt.Errorf("money transaction problem: %w", err)
-	}
-
-	ch := wallet.NewChange(opt, amount, desc)
-
-	if err = tx.CommitChanges(id, w.Balance, ch); err != nil {
-		return fmt.Errorf("finishing money transaction problem: %w", err)
+	if err = b.execute(ctx, tx, op, desc); err != nil {
+		return err
 	}
 
 	if err = tx.Commit(); err != nil {
 		return fmt.Errorf("MoneyTransaction -> %w", err)
 	}
+	metrics.Operation(string(op.Kind), op.Amount)
 
 	return nil
 }
 
-func (b *Billing) beginTx() (Storage, error) {
+func (b *Billing) beginTx(ctx context.Context) (Storage, error) {
 	if b.db == nil {
-		return &mockdb.MockDb{}, nil
+		return &publishingTx{Storage: &mockdb.MockDb{}, ctx: ctx, hub: b.notify, emitter: b.events}, nil
 	}
 
-	tx, err := b.db.NewTransaction()
+	tx, err := b.db.NewTransaction(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("beginTx -> %w", err)
 	}
-	return tx, nil
+	return &publishingTx{Storage: tx, ctx: ctx, hub: b.notify, emitter: b.events}, nil
+}
+
+// execute checks limits and applies the operation to the wallets, the fee is withdrawn after the operation. The limits
+// bound the amount of the operation only, the fee is posted as wallet.Fee, so it is not counted in the usage of the limits either
+func (b *Billing) execute(ctx context.Context, s Storage, op risk.Operation, desc string) error {
+	sched := b.feeSchedule()
+
+	switch op.Kind {
+	case risk.KindReplenishment:
+		return b.moneyTransaction(ctx, s, op.WalletID, wallet.Replenishment, op.Amount, desc, 0)
+	case risk.KindWithdrawal:
+		if err := b.checkLimits(s, op.WalletID, limits.Withdrawal, op.Amount); err != nil {
+			return err
+		}
+		q, err := quoteFee(s, sched, op)
+		if err != nil {
+			return err
+		}
+		if err = b.moneyTransaction(ctx, s, op.WalletID, wallet.Withdrawal, op.Amount, desc, 0); err != nil {
+			return err
+		}
+		return b.chargeFee(ctx, s, sched, op, q)
+	case risk.KindTransfer:
+		if err := b.checkLimits(s, op.WalletID, limits.Transfer, op.Amount); err != nil {
+			return fmt.Errorf("transfer error: %w", err)
+		}
+		q, err := quoteFee(s, sched, op)
+		if err != nil {
+			return fmt.Errorf("transfer error: %w", err)
+		}
+
+		err = b.moneyTransaction(ctx, s, op.WalletID, wallet.Withdrawal, op.Amount, fmt.Sprintf("transfer to user %v", op.To), op.To)
+		if err != nil {
+			return fmt.Errorf("transfer error: %w", err)
+		}
+
+		err = b.moneyTransaction(ctx, s, op.To, wallet.Replenishment, op.Amount, fmt.Sprintf("transfer from user %v", op.WalletID), op.WalletID)
+		if err != nil {
+			return fmt.Errorf("transfer error: %w", err)
+		}
+
+		if err = b.chargeFee(ctx, s, sched, op, q); err != nil {
+			return fmt.Errorf("transfer error: %w", err)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unknown operation %q", op.Kind)
 }
 
-func (b *Billing) moneyTransaction(s Storage, id int, opt wallet.Operation, amount decimal.Decimal, desc string) error {
+func (b *Billing) moneyTransaction(ctx context.Context, s Storage, id int, opt wallet.Operation, amount decimal.Decimal, desc string, counterparty int) error {
 	w, err := s.GetBalance(id)
 	if err != nil {
 		if errors.Is(err, database.UserDoesNotExistErr) {
 			switch opt {
-			case wallet.Withdrawal:
+			case wallet.Withdrawal, wallet.Fee:
 				return fmt.Errorf("problem with getting balance: %w", err)
 			case wallet.Replenishment:
-				err = s.NewUser(id)
-				fmt.Println(err)
-				if err != nil {
+				if err = s.NewUser(id); err != nil {
 					return fmt.Errorf("problem with creating a new user: %w", err)
 				}
+				if !risk.IsPreview(ctx) {
+					slog.InfoContext(ctx, "wallet created", "wallet_id", id)
+				}
 				w = &wallet.Wallet{ID: id, Balance: decimal.NewFromInt(0)}
 			}
 		} else {
@@ -105,44 +268,55 @@ func (b *Billing) moneyTransaction(s Storage, id int, opt wallet.Operation, amou
 	}
 
 	if err = w.ChangeBalance(amount, opt); err != nil {
+		if errors.Is(err, wallet.InsufficientFundsErr) && !risk.IsPreview(ctx) {
+			metrics.InsufficientFunds()
+		}
 		return fmt.Errorf("money transaction problem: %w", err)
 	}
 
 	ch := wallet.NewChange(opt, amount, desc)
+	ch.Actor = audit.Actor(ctx)
+	ch.Counterparty = counterparty
 
-	if err = s.CommitChanges(id, w.Balance, ch); err != nil {
+	if _, err = s.CommitChanges(id, w.Balance, ch); err != nil {
 		return fmt.Errorf("finishing money transaction problem: %w", err)
 	}
 
 	return nil
 }
 
-func (b *Billing) Transfer(from, to int, amount decimal.Decimal) error {
-	tx, err := b.beginTx()
+func (b *Billing) Transfer(ctx context.Context, from, to int, amount decimal.Decimal) (err error) {
+	ctx, span := tracing.Start(ctx, "billing.Transfer", attribute.Int("wallet.from", from), attribute.Int("wallet.to", to))
+	defer tracing.End(span, &err)
+
+	tx, err := b.beginTx(ctx)
 	if err != nil {
 		return fmt.Errorf("Transfer -> %w", err)
 	}
+	defer tx.Rollback()
 
-	err = b.moneyTransaction(tx, from, wallet.Withdrawal, amount, fmt.Sprintf("transfer to user %v", to))
-	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("transfer error: %w", err)
+	op := risk.Operation{Kind: risk.KindTransfer, WalletID: from, To: to, Amount: amount, Time: time.Now()}
+	if err = b.assess(ctx, tx, op, ""); err != nil {
+		return err
 	}
 
-	err = b.moneyTransaction(tx, to, wallet.Replenishment, amount, fmt.Sprintf("transfer from user %v", from))
-	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("transfer error: %w", err)
+	if err = b.execute(ctx, tx, op, ""); err != nil {
+		return err
 	}
 
 	if err = tx.Commit(); err != nil {
 		return fmt.Errorf("Transfer -> %w", err)
 	}
+	metrics.Operation(string(op.Kind), op.Amount)
+
 	return nil
 }
 
-func (b *Billing) CheckBalance(id int) (string, error) {
-	tx, err := b.beginTx()
+func (b *Billing) CheckBalance(ctx context.Context, id int) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "billing.CheckBalance", attribute.Int("wallet.id", id))
+	defer tracing.End(span, &err)
+
+	tx, err := b.beginTx(ctx)
 	if err != nil {
 		return "", fmt.Errorf("billing.CheckBalance -> %w", err)
 	}
@@ -157,8 +331,11 @@ func (b *Billing) CheckBalance(id int) (string, error) {
 	return w.StringBalance(), nil
 }
 
-func (b *Billing) CheckHistory(id int, orderBy database.OrderBy, order database.Order, limit int) ([]wallet.HistoryChange, error) {
-	tx, err := b.beginTx()
+func (b *Billing) CheckHistory(ctx context.Context, id int, orderBy database.OrderBy, order database.Order, limit int) (_ []wallet.HistoryChange, err error) {
+	ctx, span := tracing.Start(ctx, "billing.CheckHistory", attribute.Int("wallet.id", id))
+	defer tracing.End(span, &err)
+
+	tx, err := b.beginTx(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("billing.CheckHistory -> %w", err)
 	}
@@ -172,3 +349,22 @@ func (b *Billing) CheckHistory(id int, orderBy database.OrderBy, order database.
 	tx.Commit()
 	return w.History, nil
 }
+
+// VerifyHistory checks that the wallet history hash chain was not broken by changing or deleting records
+func (b *Billing) VerifyHistory(ctx context.Context, id int) (_ audit.Report, err error) {
+	ctx, span := tracing.Start(ctx, "billing.VerifyHistory", attribute.Int("wallet.id", id))
+	defer tracing.End(span, &err)
+
+	tx, err := b.beginTx(ctx)
+	if err != nil {
+		return audit.Report{}, fmt.Errorf("billing.VerifyHistory -> %w", err)
+	}
+	defer tx.Rollback()
+
+	records, head, err := tx.GetHistoryChain(id)
+	if err != nil {
+		return audit.Report{}, err
+	}
+
+	return audit.Verify(id, records, head), nil
+}
@@ -1,12 +1,33 @@
 package billing
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 
+	"github.com/KseniiaSalmina/Balance/internal/audit"
+	"github.com/KseniiaSalmina/Balance/internal/batch"
+	"github.com/KseniiaSalmina/Balance/internal/credit"
 	"github.com/KseniiaSalmina/Balance/internal/database"
+	"github.com/KseniiaSalmina/Balance/internal/events"
+	"github.com/KseniiaSalmina/Balance/internal/fees"
+	"github.com/KseniiaSalmina/Balance/internal/jobs"
+	"github.com/KseniiaSalmina/Balance/internal/limits"
+	"github.com/KseniiaSalmina/Balance/internal/notify"
+	"github.com/KseniiaSalmina/Balance/internal/recurring"
+	"github.com/KseniiaSalmina/Balance/internal/risk"
+	"github.com/KseniiaSalmina/Balance/internal/savings"
+	"github.com/KseniiaSalmina/Balance/internal/schedule"
+	"github.com/KseniiaSalmina/Balance/internal/subscription"
 	"github.com/KseniiaSalmina/Balance/internal/wallet"
+	"github.com/KseniiaSalmina/Balance/internal/webhook"
 )
 
 func TestCheckBalance(t *testing.T) {
@@ -22,7 +43,7 @@ func TestCheckBalance(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := CheckBalance(nil, tt.id)
+			got, err := (&Billing{}).CheckBalance(context.Background(), tt.id)
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -46,7 +67,7 @@ func TestCheckHistory(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := CheckHistory(nil, tt.id, database.OrderByDate, database.Desc, tt.limit)
+			got, err := (&Billing{}).CheckHistory(context.Background(), tt.id, database.OrderByDate, database.Desc, tt.limit)
 			if tt.wantErr {
 				assert.Error(t, err)
 				assert.Nil(t, got)
@@ -79,7 +100,7 @@ func TestMoneyTransaction(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := MoneyTransaction(nil, tt.args.id, tt.args.opt, tt.args.amount, tt.args.desc)
+			err := (&Billing{}).MoneyTransaction(context.Background(), tt.args.id, tt.args.opt, tt.args.amount, tt.args.desc)
 			if tt.wantErr {
 				assert.Error(t, err)
 				assert.ErrorIs(t, err, tt.expectedErr)
@@ -106,7 +127,7 @@ func TestTransfer(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := Transfer(nil, tt.args.from, tt.args.to, tt.args.amount)
+			err := (&Billing{}).Transfer(context.Background(), tt.args.from, tt.args.to, tt.args.amount)
 			if tt.wantErr {
 				assert.Error(t, err)
 				assert.ErrorIs(t, err, wallet.InsufficientFundsErr)
@@ -116,3 +137,622 @@ func TestTransfer(t *testing.T) {
 		})
 	}
 }
+
+func TestVerifyHistory(t *testing.T) {
+	tests := []struct {
+		name      string
+		id        int
+		wantErr   bool
+		wantValid bool
+	}{
+		{name: "history of existing user is valid", id: 10, wantErr: false, wantValid: true},
+		{name: "user does not exist", id: -10, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := (&Billing{}).VerifyHistory(context.Background(), tt.id)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, database.UserDoesNotExistErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantValid, got.Valid)
+			assert.Equal(t, 1, got.Checked)
+		})
+	}
+}
+
+func TestLimits(t *testing.T) {
+	b := &Billing{limits: limits.Limits{MaxTransfer: decimal.NewNullDecimal(decimal.NewFromInt(100))}}
+
+	t.Run("transfer above the global limit", func(t *testing.T) {
+		err := b.Transfer(context.Background(), 456, 123, decimal.NewFromInt(120))
+		assert.ErrorIs(t, err, limits.LimitExceededErr)
+	})
+
+	t.Run("transfer within the global limit", func(t *testing.T) {
+		err := b.Transfer(context.Background(), 456, 123, decimal.NewFromInt(100))
+		assert.NoError(t, err)
+	})
+
+	t.Run("withdrawal above the wallet daily limit", func(t *testing.T) {
+		err := b.MoneyTransaction(context.Background(), 777, wallet.Withdrawal, decimal.NewFromInt(60), "rent")
+		var exceeded *limits.ExceededError
+		if assert.ErrorAs(t, err, &exceeded) {
+			assert.Equal(t, "daily_withdrawal", exceeded.Limit)
+			assert.Equal(t, "50", exceeded.Remaining.String())
+		}
+	})
+
+	t.Run("replenishment is not limited", func(t *testing.T) {
+		err := b.MoneyTransaction(context.Background(), 777, wallet.Replenishment, decimal.NewFromInt(1000), "salary")
+		assert.NoError(t, err)
+	})
+}
+
+func TestRisk(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "rules*.yaml")
+	assert.NoError(t, err)
+	f.WriteString("rules:\n  - type: ping_pong\n    action: deny\n    window: 1h\n  - type: large_withdrawal\n    action: review\n    amount: 1000\n")
+	f.Close()
+
+	engine, err := risk.Load(f.Name())
+	assert.NoError(t, err)
+	b := &Billing{risk: engine}
+
+	t.Run("ping pong transfer is denied", func(t *testing.T) {
+		err := b.Transfer(context.Background(), 901, 900, decimal.NewFromInt(10))
+		assert.ErrorIs(t, err, risk.DeniedErr)
+	})
+
+	t.Run("large withdrawal is held for review", func(t *testing.T) {
+		err := b.MoneyTransaction(context.Background(), 5000, wallet.Withdrawal, decimal.NewFromInt(1000), "car")
+		var review *risk.ReviewRequiredError
+		if assert.ErrorAs(t, err, &review) {
+			assert.Equal(t, int64(1), review.ReviewID)
+		}
+	})
+
+	t.Run("usual operations are allowed", func(t *testing.T) {
+		assert.NoError(t, b.Transfer(context.Background(), 456, 123, decimal.NewFromInt(10)))
+		assert.NoError(t, b.MoneyTransaction(context.Background(), 5000, wallet.Withdrawal, decimal.NewFromInt(10), "coffee"))
+	})
+}
+
+func TestResolveReview(t *testing.T) {
+	b := &Billing{}
+
+	assert.NoError(t, b.ApproveReview(context.Background(), 1))
+	assert.NoError(t, b.RejectReview(context.Background(), 1))
+	assert.ErrorIs(t, b.ApproveReview(context.Background(), 2), risk.ReviewResolvedErr)
+	assert.ErrorIs(t, b.RejectReview(context.Background(), 3), database.ReviewDoesNotExistErr)
+	assert.ErrorIs(t, b.ApproveReview(audit.WithActor(context.Background(), "shop/alice"), 4), risk.SelfApprovalErr)
+	assert.ErrorIs(t, b.ApproveReview(audit.WithActor(context.Background(), "shop/bob"), 4), risk.SelfApprovalErr, "X-User-ID must not bypass the check")
+	assert.ErrorIs(t, b.ApproveReview(audit.WithActor(context.Background(), "shop"), 4), risk.SelfApprovalErr)
+	assert.NoError(t, b.RejectReview(audit.WithActor(context.Background(), "shop/alice"), 4))
+
+	reviews, err := b.Reviews(context.Background(), risk.Pending, 100)
+	assert.NoError(t, err)
+	assert.Len(t, reviews, 1)
+}
+
+func TestNotifications(t *testing.T) {
+	b := &Billing{notify: notify.NewHub()}
+	from, to := b.Subscribe(456), b.Subscribe(123)
+	defer from.Close()
+	defer to.Close()
+
+	assert.NoError(t, b.Transfer(context.Background(), 456, 123, decimal.NewFromInt(10)))
+
+	e := <-from.C
+	assert.Equal(t, wallet.Withdrawal, e.Change.Operation)
+	assert.Equal(t, "290", e.Balance.String())
+	e = <-to.C
+	assert.Equal(t, wallet.Replenishment, e.Change.Operation)
+	assert.Equal(t, "310", e.Balance.String())
+
+	assert.Error(t, b.MoneyTransaction(context.Background(), 456, wallet.Withdrawal, decimal.NewFromInt(1000), "too much"))
+	select {
+	case e = <-from.C:
+		t.Fatalf("unexpected event of the failed operation: %v", e)
+	default:
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	b := &Billing{}
+
+	w, err := b.CreateWebhook(context.Background(), "https://example.com/hook")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), w.ID)
+	assert.NotEmpty(t, w.Secret)
+	_, err = b.CreateWebhook(context.Background(), "example.com")
+	assert.ErrorIs(t, err, webhook.InvalidURLErr)
+
+	assert.NoError(t, b.DeleteWebhook(context.Background(), 1))
+	assert.ErrorIs(t, b.DeleteWebhook(context.Background(), 2), database.WebhookDoesNotExistErr)
+
+	n, err := b.ReplayDeadDeliveries(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	assert.ErrorIs(t, b.ReplayDelivery(context.Background(), 2), database.DeliveryDoesNotExistErr)
+}
+
+func TestDomainEvents(t *testing.T) {
+	bus := events.NewInProcess()
+	var got []string
+	bus.Subscribe(func(ctx context.Context, e events.Event) { got = append(got, e.Type+" "+e.Subject) })
+	b := &Billing{events: events.NewEmitter("/balance", bus)}
+
+	assert.NoError(t, b.Transfer(context.Background(), 456, 123, decimal.NewFromInt(10)))
+	assert.Equal(t, []string{
+		"balance.wallet.withdrawn.v1 wallets/456",
+		"balance.wallet.replenished.v1 wallets/123",
+		"balance.transfer.completed.v1 wallets/456",
+	}, got)
+
+	got = nil
+	assert.NoError(t, b.MoneyTransaction(context.Background(), -1, wallet.Replenishment, decimal.NewFromInt(10), "first"))
+	assert.Equal(t, []string{"balance.wallet.created.v1 wallets/-1", "balance.wallet.replenished.v1 wallets/-1"}, got)
+
+	got = nil
+	assert.Error(t, b.MoneyTransaction(context.Background(), 456, wallet.Withdrawal, decimal.NewFromInt(1000), "too much"))
+	assert.Empty(t, got, "events of the failed operation must not be published")
+
+	assert.NoError(t, b.ApproveReview(context.Background(), 1))
+	assert.Contains(t, got, "balance.review.resolved.v1 reviews/1")
+}
+
+func TestExecuteBatch(t *testing.T) {
+	items := []batch.Item{
+		{Type: risk.KindTransfer, WalletID: 456, To: 123, Amount: decimal.NewFromInt(10)},
+		{Type: risk.KindWithdrawal, WalletID: 456, Amount: decimal.NewFromInt(1000), Description: "too much"},
+		{Type: risk.KindReplenishment, WalletID: 123, Amount: decimal.NewFromInt(5), Description: "salary"},
+	}
+
+	t.Run("atomic batch is rolled back", func(t *testing.T) {
+		b := &Billing{notify: notify.NewHub()}
+		sub := b.Subscribe(456)
+		defer sub.Close()
+
+		results, err := b.ExecuteBatch(context.Background(), batch.Atomic, items)
+		assert.NoError(t, err)
+		assert.Equal(t, []batch.ItemStatus{batch.ItemRolledBack, batch.ItemFailed, batch.ItemRolledBack},
+			[]batch.ItemStatus{results[0].Status, results[1].Status, results[2].Status})
+		assert.Contains(t, results[1].Error, wallet.InsufficientFundsErr.Error())
+		select {
+		case e := <-sub.C:
+			t.Fatalf("unexpected event of the rolled back batch: %v", e)
+		default:
+		}
+	})
+
+	t.Run("atomic batch is committed", func(t *testing.T) {
+		results, err := (&Billing{}).ExecuteBatch(context.Background(), batch.Atomic, []batch.Item{items[0], items[2]})
+		assert.NoError(t, err)
+		assert.Equal(t, batch.Completed, batch.NewReport(results).Status)
+	})
+
+	t.Run("best effort batch is partial", func(t *testing.T) {
+		results, err := (&Billing{}).ExecuteBatch(context.Background(), batch.BestEffort, items)
+		assert.NoError(t, err)
+		assert.Equal(t, []batch.ItemStatus{batch.ItemCompleted, batch.ItemFailed, batch.ItemCompleted},
+			[]batch.ItemStatus{results[0].Status, results[1].Status, results[2].Status})
+		assert.Equal(t, batch.Partial, batch.NewReport(results).Status)
+	})
+
+	t.Run("async batch", func(t *testing.T) {
+		b := &Billing{}
+		job, err := b.SubmitBatch(context.Background(), batch.BestEffort, items)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), job.ID)
+		assert.Equal(t, jobs.Queued, job.Status)
+		assert.Equal(t, 1, job.MaxAttempts)
+
+		artifact, err := b.BatchJob(context.Background(), job)
+		assert.NoError(t, err)
+		var report batch.Report
+		assert.NoError(t, json.Unmarshal(artifact.Data, &report))
+		assert.Equal(t, batch.Partial, report.Status)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		artifact, err = b.BatchJob(ctx, job)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.NoError(t, json.Unmarshal(artifact.Data, &report))
+		assert.Equal(t, batch.ItemCanceled, report.Results[0].Status)
+	})
+}
+
+func TestJobs(t *testing.T) {
+	b := &Billing{}
+
+	_, err := b.Job(context.Background(), 4)
+	assert.ErrorIs(t, err, database.JobDoesNotExistErr)
+
+	job, err := b.CancelJob(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.True(t, job.CancelRequested)
+	_, err = b.CancelJob(context.Background(), 2)
+	assert.ErrorIs(t, err, jobs.FinishedErr)
+
+	artifact, err := b.JobArtifact(context.Background(), 2)
+	assert.NoError(t, err)
+	assert.Nil(t, artifact)
+
+	//job 3 belongs to shop/alice
+	_, err = b.Job(context.Background(), 3)
+	assert.ErrorIs(t, err, database.JobDoesNotExistErr)
+	_, err = b.JobArtifact(context.Background(), 3)
+	assert.ErrorIs(t, err, database.JobDoesNotExistErr)
+	_, err = b.CancelJob(context.Background(), 3)
+	assert.ErrorIs(t, err, database.JobDoesNotExistErr)
+	job, err = b.Job(audit.WithActor(context.Background(), "shop/alice"), 3)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), job.ID)
+	//the same API key with another or no end user
+	job, err = b.Job(audit.WithActor(context.Background(), "shop/bob"), 3)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), job.ID)
+	_, err = b.JobArtifact(audit.WithActor(context.Background(), "shop"), 3)
+	assert.NoError(t, err)
+	_, err = b.Job(audit.WithActor(context.Background(), "market/alice"), 3)
+	assert.ErrorIs(t, err, database.JobDoesNotExistErr)
+
+	list, err := b.Jobs(audit.WithActor(context.Background(), "shop/alice"), jobs.Running, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, "shop", list[0].Actor, "jobs are listed by API key")
+}
+
+func TestScheduled(t *testing.T) {
+	bus := events.NewInProcess()
+	var got []string
+	bus.Subscribe(func(ctx context.Context, e events.Event) { got = append(got, e.Type+" "+e.Subject) })
+	b := &Billing{events: events.NewEmitter("/balance", bus)}
+
+	item := batch.Item{Type: risk.KindTransfer, WalletID: 456, To: 123, Amount: decimal.NewFromInt(10)}
+	op, err := b.ScheduleOperation(context.Background(), item, time.Now().Add(time.Hour).Unix())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), op.ID)
+	assert.Equal(t, schedule.Scheduled, op.Status)
+	_, err = b.ScheduleOperation(context.Background(), item, time.Now().Add(-time.Hour).Unix())
+	assert.ErrorIs(t, err, schedule.InvalidOperationErr)
+
+	assert.NoError(t, b.ExecuteScheduled(context.Background(), 1))
+	assert.Equal(t, []string{
+		"balance.wallet.withdrawn.v1 wallets/123",
+		"balance.scheduled.executed.v1 wallets/123",
+	}, got)
+
+	got = nil
+	assert.NoError(t, b.ExecuteScheduled(context.Background(), 2), "insufficient funds must fail the operation, not the execution")
+	assert.Equal(t, []string{"balance.scheduled.failed.v1 wallets/123"}, got)
+
+	got = nil
+	assert.NoError(t, b.ExecuteScheduled(context.Background(), 3))
+	assert.Empty(t, got, "executed operation must not be executed again")
+
+	canceled, err := b.CancelScheduled(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, schedule.Canceled, canceled.Status)
+	_, err = b.CancelScheduled(context.Background(), 3)
+	assert.ErrorIs(t, err, schedule.NotScheduledErr)
+	_, err = b.CancelScheduled(context.Background(), 5)
+	assert.ErrorIs(t, err, database.ScheduledDoesNotExistErr)
+
+	//operation 6 belongs to shop/alice
+	_, err = b.ScheduledOperation(context.Background(), 6)
+	assert.ErrorIs(t, err, database.ScheduledDoesNotExistErr)
+	_, err = b.CancelScheduled(audit.WithActor(context.Background(), "market"), 6)
+	assert.ErrorIs(t, err, database.ScheduledDoesNotExistErr)
+	op6, err := b.ScheduledOperation(audit.WithActor(context.Background(), "shop/bob"), 6)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), op6.ID)
+	canceled, err = b.CancelScheduled(audit.WithActor(context.Background(), "shop/alice"), 6)
+	assert.NoError(t, err)
+	assert.Equal(t, schedule.Canceled, canceled.Status)
+
+	list, err := b.ScheduledOperations(audit.WithActor(context.Background(), "shop/alice"), 123, schedule.Scheduled, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, "shop", list[0].Actor, "operations are listed by API key")
+}
+
+func TestRecurring(t *testing.T) {
+	b := &Billing{}
+
+	p, err := b.CreateRecurring(context.Background(), recurring.Payment{
+		WalletID:  456,
+		To:        123,
+		Amount:    decimal.NewFromInt(10),
+		Rule:      recurring.Rule{Frequency: recurring.Weekly},
+		StartAt:   time.Now().Add(time.Hour).Unix(),
+		OnFailure: recurring.Retry,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), p.ID)
+	assert.Equal(t, int64(1), p.ScheduledID, "first occurrence must be scheduled")
+	assert.Equal(t, p.StartAt, p.NextAt)
+
+	_, err = b.CreateRecurring(context.Background(), recurring.Payment{WalletID: 456, To: 456, Amount: decimal.NewFromInt(10), Rule: recurring.Rule{Frequency: recurring.Daily}})
+	assert.ErrorIs(t, err, recurring.InvalidPaymentErr)
+
+	assert.NoError(t, b.ExecuteScheduled(context.Background(), 4), "occurrence must be executed and the next one scheduled")
+	_, err = b.CancelScheduled(context.Background(), 4)
+	assert.NoError(t, err, "occurrence must be skipped")
+
+	_, err = b.CancelRecurring(context.Background(), 2)
+	assert.ErrorIs(t, err, recurring.NotActiveErr)
+	_, err = b.CancelRecurring(context.Background(), 3)
+	assert.ErrorIs(t, err, database.RecurringDoesNotExistErr)
+
+	//payment 4 belongs to shop/alice
+	_, err = b.RecurringPayment(context.Background(), 4)
+	assert.ErrorIs(t, err, database.RecurringDoesNotExistErr)
+	_, err = b.CancelRecurring(audit.WithActor(context.Background(), "market"), 4)
+	assert.ErrorIs(t, err, database.RecurringDoesNotExistErr)
+	p4, err := b.RecurringPayment(audit.WithActor(context.Background(), "shop/bob"), 4)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), p4.ID)
+	canceled, err := b.CancelRecurring(audit.WithActor(context.Background(), "shop"), 4)
+	assert.NoError(t, err)
+	assert.Equal(t, recurring.Canceled, canceled.Status)
+
+	list, err := b.RecurringPayments(audit.WithActor(context.Background(), "shop/alice"), 456, recurring.Active, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, "shop", list[0].Actor, "payments are listed by API key")
+}
+
+func TestSubscriptions(t *testing.T) {
+	bus := events.NewInProcess()
+	var got []string
+	bus.Subscribe(func(ctx context.Context, e events.Event) { got = append(got, e.Type+" "+e.Subject) })
+	b := &Billing{events: events.NewEmitter("/balance", bus)}
+
+	plan, err := b.CreatePlan(context.Background(), subscription.Plan{Name: "pro", Price: decimal.NewFromInt(100), Period: recurring.Rule{Frequency: recurring.Monthly}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), plan.ID)
+	_, err = b.CreatePlan(context.Background(), subscription.Plan{Name: "pro", Price: decimal.NewFromInt(-1), Period: recurring.Rule{Frequency: recurring.Monthly}})
+	assert.ErrorIs(t, err, subscription.InvalidPlanErr)
+
+	sub, err := b.CreateSubscription(context.Background(), 1, 123, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, subscription.Active, sub.Status)
+	_, err = b.CreateSubscription(context.Background(), 9, 123, 0)
+	assert.ErrorIs(t, err, database.PlanDoesNotExistErr)
+
+	assert.NoError(t, b.ChargeSubscription(context.Background(), 1))
+	assert.Equal(t, []string{
+		"balance.wallet.withdrawn.v1 wallets/123",
+		"balance.subscription.charged.v1 subscriptions/1",
+	}, got)
+
+	got = nil
+	assert.NoError(t, b.ChargeSubscription(context.Background(), 2), "insufficient funds must fail the charge, not the execution")
+	assert.Equal(t, []string{"balance.subscription.past_due.v1 subscriptions/2"}, got)
+
+	got = nil
+	assert.NoError(t, b.ChargeSubscription(context.Background(), 3))
+	assert.Empty(t, got, "canceled subscription must not be charged")
+
+	canceled, err := b.CancelSubscription(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, subscription.Canceled, canceled.Status)
+	_, err = b.CancelSubscription(context.Background(), 3)
+	assert.ErrorIs(t, err, subscription.CanceledErr)
+	_, err = b.CancelSubscription(context.Background(), 4)
+	assert.ErrorIs(t, err, database.SubscriptionDoesNotExistErr)
+
+	//subscription 5 belongs to shop/alice
+	_, err = b.Subscription(context.Background(), 5)
+	assert.ErrorIs(t, err, database.SubscriptionDoesNotExistErr)
+	_, err = b.CancelSubscription(audit.WithActor(context.Background(), "market"), 5)
+	assert.ErrorIs(t, err, database.SubscriptionDoesNotExistErr)
+	sub5, err := b.Subscription(audit.WithActor(context.Background(), "shop/bob"), 5)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), sub5.ID)
+	canceled, err = b.CancelSubscription(audit.WithActor(context.Background(), "shop/alice"), 5)
+	assert.NoError(t, err)
+	assert.Equal(t, subscription.Canceled, canceled.Status)
+
+	list, err := b.Subscriptions(audit.WithActor(context.Background(), "shop/alice"), 123, subscription.Active, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, "shop", list[0].Actor, "subscriptions are listed by API key")
+
+	f, err := os.CreateTemp(t.TempDir(), "rules*.yaml")
+	assert.NoError(t, err)
+	f.WriteString("rules:\n  - type: large_withdrawal\n    action: review\n    amount: 50\n")
+	f.Close()
+	engine, err := risk.Load(f.Name())
+	assert.NoError(t, err)
+	b.risk = engine
+
+	got = nil
+	assert.NoError(t, b.ChargeSubscription(context.Background(), 1), "held charge must fail the charge, not the execution")
+	assert.Equal(t, []string{"balance.subscription.past_due.v1 subscriptions/1"}, got, "held charge must not be withdrawn")
+}
+
+func TestFees(t *testing.T) {
+	bus := events.NewInProcess()
+	var got []string
+	bus.Subscribe(func(ctx context.Context, e events.Event) { got = append(got, e.Type+" "+e.Subject) })
+	sched, err := fees.NewSchedule(1,
+		fees.Rule{Name: "withdrawal", Kind: risk.KindWithdrawal, Percent: decimal.NewFromInt(1)},
+		fees.Rule{Name: "business transfer", Kind: risk.KindTransfer, Tier: "business", Fixed: decimal.NewFromInt(0)},
+		fees.Rule{Name: "transfer", Kind: risk.KindTransfer, Fixed: decimal.NewFromInt(2)},
+	)
+	assert.NoError(t, err)
+	b := &Billing{fees: sched, events: events.NewEmitter("/balance", bus), notify: notify.NewHub()}
+
+	changes := b.Subscribe(123)
+	assert.NoError(t, b.MoneyTransaction(context.Background(), 123, wallet.Withdrawal, decimal.NewFromInt(100), "test"))
+	assert.Equal(t, []string{
+		"balance.wallet.withdrawn.v1 wallets/123",
+		"balance.wallet.withdrawn.v1 wallets/123",
+		"balance.wallet.replenished.v1 wallets/1",
+	}, got, "fee must be posted to the revenue wallet")
+	assert.Equal(t, wallet.Withdrawal, (<-changes.C).Change.Operation)
+	assert.Equal(t, wallet.Fee, (<-changes.C).Change.Operation, "fee must not be counted in the limits and risk rules as a withdrawal")
+	changes.Close()
+
+	got = nil
+	assert.NoError(t, b.Transfer(context.Background(), 888, 123, decimal.NewFromInt(100)))
+	assert.Len(t, got, 3, "business tier must not be charged a fee")
+
+	q, err := b.QuoteFee(context.Background(), 456, risk.KindTransfer, decimal.NewFromInt(100))
+	assert.NoError(t, err)
+	assert.Equal(t, "2", q.Fee.String())
+	assert.Equal(t, "102", q.Total.String())
+	assert.Equal(t, "transfer", q.Rule)
+	q, err = b.QuoteFee(context.Background(), 1, risk.KindWithdrawal, decimal.NewFromInt(100))
+	assert.NoError(t, err)
+	assert.True(t, q.Fee.IsZero(), "revenue wallet must not be charged a fee")
+	_, err = b.QuoteFee(context.Background(), -1, risk.KindWithdrawal, decimal.NewFromInt(100))
+	assert.ErrorIs(t, err, database.UserDoesNotExistErr)
+}
+
+func TestPreviewOperation(t *testing.T) {
+	bus := events.NewInProcess()
+	var got []string
+	bus.Subscribe(func(ctx context.Context, e events.Event) { got = append(got, e.Type+" "+e.Subject) })
+	f, err := os.CreateTemp(t.TempDir(), "rules*.yaml")
+	assert.NoError(t, err)
+	f.WriteString("rules:\n  - type: ping_pong\n    action: deny\n    window: 1h\n  - type: large_withdrawal\n    action: review\n    amount: 200\n")
+	f.Close()
+	engine, err := risk.Load(f.Name())
+	assert.NoError(t, err)
+	sched, err := fees.NewSchedule(1, fees.Rule{Name: "withdrawal", Kind: risk.KindWithdrawal, Percent: decimal.NewFromInt(1)})
+	assert.NoError(t, err)
+	b := &Billing{risk: engine, fees: sched, events: events.NewEmitter("/balance", bus)}
+
+	p, err := b.PreviewOperation(context.Background(), risk.Operation{Kind: risk.KindWithdrawal, WalletID: 123, Amount: decimal.NewFromInt(100)}, "test")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", p.Fee.String())
+	assert.Equal(t, "withdrawal", p.FeeRule)
+	assert.False(t, p.ReviewRequired)
+	assert.Empty(t, got, "dry run must not publish events")
+
+	p, err = b.PreviewOperation(context.Background(), risk.Operation{Kind: risk.KindWithdrawal, WalletID: 5000, Amount: decimal.NewFromInt(250)}, "car")
+	assert.NoError(t, err)
+	assert.True(t, p.ReviewRequired)
+
+	_, err = b.PreviewOperation(context.Background(), risk.Operation{Kind: risk.KindTransfer, WalletID: 901, To: 900, Amount: decimal.NewFromInt(10)}, "")
+	assert.ErrorIs(t, err, risk.DeniedErr)
+	_, err = b.PreviewOperation(context.Background(), risk.Operation{Kind: risk.KindWithdrawal, WalletID: 123, Amount: decimal.NewFromInt(1000)}, "test")
+	assert.ErrorIs(t, err, wallet.InsufficientFundsErr)
+	_, err = b.PreviewOperation(context.Background(), risk.Operation{Kind: risk.KindWithdrawal, WalletID: 777, Amount: decimal.NewFromInt(60)}, "test")
+	assert.ErrorIs(t, err, limits.LimitExceededErr)
+
+	p, err = b.PreviewOperation(context.Background(), risk.Operation{Kind: risk.KindReplenishment, WalletID: 123, Amount: decimal.NewFromInt(10)}, "test")
+	assert.NoError(t, err)
+	assert.True(t, p.Fee.IsZero())
+	assert.Empty(t, got)
+
+	t.Run("preview is not logged or counted", func(t *testing.T) {
+		var logs bytes.Buffer
+		logger := slog.Default()
+		slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+		defer slog.SetDefault(logger)
+		before := counterValue(t, "balance_insufficient_funds_total")
+
+		_, err := b.PreviewOperation(context.Background(), risk.Operation{Kind: risk.KindWithdrawal, WalletID: 123, Amount: decimal.NewFromInt(1000)}, "test")
+		assert.ErrorIs(t, err, wallet.InsufficientFundsErr)
+		b.PreviewOperation(context.Background(), risk.Operation{Kind: risk.KindReplenishment, WalletID: -1, Amount: decimal.NewFromInt(10)}, "new wallet")
+		b.PreviewOperation(context.Background(), risk.Operation{Kind: risk.KindWithdrawal, WalletID: 5000, Amount: decimal.NewFromInt(250)}, "car")
+		assert.Equal(t, before, counterValue(t, "balance_insufficient_funds_total"))
+		assert.Empty(t, logs.String())
+
+		executed := &Billing{}
+		assert.ErrorIs(t, executed.MoneyTransaction(context.Background(), 123, wallet.Withdrawal, decimal.NewFromInt(1000), "test"), wallet.InsufficientFundsErr)
+		assert.Equal(t, before+1, counterValue(t, "balance_insufficient_funds_total"), "executed operation must be counted")
+	})
+}
+
+// counterValue returns the value of the counter without labels from the default registry
+func counterValue(t *testing.T, name string) float64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	assert.NoError(t, err)
+	for _, f := range families {
+		if f.GetName() == name {
+			return f.GetMetric()[0].GetCounter().GetValue()
+		}
+	}
+	return 0
+}
+
+func TestCreditLine(t *testing.T) {
+	bus := events.NewInProcess()
+	var got []string
+	bus.Subscribe(func(ctx context.Context, e events.Event) { got = append(got, e.Type+" "+e.Subject) })
+	b := &Billing{events: events.NewEmitter("/balance", bus), notify: notify.NewHub()}
+
+	assert.NoError(t, b.MoneyTransaction(context.Background(), 999, wallet.Withdrawal, decimal.NewFromInt(800), "test"), "withdrawal within the credit line must be allowed")
+	assert.ErrorIs(t, b.MoneyTransaction(context.Background(), 999, wallet.Withdrawal, decimal.NewFromInt(1000), "test"), wallet.InsufficientFundsErr)
+
+	w, err := b.WalletBalance(context.Background(), 999)
+	assert.NoError(t, err)
+	assert.Equal(t, "900", w.Available().String())
+
+	assert.ErrorIs(t, b.SetCreditLine(context.Background(), 999, credit.Line{Limit: decimal.NewFromInt(-1)}), credit.InvalidLineErr)
+	assert.ErrorIs(t, b.SetCreditLine(context.Background(), -1, credit.Line{}), database.UserDoesNotExistErr)
+	assert.NoError(t, b.SetCreditLine(context.Background(), 999, credit.Line{Limit: decimal.NewFromInt(500)}))
+
+	//wallet 999 was last charged 4 days ago
+	got = nil
+	changes := b.Subscribe(999)
+	assert.NoError(t, b.ChargeOverdraftInterest(context.Background(), 999))
+	assert.Equal(t, []string{
+		"balance.wallet.interest_charged.v1 wallets/999",
+		"balance.wallet.interest_charged.v1 wallets/999",
+		"balance.wallet.interest_charged.v1 wallets/999",
+	}, got, "every missed day must be charged, interest must not be reported as a withdrawal")
+	today := limits.DayStart(time.Now())
+	for i := 3; i >= 1; i-- {
+		ch := (<-changes.C).Change
+		assert.Equal(t, wallet.OverdraftInterest, ch.Operation, "interest must not be counted in the limits and risk rules as a withdrawal")
+		assert.Equal(t, "overdraft interest for "+today.AddDate(0, 0, -i).Format(time.DateOnly), ch.Description)
+	}
+	changes.Close()
+
+	got = nil
+	assert.NoError(t, b.ChargeOverdraftInterest(context.Background(), 123))
+	assert.Empty(t, got, "positive balance must not be charged")
+}
+
+func TestSavings(t *testing.T) {
+	bus := events.NewInProcess()
+	var got []string
+	bus.Subscribe(func(ctx context.Context, e events.Event) { got = append(got, e.Type+" "+e.Subject) })
+	products, err := savings.NewProducts(savings.Product{Name: "basic", Rate: decimal.NewFromInt(3)})
+	assert.NoError(t, err)
+	b := &Billing{products: products, events: events.NewEmitter("/balance", bus)}
+
+	_, err = b.SetSavings(context.Background(), 123, savings.Account{Product: "gold"})
+	assert.ErrorIs(t, err, savings.InvalidAccountErr)
+	a, err := b.SetSavings(context.Background(), 123, savings.Account{Product: "basic"})
+	assert.NoError(t, err)
+	assert.Equal(t, limits.DayStart(time.Now()).Unix(), a.Since, "enabled savings must be accrued from today")
+	a, err = b.SetSavings(context.Background(), 555, savings.Account{Rate: decimal.NewNullDecimal(decimal.NewFromInt(5))})
+	assert.NoError(t, err)
+	assert.Less(t, a.Since, limits.DayStart(time.Now()).Unix(), "changed rate must keep the accrual start")
+
+	//wallet 555 was last accrued 40 days ago, the interest is posted after every month end since then
+	var want []string
+	for day := limits.DayStart(time.Now().AddDate(0, 0, -39)); day.Before(limits.DayStart(time.Now())); day = day.AddDate(0, 0, 1) {
+		if savings.MonthEnd(day) {
+			want = append(want, "balance.wallet.replenished.v1 wallets/555")
+		}
+	}
+	assert.NoError(t, b.AccrueInterest(context.Background(), 555))
+	assert.Equal(t, want, got)
+
+	got = nil
+	assert.NoError(t, b.AccrueInterest(context.Background(), 123))
+	assert.Empty(t, got, "disabled savings must not be accrued")
+
+	r, err := b.InterestReport(context.Background(), 555, 0, time.Now().Unix())
+	assert.NoError(t, err)
+	assert.Equal(t, "0.1", r.Accrued.String())
+	assert.Equal(t, "10.005", r.Pending.String())
+	_, err = b.InterestReport(context.Background(), -1, 0, time.Now().Unix())
+	assert.ErrorIs(t, err, database.UserDoesNotExistErr)
+}
@@ -0,0 +1,157 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/KseniiaSalmina/Balance/internal/audit"
+	"github.com/KseniiaSalmina/Balance/internal/credit"
+	"github.com/KseniiaSalmina/Balance/internal/limits"
+	"github.com/KseniiaSalmina/Balance/internal/tracing"
+	"github.com/KseniiaSalmina/Balance/internal/wallet"
+)
+
+// WalletBalance returns the balance of the wallet with its credit limit
+func (b *Billing) WalletBalance(ctx context.Context, id int) (_ *wallet.Wallet, err error) {
+	ctx, span := tracing.Start(ctx, "billing.WalletBalance", attribute.Int("wallet.id", id))
+	defer tracing.End(span, &err)
+
+	tx, err := b.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("billing.WalletBalance -> %w", err)
+	}
+	defer tx.Rollback()
+
+	return tx.GetBalance(id)
+}
+
+func (b *Billing) CreditLine(ctx context.Context, id int) (_ credit.Line, err error) {
+	ctx, span := tracing.Start(ctx, "billing.CreditLine", attribute.Int("wallet.id", id))
+	defer tracing.End(span, &err)
+
+	tx, err := b.beginTx(ctx)
+	if err != nil {
+		return credit.Line{}, fmt.Errorf("billing.CreditLine -> %w", err)
+	}
+	defer tx.Rollback()
+
+	return tx.GetCreditLine(id)
+}
+
+// SetCreditLine sets the credit line of the wallet. The lower limit does not change the current balance,
+// the withdrawals are rejected until the balance is above the new limit. The interest is charged from the day
+// it is enabled, changing the rate of the enabled interest keeps the days charged
+func (b *Billing) SetCreditLine(ctx context.Context, id int, l credit.Line) (err error) {
+	ctx, span := tracing.Start(ctx, "billing.SetCreditLine", attribute.Int("wallet.id", id))
+	defer tracing.End(span, &err)
+
+	if err = l.Validate(); err != nil {
+		return err
+	}
+
+	return b.inTx(ctx, func(s Storage) error {
+		if err := s.LockWallet(id); err != nil {
+			return err
+		}
+		old, err := s.GetCreditLine(id)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case !l.InterestRate.IsPositive():
+			l.Since = 0
+		case old.InterestRate.IsPositive():
+			l.Since = old.Since
+		default:
+			l.Since = limits.DayStart(time.Now()).Unix()
+		}
+		return s.SetCreditLine(id, l)
+	})
+}
+
+// ChargeOverdraftInterest charges the interest for the negative balance of the wallet at the end of every finished day
+// that is not charged yet, so the days missed while the service was stopped are charged too. The charged days are saved
+// in the transaction of the charges, so every day is charged once even if several instances run the charges
+func (b *Billing) ChargeOverdraftInterest(ctx context.Context, id int64) (err error) {
+	ctx, span := tracing.Start(ctx, "billing.ChargeOverdraftInterest", attribute.Int64("wallet.id", id))
+	defer tracing.End(span, &err)
+
+	walletID := int(id)
+	last := credit.ChargedDay(time.Now())
+	type charge struct {
+		day    time.Time
+		amount decimal.Decimal
+	}
+	var charges []charge
+	err = b.inTx(ctx, func(s Storage) error {
+		if err := s.LockWallet(walletID); err != nil {
+			return err
+		}
+		line, err := s.GetCreditLine(walletID)
+		if err != nil || !line.InterestRate.IsPositive() {
+			return err
+		}
+		w, err := s.GetBalance(walletID)
+		if err != nil {
+			return err
+		}
+
+		day := limits.DayStart(time.Unix(line.Since, 0))
+		charged, ok, err := s.LastOverdraftCharge(walletID)
+		if err != nil {
+			return err
+		}
+		if next := time.Unix(charged, 0).AddDate(0, 0, 1); ok && next.After(day) {
+			day = limits.DayStart(next)
+		}
+		//the interest enabled before its start day was saved is charged from the last finished day
+		if line.Since == 0 && !ok {
+			day = last
+		}
+
+		posted := decimal.Zero
+		for ; !day.After(last); day = day.AddDate(0, 0, 1) {
+			//the balance at the end of the day is the current one without the changes made after it. The interest charged
+			//by this call for the previous days is dated now, so the change takes it back, but it was due before the end of the day
+			change, err := s.NetChangeSince(walletID, day.AddDate(0, 0, 1).Unix())
+			if err != nil {
+				return err
+			}
+			balance := w.Balance.Sub(change).Sub(posted)
+
+			interest := credit.Interest(balance, line.InterestRate, day)
+			added, err := s.AddOverdraftCharge(walletID, day.Unix(), balance, interest)
+			if err != nil {
+				return err
+			}
+			if !added || !interest.IsPositive() {
+				continue
+			}
+
+			//the interest is charged even if the balance goes below the credit limit
+			//posted as its own operation, so it is not counted in the limits and risk rules and is not reported as a withdrawal
+			ch := wallet.NewChange(wallet.OverdraftInterest, interest, fmt.Sprintf("overdraft interest for %s", day.Format(time.DateOnly)))
+			ch.Actor = audit.System
+			w.Balance = w.Balance.Sub(interest)
+			if _, err = s.CommitChanges(walletID, w.Balance, ch); err != nil {
+				return err
+			}
+			posted = posted.Add(interest)
+			charges = append(charges, charge{day: day, amount: interest})
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("billing.ChargeOverdraftInterest -> %w", err)
+	}
+	for _, c := range charges {
+		slog.InfoContext(ctx, "overdraft interest charged", "wallet_id", walletID, "day", c.day.Format(time.DateOnly), "amount", c.amount.String())
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+	"github.com/shopspring/decimal"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/KseniiaSalmina/Balance/internal/events"
+	"github.com/KseniiaSalmina/Balance/internal/notify"
+	"github.com/KseniiaSalmina/Balance/internal/risk"
+	"github.com/KseniiaSalmina/Balance/internal/schedule"
+	"github.com/KseniiaSalmina/Balance/internal/subscription"
+	"github.com/KseniiaSalmina/Balance/internal/tracing"
+	"github.com/KseniiaSalmina/Balance/internal/wallet"
+)
+
+// publishingTx collects the changes of the transaction and the domain events, after the commit the changes are sent
+// to the subscribers and the domain events are published
+type publishingTx struct {
+	Storage
+	ctx     context.Context
+	hub     *notify.Hub
+	emitter *events.Emitter
+	changes []notify.Event
+	domain  []events.Data
+}
+
+func (t *publishingTx) NewUser(id int) error {
+	if err := t.Storage.NewUser(id); err != nil {
+		return err
+	}
+
+	t.domain = append(t.domain, events.WalletCreated{WalletID: id})
+	return nil
+}
+
+func (t *publishingTx) CommitChanges(id int, balance decimal.Decimal, ch wallet.HistoryChange) (int64, error) {
+	historyID, err := t.Storage.CommitChanges(id, balance, ch)
+	if err != nil {
+		return 0, err
+	}
+
+	t.changes = append(t.changes, notify.Event{ID: historyID, WalletID: id, Balance: balance, Change: ch})
+
+	changed := events.BalanceChanged{WalletID: id, HistoryID: historyID, Amount: ch.Amount, Balance: balance, Description: ch.Description, Actor: ch.Actor, Counterparty: ch.Counterparty}
+	switch ch.Operation {
+	case wallet.Replenishment:
+		t.domain = append(t.domain, events.Replenished(changed))
+		//the replenishment of the recipient is the last step of a transfer
+		if ch.Counterparty != 0 {
+			t.domain = append(t.domain, events.TransferCompleted{From: ch.Counterparty, To: id, Amount: ch.Amount, Actor: ch.Actor})
+		}
+	case wallet.Withdrawal, wallet.Fee:
+		t.domain = append(t.domain, events.Withdrawn(changed))
+	case wallet.OverdraftInterest:
+		t.domain = append(t.domain, events.InterestCharged(changed))
+	}
+	return historyID, nil
+}
+
+func (t *publishingTx) CreateReview(r risk.Review) (int64, error) {
+	id, err := t.Storage.CreateReview(r)
+	if err != nil {
+		return 0, err
+	}
+
+	op := r.Operation
+	t.domain = append(t.domain, events.ReviewRequested{ReviewID: id, Kind: string(op.Kind), WalletID: op.WalletID, To: op.To, Amount: op.Amount, Actor: r.Actor})
+	return id, nil
+}
+
+func (t *publishingTx) ResolveReview(id int64, status risk.ReviewStatus, by string, at int64) error {
+	if err := t.Storage.ResolveReview(id, status, by, at); err != nil {
+		return err
+	}
+
+	t.domain = append(t.domain, events.ReviewResolved{ReviewID: id, Status: string(status), ResolvedBy: by})
+	return nil
+}
+
+func (t *publishingTx) FinishScheduled(op schedule.Operation) error {
+	if err := t.Storage.FinishScheduled(op); err != nil {
+		return err
+	}
+
+	finished := events.ScheduledFinished{ScheduledID: op.ID, Kind: string(op.Type), WalletID: op.WalletID, To: op.To, Amount: op.Amount, Status: string(op.Status), ReviewID: op.ReviewID, Error: op.Error, RecurringID: op.RecurringID, Actor: op.Actor}
+	if op.Status == schedule.Failed {
+		t.domain = append(t.domain, events.ScheduledFailed(finished))
+	} else {
+		t.domain = append(t.domain, events.ScheduledExecuted(finished))
+	}
+	return nil
+}
+
+func (t *publishingTx) SaveCharge(s subscription.Subscription) error {
+	if err := t.Storage.SaveCharge(s); err != nil {
+		return err
+	}
+
+	switch s.Status {
+	case subscription.Active:
+		t.domain = append(t.domain, events.SubscriptionCharged{SubscriptionID: s.ID, PlanID: s.PlanID, WalletID: s.WalletID, PeriodStart: s.PeriodStart, PeriodEnd: s.PeriodEnd})
+	case subscription.PastDue:
+		t.domain = append(t.domain, events.SubscriptionPastDue{SubscriptionID: s.ID, PlanID: s.PlanID, WalletID: s.WalletID, Attempt: s.Attempts, Error: s.LastError, NextChargeAt: s.NextChargeAt, GraceUntil: s.GraceUntil})
+	case subscription.Suspended:
+		t.domain = append(t.domain, events.SubscriptionSuspended{SubscriptionID: s.ID, PlanID: s.PlanID, WalletID: s.WalletID, Attempts: s.Attempts, Error: s.LastError})
+	}
+	return nil
+}
+
+func (t *publishingTx) Commit() error {
+	if err := t.Storage.Commit(); err != nil {
+		return err
+	}
+
+	t.hub.Publish(t.changes...)
+	t.emitter.Emit(t.ctx, t.domain...)
+	t.changes, t.domain = nil, nil
+	return nil
+}
+
+// Subscribe returns the subscription to the changes of the wallet committed after the call
+func (b *Billing) Subscribe(id int) *notify.Subscription {
+	return b.notify.Subscribe(id)
+}
+
+// Events returns up to limit changes of the wallet committed after the history record with ID after
+func (b *Billing) Events(ctx context.Context, id int, after int64, limit int) (_ []notify.Event, err error) {
+	ctx, span := tracing.Start(ctx, "billing.Events", attribute.Int("wallet.id", id), attribute.Int64("after", after))
+	defer tracing.End(span, &err)
+
+	tx, err := b.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("billing.Events -> %w", err)
+	}
+	defer tx.Rollback()
+
+	return tx.GetEvents(id, after, limit)
+}
+
+// LastEvent returns the last change of the wallet with the current balance, nil if the wallet has no history
+func (b *Billing) LastEvent(ctx context.Context, id int) (_ *notify.Event, err error) {
+	ctx, span := tracing.Start(ctx, "billing.LastEvent", attribute.Int("wallet.id", id))
+	defer tracing.End(span, &err)
+
+	tx, err := b.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("billing.LastEvent -> %w", err)
+	}
+	defer tx.Rollback()
+
+	return tx.GetLastEvent(id)
+}
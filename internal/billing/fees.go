@@ -0,0 +1,68 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/KseniiaSalmina/Balance/internal/fees"
+	"github.com/KseniiaSalmina/Balance/internal/risk"
+	"github.com/KseniiaSalmina/Balance/internal/tracing"
+	"github.com/KseniiaSalmina/Balance/internal/wallet"
+)
+
+// quoteFee returns the fee of the operation by the tier of the wallet. Operations of the revenue wallet have no fee
+func quoteFee(s Storage, sched *fees.Schedule, op risk.Operation) (fees.Quote, error) {
+	if sched == nil || op.WalletID == sched.Wallet() {
+		return fees.Quote{Operation: op.Kind, Amount: op.Amount, Fee: decimal.Zero, Total: op.Amount}, nil
+	}
+
+	tier, err := s.GetTier(op.WalletID)
+	if err != nil {
+		return fees.Quote{}, fmt.Errorf("problem with getting balance: %w", err)
+	}
+	return sched.Quote(op.Kind, tier, op.Amount), nil
+}
+
+// chargeFee withdraws the fee from the wallet of the operation and posts it to the revenue wallet
+func (b *Billing) chargeFee(ctx context.Context, s Storage, sched *fees.Schedule, op risk.Operation, q fees.Quote) error {
+	if !q.Fee.IsPositive() {
+		return nil
+	}
+
+	desc := "fee for withdrawal"
+	if op.Kind == risk.KindTransfer {
+		desc = fmt.Sprintf("fee for transfer to user %v", op.To)
+	}
+	if err := b.moneyTransaction(ctx, s, op.WalletID, wallet.Fee, q.Fee, desc, 0); err != nil {
+		return err
+	}
+	return b.moneyTransaction(ctx, s, sched.Wallet(), wallet.Replenishment, q.Fee, fmt.Sprintf("fee from user %v", op.WalletID), 0)
+}
+
+// QuoteFee returns the fee the operation of the wallet would be charged now, nothing is changed
+func (b *Billing) QuoteFee(ctx context.Context, id int, kind risk.Kind, amount decimal.Decimal) (_ fees.Quote, err error) {
+	ctx, span := tracing.Start(ctx, "billing.QuoteFee", attribute.Int("wallet.id", id), attribute.String("operation", string(kind)))
+	defer tracing.End(span, &err)
+
+	tx, err := b.beginTx(ctx)
+	if err != nil {
+		return fees.Quote{}, fmt.Errorf("billing.QuoteFee -> %w", err)
+	}
+	defer tx.Rollback()
+
+	return quoteFee(tx, b.feeSchedule(), risk.Operation{Kind: kind, WalletID: id, Amount: amount, Time: time.Now()})
+}
+
+// SetWalletTier sets the tier of the wallet, the fee rules of the tier are applied to its next operations
+func (b *Billing) SetWalletTier(ctx context.Context, id int, tier string) (err error) {
+	ctx, span := tracing.Start(ctx, "billing.SetWalletTier", attribute.Int("wallet.id", id))
+	defer tracing.End(span, &err)
+
+	return b.inTx(ctx, func(s Storage) error {
+		return s.SetTier(id, tier)
+	})
+}
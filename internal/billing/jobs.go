@@ -0,0 +1,108 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/KseniiaSalmina/Balance/internal/audit"
+	"github.com/KseniiaSalmina/Balance/internal/database"
+	"github.com/KseniiaSalmina/Balance/internal/jobs"
+	"github.com/KseniiaSalmina/Balance/internal/tracing"
+)
+
+func (b *Billing) enqueue(ctx context.Context, j jobs.Job) (jobs.Job, error) {
+	err := b.inTx(ctx, func(s Storage) error {
+		var err error
+		j.ID, err = s.CreateJob(j)
+		return err
+	})
+	if err != nil {
+		return jobs.Job{}, fmt.Errorf("enqueue -> %w", err)
+	}
+	return j, nil
+}
+
+// owns reports whether the object created by actor belongs to the client of ctx. Only the API key part of the actors is compared,
+// the end user is set by the client itself
+func owns(ctx context.Context, actor string) bool {
+	return audit.Client(actor) == audit.Client(audit.Actor(ctx))
+}
+
+// ownJob returns the job if it was created by the client of ctx, the jobs of the other clients are reported as not existing,
+// so their IDs can not be probed
+func ownJob(ctx context.Context, s Storage, id int64) (*jobs.Job, error) {
+	j, err := s.GetJob(id)
+	if err != nil {
+		return nil, err
+	}
+	if !owns(ctx, j.Actor) {
+		return nil, database.JobDoesNotExistErr
+	}
+	return j, nil
+}
+
+// Job returns the job of the client of ctx
+func (b *Billing) Job(ctx context.Context, id int64) (_ *jobs.Job, err error) {
+	ctx, span := tracing.Start(ctx, "billing.Job", attribute.Int64("job.id", id))
+	defer tracing.End(span, &err)
+
+	tx, err := b.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("billing.Job -> %w", err)
+	}
+	defer tx.Rollback()
+
+	return ownJob(ctx, tx, id)
+}
+
+// Jobs returns the jobs of the client of ctx
+func (b *Billing) Jobs(ctx context.Context, status jobs.Status, limit int) (_ []jobs.Job, err error) {
+	ctx, span := tracing.Start(ctx, "billing.Jobs", attribute.String("status", string(status)))
+	defer tracing.End(span, &err)
+
+	tx, err := b.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("billing.Jobs -> %w", err)
+	}
+	defer tx.Rollback()
+
+	return tx.ListJobs(audit.Client(audit.Actor(ctx)), status, limit)
+}
+
+// JobArtifact returns the artifact of the job of the client of ctx, nil if the job has no artifact
+func (b *Billing) JobArtifact(ctx context.Context, id int64) (_ *jobs.Artifact, err error) {
+	ctx, span := tracing.Start(ctx, "billing.JobArtifact", attribute.Int64("job.id", id))
+	defer tracing.End(span, &err)
+
+	tx, err := b.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("billing.JobArtifact -> %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err = ownJob(ctx, tx, id); err != nil {
+		return nil, err
+	}
+	return tx.GetJobArtifact(id)
+}
+
+// CancelJob cancels the job of the client of ctx
+func (b *Billing) CancelJob(ctx context.Context, id int64) (_ *jobs.Job, err error) {
+	ctx, span := tracing.Start(ctx, "billing.CancelJob", attribute.Int64("job.id", id))
+	defer tracing.End(span, &err)
+
+	var j *jobs.Job
+	err = b.inTx(ctx, func(s Storage) error {
+		if _, err := ownJob(ctx, s, id); err != nil {
+			return err
+		}
+
+		var err error
+		j, err = s.CancelJob(id, time.Now().Unix())
+		return err
+	})
+	return j, err
+}
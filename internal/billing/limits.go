@@ -0,0 +1,88 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/KseniiaSalmina/Balance/internal/limits"
+	"github.com/KseniiaSalmina/Balance/internal/tracing"
+)
+
+// checkLimits locks the wallet and checks the operation against its limits and the withdrawals made in the current day and month
+func (b *Billing) checkLimits(s Storage, id int, kind limits.Kind, amount decimal.Decimal) error {
+	if err := s.LockWallet(id); err != nil {
+		return fmt.Errorf("problem with getting balance: %w", err)
+	}
+
+	own, err := s.GetLimits(id)
+	if err != nil {
+		return fmt.Errorf("checkLimits -> %w", err)
+	}
+	l := b.globalLimits().Override(own)
+
+	var usage limits.Usage
+	now := time.Now()
+	if l.DailyWithdrawal.Valid {
+		if usage.Daily, err = s.SumWithdrawals(id, limits.DayStart(now).Unix()); err != nil {
+			return fmt.Errorf("checkLimits -> %w", err)
+		}
+	}
+	if l.MonthlyWithdrawal.Valid {
+		if usage.Monthly, err = s.SumWithdrawals(id, limits.MonthStart(now).Unix()); err != nil {
+			return fmt.Errorf("checkLimits -> %w", err)
+		}
+	}
+
+	return l.Check(kind, amount, usage)
+}
+
+// WalletLimits returns limits set for the wallet and the limits in effect after applying global ones
+func (b *Billing) WalletLimits(ctx context.Context, id int) (_ limits.Limits, _ limits.Limits, err error) {
+	ctx, span := tracing.Start(ctx, "billing.WalletLimits", attribute.Int("wallet.id", id))
+	defer tracing.End(span, &err)
+
+	tx, err := b.beginTx(ctx)
+	if err != nil {
+		return limits.Limits{}, limits.Limits{}, fmt.Errorf("billing.WalletLimits -> %w", err)
+	}
+	defer tx.Rollback()
+
+	if err = tx.LockWallet(id); err != nil {
+		return limits.Limits{}, limits.Limits{}, err
+	}
+
+	own, err := tx.GetLimits(id)
+	if err != nil {
+		return limits.Limits{}, limits.Limits{}, err
+	}
+
+	return own, b.globalLimits().Override(own), nil
+}
+
+func (b *Billing) SetWalletLimits(ctx context.Context, id int, l limits.Limits) (err error) {
+	ctx, span := tracing.Start(ctx, "billing.SetWalletLimits", attribute.Int("wallet.id", id))
+	defer tracing.End(span, &err)
+
+	tx, err := b.beginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("billing.SetWalletLimits -> %w", err)
+	}
+	defer tx.Rollback()
+
+	if err = tx.LockWallet(id); err != nil {
+		return err
+	}
+
+	if err = tx.SetLimits(id, l); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("billing.SetWalletLimits -> %w", err)
+	}
+	return nil
+}
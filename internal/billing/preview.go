@@ -0,0 +1,56 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/KseniiaSalmina/Balance/internal/fees"
+	"github.com/KseniiaSalmina/Balance/internal/risk"
+	"github.com/KseniiaSalmina/Balance/internal/tracing"
+	"github.com/KseniiaSalmina/Balance/internal/wallet"
+)
+
+// PreviewOperation executes the operation with the limits, the fee and the funds checked in the transaction that is always
+// rolled back and returns the would-be result. The errors are the same as the operation would return. The risk rules
+// are evaluated, but the review is not created. Nothing is logged or counted in the metrics
+func (b *Billing) PreviewOperation(ctx context.Context, op risk.Operation, desc string) (_ wallet.Preview, err error) {
+	ctx, span := tracing.Start(ctx, "billing.PreviewOperation", attribute.Int("wallet.id", op.WalletID), attribute.String("operation", string(op.Kind)))
+	defer tracing.End(span, &err)
+
+	tx, err := b.beginTx(ctx)
+	if err != nil {
+		return wallet.Preview{}, fmt.Errorf("billing.PreviewOperation -> %w", err)
+	}
+	defer tx.Rollback()
+
+	ctx = risk.WithPreview(ctx)
+	op.Time = time.Now()
+	res, err := b.riskEngine().Evaluate(ctx, op, tx)
+	if err != nil {
+		return wallet.Preview{}, fmt.Errorf("billing.PreviewOperation -> %w", err)
+	}
+	if res.Decision == risk.Deny {
+		return wallet.Preview{}, risk.DeniedErr
+	}
+
+	q := fees.Quote{}
+	if op.Kind != risk.KindReplenishment {
+		if q, err = quoteFee(tx, b.feeSchedule(), op); err != nil {
+			return wallet.Preview{}, err
+		}
+	}
+
+	if err = b.execute(ctx, tx, op, desc); err != nil {
+		return wallet.Preview{}, err
+	}
+
+	w, err := tx.GetBalance(op.WalletID)
+	if err != nil {
+		return wallet.Preview{}, fmt.Errorf("billing.PreviewOperation -> %w", err)
+	}
+
+	return wallet.Preview{Balance: w.Balance, Fee: q.Fee, FeeRule: q.Rule, ReviewRequired: res.Decision == risk.ManualReview}, nil
+}
@@ -0,0 +1,134 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/KseniiaSalmina/Balance/internal/audit"
+	"github.com/KseniiaSalmina/Balance/internal/batch"
+	"github.com/KseniiaSalmina/Balance/internal/database"
+	"github.com/KseniiaSalmina/Balance/internal/recurring"
+	"github.com/KseniiaSalmina/Balance/internal/risk"
+	"github.com/KseniiaSalmina/Balance/internal/schedule"
+	"github.com/KseniiaSalmina/Balance/internal/tracing"
+)
+
+// CreateRecurring saves the payment on behalf of the current client and schedules its first occurrence
+func (b *Billing) CreateRecurring(ctx context.Context, p recurring.Payment) (_ recurring.Payment, err error) {
+	ctx, span := tracing.Start(ctx, "billing.CreateRecurring", attribute.Int("wallet.id", p.WalletID), attribute.String("frequency", string(p.Frequency)))
+	defer tracing.End(span, &err)
+
+	now := time.Now().Unix()
+	p, err = recurring.New(p, audit.Actor(ctx), now)
+	if err != nil {
+		return recurring.Payment{}, err
+	}
+
+	err = b.inTx(ctx, func(s Storage) error {
+		var err error
+		if p.ID, err = s.CreateRecurring(p); err != nil {
+			return err
+		}
+		return scheduleOccurrence(s, &p, now)
+	})
+	if err != nil {
+		return recurring.Payment{}, fmt.Errorf("billing.CreateRecurring -> %w", err)
+	}
+	return p, nil
+}
+
+// ownRecurring returns the payment if it was created by the client of ctx, the payments of the other clients are reported as not existing
+func ownRecurring(ctx context.Context, s Storage, id int64) (*recurring.Payment, error) {
+	p, err := s.GetRecurring(id)
+	if err != nil {
+		return nil, err
+	}
+	if !owns(ctx, p.Actor) {
+		return nil, database.RecurringDoesNotExistErr
+	}
+	return p, nil
+}
+
+// RecurringPayment returns the payment created by the client of ctx
+func (b *Billing) RecurringPayment(ctx context.Context, id int64) (_ *recurring.Payment, err error) {
+	ctx, span := tracing.Start(ctx, "billing.RecurringPayment", attribute.Int64("recurring.id", id))
+	defer tracing.End(span, &err)
+
+	tx, err := b.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("billing.RecurringPayment -> %w", err)
+	}
+	defer tx.Rollback()
+
+	return ownRecurring(ctx, tx, id)
+}
+
+// RecurringPayments returns the payments from the wallet created by the client of ctx
+func (b *Billing) RecurringPayments(ctx context.Context, walletID int, status recurring.Status, limit int) (_ []recurring.Payment, err error) {
+	ctx, span := tracing.Start(ctx, "billing.RecurringPayments", attribute.Int("wallet.id", walletID), attribute.String("status", string(status)))
+	defer tracing.End(span, &err)
+
+	tx, err := b.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("billing.RecurringPayments -> %w", err)
+	}
+	defer tx.Rollback()
+
+	return tx.ListRecurring(audit.Client(audit.Actor(ctx)), walletID, status, limit)
+}
+
+// CancelRecurring cancels the payment of the client of ctx with its pending occurrence
+func (b *Billing) CancelRecurring(ctx context.Context, id int64) (_ *recurring.Payment, err error) {
+	ctx, span := tracing.Start(ctx, "billing.CancelRecurring", attribute.Int64("recurring.id", id))
+	defer tracing.End(span, &err)
+
+	var p *recurring.Payment
+	err = b.inTx(ctx, func(s Storage) error {
+		if _, err := ownRecurring(ctx, s, id); err != nil {
+			return err
+		}
+
+		var err error
+		p, err = s.CancelRecurring(id, time.Now().Unix())
+		return err
+	})
+	return p, err
+}
+
+// continueRecurring schedules the retry or the next occurrence of the recurring payment after the occurrence is finished
+// or canceled, in the transaction of the occurrence
+func continueRecurring(s Storage, op schedule.Operation) error {
+	p, err := s.LockRecurring(op.RecurringID)
+	if err != nil {
+		return fmt.Errorf("continueRecurring -> %w", err)
+	}
+	if p.Status != recurring.Active || p.ScheduledID != op.ID {
+		return nil
+	}
+
+	now := time.Now().Unix()
+	if op.Status != schedule.Failed || !p.Retry(now) {
+		if !p.Advance(now) {
+			return s.UpdateRecurring(*p)
+		}
+	}
+	return scheduleOccurrence(s, p, now)
+}
+
+// scheduleOccurrence schedules the pending execution of the payment
+func scheduleOccurrence(s Storage, p *recurring.Payment, now int64) error {
+	item := batch.Item{Type: risk.KindTransfer, WalletID: p.WalletID, To: p.To, Amount: p.Amount}
+	op, err := schedule.New(item, p.ExecuteAt(), p.Actor, now)
+	if err != nil {
+		return fmt.Errorf("scheduleOccurrence -> %w", err)
+	}
+	op.RecurringID = p.ID
+
+	if p.ScheduledID, err = s.CreateScheduled(op); err != nil {
+		return err
+	}
+	return s.UpdateRecurring(*p)
+}
@@ -0,0 +1,122 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/KseniiaSalmina/Balance/internal/audit"
+	"github.com/KseniiaSalmina/Balance/internal/metrics"
+	"github.com/KseniiaSalmina/Balance/internal/risk"
+	"github.com/KseniiaSalmina/Balance/internal/tracing"
+)
+
+// assess evaluates risk rules for the pending operation. If the operation requires manual review,
+// it is saved to the review queue, the transaction is committed and ReviewRequiredError is returned
+func (b *Billing) assess(ctx context.Context, s Storage, op risk.Operation, desc string) error {
+	reviewID, err := b.evaluate(ctx, s, op, desc)
+	if err != nil || reviewID == 0 {
+		return err
+	}
+
+	if err = s.Commit(); err != nil {
+		return fmt.Errorf("assess -> %w", err)
+	}
+	return &risk.ReviewRequiredError{ReviewID: reviewID}
+}
+
+// evaluate evaluates risk rules for the pending operation. If the operation requires manual review,
+// it is saved to the review queue in the transaction and the ID of the review is returned
+func (b *Billing) evaluate(ctx context.Context, s Storage, op risk.Operation, desc string) (int64, error) {
+	res, err := b.riskEngine().Evaluate(ctx, op, s)
+	if err != nil {
+		return 0, fmt.Errorf("evaluate -> %w", err)
+	}
+
+	switch res.Decision {
+	case risk.Deny:
+		return 0, risk.DeniedErr
+	case risk.ManualReview:
+		id, err := s.CreateReview(risk.Review{
+			Created:     op.Time.Unix(),
+			Operation:   op,
+			Description: desc,
+			Actor:       audit.Actor(ctx),
+			Hits:        res.Hits,
+			Status:      risk.Pending,
+		})
+		if err != nil {
+			return 0, fmt.Errorf("evaluate -> %w", err)
+		}
+		return id, nil
+	}
+
+	return 0, nil
+}
+
+func (b *Billing) Reviews(ctx context.Context, status risk.ReviewStatus, limit int) (_ []risk.Review, err error) {
+	ctx, span := tracing.Start(ctx, "billing.Reviews", attribute.String("status", string(status)))
+	defer tracing.End(span, &err)
+
+	tx, err := b.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("billing.Reviews -> %w", err)
+	}
+	defer tx.Rollback()
+
+	return tx.ListReviews(status, limit)
+}
+
+// ApproveReview executes the held operation on behalf of the client who requested it, limits and funds are checked again
+func (b *Billing) ApproveReview(ctx context.Context, id int64) error {
+	return b.resolveReview(ctx, id, risk.Approved, func(s Storage, r *risk.Review) error {
+		return b.execute(audit.WithActor(ctx, r.Actor), s, r.Operation, r.Description)
+	})
+}
+
+func (b *Billing) RejectReview(ctx context.Context, id int64) error {
+	return b.resolveReview(ctx, id, risk.Rejected, func(Storage, *risk.Review) error {
+		return nil
+	})
+}
+
+func (b *Billing) resolveReview(ctx context.Context, id int64, status risk.ReviewStatus, apply func(s Storage, r *risk.Review) error) (err error) {
+	ctx, span := tracing.Start(ctx, "billing.resolveReview", attribute.Int64("review.id", id), attribute.String("status", string(status)))
+	defer tracing.End(span, &err)
+
+	tx, err := b.beginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("billing.resolveReview -> %w", err)
+	}
+	defer tx.Rollback()
+
+	r, err := tx.GetReview(id)
+	if err != nil {
+		return err
+	}
+	if r.Status != risk.Pending {
+		return fmt.Errorf("review %d is %s: %w", id, r.Status, risk.ReviewResolvedErr)
+	}
+	if status == risk.Approved && audit.Client(audit.Actor(ctx)) == audit.Client(r.Actor) {
+		return fmt.Errorf("review %d: %w", id, risk.SelfApprovalErr)
+	}
+
+	if err = apply(tx, r); err != nil {
+		return err
+	}
+
+	if err = tx.ResolveReview(id, status, audit.Actor(ctx), time.Now().Unix()); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("billing.resolveReview -> %w", err)
+	}
+	if status == risk.Approved {
+		metrics.Operation(string(r.Operation.Kind), r.Operation.Amount)
+	}
+
+	return nil
+}
@@ -0,0 +1,202 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/KseniiaSalmina/Balance/internal/audit"
+	"github.com/KseniiaSalmina/Balance/internal/limits"
+	"github.com/KseniiaSalmina/Balance/internal/savings"
+	"github.com/KseniiaSalmina/Balance/internal/tracing"
+	"github.com/KseniiaSalmina/Balance/internal/wallet"
+)
+
+func (b *Billing) Savings(ctx context.Context, id int) (_ savings.Account, err error) {
+	ctx, span := tracing.Start(ctx, "billing.Savings", attribute.Int("wallet.id", id))
+	defer tracing.End(span, &err)
+
+	tx, err := b.beginTx(ctx)
+	if err != nil {
+		return savings.Account{}, fmt.Errorf("billing.Savings -> %w", err)
+	}
+	defer tx.Rollback()
+
+	return tx.GetSavings(id)
+}
+
+// SetSavings sets the savings product or the interest rate of the wallet. The interest is accrued from the day the savings
+// are enabled, changing the rate of the enabled savings keeps the accrued interest
+func (b *Billing) SetSavings(ctx context.Context, id int, a savings.Account) (_ savings.Account, err error) {
+	ctx, span := tracing.Start(ctx, "billing.SetSavings", attribute.Int("wallet.id", id))
+	defer tracing.End(span, &err)
+
+	if err = a.Validate(b.savingsProducts()); err != nil {
+		return savings.Account{}, err
+	}
+
+	err = b.inTx(ctx, func(s Storage) error {
+		if err := s.LockWallet(id); err != nil {
+			return err
+		}
+		old, err := s.GetSavings(id)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case !a.Enabled():
+			a.Since = 0
+		case old.Enabled():
+			a.Since = old.Since
+		default:
+			a.Since = limits.DayStart(time.Now()).Unix()
+		}
+		return s.SetSavings(id, a)
+	})
+	if err != nil {
+		return savings.Account{}, fmt.Errorf("billing.SetSavings -> %w", err)
+	}
+	return a, nil
+}
+
+// AccrueInterest accrues the interest for the finished days of the wallet that are not accrued yet, the interest of the month
+// is posted to the balance after its last day. The accrued days are saved in the transaction of the payout, so every day
+// is paid once even if several instances run the accrual
+func (b *Billing) AccrueInterest(ctx context.Context, id int64) (err error) {
+	ctx, span := tracing.Start(ctx, "billing.AccrueInterest", attribute.Int64("wallet.id", id))
+	defer tracing.End(span, &err)
+
+	walletID := int(id)
+	last := limits.DayStart(time.Now()).AddDate(0, 0, -1)
+	products := b.savingsProducts()
+	var days int
+	var paid []savings.Capitalization
+	err = b.inTx(ctx, func(s Storage) error {
+		if err := s.LockWallet(walletID); err != nil {
+			return err
+		}
+		acc, err := s.GetSavings(walletID)
+		if err != nil || !acc.Enabled() {
+			return err
+		}
+		w, err := s.GetBalance(walletID)
+		if err != nil {
+			return err
+		}
+
+		day := limits.DayStart(time.Unix(acc.Since, 0))
+		accrued, ok, err := s.LastInterestAccrual(walletID)
+		if err != nil {
+			return err
+		}
+		if next := time.Unix(accrued, 0).AddDate(0, 0, 1); ok && next.After(day) {
+			day = limits.DayStart(next)
+		}
+
+		rate := acc.AnnualRate(products)
+		posted := decimal.Zero
+		for ; !day.After(last); day = day.AddDate(0, 0, 1) {
+			//the balance at the end of the day is the current one without the changes made after it,
+			//the interest posted by this accrual is already in the balance of the day
+			change, err := s.NetChangeSince(walletID, day.AddDate(0, 0, 1).Unix())
+			if err != nil {
+				return err
+			}
+			balance := w.Balance.Sub(change).Add(posted)
+
+			a := savings.Accrual{Day: day.Unix(), Balance: balance, Rate: rate, Amount: savings.DailyInterest(balance, rate, day)}
+			added, err := s.AddInterestAccrual(walletID, a)
+			if err != nil {
+				return err
+			}
+			if !added {
+				continue
+			}
+			days++
+
+			if !savings.MonthEnd(day) {
+				continue
+			}
+			c, err := capitalize(s, w, day)
+			if err != nil {
+				return err
+			}
+			if c != nil {
+				posted = posted.Add(c.Amount)
+				paid = append(paid, *c)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("billing.AccrueInterest -> %w", err)
+	}
+	if days > 0 {
+		slog.InfoContext(ctx, "interest accrued", "wallet_id", walletID, "days", days, "last_day", last.Format(time.DateOnly))
+	}
+	for _, c := range paid {
+		slog.InfoContext(ctx, "interest capitalized", "wallet_id", walletID, "month", c.Month, "amount", c.Amount.String())
+	}
+	return nil
+}
+
+// capitalize posts the accrued interest not paid yet rounded down to hundredths after the last day of the month,
+// the rest is carried to the next month. Nothing is posted if less than a hundredth is accrued
+func capitalize(s Storage, w *wallet.Wallet, monthEnd time.Time) (*savings.Capitalization, error) {
+	accrued, capitalized, err := s.InterestTotals(w.ID)
+	if err != nil {
+		return nil, err
+	}
+	amount := savings.Payout(accrued, capitalized)
+	if !amount.IsPositive() {
+		return nil, nil
+	}
+
+	c := savings.Capitalization{Month: savings.Month(monthEnd), Amount: amount, Date: time.Now().Unix()}
+	if err = w.ChangeBalance(amount, wallet.Replenishment); err != nil {
+		return nil, err
+	}
+	ch := wallet.NewChange(wallet.Replenishment, amount, "interest for "+c.Month)
+	ch.Actor = audit.System
+	if c.HistoryID, err = s.CommitChanges(w.ID, w.Balance, ch); err != nil {
+		return nil, err
+	}
+	if err = s.AddCapitalization(w.ID, c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// InterestReport returns the interest accrued for the days starting in [from, to) and posted for the months of the period
+func (b *Billing) InterestReport(ctx context.Context, id int, from, to int64) (_ savings.Report, err error) {
+	ctx, span := tracing.Start(ctx, "billing.InterestReport", attribute.Int("wallet.id", id))
+	defer tracing.End(span, &err)
+
+	tx, err := b.beginTx(ctx)
+	if err != nil {
+		return savings.Report{}, fmt.Errorf("billing.InterestReport -> %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.GetSavings(id); err != nil {
+		return savings.Report{}, err
+	}
+	accruals, err := tx.ListAccruals(id, from, to)
+	if err != nil {
+		return savings.Report{}, err
+	}
+	caps, err := tx.ListCapitalizations(id, savings.Month(time.Unix(from, 0)), savings.Month(time.Unix(to-1, 0)))
+	if err != nil {
+		return savings.Report{}, err
+	}
+	accrued, capitalized, err := tx.InterestTotals(id)
+	if err != nil {
+		return savings.Report{}, err
+	}
+	return savings.NewReport(id, from, to, accruals, caps, accrued.Sub(capitalized)), nil
+}
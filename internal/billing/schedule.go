@@ -0,0 +1,176 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/KseniiaSalmina/Balance/internal/audit"
+	"github.com/KseniiaSalmina/Balance/internal/batch"
+	"github.com/KseniiaSalmina/Balance/internal/database"
+	"github.com/KseniiaSalmina/Balance/internal/metrics"
+	"github.com/KseniiaSalmina/Balance/internal/schedule"
+	"github.com/KseniiaSalmina/Balance/internal/tracing"
+)
+
+// ScheduleOperation saves the operation to execute it at executeAt on behalf of the current client
+func (b *Billing) ScheduleOperation(ctx context.Context, item batch.Item, executeAt int64) (_ schedule.Operation, err error) {
+	ctx, span := tracing.Start(ctx, "billing.ScheduleOperation", attribute.Int("wallet.id", item.WalletID), attribute.String("operation", string(item.Type)))
+	defer tracing.End(span, &err)
+
+	op, err := schedule.New(item, executeAt, audit.Actor(ctx), time.Now().Unix())
+	if err != nil {
+		return schedule.Operation{}, err
+	}
+
+	err = b.inTx(ctx, func(s Storage) error {
+		var err error
+		op.ID, err = s.CreateScheduled(op)
+		return err
+	})
+	if err != nil {
+		return schedule.Operation{}, fmt.Errorf("billing.ScheduleOperation -> %w", err)
+	}
+	return op, nil
+}
+
+// ownScheduled returns the operation if it was scheduled by the client of ctx, the operations of the other clients are reported as not existing
+func ownScheduled(ctx context.Context, s Storage, id int64) (*schedule.Operation, error) {
+	op, err := s.GetScheduled(id)
+	if err != nil {
+		return nil, err
+	}
+	if !owns(ctx, op.Actor) {
+		return nil, database.ScheduledDoesNotExistErr
+	}
+	return op, nil
+}
+
+// ScheduledOperation returns the operation scheduled by the client of ctx
+func (b *Billing) ScheduledOperation(ctx context.Context, id int64) (_ *schedule.Operation, err error) {
+	ctx, span := tracing.Start(ctx, "billing.ScheduledOperation", attribute.Int64("scheduled.id", id))
+	defer tracing.End(span, &err)
+
+	tx, err := b.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("billing.ScheduledOperation -> %w", err)
+	}
+	defer tx.Rollback()
+
+	return ownScheduled(ctx, tx, id)
+}
+
+// ScheduledOperations returns the operations of the wallet scheduled by the client of ctx
+func (b *Billing) ScheduledOperations(ctx context.Context, walletID int, status schedule.Status, limit int) (_ []schedule.Operation, err error) {
+	ctx, span := tracing.Start(ctx, "billing.ScheduledOperations", attribute.Int("wallet.id", walletID), attribute.String("status", string(status)))
+	defer tracing.End(span, &err)
+
+	tx, err := b.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("billing.ScheduledOperations -> %w", err)
+	}
+	defer tx.Rollback()
+
+	return tx.ListScheduled(audit.Client(audit.Actor(ctx)), walletID, status, limit)
+}
+
+// CancelScheduled cancels the operation of the client of ctx that is not executed yet. The canceled occurrence of the recurring payment is skipped
+func (b *Billing) CancelScheduled(ctx context.Context, id int64) (_ *schedule.Operation, err error) {
+	ctx, span := tracing.Start(ctx, "billing.CancelScheduled", attribute.Int64("scheduled.id", id))
+	defer tracing.End(span, &err)
+
+	var op *schedule.Operation
+	err = b.inTx(ctx, func(s Storage) error {
+		if _, err := ownScheduled(ctx, s, id); err != nil {
+			return err
+		}
+
+		var err error
+		if op, err = s.CancelScheduled(id, time.Now().Unix()); err != nil || op.RecurringID == 0 {
+			return err
+		}
+		return continueRecurring(s, *op)
+	})
+	return op, err
+}
+
+// ExecuteScheduled executes the due operation on behalf of the client who scheduled it. The status of the operation
+// is saved in the transaction of the operation, so it is executed once even if several instances run the scheduler.
+// Operations rejected by the limits, the funds or the risk rules are saved as failed, the returned error keeps
+// the operation scheduled
+func (b *Billing) ExecuteScheduled(ctx context.Context, id int64) (err error) {
+	ctx, span := tracing.Start(ctx, "billing.ExecuteScheduled", attribute.Int64("scheduled.id", id))
+	defer tracing.End(span, &err)
+
+	tx, err := b.beginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("billing.ExecuteScheduled -> %w", err)
+	}
+	defer tx.Rollback()
+
+	op, err := tx.LockScheduled(id)
+	if err != nil || op == nil {
+		return err
+	}
+	ctx = audit.WithActor(ctx, op.Actor)
+
+	riskOp := op.Operation()
+	riskOp.Time = time.Now()
+	op.ReviewID, err = b.evaluate(ctx, tx, riskOp, op.Description)
+	if err == nil && op.ReviewID == 0 {
+		err = b.execute(ctx, tx, riskOp, op.Description)
+	}
+	if err != nil {
+		msg, ok := itemError(err)
+		if !ok {
+			return fmt.Errorf("billing.ExecuteScheduled -> %w", err)
+		}
+		tx.Rollback()
+		return b.failScheduled(ctx, *op, msg)
+	}
+
+	op.Status, op.Updated = schedule.Completed, time.Now().Unix()
+	if op.ReviewID != 0 {
+		op.Status = schedule.PendingReview
+	}
+	if err = finishScheduled(tx, *op); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("billing.ExecuteScheduled -> %w", err)
+	}
+	if op.Status == schedule.Completed {
+		metrics.Operation(string(op.Type), op.Amount)
+	}
+	slog.InfoContext(ctx, "scheduled operation executed", "scheduled_id", op.ID, "status", op.Status)
+	return nil
+}
+
+// failScheduled saves the operation as failed if it is still scheduled, the changes of the operation are rolled back before
+func (b *Billing) failScheduled(ctx context.Context, op schedule.Operation, reason string) error {
+	err := b.inTx(ctx, func(s Storage) error {
+		locked, err := s.LockScheduled(op.ID)
+		if err != nil || locked == nil {
+			return err
+		}
+
+		locked.Status, locked.Error, locked.Updated = schedule.Failed, reason, time.Now().Unix()
+		return finishScheduled(s, *locked)
+	})
+	if err != nil {
+		return fmt.Errorf("failScheduled -> %w", err)
+	}
+	slog.InfoContext(ctx, "scheduled operation failed", "scheduled_id", op.ID, "error", reason)
+	return nil
+}
+
+// finishScheduled saves the result of the operation, the recurring payment continues in the same transaction
+func finishScheduled(s Storage, op schedule.Operation) error {
+	if err := s.FinishScheduled(op); err != nil || op.RecurringID == 0 {
+		return err
+	}
+	return continueRecurring(s, op)
+}
@@ -0,0 +1,227 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/KseniiaSalmina/Balance/internal/audit"
+	"github.com/KseniiaSalmina/Balance/internal/database"
+	"github.com/KseniiaSalmina/Balance/internal/metrics"
+	"github.com/KseniiaSalmina/Balance/internal/risk"
+	"github.com/KseniiaSalmina/Balance/internal/subscription"
+	"github.com/KseniiaSalmina/Balance/internal/tracing"
+)
+
+func (b *Billing) CreatePlan(ctx context.Context, p subscription.Plan) (_ subscription.Plan, err error) {
+	ctx, span := tracing.Start(ctx, "billing.CreatePlan")
+	defer tracing.End(span, &err)
+
+	p, err = subscription.NewPlan(p, time.Now().Unix())
+	if err != nil {
+		return subscription.Plan{}, err
+	}
+
+	err = b.inTx(ctx, func(s Storage) error {
+		var err error
+		p.ID, err = s.CreatePlan(p)
+		return err
+	})
+	if err != nil {
+		return subscription.Plan{}, fmt.Errorf("billing.CreatePlan -> %w", err)
+	}
+	return p, nil
+}
+
+func (b *Billing) Plan(ctx context.Context, id int64) (_ *subscription.Plan, err error) {
+	ctx, span := tracing.Start(ctx, "billing.Plan", attribute.Int64("plan.id", id))
+	defer tracing.End(span, &err)
+
+	tx, err := b.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("billing.Plan -> %w", err)
+	}
+	defer tx.Rollback()
+
+	return tx.GetPlan(id)
+}
+
+func (b *Billing) Plans(ctx context.Context, limit int) (_ []subscription.Plan, err error) {
+	ctx, span := tracing.Start(ctx, "billing.Plans")
+	defer tracing.End(span, &err)
+
+	tx, err := b.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("billing.Plans -> %w", err)
+	}
+	defer tx.Rollback()
+
+	return tx.ListPlans(limit)
+}
+
+// CreateSubscription subscribes the wallet to the plan on behalf of the current client, the first period is charged at startAt
+func (b *Billing) CreateSubscription(ctx context.Context, planID int64, walletID int, startAt int64) (_ subscription.Subscription, err error) {
+	ctx, span := tracing.Start(ctx, "billing.CreateSubscription", attribute.Int64("plan.id", planID), attribute.Int("wallet.id", walletID))
+	defer tracing.End(span, &err)
+
+	var sub subscription.Subscription
+	err = b.inTx(ctx, func(s Storage) error {
+		plan, err := s.GetPlan(planID)
+		if err != nil {
+			return err
+		}
+
+		if sub, err = subscription.New(*plan, walletID, startAt, audit.Actor(ctx), time.Now().Unix()); err != nil {
+			return err
+		}
+		sub.ID, err = s.CreateSubscription(sub)
+		return err
+	})
+	if err != nil {
+		return subscription.Subscription{}, fmt.Errorf("billing.CreateSubscription -> %w", err)
+	}
+	return sub, nil
+}
+
+// ownSubscription returns the subscription if it was created by the client of ctx, the subscriptions of the other clients are reported as not existing
+func ownSubscription(ctx context.Context, s Storage, id int64) (*subscription.Subscription, error) {
+	sub, err := s.GetSubscription(id)
+	if err != nil {
+		return nil, err
+	}
+	if !owns(ctx, sub.Actor) {
+		return nil, database.SubscriptionDoesNotExistErr
+	}
+	return sub, nil
+}
+
+// Subscription returns the subscription created by the client of ctx
+func (b *Billing) Subscription(ctx context.Context, id int64) (_ *subscription.Subscription, err error) {
+	ctx, span := tracing.Start(ctx, "billing.Subscription", attribute.Int64("subscription.id", id))
+	defer tracing.End(span, &err)
+
+	tx, err := b.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("billing.Subscription -> %w", err)
+	}
+	defer tx.Rollback()
+
+	return ownSubscription(ctx, tx, id)
+}
+
+// Subscriptions returns the subscriptions of the wallet created by the client of ctx
+func (b *Billing) Subscriptions(ctx context.Context, walletID int, status subscription.Status, limit int) (_ []subscription.Subscription, err error) {
+	ctx, span := tracing.Start(ctx, "billing.Subscriptions", attribute.Int("wallet.id", walletID), attribute.String("status", string(status)))
+	defer tracing.End(span, &err)
+
+	tx, err := b.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("billing.Subscriptions -> %w", err)
+	}
+	defer tx.Rollback()
+
+	return tx.ListSubscriptions(audit.Client(audit.Actor(ctx)), walletID, status, limit)
+}
+
+// CancelSubscription stops charging the subscription of the client of ctx, the paid period is kept
+func (b *Billing) CancelSubscription(ctx context.Context, id int64) (_ *subscription.Subscription, err error) {
+	ctx, span := tracing.Start(ctx, "billing.CancelSubscription", attribute.Int64("subscription.id", id))
+	defer tracing.End(span, &err)
+
+	var sub *subscription.Subscription
+	err = b.inTx(ctx, func(s Storage) error {
+		if _, err := ownSubscription(ctx, s, id); err != nil {
+			return err
+		}
+
+		var err error
+		sub, err = s.CancelSubscription(id, time.Now().Unix())
+		return err
+	})
+	return sub, err
+}
+
+// ChargeSubscription withdraws the price of the plan for the due period on behalf of the client who subscribed,
+// the result is saved in the transaction of the withdrawal. The charge is checked by the risk rules as any withdrawal:
+// the denied charge fails, and the charge held for review fails too instead of waiting for the review, no review is saved.
+// A failed charge makes the subscription past due, it is retried by the retry schedule of the plan until the end
+// of the grace period, then the subscription is suspended. The returned error keeps the charge due
+func (b *Billing) ChargeSubscription(ctx context.Context, id int64) (err error) {
+	ctx, span := tracing.Start(ctx, "billing.ChargeSubscription", attribute.Int64("subscription.id", id))
+	defer tracing.End(span, &err)
+
+	tx, err := b.beginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("billing.ChargeSubscription -> %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	sub, err := tx.LockSubscription(id)
+	if err != nil || sub == nil || !sub.Chargeable(now.Unix()) {
+		return err
+	}
+	plan, err := tx.GetPlan(sub.PlanID)
+	if err != nil {
+		return err
+	}
+	ctx = audit.WithActor(ctx, sub.Actor)
+
+	op := risk.Operation{Kind: risk.KindWithdrawal, WalletID: sub.WalletID, Amount: plan.Price, Time: now}
+	desc := fmt.Sprintf("subscription %d: %s", sub.ID, plan.Name)
+	reviewID, err := b.evaluate(ctx, tx, op, desc)
+	if err == nil && reviewID != 0 {
+		//the period can not stay unpaid until the review, so the held charge fails and is retried as usual,
+		//the review is rolled back with the transaction
+		tx.Rollback()
+		return b.failCharge(ctx, id, *plan, "charge requires manual review")
+	}
+	if err == nil {
+		err = b.execute(ctx, tx, op, desc)
+	}
+	if err != nil {
+		msg, ok := itemError(err)
+		if !ok {
+			return fmt.Errorf("billing.ChargeSubscription -> %w", err)
+		}
+		tx.Rollback()
+		return b.failCharge(ctx, id, *plan, msg)
+	}
+
+	sub.Charged(*plan, now.Unix())
+	if err = tx.SaveCharge(*sub); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("billing.ChargeSubscription -> %w", err)
+	}
+	metrics.Operation(string(op.Kind), op.Amount)
+	slog.InfoContext(ctx, "subscription charged", "subscription_id", sub.ID, "period_end", sub.PeriodEnd)
+	return nil
+}
+
+// failCharge saves the failed attempt if the subscription is still due, the withdrawal is rolled back before
+func (b *Billing) failCharge(ctx context.Context, id int64, plan subscription.Plan, reason string) error {
+	var sub *subscription.Subscription
+	err := b.inTx(ctx, func(s Storage) error {
+		var err error
+		now := time.Now().Unix()
+		if sub, err = s.LockSubscription(id); err != nil || sub == nil || !sub.Chargeable(now) {
+			sub = nil
+			return err
+		}
+
+		sub.Failed(plan, reason, now)
+		return s.SaveCharge(*sub)
+	})
+	if err != nil {
+		return fmt.Errorf("failCharge -> %w", err)
+	}
+	if sub != nil {
+		slog.InfoContext(ctx, "subscription charge failed", "subscription_id", id, "status", sub.Status, "attempts", sub.Attempts, "error", reason)
+	}
+	return nil
+}
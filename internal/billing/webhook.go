@@ -0,0 +1,112 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/KseniiaSalmina/Balance/internal/tracing"
+	"github.com/KseniiaSalmina/Balance/internal/webhook"
+)
+
+// CreateWebhook subscribes the URL to the events, the returned webhook contains the secret of the signatures
+func (b *Billing) CreateWebhook(ctx context.Context, url string) (_ webhook.Webhook, err error) {
+	ctx, span := tracing.Start(ctx, "billing.CreateWebhook")
+	defer tracing.End(span, &err)
+
+	w, err := webhook.NewWebhook(url, time.Now().Unix())
+	if err != nil {
+		return webhook.Webhook{}, err
+	}
+
+	tx, err := b.beginTx(ctx)
+	if err != nil {
+		return webhook.Webhook{}, fmt.Errorf("billing.CreateWebhook -> %w", err)
+	}
+	defer tx.Rollback()
+
+	if w.ID, err = tx.CreateWebhook(w); err != nil {
+		return webhook.Webhook{}, err
+	}
+	if err = tx.Commit(); err != nil {
+		return webhook.Webhook{}, fmt.Errorf("billing.CreateWebhook -> %w", err)
+	}
+	return w, nil
+}
+
+func (b *Billing) Webhooks(ctx context.Context) (_ []webhook.Webhook, err error) {
+	ctx, span := tracing.Start(ctx, "billing.Webhooks")
+	defer tracing.End(span, &err)
+
+	tx, err := b.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("billing.Webhooks -> %w", err)
+	}
+	defer tx.Rollback()
+
+	return tx.ListWebhooks()
+}
+
+// DeleteWebhook unsubscribes the webhook, its deliveries are not sent anymore
+func (b *Billing) DeleteWebhook(ctx context.Context, id int64) (err error) {
+	ctx, span := tracing.Start(ctx, "billing.DeleteWebhook", attribute.Int64("webhook.id", id))
+	defer tracing.End(span, &err)
+
+	return b.inTx(ctx, func(s Storage) error {
+		return s.DeleteWebhook(id)
+	})
+}
+
+func (b *Billing) Deliveries(ctx context.Context, webhookID int64, status webhook.Status, limit int) (_ []webhook.Delivery, err error) {
+	ctx, span := tracing.Start(ctx, "billing.Deliveries", attribute.Int64("webhook.id", webhookID), attribute.String("status", string(status)))
+	defer tracing.End(span, &err)
+
+	tx, err := b.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("billing.Deliveries -> %w", err)
+	}
+	defer tx.Rollback()
+
+	return tx.ListDeliveries(webhookID, status, limit)
+}
+
+// ReplayDelivery sends the delivery again whatever its status is
+func (b *Billing) ReplayDelivery(ctx context.Context, id int64) (err error) {
+	ctx, span := tracing.Start(ctx, "billing.ReplayDelivery", attribute.Int64("delivery.id", id))
+	defer tracing.End(span, &err)
+
+	return b.inTx(ctx, func(s Storage) error {
+		return s.ReplayDelivery(id, time.Now().Unix())
+	})
+}
+
+// ReplayDeadDeliveries sends again all the dead letters of the webhook, returns their number
+func (b *Billing) ReplayDeadDeliveries(ctx context.Context, webhookID int64) (n int64, err error) {
+	ctx, span := tracing.Start(ctx, "billing.ReplayDeadDeliveries", attribute.Int64("webhook.id", webhookID))
+	defer tracing.End(span, &err)
+
+	err = b.inTx(ctx, func(s Storage) error {
+		n, err = s.ReplayDeadDeliveries(webhookID, time.Now().Unix())
+		return err
+	})
+	return n, err
+}
+
+// inTx runs fn in a transaction and commits it if fn succeeds
+func (b *Billing) inTx(ctx context.Context, fn func(s Storage) error) error {
+	tx, err := b.beginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("inTx -> %w", err)
+	}
+	defer tx.Rollback()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("inTx -> %w", err)
+	}
+	return nil
+}
@@ -1,6 +1,17 @@
 package config
 
 type Application struct {
-	Postgres Postgres
-	Server   Server
+	Postgres  Postgres
+	Server    Server
+	GRPC      GRPC
+	Limits    Limits
+	Risk      Risk
+	Fees      Fees
+	Savings   Savings
+	Webhooks  Webhooks
+	Jobs      Jobs
+	Scheduler Scheduler
+	Events    Events
+	Tracing   Tracing
+	Log       Log
 }
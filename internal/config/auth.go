@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type Auth struct {
+	APIKeys   APIKeys `env:"API_KEYS" secret:"true"`       //empty list disables authentication
+	AdminKeys APIKeys `env:"ADMIN_API_KEYS" secret:"true"` //keys of the administrators, empty list disables the admin endpoints
+}
+
+// APIKeys maps an API key to the client name, set as "key1:client1,key2:client2"
+type APIKeys map[string]string
+
+func (k *APIKeys) UnmarshalText(text []byte) error {
+	keys := make(APIKeys)
+	for _, pair := range strings.Split(string(text), ",") {
+		if pair == "" {
+			continue
+		}
+		key, client, ok := strings.Cut(pair, ":")
+		if !ok || key == "" || client == "" {
+			return fmt.Errorf("invalid API key %q: expected key:client", pair)
+		}
+		keys[key] = client
+	}
+	*k = keys
+	return nil
+}
+
+func (k APIKeys) MarshalText() ([]byte, error) {
+	pairs := make([]string, 0, len(k))
+	for key, client := range k {
+		pairs = append(pairs, key+":"+client)
+	}
+	sort.Strings(pairs)
+	return []byte(strings.Join(pairs, ",")), nil
+}
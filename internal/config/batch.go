@@ -0,0 +1,6 @@
+package config
+
+type Batch struct {
+	MaxSize      int `env:"BATCH_MAX_SIZE" envDefault:"1000"`        //max operations of a batch executed within the request
+	MaxAsyncSize int `env:"BATCH_MAX_ASYNC_SIZE" envDefault:"10000"` //max operations of a batch executed in the background
+}
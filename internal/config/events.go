@@ -0,0 +1,11 @@
+package config
+
+import "time"
+
+type Events struct {
+	Publisher string        `env:"EVENTS_PUBLISHER" envDefault:"none"`  //none, file or http
+	Source    string        `env:"EVENTS_SOURCE" envDefault:"/balance"` //CloudEvents source of the events
+	File      string        `env:"EVENTS_FILE"`                         //file the events are appended to by the file publisher
+	URL       string        `env:"EVENTS_URL"`                          //broker endpoint of the http publisher
+	Timeout   time.Duration `env:"EVENTS_TIMEOUT" envDefault:"5s"`      //time to wait for the response of the broker
+}
@@ -0,0 +1,5 @@
+package config
+
+type Fees struct {
+	File string `env:"FEES_FILE"` //YAML file with the fee schedule, empty value disables the fees
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// field is a configuration value read from an environment variable.
+// In the config file it is set by the snake_case path of the struct fields, e.g. server.rate_limit.client_rate
+type field struct {
+	env    string
+	path   string
+	secret bool //marked with secret:"true", can be read from the file named by <env>_FILE and is redacted on print
+	value  reflect.Value
+}
+
+// fields returns the configuration values of v, which must be a pointer to a struct
+func fields(v interface{}) []field {
+	return appendFields(nil, reflect.ValueOf(v).Elem(), "")
+}
+
+func appendFields(list []field, v reflect.Value, prefix string) []field {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		path := prefix + snakeCase(sf.Name)
+
+		env, _, _ := strings.Cut(sf.Tag.Get("env"), ",")
+		if env == "" {
+			if sf.Type.Kind() == reflect.Struct {
+				list = appendFields(list, v.Field(i), path+".")
+			}
+			continue
+		}
+
+		list = append(list, field{
+			env:    env,
+			path:   path,
+			secret: sf.Tag.Get("secret") == "true",
+			value:  v.Field(i),
+		})
+	}
+	return list
+}
+
+// String returns the value as it would be set in the environment
+func (f field) String() string {
+	if m, ok := f.value.Interface().(encoding.TextMarshaler); ok {
+		text, err := m.MarshalText()
+		if err != nil {
+			return err.Error()
+		}
+		return string(text)
+	}
+	return fmt.Sprint(f.value.Interface())
+}
+
+// snakeCase converts a Go field name to snake case, keeping acronyms together: OTLPEndpoint -> otlp_endpoint
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prevLower := !unicode.IsUpper(runes[i-1])
+			acronymEnd := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || acronymEnd {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
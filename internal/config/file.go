@@ -0,0 +1,94 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// readConfigFile reads a YAML or TOML config file and returns its values by environment variable names.
+// Unknown keys are reported together with the known values, so a typo does not silently leave the default value
+func readConfigFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("readConfigFile -> %w", err)
+	}
+
+	tree := make(map[string]interface{})
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".toml":
+		err = toml.Unmarshal(data, &tree)
+	default:
+		return nil, fmt.Errorf("unsupported config file format %q, use .yaml, .yml or .toml", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("readConfigFile -> %s: %w", path, err)
+	}
+
+	known := make(map[string]field)
+	for _, f := range fields(&Application{}) {
+		known[f.path] = f
+	}
+
+	vars := make(map[string]string)
+	var errs []error
+	flatten(tree, "", known, vars, &errs)
+	if len(errs) != 0 {
+		return vars, fmt.Errorf("%s: %w", path, errors.Join(errs...))
+	}
+	return vars, nil
+}
+
+func flatten(tree map[string]interface{}, prefix string, known map[string]field, vars map[string]string, errs *[]error) {
+	keys := make([]string, 0, len(tree))
+	for k := range tree {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		path := prefix + k
+		value := tree[k]
+
+		if f, ok := known[path]; ok {
+			vars[f.env] = fileValue(value)
+			continue
+		}
+		if sub, ok := value.(map[string]interface{}); ok {
+			flatten(sub, path+".", known, vars, errs)
+			continue
+		}
+		*errs = append(*errs, fmt.Errorf("unknown key %q", path))
+	}
+}
+
+// fileValue converts a value of the file to the environment variable form: lists are joined by commas, maps are written as key:value pairs
+func fileValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []interface{}:
+		items := make([]string, len(v))
+		for i := range v {
+			items[i] = fileValue(v[i])
+		}
+		return strings.Join(items, ",")
+	case map[string]interface{}:
+		pairs := make([]string, 0, len(v))
+		for k, item := range v {
+			pairs = append(pairs, k+":"+fileValue(item))
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,5 @@
+package config
+
+type GRPC struct {
+	Listen string `env:"GRPC_LISTEN"` //address of the gRPC server, e.g. :9090, empty value disables it
+}
@@ -0,0 +1,11 @@
+package config
+
+import "time"
+
+type Jobs struct {
+	Workers      int           `env:"JOBS_WORKERS" envDefault:"4"`        //number of jobs run at once by the instance
+	PollInterval time.Duration `env:"JOBS_POLL_INTERVAL" envDefault:"1s"` //how often the queue is checked for due jobs
+	Lease        time.Duration `env:"JOBS_LEASE" envDefault:"30s"`        //running job is taken by another instance if its lease is not extended within this time
+	RetryMin     time.Duration `env:"JOBS_RETRY_MIN" envDefault:"10s"`    //delay before the first retry, doubled with every attempt
+	RetryMax     time.Duration `env:"JOBS_RETRY_MAX" envDefault:"10m"`
+}
@@ -0,0 +1,11 @@
+package config
+
+import "github.com/shopspring/decimal"
+
+// Limits are global transaction limits, they can be overridden for a single wallet. Empty value means no limit
+type Limits struct {
+	MaxWithdrawal     decimal.NullDecimal `env:"LIMIT_MAX_WITHDRAWAL"`
+	MaxTransfer       decimal.NullDecimal `env:"LIMIT_MAX_TRANSFER"`
+	DailyWithdrawal   decimal.NullDecimal `env:"LIMIT_DAILY_WITHDRAWAL"`
+	MonthlyWithdrawal decimal.NullDecimal `env:"LIMIT_MONTHLY_WITHDRAWAL"`
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/caarlos0/env/v6"
+	"github.com/joho/godotenv"
+)
+
+// Loader reads the application configuration, it is used on start and on reload.
+// Values are taken from, in increasing priority: defaults, the config file, the env file, the environment
+type Loader struct {
+	EnvFile    string //variables missing in the environment are taken from this file if it exists
+	ConfigFile string //optional YAML or TOML file
+}
+
+// Load reads and validates the configuration, all the problems are reported at once.
+// The process environment is not changed, so changes in the files are seen by the next Load
+func (l Loader) Load() (Application, error) {
+	cfg, err := l.Parse()
+	if err = errors.Join(err, cfg.Validate()); err != nil {
+		return Application{}, err
+	}
+	return cfg, nil
+}
+
+// Parse reads the configuration without validation. Unreadable sources and variables that cannot be parsed
+// are reported, the returned configuration is built from the rest
+func (l Loader) Parse() (Application, error) {
+	vars, sourcesErr := l.variables()
+
+	var cfg Application
+	err := env.Parse(&cfg, env.Options{Environment: vars})
+	if err == nil {
+		return cfg, sourcesErr
+	}
+
+	//env.Parse stops on the first error, so every variable is parsed separately to find all of them
+	errs := []error{sourcesErr}
+	for _, f := range fields(&Application{}) {
+		value, ok := vars[f.env]
+		if !ok {
+			continue
+		}
+
+		if fieldErr := env.Parse(&Application{}, env.Options{Environment: map[string]string{f.env: value}}); fieldErr != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.env, fieldErr))
+			delete(vars, f.env)
+		}
+	}
+
+	cfg = Application{}
+	if retryErr := env.Parse(&cfg, env.Options{Environment: vars}); retryErr != nil {
+		return Application{}, fmt.Errorf("Parse -> %w", err)
+	}
+	return cfg, errors.Join(errs...)
+}
+
+// variables merges the variables of all the sources. The errors of the sources are returned together with the variables read
+func (l Loader) variables() (map[string]string, error) {
+	vars := make(map[string]string)
+	var errs []error
+
+	if l.ConfigFile != "" {
+		fileVars, err := readConfigFile(l.ConfigFile)
+		errs = append(errs, err)
+		for k, v := range fileVars {
+			vars[k] = v
+		}
+	}
+
+	envVars, err := godotenv.Read(l.EnvFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		errs = append(errs, fmt.Errorf("variables -> %w", err))
+	}
+	for k, v := range envVars {
+		vars[k] = v
+	}
+
+	for _, kv := range os.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		vars[k] = v
+	}
+
+	errs = append(errs, readSecretFiles(vars))
+	return vars, errors.Join(errs...)
+}
+
+// readSecretFiles sets the secret variables from the files named by <name>_FILE, e.g. PG_PASSWORD_FILE
+func readSecretFiles(vars map[string]string) error {
+	var errs []error
+	for _, f := range fields(&Application{}) {
+		path := vars[f.env+"_FILE"]
+		if !f.secret || path == "" {
+			continue
+		}
+		if vars[f.env] != "" {
+			errs = append(errs, fmt.Errorf("%s, %s_FILE: only one of them can be set", f.env, f.env))
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s_FILE: %w", f.env, err))
+			continue
+		}
+		vars[f.env] = strings.TrimRight(string(data), "\r\n")
+	}
+	return errors.Join(errs...)
+}
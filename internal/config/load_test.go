@@ -0,0 +1,218 @@
+package config
+
+import (
+	"bytes"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoader_Load(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), ".env")
+	assert.NoError(t, os.WriteFile(envFile, []byte("LOG_LEVEL=debug\nLIMIT_MAX_WITHDRAWAL=100\nPG_HOST=db\n"), 0o600))
+	t.Setenv("PG_HOST", "postgres")
+
+	cfg, err := Loader{EnvFile: envFile}.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, slog.LevelDebug, cfg.Log.Level)
+	assert.Equal(t, "100", cfg.Limits.MaxWithdrawal.Decimal.String())
+	assert.Equal(t, "postgres", cfg.Postgres.Host, "environment must override the env file")
+
+	assert.NoError(t, os.WriteFile(envFile, []byte("LOG_LEVEL=warn\n"), 0o600))
+	cfg, err = Loader{EnvFile: envFile}.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, slog.LevelWarn, cfg.Log.Level, "changes in the env file must be seen on reload")
+	assert.False(t, cfg.Limits.MaxWithdrawal.Valid)
+
+	_, err = Loader{EnvFile: filepath.Join(t.TempDir(), "missing.env")}.Load()
+	assert.NoError(t, err)
+}
+
+func TestApplication_Validate(t *testing.T) {
+	valid, err := Loader{}.Load()
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		change  func(cfg *Application)
+		wantErr []string
+	}{
+		{name: "defaults", change: func(cfg *Application) {}},
+		{name: "unknown log format", change: func(cfg *Application) { cfg.Log.Format = "xml" }, wantErr: []string{"LOG_FORMAT"}},
+		{name: "file publisher without file", change: func(cfg *Application) { cfg.Events.Publisher = "file" }, wantErr: []string{"EVENTS_FILE"}},
+		{name: "async batches are smaller", change: func(cfg *Application) { cfg.Server.Batch.MaxAsyncSize = 10 }, wantErr: []string{"BATCH_MAX_ASYNC_SIZE"}},
+		{name: "no scheduled operations", change: func(cfg *Application) { cfg.Scheduler.BatchSize = 0 }, wantErr: []string{"SCHEDULER_BATCH_SIZE"}},
+		{name: "no retry interval", change: func(cfg *Application) { cfg.Scheduler.RetryInterval = 0 }, wantErr: []string{"SCHEDULER_RETRY_INTERVAL"}},
+		{name: "no job workers", change: func(cfg *Application) { cfg.Jobs.Workers = 0 }, wantErr: []string{"JOBS_WORKERS"}},
+		{name: "webhook retries", change: func(cfg *Application) { cfg.Webhooks.RetryMax = time.Second }, wantErr: []string{"WEBHOOK_RETRY_MAX"}},
+		{
+			name: "all errors are reported",
+			change: func(cfg *Application) {
+				cfg.Server.RateLimit.Backend = "redis"
+				cfg.Limits.DailyWithdrawal = decimal.NewNullDecimal(decimal.NewFromInt(-1))
+				cfg.Tracing.SampleRatio = 2
+			},
+			wantErr: []string{"RATE_LIMIT_BACKEND", "LIMIT_DAILY_WITHDRAWAL", "TRACING_SAMPLE_RATIO"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid
+			tt.change(&cfg)
+
+			err := cfg.Validate()
+			if len(tt.wantErr) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			for _, want := range tt.wantErr {
+				assert.ErrorContains(t, err, want)
+			}
+		})
+	}
+}
+
+func TestLoader_ConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	yamlFile := filepath.Join(dir, "config.yaml")
+	assert.NoError(t, os.WriteFile(yamlFile, []byte(`
+server:
+  listen: ":9000"
+  read_timeout: 10s
+  auth:
+    api_keys:
+      key1: billing
+  rate_limit:
+    client_rate: 2.5
+postgres:
+  host: db
+tracing:
+  otlp_endpoint: http://collector:4318/v1/traces
+`), 0o600))
+	tomlFile := filepath.Join(dir, "config.toml")
+	assert.NoError(t, os.WriteFile(tomlFile, []byte(`
+[server]
+listen = ":9001"
+[server.auth]
+api_keys = "key1:billing"
+[postgres]
+host = "db"
+`), 0o600))
+	t.Setenv("PG_HOST", "postgres")
+
+	for _, file := range []string{yamlFile, tomlFile} {
+		t.Run(filepath.Ext(file), func(t *testing.T) {
+			cfg, err := Loader{ConfigFile: file}.Load()
+			assert.NoError(t, err)
+			assert.Contains(t, []string{":9000", ":9001"}, cfg.Server.Listen)
+			assert.Equal(t, APIKeys{"key1": "billing"}, cfg.Server.Auth.APIKeys)
+			assert.Equal(t, "postgres", cfg.Postgres.Host, "environment must override the config file")
+		})
+	}
+
+	cfg, err := Loader{ConfigFile: yamlFile}.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, 10*time.Second, cfg.Server.ReadTimeout)
+	assert.Equal(t, 2.5, cfg.Server.RateLimit.ClientRate)
+	assert.Equal(t, "http://collector:4318/v1/traces", cfg.Tracing.OTLPEndpoint)
+
+	unknown := filepath.Join(dir, "unknown.yaml")
+	assert.NoError(t, os.WriteFile(unknown, []byte("server:\n  listen: \":9000\"\n  lisen: \":9001\"\n"), 0o600))
+	_, err = Loader{ConfigFile: unknown}.Load()
+	assert.ErrorContains(t, err, `unknown key "server.lisen"`)
+
+	_, err = Loader{ConfigFile: filepath.Join(dir, "config.ini")}.Load()
+	assert.Error(t, err)
+}
+
+func TestLoader_SecretFiles(t *testing.T) {
+	password := filepath.Join(t.TempDir(), "password")
+	assert.NoError(t, os.WriteFile(password, []byte("s3cret\n"), 0o600))
+	t.Setenv("PG_PASSWORD_FILE", password)
+
+	cfg, err := Loader{}.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cret", cfg.Postgres.Password)
+
+	t.Setenv("PG_PASSWORD", "other")
+	_, err = Loader{}.Load()
+	assert.ErrorContains(t, err, "only one of them can be set")
+}
+
+func TestLoader_AllErrors(t *testing.T) {
+	t.Setenv("PG_PORT", "abc")
+	t.Setenv("SERVER_READ_TIMEOUT", "5 seconds")
+	t.Setenv("LOG_FORMAT", "xml")
+	t.Setenv("DATABASE_URL", "postgres://user:pass@%zz")
+
+	_, err := Loader{}.Load()
+	for _, want := range []string{"PG_PORT", "SERVER_READ_TIMEOUT", "LOG_FORMAT", "DATABASE_URL"} {
+		assert.ErrorContains(t, err, want)
+	}
+	assert.NotContains(t, err.Error(), "pass")
+}
+
+func TestPostgres_ParseURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		wantHost string
+		wantErr  string
+	}{
+		{name: "url", url: "postgres://user:pass@db:5432/balance", wantHost: "db"},
+		{name: "dsn", url: "host=db user=user password=pass", wantHost: "db"},
+		{name: "bad port", url: "postgres://user:pass@db:bad/balance", wantErr: "invalid port"},
+		{name: "bad escape", url: "postgresql://user:pass@%zz", wantErr: "invalid URL escape"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := Postgres{URL: tt.url}.ParseURL()
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.NotContains(t, err.Error(), "pass")
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantHost, conn.Host)
+		})
+	}
+}
+
+func TestApplication_Print(t *testing.T) {
+	t.Setenv("PG_PASSWORD", "s3cret")
+	t.Setenv("API_KEYS", "key1:billing")
+	t.Setenv("LIMIT_MAX_WITHDRAWAL", "100")
+
+	cfg, err := Loader{}.Load()
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, cfg.Print(&buf))
+	out := buf.String()
+	assert.Contains(t, out, "PG_PASSWORD=[REDACTED]\n")
+	assert.Contains(t, out, "API_KEYS=[REDACTED]\n")
+	assert.Contains(t, out, "DATABASE_URL=\n")
+	assert.Contains(t, out, "LIMIT_MAX_WITHDRAWAL=100\n")
+	assert.Contains(t, out, "SERVER_READ_TIMEOUT=5s\n")
+	assert.NotContains(t, out, "s3cret")
+	assert.NotContains(t, out, "key1")
+}
+
+func TestSnakeCase(t *testing.T) {
+	for name, want := range map[string]string{
+		"Listen":         "listen",
+		"RateLimit":      "rate_limit",
+		"OTLPEndpoint":   "otlp_endpoint",
+		"APIKeys":        "api_keys",
+		"URL":            "url",
+		"MaxConnections": "max_connections",
+	} {
+		assert.Equal(t, want, snakeCase(name))
+	}
+}
@@ -0,0 +1,8 @@
+package config
+
+import "log/slog"
+
+type Log struct {
+	Level  slog.Level `env:"LOG_LEVEL" envDefault:"info"`  //debug, info, warn or error
+	Format string     `env:"LOG_FORMAT" envDefault:"text"` //text or json
+}
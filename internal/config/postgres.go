@@ -1,9 +1,41 @@
 package config
 
+import (
+	"errors"
+	"github.com/jackc/pgx"
+	"net/url"
+	"strings"
+	"time"
+)
+
 type Postgres struct {
+	URL      string `env:"DATABASE_URL" secret:"true"` //connection string, if set it is used instead of the PG_ connection variables
 	User     string `env:"PG_USER"`
-	Password string `env:"PG_PASSWORD"`
+	Password string `env:"PG_PASSWORD" secret:"true"`
 	Host     string `env:"PG_HOST" envDefault:"localhost"`
 	Port     int    `env:"PG_PORT" envDefault:"5432"`
 	Database string `env:"PG_DATABASE"`
+
+	SSLMode     string `env:"PG_SSLMODE" envDefault:"prefer"` //disable, allow, prefer, require, verify-ca or verify-full, as in libpq
+	SSLRootCert string `env:"PG_SSLROOTCERT"`                 //CA certificate to verify the server with verify-ca and verify-full
+	SSLCert     string `env:"PG_SSLCERT"`                     //client certificate
+	SSLKey      string `env:"PG_SSLKEY"`
+
+	MaxConnections int           `env:"PG_MAX_CONNECTIONS" envDefault:"10"`
+	AcquireTimeout time.Duration `env:"PG_ACQUIRE_TIMEOUT" envDefault:"5s"`
+}
+
+// ParseURL parses the connection string. URLs are parsed as URLs only, so a malformed URL is reported with its own
+// error instead of being parsed again as key=value pairs. The error does not contain the string as it has the password
+func (p Postgres) ParseURL() (pgx.ConnConfig, error) {
+	if !strings.HasPrefix(p.URL, "postgres://") && !strings.HasPrefix(p.URL, "postgresql://") {
+		return pgx.ParseDSN(p.URL)
+	}
+
+	conn, err := pgx.ParseURI(p.URL)
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return pgx.ConnConfig{}, urlErr.Err
+	}
+	return conn, err
 }
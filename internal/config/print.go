@@ -0,0 +1,22 @@
+package config
+
+import (
+	"fmt"
+	"io"
+)
+
+const redacted = "[REDACTED]"
+
+// Print writes the effective configuration in the form of environment variables, secrets are redacted
+func (a Application) Print(w io.Writer) error {
+	for _, f := range fields(&a) {
+		value := f.String()
+		if f.secret && value != "" {
+			value = redacted
+		}
+		if _, err := fmt.Fprintf(w, "%s=%s\n", f.env, value); err != nil {
+			return fmt.Errorf("Print -> %w", err)
+		}
+	}
+	return nil
+}
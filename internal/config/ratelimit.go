@@ -0,0 +1,11 @@
+package config
+
+type RateLimit struct {
+	Backend      string  `env:"RATE_LIMIT_BACKEND" envDefault:"memory"` //memory or postgres, use postgres to share limits between instances
+	ClientRate   float64 `env:"RATE_LIMIT_CLIENT_RATE" envDefault:"0"`  //requests per second for an API client, 0 disables the limit
+	ClientBurst  int     `env:"RATE_LIMIT_CLIENT_BURST" envDefault:"20"`
+	WalletRate   float64 `env:"RATE_LIMIT_WALLET_RATE" envDefault:"0"` //requests per second for a wallet, 0 disables the limit
+	WalletBurst  int     `env:"RATE_LIMIT_WALLET_BURST" envDefault:"10"`
+	AddressRate  float64 `env:"RATE_LIMIT_ADDRESS_RATE" envDefault:"1"` //requests per second from an IP address without a valid API key, 0 disables the limit
+	AddressBurst int     `env:"RATE_LIMIT_ADDRESS_BURST" envDefault:"10"`
+}
@@ -0,0 +1,5 @@
+package config
+
+type Risk struct {
+	RulesFile string `env:"RISK_RULES_FILE"` //YAML file with fraud rules, empty value disables the checks
+}
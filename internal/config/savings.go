@@ -0,0 +1,5 @@
+package config
+
+type Savings struct {
+	File string `env:"SAVINGS_FILE"` //YAML file with the savings products, empty value means only the wallet rates are applied
+}
@@ -0,0 +1,9 @@
+package config
+
+import "time"
+
+type Scheduler struct {
+	PollInterval  time.Duration `env:"SCHEDULER_POLL_INTERVAL" envDefault:"1s"`  //how often the due scheduled operations are looked for
+	BatchSize     int           `env:"SCHEDULER_BATCH_SIZE" envDefault:"100"`    //number of due operations taken at once
+	RetryInterval time.Duration `env:"SCHEDULER_RETRY_INTERVAL" envDefault:"1m"` //taken operation is not taken again within this time, so the failing ones do not block the others
+}
@@ -3,8 +3,15 @@ package config
 import "time"
 
 type Server struct {
-	Listen       string        `env:"SERVER_LISTEN" envDefault:":8088"`
-	ReadTimeout  time.Duration `env:"SERVER_READ_TIMEOUT" envDefault:"5s"`
-	WriteTimeout time.Duration `env:"SERVER_WRITE_TIMEOUT" envDefault:"5s"`
-	IdleTimeout  time.Duration `env:"SERVER_IDLE_TIMEOUT" envDefault:"30s"`
+	Listen          string        `env:"SERVER_LISTEN" envDefault:":8088"`
+	ReadTimeout     time.Duration `env:"SERVER_READ_TIMEOUT" envDefault:"5s"`
+	WriteTimeout    time.Duration `env:"SERVER_WRITE_TIMEOUT" envDefault:"5s"`
+	IdleTimeout     time.Duration `env:"SERVER_IDLE_TIMEOUT" envDefault:"30s"`
+	ShutdownDelay   time.Duration `env:"SERVER_SHUTDOWN_DELAY" envDefault:"5s"`    //time between failing the readiness probe and stopping the server
+	ShutdownTimeout time.Duration `env:"SERVER_SHUTDOWN_TIMEOUT" envDefault:"15s"` //time to finish requests in progress and database work
+
+	TLS       TLS
+	Auth      Auth
+	RateLimit RateLimit
+	Batch     Batch
 }
@@ -0,0 +1,11 @@
+package config
+
+type TLS struct {
+	CertFile     string `env:"SERVER_TLS_CERT_FILE"` //HTTPS is enabled if the certificate and the key are set
+	KeyFile      string `env:"SERVER_TLS_KEY_FILE"`
+	ClientCAFile string `env:"SERVER_TLS_CLIENT_CA_FILE"` //if set, clients must present a certificate signed by this CA (mTLS)
+}
+
+func (t TLS) Enabled() bool {
+	return t.CertFile != "" || t.KeyFile != ""
+}
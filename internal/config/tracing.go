@@ -0,0 +1,8 @@
+package config
+
+type Tracing struct {
+	Exporter     string  `env:"TRACING_EXPORTER" envDefault:"none"` //none, stdout or otlp
+	OTLPEndpoint string  `env:"TRACING_OTLP_ENDPOINT" envDefault:"http://localhost:4318/v1/traces"`
+	ServiceName  string  `env:"TRACING_SERVICE_NAME" envDefault:"balance"`
+	SampleRatio  float64 `env:"TRACING_SAMPLE_RATIO" envDefault:"1"`
+}
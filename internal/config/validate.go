@@ -0,0 +1,130 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// Validate checks the values that can be parsed but make no sense, all the problems are reported at once
+func (a Application) Validate() error {
+	var errs []error
+
+	switch a.Log.Format {
+	case "text", "json":
+	default:
+		errs = append(errs, fmt.Errorf("LOG_FORMAT: unknown format %q", a.Log.Format))
+	}
+
+	tls := a.Server.TLS
+	if tls.Enabled() && (tls.CertFile == "" || tls.KeyFile == "") {
+		errs = append(errs, errors.New("SERVER_TLS_CERT_FILE, SERVER_TLS_KEY_FILE: both must be set"))
+	}
+	if tls.ClientCAFile != "" && !tls.Enabled() {
+		errs = append(errs, errors.New("SERVER_TLS_CLIENT_CA_FILE: requires SERVER_TLS_CERT_FILE and SERVER_TLS_KEY_FILE"))
+	}
+
+	rl := a.Server.RateLimit
+	switch rl.Backend {
+	case "memory", "postgres":
+	default:
+		errs = append(errs, fmt.Errorf("RATE_LIMIT_BACKEND: unknown backend %q", rl.Backend))
+	}
+	if rl.ClientRate < 0 || rl.WalletRate < 0 || rl.AddressRate < 0 {
+		errs = append(errs, errors.New("RATE_LIMIT_CLIENT_RATE, RATE_LIMIT_WALLET_RATE, RATE_LIMIT_ADDRESS_RATE: must not be negative"))
+	}
+	if rl.ClientRate > 0 && rl.ClientBurst < 1 || rl.WalletRate > 0 && rl.WalletBurst < 1 || rl.AddressRate > 0 && rl.AddressBurst < 1 {
+		errs = append(errs, errors.New("RATE_LIMIT_CLIENT_BURST, RATE_LIMIT_WALLET_BURST, RATE_LIMIT_ADDRESS_BURST: must be positive if the rate is set"))
+	}
+
+	if b := a.Server.Batch; b.MaxSize < 1 || b.MaxAsyncSize < b.MaxSize {
+		errs = append(errs, errors.New("BATCH_MAX_SIZE, BATCH_MAX_ASYNC_SIZE: must be positive, the async size must not be less than BATCH_MAX_SIZE"))
+	}
+
+	for _, l := range []struct {
+		name  string
+		value decimal.NullDecimal
+	}{
+		{"LIMIT_MAX_WITHDRAWAL", a.Limits.MaxWithdrawal},
+		{"LIMIT_MAX_TRANSFER", a.Limits.MaxTransfer},
+		{"LIMIT_DAILY_WITHDRAWAL", a.Limits.DailyWithdrawal},
+		{"LIMIT_MONTHLY_WITHDRAWAL", a.Limits.MonthlyWithdrawal},
+	} {
+		if l.value.Valid && l.value.Decimal.IsNegative() {
+			errs = append(errs, fmt.Errorf("%s: must not be negative", l.name))
+		}
+	}
+
+	switch a.Tracing.Exporter {
+	case "none", "stdout", "otlp":
+	default:
+		errs = append(errs, fmt.Errorf("TRACING_EXPORTER: unknown exporter %q", a.Tracing.Exporter))
+	}
+	if a.Tracing.SampleRatio < 0 || a.Tracing.SampleRatio > 1 {
+		errs = append(errs, errors.New("TRACING_SAMPLE_RATIO: must be between 0 and 1"))
+	}
+
+	wh := a.Webhooks
+	if wh.PollInterval <= 0 || wh.Timeout <= 0 || wh.RetryMin <= 0 {
+		errs = append(errs, errors.New("WEBHOOK_POLL_INTERVAL, WEBHOOK_TIMEOUT, WEBHOOK_RETRY_MIN: must be positive"))
+	}
+	if wh.RetryMax < wh.RetryMin {
+		errs = append(errs, errors.New("WEBHOOK_RETRY_MAX: must not be less than WEBHOOK_RETRY_MIN"))
+	}
+	if wh.MaxAttempts < 1 || wh.BatchSize < 1 {
+		errs = append(errs, errors.New("WEBHOOK_MAX_ATTEMPTS, WEBHOOK_BATCH_SIZE: must be positive"))
+	}
+
+	jobs := a.Jobs
+	if jobs.Workers < 1 || jobs.PollInterval <= 0 || jobs.Lease <= 0 || jobs.RetryMin <= 0 {
+		errs = append(errs, errors.New("JOBS_WORKERS, JOBS_POLL_INTERVAL, JOBS_LEASE, JOBS_RETRY_MIN: must be positive"))
+	}
+	if jobs.RetryMax < jobs.RetryMin {
+		errs = append(errs, errors.New("JOBS_RETRY_MAX: must not be less than JOBS_RETRY_MIN"))
+	}
+
+	if a.Scheduler.PollInterval <= 0 || a.Scheduler.BatchSize < 1 || a.Scheduler.RetryInterval <= 0 {
+		errs = append(errs, errors.New("SCHEDULER_POLL_INTERVAL, SCHEDULER_BATCH_SIZE, SCHEDULER_RETRY_INTERVAL: must be positive"))
+	}
+
+	ev := a.Events
+	switch ev.Publisher {
+	case "none":
+	case "file":
+		if ev.File == "" {
+			errs = append(errs, errors.New("EVENTS_FILE: required for the file publisher"))
+		}
+	case "http":
+		if ev.URL == "" {
+			errs = append(errs, errors.New("EVENTS_URL: required for the http publisher"))
+		}
+		if ev.Timeout <= 0 {
+			errs = append(errs, errors.New("EVENTS_TIMEOUT: must be positive"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("EVENTS_PUBLISHER: unknown publisher %q", ev.Publisher))
+	}
+	if ev.Source == "" {
+		errs = append(errs, errors.New("EVENTS_SOURCE: must not be empty"))
+	}
+
+	if a.Postgres.URL != "" {
+		if _, err := a.Postgres.ParseURL(); err != nil {
+			errs = append(errs, fmt.Errorf("DATABASE_URL: invalid connection string: %w", err))
+		}
+	}
+	switch a.Postgres.SSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		errs = append(errs, fmt.Errorf("PG_SSLMODE: unknown mode %q", a.Postgres.SSLMode))
+	}
+	if (a.Postgres.SSLCert == "") != (a.Postgres.SSLKey == "") {
+		errs = append(errs, errors.New("PG_SSLCERT, PG_SSLKEY: both must be set"))
+	}
+	if a.Postgres.MaxConnections < 1 {
+		errs = append(errs, errors.New("PG_MAX_CONNECTIONS: must be positive"))
+	}
+
+	return errors.Join(errs...)
+}
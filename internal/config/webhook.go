@@ -0,0 +1,12 @@
+package config
+
+import "time"
+
+type Webhooks struct {
+	PollInterval time.Duration `env:"WEBHOOK_POLL_INTERVAL" envDefault:"1s"` //how often the outbox is checked for due deliveries
+	Timeout      time.Duration `env:"WEBHOOK_TIMEOUT" envDefault:"10s"`      //time to wait for the response of a webhook
+	MaxAttempts  int           `env:"WEBHOOK_MAX_ATTEMPTS" envDefault:"10"`  //failed delivery is moved to the dead letters after that number of attempts
+	RetryMin     time.Duration `env:"WEBHOOK_RETRY_MIN" envDefault:"10s"`    //delay before the first retry, doubled with every attempt
+	RetryMax     time.Duration `env:"WEBHOOK_RETRY_MAX" envDefault:"1h"`
+	BatchSize    int           `env:"WEBHOOK_BATCH_SIZE" envDefault:"50"` //number of deliveries sent at once
+}
@@ -0,0 +1,44 @@
+package credit
+
+import (
+	"errors"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+var InvalidLineErr = errors.New("invalid credit line")
+
+// Line allows the wallet to go negative down to -Limit, the negative balance is charged the interest daily
+type Line struct {
+	Limit        decimal.Decimal `json:"limit"`         //the lowest allowed balance is -limit, 0 means no overdraft
+	InterestRate decimal.Decimal `json:"interest_rate"` //annual percent charged on the negative balance, 0 means no interest
+	Since        int64           `json:"since"`         //start of the first day the interest is charged for, set when the interest is enabled
+}
+
+func (l Line) Validate() error {
+	switch {
+	case l.Limit.IsNegative():
+		return fmt.Errorf("%w: limit must not be negative", InvalidLineErr)
+	case l.InterestRate.IsNegative():
+		return fmt.Errorf("%w: interest rate must not be negative", InvalidLineErr)
+	}
+	return nil
+}
+
+// ChargedDay returns the start of the last finished calendar day in the location of now, its interest is charged
+func ChargedDay(now time.Time) time.Time {
+	y, m, d := now.Date()
+	return time.Date(y, m, d-1, 0, 0, 0, 0, now.Location())
+}
+
+// Interest returns the interest of the day for the balance at the end of the day rounded to hundredths,
+// the annual rate is divided by the number of days in the year of the day. Positive balance is charged nothing
+func Interest(balance, rate decimal.Decimal, day time.Time) decimal.Decimal {
+	if !balance.IsNegative() || !rate.IsPositive() {
+		return decimal.Zero
+	}
+
+	days := int64(time.Date(day.Year(), 12, 31, 0, 0, 0, 0, time.UTC).YearDay())
+	return balance.Neg().Mul(rate).Div(decimal.NewFromInt(100 * days)).Round(2)
+}
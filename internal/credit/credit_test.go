@@ -0,0 +1,61 @@
+package credit
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLine_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    Line
+		wantErr bool
+	}{
+		{name: "no overdraft", line: Line{}},
+		{name: "limit with interest", line: Line{Limit: decimal.NewFromInt(1000), InterestRate: decimal.RequireFromString("19.9")}},
+		{name: "negative limit", line: Line{Limit: decimal.NewFromInt(-1)}, wantErr: true},
+		{name: "negative rate", line: Line{Limit: decimal.NewFromInt(1000), InterestRate: decimal.NewFromInt(-1)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.line.Validate()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, InvalidLineErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestChargedDay(t *testing.T) {
+	loc := time.FixedZone("UTC+3", 3*60*60)
+	assert.Equal(t, time.Date(2024, 2, 29, 0, 0, 0, 0, loc), ChargedDay(time.Date(2024, 3, 1, 0, 30, 0, 0, loc)))
+	assert.Equal(t, time.Date(2023, 12, 31, 0, 0, 0, 0, loc), ChargedDay(time.Date(2024, 1, 1, 23, 59, 0, 0, loc)))
+}
+
+func TestInterest(t *testing.T) {
+	tests := []struct {
+		name    string
+		balance string
+		rate    string
+		day     time.Time
+		want    string
+	}{
+		{name: "common year", balance: "-36500", rate: "10", day: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), want: "10"},
+		{name: "leap year", balance: "-36600", rate: "10", day: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), want: "10"},
+		{name: "rounded to hundredths", balance: "-100", rate: "20", day: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), want: "0.05"},
+		{name: "positive balance", balance: "100", rate: "20", day: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), want: "0"},
+		{name: "no rate", balance: "-100", rate: "0", day: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), want: "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Interest(decimal.RequireFromString(tt.balance), decimal.RequireFromString(tt.rate), tt.day)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx"
+	"github.com/jackc/pgx/pgtype"
+	"github.com/shopspring/decimal"
+	"time"
+
+	"github.com/KseniiaSalmina/Balance/internal/credit"
+	"github.com/KseniiaSalmina/Balance/internal/wallet"
+)
+
+func (t *Transaction) GetCreditLine(id int) (credit.Line, error) {
+	var l credit.Line
+	if err := t.queryRow(`SELECT credit_limit, overdraft_rate, overdraft_since FROM balances WHERE id = $1`, id).Scan(&l.Limit, &l.InterestRate, &l.Since); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return credit.Line{}, UserDoesNotExistErr
+		}
+		return credit.Line{}, fmt.Errorf("GetCreditLine -> %w", err)
+	}
+	return l, nil
+}
+
+func (t *Transaction) SetCreditLine(id int, l credit.Line) error {
+	tag, err := t.exec(`UPDATE balances SET credit_limit = $2, overdraft_rate = $3, overdraft_since = $4 WHERE id = $1`, id, l.Limit, l.InterestRate, l.Since)
+	if err != nil {
+		return fmt.Errorf("SetCreditLine -> %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return UserDoesNotExistErr
+	}
+	return nil
+}
+
+// NetChangeSince returns the replenishments minus the withdrawals, fees and overdraft interest of the wallet made since the unix time
+func (t *Transaction) NetChangeSince(id int, since int64) (decimal.Decimal, error) {
+	var sum decimal.Decimal
+	err := t.queryRow(`SELECT COALESCE(SUM(CASE WHEN option = $2 THEN amount ELSE -amount END), 0) FROM history WHERE wallet_id = $1 AND date >= $3`,
+		id, wallet.Replenishment, since).Scan(&sum)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("NetChangeSince -> %w", err)
+	}
+	return sum, nil
+}
+
+// LastOverdraftCharge returns the last day the interest of the wallet is charged for, false if it was never charged
+func (t *Transaction) LastOverdraftCharge(id int) (int64, bool, error) {
+	var day pgtype.Int8
+	if err := t.queryRow(`SELECT MAX(day) FROM overdraft_charges WHERE wallet_id = $1`, id).Scan(&day); err != nil {
+		return 0, false, fmt.Errorf("LastOverdraftCharge -> %w", err)
+	}
+	return day.Int, day.Status == pgtype.Present, nil
+}
+
+// AddOverdraftCharge saves the interest charged for the day, returns false if the day is already charged
+func (t *Transaction) AddOverdraftCharge(id int, day int64, balance, amount decimal.Decimal) (bool, error) {
+	tag, err := t.exec(`INSERT INTO overdraft_charges (wallet_id, day, balance, amount, created_at) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (wallet_id, day) DO NOTHING`,
+		id, day, balance, amount, time.Now().Unix())
+	if err != nil {
+		return false, fmt.Errorf("AddOverdraftCharge -> %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// ClaimOverdraftInterest returns the wallets with the interest rate that are not charged for the last finished day
+// and are negative or changed since the first day that is not charged, so they may have been negative at the end of a day
+// that is not charged. The wallets are postponed until lease, the locked ones are skipped
+func (db *DB) ClaimOverdraftInterest(now, lease time.Time, limit int) ([]int64, error) {
+	day := credit.ChargedDay(now)
+	rows, err := db.db.Query(`WITH claimed AS (UPDATE balances SET overdraft_claimed_until = $4
+			WHERE id IN (SELECT id FROM balances b WHERE overdraft_rate > 0 AND overdraft_since <= $1 AND overdraft_claimed_until <= $3
+				AND NOT EXISTS (SELECT 1 FROM overdraft_charges c WHERE c.wallet_id = b.id AND c.day = $1)
+				AND (balance < 0 OR EXISTS (SELECT 1 FROM history h WHERE h.wallet_id = b.id
+					AND h.date >= GREATEST(overdraft_since, COALESCE((SELECT MAX(c.day) FROM overdraft_charges c WHERE c.wallet_id = b.id), $1))))
+				ORDER BY id LIMIT $2 FOR UPDATE SKIP LOCKED)
+			RETURNING id)
+		SELECT id FROM claimed ORDER BY id`,
+		day.Unix(), limit, now.Unix(), lease.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("ClaimOverdraftInterest -> %w", err)
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("ClaimOverdraftInterest -> %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ClaimOverdraftInterest -> %w", err)
+	}
+	return ids, nil
+}
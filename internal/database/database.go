@@ -5,53 +5,128 @@ import (
 	"errors"
 	"fmt"
 	"github.com/jackc/pgx"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/KseniiaSalmina/Balance/internal/config"
+	"github.com/KseniiaSalmina/Balance/internal/metrics"
+	"github.com/KseniiaSalmina/Balance/internal/tracing"
 )
 
 type DB struct {
-	db *pgx.Conn
+	db *pgx.ConnPool
 }
 
 func NewDB(cfg config.Postgres) (*DB, error) {
-	config := pgx.ConnConfig{
-		User:     cfg.User,
-		Password: cfg.Password,
-		Database: cfg.Database,
-		Host:     cfg.Host,
-		Port:     uint16(cfg.Port),
+	conn, err := connConfig(cfg)
+	if err != nil {
+		return nil, err
 	}
 
-	db, err := pgx.Connect(config)
+	config := pgx.ConnPoolConfig{
+		ConnConfig:     conn,
+		MaxConnections: cfg.MaxConnections,
+		AcquireTimeout: cfg.AcquireTimeout,
+	}
+
+	pool, err := pgx.NewConnPool(config)
 	if err != nil {
 		return nil, errors.New("cannot connect to database")
 	}
 
+	db := &DB{
+		db: pool,
+	}
+
 	ctx, cansel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cansel()
 	if err := db.Ping(ctx); err != nil {
+		pool.Close()
 		return nil, errors.New("cannot connect to database: ping fail")
 	}
 
-	log.Println("database connected")
+	slog.Info("database connected", "host", conn.Host, "database", conn.Database)
+
+	return db, nil
+}
+
+// connConfig uses the connection string if it is set, its sslmode, sslrootcert, sslcert and sslkey parameters
+// are used for TLS. Otherwise the separate connection and TLS settings are used
+func connConfig(cfg config.Postgres) (pgx.ConnConfig, error) {
+	if cfg.URL != "" {
+		conn, err := cfg.ParseURL()
+		if err != nil {
+			return pgx.ConnConfig{}, fmt.Errorf("connConfig -> cannot parse database connection string: %w", err)
+		}
+		return conn, nil
+	}
+
+	conn := pgx.ConnConfig{
+		User:     cfg.User,
+		Password: cfg.Password,
+		Database: cfg.Database,
+		Host:     cfg.Host,
+		Port:     uint16(cfg.Port),
+	}
+	if err := configureTLS(cfg, &conn); err != nil {
+		return pgx.ConnConfig{}, fmt.Errorf("connConfig -> %w", err)
+	}
+	return conn, nil
+}
+
+// Close closes the pool, connections in use are closed as soon as they are released
+func (db *DB) Close() {
+	db.db.Close()
+}
+
+const drainInterval = 50 * time.Millisecond
+
+// Drain waits until every connection is released to the pool, i.e. all transactions are finished, or ctx is done
+func (db *DB) Drain(ctx context.Context) error {
+	ticker := time.NewTicker(drainInterval)
+	defer ticker.Stop()
+
+	for {
+		s := db.db.Stat()
+		if s.CurrentConnections == s.AvailableConnections {
+			return nil
+		}
 
-	return &DB{
-		db: db,
-	}, nil
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("Drain -> %d connections in use: %w", s.CurrentConnections-s.AvailableConnections, ctx.Err())
+		case <-ticker.C:
+		}
+	}
 }
 
-func (db *DB) Close() error {
-	return db.db.Close()
+func (db *DB) Ping(ctx context.Context) error {
+	conn, err := db.db.AcquireEx(ctx)
+	if err != nil {
+		return fmt.Errorf("Ping -> %w", err)
+	}
+	defer db.db.Release(conn)
+
+	return conn.Ping(ctx)
 }
 
-func (db *DB) NewTransaction() (*Transaction, error) {
-	tx, err := db.db.Begin()
+// NewTransaction begins a transaction, its queries are traced as children of the span from ctx
+func (db *DB) NewTransaction(ctx context.Context) (*Transaction, error) {
+	ctx, span := tracing.Start(ctx, "db.transaction")
+
+	tx, err := db.db.BeginEx(ctx, nil)
 	if err != nil {
+		metrics.DBError("begin")
+		tracing.End(span, &err)
 		return nil, fmt.Errorf("NewTransaction -> %w", err)
 	}
-	return &Transaction{tx: tx}, nil
+	return &Transaction{tx: tx, ctx: ctx, span: span, started: time.Now()}, nil
+}
+
+// Stat returns the maximum, current and available number of pool connections
+func (db *DB) Stat() (int, int, int) {
+	s := db.db.Stat()
+	return s.MaxConnections, s.CurrentConnections, s.AvailableConnections
 }
 
 // OrderBy can be date or amount
@@ -71,3 +146,21 @@ const (
 )
 
 var UserDoesNotExistErr error = errors.New("user does not exist")
+
+var ReviewDoesNotExistErr error = errors.New("review does not exist")
+
+var WebhookDoesNotExistErr error = errors.New("webhook does not exist")
+
+var DeliveryDoesNotExistErr error = errors.New("webhook delivery does not exist")
+
+var JobDoesNotExistErr error = errors.New("job does not exist")
+
+var ScheduledDoesNotExistErr error = errors.New("scheduled operation does not exist")
+
+var RecurringDoesNotExistErr error = errors.New("recurring payment does not exist")
+
+var PlanDoesNotExistErr error = errors.New("plan does not exist")
+
+var SubscriptionDoesNotExistErr error = errors.New("subscription does not exist")
+
+var SchemaVersionErr error = errors.New("unexpected database schema version")
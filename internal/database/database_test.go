@@ -12,12 +12,12 @@ import (
 	"github.com/KseniiaSalmina/Balance/internal/wallet"
 )
 
-var config = pgx.ConnConfig{User: "user", Password: "password", Database: "testdb"} //TODO
+var testConfig = pgx.ConnConfig{User: "user", Password: "password", Database: "testdb"} //TODO
 var testTime = time.Now().Unix()
 var testTime2 = testTime + 1
 
 func prepareDB() *pgx.Conn {
-	db, err := pgx.Connect(config)
+	db, err := pgx.Connect(testConfig)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -124,7 +124,7 @@ func TestTransaction_CommitChanges(t1 *testing.T) {
 			}
 			t := &Transaction{tx: tx}
 
-			err = t.CommitChanges(tt.args.id, tt.args.balance, tt.args.ch)
+			_, err = t.CommitChanges(tt.args.id, tt.args.balance, tt.args.ch)
 			assert.NoError(t1, err)
 			if err == nil {
 				t.tx.Commit()
@@ -264,3 +264,42 @@ func TestTransaction_GetHistory(t1 *testing.T) {
 		})
 	}
 }
+
+func TestTransaction_GetEvents(t1 *testing.T) {
+	db := prepareDB()
+	defer cleanup(db)
+
+	tests := []struct {
+		name        string
+		after       int64
+		limit       int
+		wantIDs     []int64
+		wantBalance []string
+	}{
+		{name: "all events of user 4", after: 0, limit: 10, wantIDs: []int64{1, 2}, wantBalance: []string{"1001", "1"}},
+		{name: "events of user 4 after the first one", after: 1, limit: 10, wantIDs: []int64{2}, wantBalance: []string{"1"}},
+		{name: "first event of user 4", after: 0, limit: 1, wantIDs: []int64{1}, wantBalance: []string{"1001"}},
+		{name: "no new events of user 4", after: 2, limit: 10, wantIDs: []int64{}, wantBalance: []string{}},
+	}
+	for _, tt := range tests {
+		t1.Run(tt.name, func(t1 *testing.T) {
+			tx, err := db.Begin()
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer tx.Rollback()
+			t := &Transaction{tx: tx}
+
+			events, err := t.GetEvents(4, tt.after, tt.limit)
+			assert.NoError(t1, err)
+
+			ids, balances := make([]int64, 0), make([]string, 0)
+			for _, e := range events {
+				ids = append(ids, e.ID)
+				balances = append(balances, e.Balance.String())
+			}
+			assert.Equal(t1, tt.wantIDs, ids)
+			assert.Equal(t1, tt.wantBalance, balances)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/KseniiaSalmina/Balance/internal/notify"
+	"github.com/KseniiaSalmina/Balance/internal/wallet"
+)
+
+// eventsQuery selects the history records with the balance after each of them, it is calculated back from the current
+// balance in the same snapshot. $2 is the ID of the last known record
+const eventsQuery = `SELECT id, date, option, amount, description, actor, counterparty, balance FROM (
+	SELECT h.id, h.date, h.option, h.amount, h.description, h.actor, COALESCE(h.counterparty, 0) AS counterparty,
+		b.balance - COALESCE(SUM(CASE WHEN h.option = 'replenishment' THEN h.amount ELSE -h.amount END)
+			OVER (ORDER BY h.id DESC ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING), 0) AS balance
+	FROM history h JOIN balances b ON b.id = h.wallet_id
+	WHERE h.wallet_id = $1 AND h.id > $2
+) events`
+
+// GetEvents returns up to limit changes of the wallet made after the history record with ID after, in order of commit
+func (t *Transaction) GetEvents(walletID int, after int64, limit int) ([]notify.Event, error) {
+	events, err := t.events(walletID, eventsQuery+` ORDER BY id LIMIT $3`, walletID, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("GetEvents -> %w", err)
+	}
+	return events, nil
+}
+
+// GetLastEvent returns the last change of the wallet, nil if the wallet has no history
+func (t *Transaction) GetLastEvent(walletID int) (*notify.Event, error) {
+	events, err := t.events(walletID, eventsQuery+` ORDER BY id DESC LIMIT 1`, walletID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("GetLastEvent -> %w", err)
+	}
+	if len(events) == 0 {
+		return nil, nil
+	}
+	return &events[0], nil
+}
+
+func (t *Transaction) events(walletID int, query string, args ...interface{}) ([]notify.Event, error) {
+	rows, err := t.query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]notify.Event, 0)
+	for rows.Next() {
+		e := notify.Event{WalletID: walletID}
+		var operation string
+		if err = rows.Scan(&e.ID, &e.Change.Date, &operation, &e.Change.Amount, &e.Change.Description, &e.Change.Actor, &e.Change.Counterparty, &e.Balance); err != nil {
+			return nil, err
+		}
+		e.Change.Operation = wallet.Operation(operation)
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,30 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx"
+)
+
+// GetTier returns the tier of the wallet, the fees of the tier are applied to its operations
+func (t *Transaction) GetTier(id int) (string, error) {
+	var tier string
+	if err := t.queryRow(`SELECT tier FROM balances WHERE id = $1`, id).Scan(&tier); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", UserDoesNotExistErr
+		}
+		return "", fmt.Errorf("GetTier -> %w", err)
+	}
+	return tier, nil
+}
+
+func (t *Transaction) SetTier(id int, tier string) error {
+	tag, err := t.exec(`UPDATE balances SET tier = $2 WHERE id = $1`, id, tier)
+	if err != nil {
+		return fmt.Errorf("SetTier -> %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return UserDoesNotExistErr
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package database
+
+import (
+	"context"
+	"fmt"
+)
+
+// SchemaVersion is the version of schema.sql the service expects
+const SchemaVersion = 14
+
+// Ready reports whether the database is reachable and its schema is at the expected version
+func (db *DB) Ready(ctx context.Context) error {
+	if err := db.Ping(ctx); err != nil {
+		return fmt.Errorf("Ready -> %w", err)
+	}
+
+	var version int
+	if err := db.db.QueryRowEx(ctx, `SELECT version FROM schema_version`, nil).Scan(&version); err != nil {
+		return fmt.Errorf("Ready -> schema version: %w", err)
+	}
+	if version != SchemaVersion {
+		return fmt.Errorf("%w: have %d, want %d", SchemaVersionErr, version, SchemaVersion)
+	}
+
+	return nil
+}
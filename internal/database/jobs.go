@@ -0,0 +1,173 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx"
+	"time"
+
+	"github.com/KseniiaSalmina/Balance/internal/jobs"
+)
+
+const jobColumns = `id, type, status, payload, attempts, max_attempts, run_at, cancel_requested, last_error, artifact_type, actor, created_at, updated_at`
+
+func (t *Transaction) CreateJob(j jobs.Job) (int64, error) {
+	var id int64
+	err := t.queryRow(`INSERT INTO jobs (type, status, payload, max_attempts, run_at, actor, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`,
+		j.Type, j.Status, string(j.Payload), j.MaxAttempts, j.RunAt, j.Actor, j.Created, j.Updated).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("CreateJob -> %w", err)
+	}
+	return id, nil
+}
+
+func (t *Transaction) GetJob(id int64) (*jobs.Job, error) {
+	j, err := scanJob(t.queryRow(`SELECT `+jobColumns+` FROM jobs WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, JobDoesNotExistErr
+		}
+		return nil, fmt.Errorf("GetJob -> %w", err)
+	}
+	return &j, nil
+}
+
+// ListJobs returns the jobs created with the API key of the client with the status, newest first
+func (t *Transaction) ListJobs(client string, status jobs.Status, limit int) ([]jobs.Job, error) {
+	rows, err := t.query(`SELECT `+jobColumns+` FROM jobs WHERE split_part(actor, '/', 1) = $1 AND status = $2 ORDER BY id DESC LIMIT $3`, client, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ListJobs -> %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]jobs.Job, 0)
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ListJobs -> %w", err)
+		}
+		list = append(list, j)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListJobs -> %w", err)
+	}
+	return list, nil
+}
+
+// GetJobArtifact returns nil if the job has no artifact
+func (t *Transaction) GetJobArtifact(id int64) (*jobs.Artifact, error) {
+	var a jobs.Artifact
+	err := t.queryRow(`SELECT artifact_type, artifact FROM jobs WHERE id = $1`, id).Scan(&a.ContentType, &a.Data)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, JobDoesNotExistErr
+		}
+		return nil, fmt.Errorf("GetJobArtifact -> %w", err)
+	}
+	if a.ContentType == "" {
+		return nil, nil
+	}
+	return &a, nil
+}
+
+// CancelJob cancels the queued job at once, the running one is stopped by its worker at the next lease extension
+func (t *Transaction) CancelJob(id int64, at int64) (*jobs.Job, error) {
+	j, err := scanJob(t.queryRow(`SELECT `+jobColumns+` FROM jobs WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, JobDoesNotExistErr
+		}
+		return nil, fmt.Errorf("CancelJob -> %w", err)
+	}
+	if j.Finished() {
+		return nil, jobs.FinishedErr
+	}
+
+	if j.Status == jobs.Queued {
+		j.Status = jobs.Canceled
+	} else {
+		j.CancelRequested = true
+	}
+	j.Updated = at
+
+	_, err = t.exec(`UPDATE jobs SET status = $1, cancel_requested = $2, updated_at = $3 WHERE id = $4`, j.Status, j.CancelRequested, at, id)
+	if err != nil {
+		return nil, fmt.Errorf("CancelJob -> %w", err)
+	}
+	return &j, nil
+}
+
+// ClaimJobs returns the due queued jobs and the running ones with the expired lease, the instance running them stopped.
+// Jobs with the expired lease that have no attempts left or were canceled are finished instead
+func (db *DB) ClaimJobs(now, lease time.Time, limit int) ([]jobs.Job, error) {
+	_, err := db.db.Exec(`UPDATE jobs SET status = CASE WHEN cancel_requested THEN 'canceled' ELSE 'failed' END,
+			last_error = 'interrupted: the lease expired', locked_until = 0, updated_at = $1
+		WHERE status = 'running' AND locked_until <= $1 AND (attempts >= max_attempts OR cancel_requested)`, now.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("ClaimJobs -> %w", err)
+	}
+
+	rows, err := db.db.Query(`UPDATE jobs SET status = 'running', attempts = attempts + 1, locked_until = $2, updated_at = $1
+		WHERE id IN (SELECT id FROM jobs WHERE (status = 'queued' AND run_at <= $1) OR (status = 'running' AND locked_until <= $1)
+			ORDER BY run_at, id LIMIT $3 FOR UPDATE SKIP LOCKED)
+		RETURNING `+jobColumns, now.Unix(), lease.Unix(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("ClaimJobs -> %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]jobs.Job, 0)
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ClaimJobs -> %w", err)
+		}
+		list = append(list, j)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ClaimJobs -> %w", err)
+	}
+	return list, nil
+}
+
+// ExtendJobLease reports true if the job is canceled or was taken by another instance after its lease expired
+func (db *DB) ExtendJobLease(id int64, lease time.Time) (bool, error) {
+	var canceled bool
+	err := db.db.QueryRow(`UPDATE jobs SET locked_until = $1 WHERE id = $2 AND status = 'running' RETURNING cancel_requested`, lease.Unix(), id).Scan(&canceled)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return true, nil
+		}
+		return false, fmt.Errorf("ExtendJobLease -> %w", err)
+	}
+	return canceled, nil
+}
+
+// SaveJob saves the result of the attempt, the artifact is kept if it is nil
+func (db *DB) SaveJob(j jobs.Job, artifact *jobs.Artifact) error {
+	var data interface{} //NULL keeps the saved artifact
+	if artifact != nil {
+		data = artifact.Data
+	}
+
+	_, err := db.db.Exec(`UPDATE jobs SET status = $1, run_at = $2, last_error = $3, locked_until = 0, updated_at = $4,
+			artifact_type = $5, artifact = COALESCE($6, artifact)
+		WHERE id = $7 AND status = 'running'`,
+		j.Status, j.RunAt, j.LastError, j.Updated, j.ArtifactType, data, j.ID)
+	if err != nil {
+		return fmt.Errorf("SaveJob -> %w", err)
+	}
+	return nil
+}
+
+func scanJob(row scanner) (jobs.Job, error) {
+	var j jobs.Job
+	var status, payload string
+	err := row.Scan(&j.ID, &j.Type, &status, &payload, &j.Attempts, &j.MaxAttempts, &j.RunAt, &j.CancelRequested, &j.LastError, &j.ArtifactType, &j.Actor, &j.Created, &j.Updated)
+	if err != nil {
+		return jobs.Job{}, err
+	}
+	j.Status, j.Payload = jobs.Status(status), json.RawMessage(payload)
+	return j, nil
+}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx"
+	"github.com/shopspring/decimal"
+
+	"github.com/KseniiaSalmina/Balance/internal/limits"
+	"github.com/KseniiaSalmina/Balance/internal/wallet"
+)
+
+// LockWallet locks the wallet row until the end of the transaction, so concurrent operations are checked one by one
+func (t *Transaction) LockWallet(id int) error {
+	var locked int
+	if err := t.queryRow(`SELECT id FROM balances WHERE id = $1 FOR UPDATE`, id).Scan(&locked); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return UserDoesNotExistErr
+		}
+		return fmt.Errorf("LockWallet -> %w", err)
+	}
+	return nil
+}
+
+// GetLimits returns limits set for the wallet, unset limits are null
+func (t *Transaction) GetLimits(id int) (limits.Limits, error) {
+	var l limits.Limits
+	err := t.queryRow(`SELECT max_withdrawal, max_transfer, daily_withdrawal, monthly_withdrawal FROM wallet_limits WHERE wallet_id = $1`, id).
+		Scan(&l.MaxWithdrawal, &l.MaxTransfer, &l.DailyWithdrawal, &l.MonthlyWithdrawal)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return limits.Limits{}, fmt.Errorf("GetLimits -> %w", err)
+	}
+	return l, nil
+}
+
+func (t *Transaction) SetLimits(id int, l limits.Limits) error {
+	_, err := t.exec(`INSERT INTO wallet_limits (wallet_id, max_withdrawal, max_transfer, daily_withdrawal, monthly_withdrawal) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (wallet_id) DO UPDATE SET max_withdrawal = $2, max_transfer = $3, daily_withdrawal = $4, monthly_withdrawal = $5`,
+		id, l.MaxWithdrawal, l.MaxTransfer, l.DailyWithdrawal, l.MonthlyWithdrawal)
+	if err != nil {
+		return fmt.Errorf("SetLimits -> %w", err)
+	}
+	return nil
+}
+
+// SumWithdrawals returns the total amount of withdrawals and outgoing transfers made since the unix time, fees and overdraft interest are not included
+func (t *Transaction) SumWithdrawals(id int, since int64) (decimal.Decimal, error) {
+	var sum decimal.Decimal
+	err := t.queryRow(`SELECT COALESCE(SUM(amount), 0) FROM history WHERE wallet_id = $1 AND option = $2 AND date >= $3`, id, wallet.Withdrawal, since).Scan(&sum)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("SumWithdrawals -> %w", err)
+	}
+	return sum, nil
+}
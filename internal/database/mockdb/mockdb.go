@@ -3,9 +3,22 @@ package mockdb
 import (
 	"errors"
 	"github.com/shopspring/decimal"
+	"time"
 
+	"github.com/KseniiaSalmina/Balance/internal/audit"
+	"github.com/KseniiaSalmina/Balance/internal/batch"
+	"github.com/KseniiaSalmina/Balance/internal/credit"
 	"github.com/KseniiaSalmina/Balance/internal/database"
+	"github.com/KseniiaSalmina/Balance/internal/jobs"
+	"github.com/KseniiaSalmina/Balance/internal/limits"
+	"github.com/KseniiaSalmina/Balance/internal/notify"
+	"github.com/KseniiaSalmina/Balance/internal/recurring"
+	"github.com/KseniiaSalmina/Balance/internal/risk"
+	"github.com/KseniiaSalmina/Balance/internal/savings"
+	"github.com/KseniiaSalmina/Balance/internal/schedule"
+	"github.com/KseniiaSalmina/Balance/internal/subscription"
 	"github.com/KseniiaSalmina/Balance/internal/wallet"
+	"github.com/KseniiaSalmina/Balance/internal/webhook"
 )
 
 type MockDb struct{}
@@ -14,6 +27,9 @@ func (m *MockDb) GetBalance(id int) (*wallet.Wallet, error) {
 	if id <= 0 {
 		return nil, database.UserDoesNotExistErr
 	}
+	if id == 999 {
+		return &wallet.Wallet{ID: id, Balance: decimal.NewFromInt(-100), CreditLimit: decimal.NewFromInt(1000)}, nil
+	}
 	testBalance, _ := decimal.NewFromString("300")
 	return &wallet.Wallet{ID: id, Balance: testBalance}, nil
 }
@@ -26,16 +42,493 @@ func (m *MockDb) GetHistory(id int, orderBy database.OrderBy, order database.Ord
 	return &wallet.Wallet{ID: id, History: make([]wallet.HistoryChange, id)}, nil
 }
 
-func (m *MockDb) CommitChanges(id int, balance decimal.Decimal, ch wallet.HistoryChange) error {
-	return nil
+func (m *MockDb) CommitChanges(id int, balance decimal.Decimal, ch wallet.HistoryChange) (int64, error) {
+	return 1, nil
 }
 
 func (m *MockDb) NewUser(id int) error {
 	return nil
 }
 
+func (m *MockDb) GetHistoryChain(id int) ([]audit.Record, string, error) {
+	if id <= 0 {
+		return nil, "", database.UserDoesNotExistErr
+	}
+	ch := wallet.HistoryChange{Date: 1, Operation: wallet.Replenishment, Amount: decimal.NewFromInt(300), Description: "test", Actor: audit.Anonymous}
+	hash := audit.Hash("", id, ch)
+	return []audit.Record{{ID: 1, Change: ch, Hash: hash}}, hash, nil
+}
+
+func (m *MockDb) LockWallet(id int) error {
+	if id <= 0 {
+		return database.UserDoesNotExistErr
+	}
+	return nil
+}
+
+// GetTier reports that wallet 888 is of the business tier
+func (m *MockDb) GetTier(id int) (string, error) {
+	switch {
+	case id <= 0:
+		return "", database.UserDoesNotExistErr
+	case id == 888:
+		return "business", nil
+	}
+	return "", nil
+}
+
+func (m *MockDb) SetTier(id int, tier string) error {
+	if id <= 0 {
+		return database.UserDoesNotExistErr
+	}
+	return nil
+}
+
+// GetCreditLine reports that wallet 999 has the credit line of 1000 at 20% per year charged for 10 days
+func (m *MockDb) GetCreditLine(id int) (credit.Line, error) {
+	switch {
+	case id <= 0:
+		return credit.Line{}, database.UserDoesNotExistErr
+	case id == 999:
+		since := limits.DayStart(time.Now()).AddDate(0, 0, -10).Unix()
+		return credit.Line{Limit: decimal.NewFromInt(1000), InterestRate: decimal.NewFromInt(20), Since: since}, nil
+	}
+	return credit.Line{}, nil
+}
+
+func (m *MockDb) SetCreditLine(id int, l credit.Line) error {
+	if id <= 0 {
+		return database.UserDoesNotExistErr
+	}
+	return nil
+}
+
+func (m *MockDb) NetChangeSince(id int, since int64) (decimal.Decimal, error) {
+	return decimal.Zero, nil
+}
+
+// LastOverdraftCharge reports that wallet 999 was last charged for the day 4 days ago, so 3 days are not charged
+func (m *MockDb) LastOverdraftCharge(id int) (int64, bool, error) {
+	if id == 999 {
+		return limits.DayStart(time.Now()).AddDate(0, 0, -4).Unix(), true, nil
+	}
+	return 0, false, nil
+}
+
+func (m *MockDb) AddOverdraftCharge(id int, day int64, balance, amount decimal.Decimal) (bool, error) {
+	return true, nil
+}
+
+// GetSavings reports that wallet 555 has the savings at 10% per year
+func (m *MockDb) GetSavings(id int) (savings.Account, error) {
+	switch {
+	case id <= 0:
+		return savings.Account{}, database.UserDoesNotExistErr
+	case id == 555:
+		return savings.Account{Rate: decimal.NewNullDecimal(decimal.NewFromInt(10)), Since: time.Now().AddDate(0, 0, -60).Unix()}, nil
+	}
+	return savings.Account{}, nil
+}
+
+func (m *MockDb) SetSavings(id int, a savings.Account) error {
+	if id <= 0 {
+		return database.UserDoesNotExistErr
+	}
+	return nil
+}
+
+// LastInterestAccrual reports that the interest was last accrued 40 days ago
+func (m *MockDb) LastInterestAccrual(id int) (int64, bool, error) {
+	return time.Now().AddDate(0, 0, -40).Unix(), true, nil
+}
+
+func (m *MockDb) AddInterestAccrual(id int, a savings.Accrual) (bool, error) {
+	return true, nil
+}
+
+// InterestTotals reports that 10.005 is accrued and nothing is posted
+func (m *MockDb) InterestTotals(id int) (decimal.Decimal, decimal.Decimal, error) {
+	return decimal.RequireFromString("10.005"), decimal.Zero, nil
+}
+
+func (m *MockDb) AddCapitalization(id int, c savings.Capitalization) error {
+	return nil
+}
+
+func (m *MockDb) ListAccruals(id int, from, to int64) ([]savings.Accrual, error) {
+	return []savings.Accrual{{Day: from, Balance: decimal.NewFromInt(365), Rate: decimal.NewFromInt(10), Amount: decimal.RequireFromString("0.1")}}, nil
+}
+
+func (m *MockDb) ListCapitalizations(id int, fromMonth, toMonth string) ([]savings.Capitalization, error) {
+	return []savings.Capitalization{}, nil
+}
+
+// GetLimits limits wallet 777 to withdraw 100 per day
+func (m *MockDb) GetLimits(id int) (limits.Limits, error) {
+	if id == 777 {
+		return limits.Limits{DailyWithdrawal: decimal.NewNullDecimal(decimal.NewFromInt(100))}, nil
+	}
+	return limits.Limits{}, nil
+}
+
+func (m *MockDb) SetLimits(id int, l limits.Limits) error {
+	return nil
+}
+
+// SumWithdrawals reports that every wallet has withdrawn 50 today
+func (m *MockDb) SumWithdrawals(id int, since int64) (decimal.Decimal, error) {
+	return decimal.NewFromInt(50), nil
+}
+
+// TransfersFrom reports that wallet 900 has just transferred 10 to wallet 901
+func (m *MockDb) TransfersFrom(id int, since int64) ([]risk.Transfer, error) {
+	if id == 900 {
+		return []risk.Transfer{{To: 901, Amount: decimal.NewFromInt(10), Date: time.Now().Unix(), RecipientCreated: 1}}, nil
+	}
+	return []risk.Transfer{}, nil
+}
+
+func (m *MockDb) WalletCreatedAt(id int) (int64, bool, error) {
+	if id <= 0 {
+		return 0, false, nil
+	}
+	return 1, true, nil
+}
+
+func (m *MockDb) AverageWithdrawal(id int, since int64) (decimal.Decimal, int, error) {
+	return decimal.NewFromInt(100), 5, nil
+}
+
+func (m *MockDb) CreateReview(r risk.Review) (int64, error) {
+	return 1, nil
+}
+
+// GetReview returns pending transfer review 1, approved review 2 and pending review 4 requested by shop/alice
+func (m *MockDb) GetReview(id int64) (*risk.Review, error) {
+	op := risk.Operation{Kind: risk.KindTransfer, WalletID: 456, To: 123, Amount: decimal.NewFromInt(10)}
+	switch id {
+	case 1:
+		return &risk.Review{ID: 1, Operation: op, Status: risk.Pending}, nil
+	case 2:
+		return &risk.Review{ID: 2, Operation: op, Status: risk.Approved}, nil
+	case 4:
+		return &risk.Review{ID: 4, Operation: op, Status: risk.Pending, Actor: "shop/alice"}, nil
+	}
+	return nil, database.ReviewDoesNotExistErr
+}
+
+func (m *MockDb) ListReviews(status risk.ReviewStatus, limit int) ([]risk.Review, error) {
+	r, _ := m.GetReview(1)
+	return []risk.Review{*r}, nil
+}
+
+func (m *MockDb) ResolveReview(id int64, status risk.ReviewStatus, by string, at int64) error {
+	return nil
+}
+
 func (m *MockDb) Rollback() {}
 
 func (m *MockDb) Commit() error {
 	return nil
 }
+
+// GetEvents reports that every wallet has one change, replenishment by 300
+func (m *MockDb) GetEvents(id int, after int64, limit int) ([]notify.Event, error) {
+	if after >= 1 {
+		return []notify.Event{}, nil
+	}
+	e, err := m.GetLastEvent(id)
+	return []notify.Event{*e}, err
+}
+
+func (m *MockDb) GetLastEvent(id int) (*notify.Event, error) {
+	ch := wallet.HistoryChange{Date: 1, Operation: wallet.Replenishment, Amount: decimal.NewFromInt(300), Description: "test", Actor: audit.Anonymous}
+	return &notify.Event{ID: 1, WalletID: id, Balance: decimal.NewFromInt(300), Change: ch}, nil
+}
+
+func (m *MockDb) CreateJob(j jobs.Job) (int64, error) {
+	return 1, nil
+}
+
+// GetJob knows job 1, it is running, job 2, it is failed, and job 3 of shop/alice
+func (m *MockDb) GetJob(id int64) (*jobs.Job, error) {
+	switch id {
+	case 1:
+		return &jobs.Job{ID: 1, Type: "test", Status: jobs.Running, Attempts: 1, MaxAttempts: 1, Actor: audit.Anonymous, Created: 1, Updated: 1}, nil
+	case 2:
+		return &jobs.Job{ID: 2, Type: "test", Status: jobs.Failed, Attempts: 1, MaxAttempts: 1, Actor: audit.Anonymous, Created: 1, Updated: 1}, nil
+	case 3:
+		return &jobs.Job{ID: 3, Type: "test", Status: jobs.Running, Attempts: 1, MaxAttempts: 1, Actor: "shop/alice", Created: 1, Updated: 1}, nil
+	}
+	return nil, database.JobDoesNotExistErr
+}
+
+func (m *MockDb) ListJobs(client string, status jobs.Status, limit int) ([]jobs.Job, error) {
+	return []jobs.Job{{ID: 1, Type: "test", Status: status, Attempts: 1, MaxAttempts: 1, Actor: client, Created: 1, Updated: 1}}, nil
+}
+
+// GetJobArtifact returns the artifact of jobs 1 and 3, job 2 has no artifact
+func (m *MockDb) GetJobArtifact(id int64) (*jobs.Artifact, error) {
+	switch id {
+	case 1, 3:
+		return &jobs.Artifact{ContentType: "application/json", Data: []byte(`{"status":"completed"}`)}, nil
+	case 2:
+		return nil, nil
+	}
+	return nil, database.JobDoesNotExistErr
+}
+
+// CancelJob knows only job 1, it is running, and job 2, it is finished
+func (m *MockDb) CancelJob(id int64, at int64) (*jobs.Job, error) {
+	switch id {
+	case 1:
+		return &jobs.Job{ID: 1, Type: "test", Status: jobs.Running, CancelRequested: true, Actor: audit.Anonymous, Created: 1, Updated: at}, nil
+	case 2:
+		return nil, jobs.FinishedErr
+	}
+	return nil, database.JobDoesNotExistErr
+}
+
+func (m *MockDb) CreateScheduled(op schedule.Operation) (int64, error) {
+	return 1, nil
+}
+
+// GetScheduled knows scheduled withdrawals of 100 (1) and 1000 (2) from wallet 123, completed operation 3,
+// scheduled transfer 4, the occurrence of recurring payment 1, and scheduled withdrawal 6 of shop/alice
+func (m *MockDb) GetScheduled(id int64) (*schedule.Operation, error) {
+	op := schedule.Operation{
+		ID:        id,
+		Item:      batch.Item{Type: risk.KindWithdrawal, WalletID: 123, Amount: decimal.NewFromInt(100), Description: "test"},
+		ExecuteAt: 1,
+		Status:    schedule.Scheduled,
+		Actor:     audit.Anonymous,
+		Created:   1,
+		Updated:   1,
+	}
+	switch id {
+	case 1:
+	case 2:
+		op.Amount = decimal.NewFromInt(1000)
+	case 3:
+		op.Status = schedule.Completed
+	case 4:
+		op.Item = batch.Item{Type: risk.KindTransfer, WalletID: 456, To: 123, Amount: decimal.NewFromInt(10)}
+		op.RecurringID = 1
+	case 6:
+		op.Actor = "shop/alice"
+	default:
+		return nil, database.ScheduledDoesNotExistErr
+	}
+	return &op, nil
+}
+
+func (m *MockDb) ListScheduled(client string, walletID int, status schedule.Status, limit int) ([]schedule.Operation, error) {
+	op, _ := m.GetScheduled(1)
+	op.WalletID, op.Status, op.Actor = walletID, status, client
+	return []schedule.Operation{*op}, nil
+}
+
+func (m *MockDb) CancelScheduled(id int64, at int64) (*schedule.Operation, error) {
+	op, err := m.GetScheduled(id)
+	if err != nil {
+		return nil, err
+	}
+	if op.Status != schedule.Scheduled {
+		return nil, schedule.NotScheduledErr
+	}
+	op.Status, op.Updated = schedule.Canceled, at
+	return op, nil
+}
+
+func (m *MockDb) LockScheduled(id int64) (*schedule.Operation, error) {
+	op, err := m.GetScheduled(id)
+	if err != nil || op.Status != schedule.Scheduled {
+		return nil, nil
+	}
+	return op, nil
+}
+
+func (m *MockDb) FinishScheduled(op schedule.Operation) error {
+	return nil
+}
+
+func (m *MockDb) CreateRecurring(p recurring.Payment) (int64, error) {
+	return 1, nil
+}
+
+// GetRecurring knows daily transfer 1 from wallet 456 executed by scheduled operation 4, canceled payment 2
+// and active payment 4 of shop/alice
+func (m *MockDb) GetRecurring(id int64) (*recurring.Payment, error) {
+	p := recurring.Payment{
+		ID:          id,
+		WalletID:    456,
+		To:          123,
+		Amount:      decimal.NewFromInt(10),
+		Rule:        recurring.Rule{Frequency: recurring.Daily, Interval: 1},
+		StartAt:     time.Now().Add(-time.Hour).Unix(),
+		OnFailure:   recurring.Skip,
+		Status:      recurring.Active,
+		NextAt:      time.Now().Add(-time.Hour).Unix(),
+		ScheduledID: 4,
+		Actor:       audit.Anonymous,
+		Created:     1,
+		Updated:     1,
+	}
+	switch id {
+	case 1:
+	case 2:
+		p.Status, p.NextAt, p.ScheduledID = recurring.Canceled, 0, 0
+	case 4:
+		p.Actor = "shop/alice"
+	default:
+		return nil, database.RecurringDoesNotExistErr
+	}
+	return &p, nil
+}
+
+func (m *MockDb) LockRecurring(id int64) (*recurring.Payment, error) {
+	return m.GetRecurring(id)
+}
+
+func (m *MockDb) ListRecurring(client string, walletID int, status recurring.Status, limit int) ([]recurring.Payment, error) {
+	p, _ := m.GetRecurring(1)
+	p.WalletID, p.Status, p.Actor = walletID, status, client
+	return []recurring.Payment{*p}, nil
+}
+
+func (m *MockDb) UpdateRecurring(p recurring.Payment) error {
+	return nil
+}
+
+func (m *MockDb) CancelRecurring(id int64, at int64) (*recurring.Payment, error) {
+	p, err := m.GetRecurring(id)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status != recurring.Active {
+		return nil, recurring.NotActiveErr
+	}
+	p.Status, p.Updated = recurring.Canceled, at
+	return p, nil
+}
+
+func (m *MockDb) CreatePlan(p subscription.Plan) (int64, error) {
+	return 1, nil
+}
+
+// GetPlan knows monthly plans 1 for 100 and 2 for 1000 with the retry in a day and the grace period of 3 days
+func (m *MockDb) GetPlan(id int64) (*subscription.Plan, error) {
+	p := subscription.Plan{
+		ID:            id,
+		Name:          "test",
+		Price:         decimal.NewFromInt(100),
+		Period:        recurring.Rule{Frequency: recurring.Monthly, Interval: 1},
+		GracePeriod:   3 * 24 * 3600,
+		RetrySchedule: []int64{24 * 3600},
+		Created:       1,
+	}
+	switch id {
+	case 1:
+	case 2:
+		p.Price = decimal.NewFromInt(1000)
+	default:
+		return nil, database.PlanDoesNotExistErr
+	}
+	return &p, nil
+}
+
+func (m *MockDb) ListPlans(limit int) ([]subscription.Plan, error) {
+	p, _ := m.GetPlan(1)
+	return []subscription.Plan{*p}, nil
+}
+
+func (m *MockDb) CreateSubscription(s subscription.Subscription) (int64, error) {
+	return 1, nil
+}
+
+// GetSubscription knows due subscriptions of wallet 123 to plan 1 (1) and plan 2 (2), canceled subscription 3
+// and due subscription 5 of shop/alice to plan 1
+func (m *MockDb) GetSubscription(id int64) (*subscription.Subscription, error) {
+	due := time.Now().Add(-time.Minute).Unix()
+	s := subscription.Subscription{ID: id, PlanID: id, WalletID: 123, Status: subscription.Active, StartAt: due, DueAt: due, NextChargeAt: due, Actor: audit.Anonymous, Created: 1, Updated: 1}
+	switch id {
+	case 1, 2:
+	case 3:
+		s.PlanID, s.Status, s.NextChargeAt = 1, subscription.Canceled, 0
+	case 5:
+		s.PlanID, s.Actor = 1, "shop/alice"
+	default:
+		return nil, database.SubscriptionDoesNotExistErr
+	}
+	return &s, nil
+}
+
+func (m *MockDb) ListSubscriptions(client string, walletID int, status subscription.Status, limit int) ([]subscription.Subscription, error) {
+	s, _ := m.GetSubscription(1)
+	s.WalletID, s.Status, s.Actor = walletID, status, client
+	return []subscription.Subscription{*s}, nil
+}
+
+func (m *MockDb) LockSubscription(id int64) (*subscription.Subscription, error) {
+	s, err := m.GetSubscription(id)
+	if err != nil || s.Status == subscription.Canceled {
+		return nil, nil
+	}
+	return s, nil
+}
+
+func (m *MockDb) SaveCharge(s subscription.Subscription) error {
+	return nil
+}
+
+func (m *MockDb) CancelSubscription(id int64, at int64) (*subscription.Subscription, error) {
+	s, err := m.GetSubscription(id)
+	if err != nil {
+		return nil, err
+	}
+	if s.Status == subscription.Canceled {
+		return nil, subscription.CanceledErr
+	}
+	s.Status, s.NextChargeAt, s.Updated = subscription.Canceled, 0, at
+	return s, nil
+}
+
+func (m *MockDb) CreateWebhook(w webhook.Webhook) (int64, error) {
+	return 1, nil
+}
+
+func (m *MockDb) ListWebhooks() ([]webhook.Webhook, error) {
+	return []webhook.Webhook{{ID: 1, URL: "https://example.com/hook", Created: 1}}, nil
+}
+
+// DeleteWebhook knows only webhook 1
+func (m *MockDb) DeleteWebhook(id int64) error {
+	if id != 1 {
+		return database.WebhookDoesNotExistErr
+	}
+	return nil
+}
+
+// ListDeliveries returns one delivery of webhook 1 with the requested status
+func (m *MockDb) ListDeliveries(webhookID int64, status webhook.Status, limit int) ([]webhook.Delivery, error) {
+	if webhookID != 1 {
+		return nil, database.WebhookDoesNotExistErr
+	}
+	return []webhook.Delivery{{ID: 1, WebhookID: 1, Event: webhook.Event{ID: 1, Type: webhook.BalanceChanged}, Status: status}}, nil
+}
+
+// ReplayDelivery knows only delivery 1
+func (m *MockDb) ReplayDelivery(id int64, at int64) error {
+	if id != 1 {
+		return database.DeliveryDoesNotExistErr
+	}
+	return nil
+}
+
+// ReplayDeadDeliveries reports that webhook 1 has 2 dead deliveries
+func (m *MockDb) ReplayDeadDeliveries(webhookID int64, at int64) (int64, error) {
+	if webhookID != 1 {
+		return 0, database.WebhookDoesNotExistErr
+	}
+	return 2, nil
+}
@@ -0,0 +1,40 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/KseniiaSalmina/Balance/internal/ratelimit"
+)
+
+// UpdateBucket locks the rate limit bucket by key, applies fn to it and saves the result
+func (db *DB) UpdateBucket(key string, fn func(b *ratelimit.Bucket)) error {
+	tx, err := db.db.Begin()
+	if err != nil {
+		return fmt.Errorf("UpdateBucket -> %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(`INSERT INTO rate_limits (key) VALUES ($1) ON CONFLICT (key) DO NOTHING`, key); err != nil {
+		return fmt.Errorf("UpdateBucket -> %w", err)
+	}
+
+	var tokens float64
+	var updated int64
+	if err = tx.QueryRow(`SELECT tokens, updated_at FROM rate_limits WHERE key = $1 FOR UPDATE`, key).Scan(&tokens, &updated); err != nil {
+		return fmt.Errorf("UpdateBucket -> %w", err)
+	}
+
+	b := ratelimit.Bucket{Tokens: tokens}
+	if updated != 0 {
+		b.Updated = time.Unix(0, updated)
+	}
+
+	fn(&b)
+
+	if _, err = tx.Exec(`UPDATE rate_limits SET tokens = $1, updated_at = $2 WHERE key = $3`, b.Tokens, b.Updated.UnixNano(), key); err != nil {
+		return fmt.Errorf("UpdateBucket -> %w", err)
+	}
+
+	return tx.Commit()
+}
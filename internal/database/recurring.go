@@ -0,0 +1,119 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx"
+
+	"github.com/KseniiaSalmina/Balance/internal/recurring"
+)
+
+const recurringColumns = `id, wallet_id, to_wallet, amount, frequency, interval_count, cron, start_at, end_at, max_occurrences, on_failure, max_retries, retry_interval,
+	status, occurrences, retries, next_at, retry_at, scheduled_id, actor, created_at, updated_at`
+
+func (t *Transaction) CreateRecurring(p recurring.Payment) (int64, error) {
+	var id int64
+	err := t.queryRow(`INSERT INTO recurring_payments (wallet_id, to_wallet, amount, frequency, interval_count, cron, start_at, end_at, max_occurrences, on_failure, max_retries, retry_interval,
+		status, occurrences, retries, next_at, retry_at, scheduled_id, actor, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21) RETURNING id`,
+		p.WalletID, p.To, p.Amount, p.Frequency, p.Interval, p.Cron, p.StartAt, p.EndAt, p.MaxOccurrences, p.OnFailure, p.MaxRetries, p.RetryInterval,
+		p.Status, p.Occurrences, p.Retries, p.NextAt, p.RetryAt, p.ScheduledID, p.Actor, p.Created, p.Updated).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("CreateRecurring -> %w", err)
+	}
+	return id, nil
+}
+
+func (t *Transaction) GetRecurring(id int64) (*recurring.Payment, error) {
+	p, err := scanRecurring(t.queryRow(`SELECT `+recurringColumns+` FROM recurring_payments WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, RecurringDoesNotExistErr
+		}
+		return nil, fmt.Errorf("GetRecurring -> %w", err)
+	}
+	return &p, nil
+}
+
+// LockRecurring returns the payment locked until the end of the transaction
+func (t *Transaction) LockRecurring(id int64) (*recurring.Payment, error) {
+	p, err := scanRecurring(t.queryRow(`SELECT `+recurringColumns+` FROM recurring_payments WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, RecurringDoesNotExistErr
+		}
+		return nil, fmt.Errorf("LockRecurring -> %w", err)
+	}
+	return &p, nil
+}
+
+// ListRecurring returns the payments of the wallet created with the API key of the client with the status, newest first
+func (t *Transaction) ListRecurring(client string, walletID int, status recurring.Status, limit int) ([]recurring.Payment, error) {
+	rows, err := t.query(`SELECT `+recurringColumns+` FROM recurring_payments WHERE wallet_id = $1 AND status = $2 AND split_part(actor, '/', 1) = $3
+		ORDER BY id DESC LIMIT $4`,
+		walletID, status, client, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ListRecurring -> %w", err)
+	}
+	defer rows.Close()
+
+	payments := make([]recurring.Payment, 0)
+	for rows.Next() {
+		p, err := scanRecurring(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ListRecurring -> %w", err)
+		}
+		payments = append(payments, p)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListRecurring -> %w", err)
+	}
+	return payments, nil
+}
+
+// UpdateRecurring saves the progress of the payment
+func (t *Transaction) UpdateRecurring(p recurring.Payment) error {
+	_, err := t.exec(`UPDATE recurring_payments SET status = $1, occurrences = $2, retries = $3, next_at = $4, retry_at = $5, scheduled_id = $6, updated_at = $7 WHERE id = $8`,
+		p.Status, p.Occurrences, p.Retries, p.NextAt, p.RetryAt, p.ScheduledID, p.Updated, p.ID)
+	if err != nil {
+		return fmt.Errorf("UpdateRecurring -> %w", err)
+	}
+	return nil
+}
+
+// CancelRecurring cancels the payment and its pending occurrence. The occurrence that is being executed is not waited for
+// and is finished, the next one is not scheduled
+func (t *Transaction) CancelRecurring(id int64, at int64) (*recurring.Payment, error) {
+	p, err := t.LockRecurring(id)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status != recurring.Active {
+		return nil, recurring.NotActiveErr
+	}
+
+	p.Status, p.Updated = recurring.Canceled, at
+	if err = t.UpdateRecurring(*p); err != nil {
+		return nil, fmt.Errorf("CancelRecurring -> %w", err)
+	}
+
+	//the occurrence is locked before the payment by its execution, so it is skipped to avoid a deadlock
+	_, err = t.exec(`UPDATE scheduled_operations SET status = 'canceled', updated_at = $1
+		WHERE id IN (SELECT id FROM scheduled_operations WHERE recurring_id = $2 AND status = 'scheduled' FOR UPDATE SKIP LOCKED)`, at, id)
+	if err != nil {
+		return nil, fmt.Errorf("CancelRecurring -> %w", err)
+	}
+	return p, nil
+}
+
+func scanRecurring(row scanner) (recurring.Payment, error) {
+	var p recurring.Payment
+	var frequency, onFailure, status string
+	err := row.Scan(&p.ID, &p.WalletID, &p.To, &p.Amount, &frequency, &p.Interval, &p.Cron, &p.StartAt, &p.EndAt, &p.MaxOccurrences, &onFailure, &p.MaxRetries, &p.RetryInterval,
+		&status, &p.Occurrences, &p.Retries, &p.NextAt, &p.RetryAt, &p.ScheduledID, &p.Actor, &p.Created, &p.Updated)
+	if err != nil {
+		return recurring.Payment{}, err
+	}
+	p.Frequency, p.OnFailure, p.Status = recurring.Frequency(frequency), recurring.Policy(onFailure), recurring.Status(status)
+	return p, nil
+}
@@ -0,0 +1,138 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx"
+	"github.com/shopspring/decimal"
+
+	"github.com/KseniiaSalmina/Balance/internal/risk"
+	"github.com/KseniiaSalmina/Balance/internal/wallet"
+)
+
+// TransfersFrom returns outgoing transfers of the wallet made since the unix time
+func (t *Transaction) TransfersFrom(id int, since int64) ([]risk.Transfer, error) {
+	rows, err := t.query(`SELECT h.counterparty, h.amount, h.date, b.created_at FROM history h JOIN balances b ON b.id = h.counterparty
+		WHERE h.wallet_id = $1 AND h.option = $2 AND h.date >= $3`, id, wallet.Withdrawal, since)
+	if err != nil {
+		return nil, fmt.Errorf("TransfersFrom -> %w", err)
+	}
+	defer rows.Close()
+
+	transfers := make([]risk.Transfer, 0)
+	for rows.Next() {
+		var tr risk.Transfer
+		if err = rows.Scan(&tr.To, &tr.Amount, &tr.Date, &tr.RecipientCreated); err != nil {
+			return nil, fmt.Errorf("TransfersFrom -> %w", err)
+		}
+		transfers = append(transfers, tr)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("TransfersFrom -> %w", err)
+	}
+
+	return transfers, nil
+}
+
+// WalletCreatedAt returns unix time when the wallet was created and false if it does not exist
+func (t *Transaction) WalletCreatedAt(id int) (int64, bool, error) {
+	var created int64
+	if err := t.queryRow(`SELECT created_at FROM balances WHERE id = $1`, id).Scan(&created); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("WalletCreatedAt -> %w", err)
+	}
+	return created, true, nil
+}
+
+// AverageWithdrawal returns the average amount and the number of withdrawals and outgoing transfers made since the unix time,
+// fees and overdraft interest are not included
+func (t *Transaction) AverageWithdrawal(id int, since int64) (decimal.Decimal, int, error) {
+	var avg decimal.Decimal
+	var count int
+	err := t.queryRow(`SELECT COALESCE(AVG(amount), 0), COUNT(*) FROM history WHERE wallet_id = $1 AND option = $2 AND date >= $3`, id, wallet.Withdrawal, since).Scan(&avg, &count)
+	if err != nil {
+		return decimal.Zero, 0, fmt.Errorf("AverageWithdrawal -> %w", err)
+	}
+	return avg, count, nil
+}
+
+func (t *Transaction) CreateReview(r risk.Review) (int64, error) {
+	hits, err := json.Marshal(r.Hits)
+	if err != nil {
+		return 0, fmt.Errorf("CreateReview -> %w", err)
+	}
+
+	var id int64
+	err = t.queryRow(`INSERT INTO risk_reviews (created_at, kind, wallet_id, to_wallet, amount, description, actor, hits, status) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`,
+		r.Created, r.Operation.Kind, r.Operation.WalletID, r.Operation.To, r.Operation.Amount, r.Description, r.Actor, string(hits), r.Status).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("CreateReview -> %w", err)
+	}
+	return id, nil
+}
+
+const reviewColumns = `id, created_at, kind, wallet_id, to_wallet, amount, description, actor, hits, status, resolved_by, resolved_at`
+
+// GetReview returns the review locked until the end of the transaction
+func (t *Transaction) GetReview(id int64) (*risk.Review, error) {
+	r, err := scanReview(t.queryRow(`SELECT `+reviewColumns+` FROM risk_reviews WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ReviewDoesNotExistErr
+		}
+		return nil, fmt.Errorf("GetReview -> %w", err)
+	}
+	return r, nil
+}
+
+func (t *Transaction) ListReviews(status risk.ReviewStatus, limit int) ([]risk.Review, error) {
+	rows, err := t.query(`SELECT `+reviewColumns+` FROM risk_reviews WHERE status = $1 ORDER BY id LIMIT $2`, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ListReviews -> %w", err)
+	}
+	defer rows.Close()
+
+	reviews := make([]risk.Review, 0)
+	for rows.Next() {
+		r, err := scanReview(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ListReviews -> %w", err)
+		}
+		reviews = append(reviews, *r)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListReviews -> %w", err)
+	}
+
+	return reviews, nil
+}
+
+func (t *Transaction) ResolveReview(id int64, status risk.ReviewStatus, by string, at int64) error {
+	_, err := t.exec(`UPDATE risk_reviews SET status = $1, resolved_by = $2, resolved_at = $3 WHERE id = $4`, status, by, at, id)
+	if err != nil {
+		return fmt.Errorf("ResolveReview -> %w", err)
+	}
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanReview(row scanner) (*risk.Review, error) {
+	var r risk.Review
+	var kind, status, hits string
+	err := row.Scan(&r.ID, &r.Created, &kind, &r.Operation.WalletID, &r.Operation.To, &r.Operation.Amount, &r.Description, &r.Actor, &hits, &status, &r.ResolvedBy, &r.Resolved)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Operation.Kind, r.Status = risk.Kind(kind), risk.ReviewStatus(status)
+	if err = json.Unmarshal([]byte(hits), &r.Hits); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
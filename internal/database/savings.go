@@ -0,0 +1,152 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx"
+	"github.com/jackc/pgx/pgtype"
+	"github.com/shopspring/decimal"
+	"time"
+
+	"github.com/KseniiaSalmina/Balance/internal/limits"
+	"github.com/KseniiaSalmina/Balance/internal/savings"
+)
+
+func (t *Transaction) GetSavings(id int) (savings.Account, error) {
+	var a savings.Account
+	if err := t.queryRow(`SELECT savings_product, savings_rate, savings_since FROM balances WHERE id = $1`, id).Scan(&a.Product, &a.Rate, &a.Since); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return savings.Account{}, UserDoesNotExistErr
+		}
+		return savings.Account{}, fmt.Errorf("GetSavings -> %w", err)
+	}
+	return a, nil
+}
+
+func (t *Transaction) SetSavings(id int, a savings.Account) error {
+	tag, err := t.exec(`UPDATE balances SET savings_product = $2, savings_rate = $3, savings_since = $4 WHERE id = $1`, id, a.Product, a.Rate, a.Since)
+	if err != nil {
+		return fmt.Errorf("SetSavings -> %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return UserDoesNotExistErr
+	}
+	return nil
+}
+
+// LastInterestAccrual returns the last day the interest of the wallet is accrued for, false if it was never accrued
+func (t *Transaction) LastInterestAccrual(id int) (int64, bool, error) {
+	var day pgtype.Int8
+	if err := t.queryRow(`SELECT MAX(day) FROM interest_accruals WHERE wallet_id = $1`, id).Scan(&day); err != nil {
+		return 0, false, fmt.Errorf("LastInterestAccrual -> %w", err)
+	}
+	return day.Int, day.Status == pgtype.Present, nil
+}
+
+// AddInterestAccrual saves the interest accrued for the day, returns false if the day is already accrued
+func (t *Transaction) AddInterestAccrual(id int, a savings.Accrual) (bool, error) {
+	tag, err := t.exec(`INSERT INTO interest_accruals (wallet_id, day, balance, rate, amount, created_at) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (wallet_id, day) DO NOTHING`,
+		id, a.Day, a.Balance, a.Rate, a.Amount, time.Now().Unix())
+	if err != nil {
+		return false, fmt.Errorf("AddInterestAccrual -> %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// InterestTotals returns the interest ever accrued for the wallet and posted to its balance
+func (t *Transaction) InterestTotals(id int) (decimal.Decimal, decimal.Decimal, error) {
+	var accrued, capitalized decimal.Decimal
+	err := t.queryRow(`SELECT (SELECT COALESCE(SUM(amount), 0) FROM interest_accruals WHERE wallet_id = $1),
+		(SELECT COALESCE(SUM(amount), 0) FROM interest_capitalizations WHERE wallet_id = $1)`, id).Scan(&accrued, &capitalized)
+	if err != nil {
+		return decimal.Zero, decimal.Zero, fmt.Errorf("InterestTotals -> %w", err)
+	}
+	return accrued, capitalized, nil
+}
+
+// AddCapitalization saves the interest posted for the month, the month can be capitalized once
+func (t *Transaction) AddCapitalization(id int, c savings.Capitalization) error {
+	_, err := t.exec(`INSERT INTO interest_capitalizations (wallet_id, month, amount, history_id, created_at) VALUES ($1, $2, $3, $4, $5)`,
+		id, c.Month, c.Amount, c.HistoryID, c.Date)
+	if err != nil {
+		return fmt.Errorf("AddCapitalization -> %w", err)
+	}
+	return nil
+}
+
+// ListAccruals returns the accruals for the days starting in [from, to) in the order of the days
+func (t *Transaction) ListAccruals(id int, from, to int64) ([]savings.Accrual, error) {
+	rows, err := t.query(`SELECT day, balance, rate, amount FROM interest_accruals WHERE wallet_id = $1 AND day >= $2 AND day < $3 ORDER BY day`, id, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("ListAccruals -> %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]savings.Accrual, 0)
+	for rows.Next() {
+		var a savings.Accrual
+		if err = rows.Scan(&a.Day, &a.Balance, &a.Rate, &a.Amount); err != nil {
+			return nil, fmt.Errorf("ListAccruals -> %w", err)
+		}
+		list = append(list, a)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListAccruals -> %w", err)
+	}
+	return list, nil
+}
+
+// ListCapitalizations returns the capitalizations for the months from fromMonth to toMonth inclusive in the order of the months
+func (t *Transaction) ListCapitalizations(id int, fromMonth, toMonth string) ([]savings.Capitalization, error) {
+	rows, err := t.query(`SELECT month, amount, history_id, created_at FROM interest_capitalizations WHERE wallet_id = $1 AND month >= $2 AND month <= $3 ORDER BY month`,
+		id, fromMonth, toMonth)
+	if err != nil {
+		return nil, fmt.Errorf("ListCapitalizations -> %w", err)
+	}
+	defer rows.Close()
+
+	list := make([]savings.Capitalization, 0)
+	for rows.Next() {
+		var c savings.Capitalization
+		if err = rows.Scan(&c.Month, &c.Amount, &c.HistoryID, &c.Date); err != nil {
+			return nil, fmt.Errorf("ListCapitalizations -> %w", err)
+		}
+		list = append(list, c)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListCapitalizations -> %w", err)
+	}
+	return list, nil
+}
+
+// ClaimInterestAccruals returns the wallets with the enabled savings that are not accrued for the last finished day.
+// The wallets are postponed until lease, the locked ones are skipped
+func (db *DB) ClaimInterestAccruals(now, lease time.Time, limit int) ([]int64, error) {
+	day := limits.DayStart(now).AddDate(0, 0, -1).Unix()
+	rows, err := db.db.Query(`WITH claimed AS (UPDATE balances SET interest_claimed_until = $4
+			WHERE id IN (SELECT id FROM balances b WHERE (savings_product <> '' OR savings_rate IS NOT NULL) AND savings_since <= $1
+				AND interest_claimed_until <= $3
+				AND NOT EXISTS (SELECT 1 FROM interest_accruals a WHERE a.wallet_id = b.id AND a.day = $1)
+				ORDER BY id LIMIT $2 FOR UPDATE SKIP LOCKED)
+			RETURNING id)
+		SELECT id FROM claimed ORDER BY id`,
+		day, limit, now.Unix(), lease.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("ClaimInterestAccruals -> %w", err)
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("ClaimInterestAccruals -> %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ClaimInterestAccruals -> %w", err)
+	}
+	return ids, nil
+}
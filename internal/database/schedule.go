@@ -0,0 +1,139 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx"
+	"time"
+
+	"github.com/KseniiaSalmina/Balance/internal/risk"
+	"github.com/KseniiaSalmina/Balance/internal/schedule"
+)
+
+const scheduledColumns = `id, kind, wallet_id, to_wallet, amount, description, execute_at, status, review_id, error, recurring_id, actor, created_at, updated_at`
+
+func (t *Transaction) CreateScheduled(op schedule.Operation) (int64, error) {
+	var id int64
+	err := t.queryRow(`INSERT INTO scheduled_operations (kind, wallet_id, to_wallet, amount, description, execute_at, status, recurring_id, actor, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id`,
+		op.Type, op.WalletID, op.To, op.Amount, op.Description, op.ExecuteAt, op.Status, op.RecurringID, op.Actor, op.Created, op.Updated).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("CreateScheduled -> %w", err)
+	}
+	return id, nil
+}
+
+func (t *Transaction) GetScheduled(id int64) (*schedule.Operation, error) {
+	op, err := scanScheduled(t.queryRow(`SELECT `+scheduledColumns+` FROM scheduled_operations WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ScheduledDoesNotExistErr
+		}
+		return nil, fmt.Errorf("GetScheduled -> %w", err)
+	}
+	return &op, nil
+}
+
+// ListScheduled returns the operations of the wallet scheduled with the API key of the client with the status by the execution time
+func (t *Transaction) ListScheduled(client string, walletID int, status schedule.Status, limit int) ([]schedule.Operation, error) {
+	rows, err := t.query(`SELECT `+scheduledColumns+` FROM scheduled_operations WHERE wallet_id = $1 AND status = $2 AND split_part(actor, '/', 1) = $3
+		ORDER BY execute_at, id LIMIT $4`,
+		walletID, status, client, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ListScheduled -> %w", err)
+	}
+	defer rows.Close()
+
+	ops := make([]schedule.Operation, 0)
+	for rows.Next() {
+		op, err := scanScheduled(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ListScheduled -> %w", err)
+		}
+		ops = append(ops, op)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListScheduled -> %w", err)
+	}
+	return ops, nil
+}
+
+// CancelScheduled waits for the operation to be executed if it is in progress, so it is not canceled after the execution
+func (t *Transaction) CancelScheduled(id int64, at int64) (*schedule.Operation, error) {
+	op, err := scanScheduled(t.queryRow(`SELECT `+scheduledColumns+` FROM scheduled_operations WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ScheduledDoesNotExistErr
+		}
+		return nil, fmt.Errorf("CancelScheduled -> %w", err)
+	}
+	if op.Status != schedule.Scheduled {
+		return nil, schedule.NotScheduledErr
+	}
+
+	op.Status, op.Updated = schedule.Canceled, at
+	if _, err = t.exec(`UPDATE scheduled_operations SET status = $1, updated_at = $2 WHERE id = $3`, op.Status, at, id); err != nil {
+		return nil, fmt.Errorf("CancelScheduled -> %w", err)
+	}
+	return &op, nil
+}
+
+// LockScheduled returns the operation locked until the end of the transaction, nil if it is not scheduled anymore
+// or is being executed by another transaction
+func (t *Transaction) LockScheduled(id int64) (*schedule.Operation, error) {
+	op, err := scanScheduled(t.queryRow(`SELECT `+scheduledColumns+` FROM scheduled_operations WHERE id = $1 AND status = 'scheduled' FOR UPDATE SKIP LOCKED`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("LockScheduled -> %w", err)
+	}
+	return &op, nil
+}
+
+// FinishScheduled saves the result of the execution
+func (t *Transaction) FinishScheduled(op schedule.Operation) error {
+	_, err := t.exec(`UPDATE scheduled_operations SET status = $1, review_id = $2, error = $3, updated_at = $4 WHERE id = $5`,
+		op.Status, op.ReviewID, op.Error, op.Updated, op.ID)
+	if err != nil {
+		return fmt.Errorf("FinishScheduled -> %w", err)
+	}
+	return nil
+}
+
+// ClaimScheduled returns the due operations and postpones them until lease, the locked ones are skipped
+func (db *DB) ClaimScheduled(now, lease time.Time, limit int) ([]int64, error) {
+	rows, err := db.db.Query(`WITH claimed AS (UPDATE scheduled_operations SET claimed_until = $2
+			WHERE id IN (SELECT id FROM scheduled_operations WHERE status = 'scheduled' AND execute_at <= $1 AND claimed_until <= $1
+				ORDER BY execute_at, id LIMIT $3 FOR UPDATE SKIP LOCKED)
+			RETURNING id, execute_at)
+		SELECT id FROM claimed ORDER BY execute_at, id`, now.Unix(), lease.Unix(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("ClaimScheduled -> %w", err)
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("ClaimScheduled -> %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ClaimScheduled -> %w", err)
+	}
+	return ids, nil
+}
+
+func scanScheduled(row scanner) (schedule.Operation, error) {
+	var op schedule.Operation
+	var kind, status string
+	err := row.Scan(&op.ID, &kind, &op.WalletID, &op.To, &op.Amount, &op.Description, &op.ExecuteAt, &status, &op.ReviewID, &op.Error, &op.RecurringID, &op.Actor, &op.Created, &op.Updated)
+	if err != nil {
+		return schedule.Operation{}, err
+	}
+	op.Type, op.Status = risk.Kind(kind), schedule.Status(status)
+	return op, nil
+}
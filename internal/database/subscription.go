@@ -0,0 +1,210 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx"
+	"time"
+
+	"github.com/KseniiaSalmina/Balance/internal/recurring"
+	"github.com/KseniiaSalmina/Balance/internal/subscription"
+)
+
+const (
+	planColumns         = `id, name, price, frequency, interval_count, grace_period, retry_schedule, created_at`
+	subscriptionColumns = `id, plan_id, wallet_id, status, start_at, period_start, period_end, due_at, next_charge_at, attempts, grace_until, last_error,
+	actor, created_at, updated_at`
+)
+
+func (t *Transaction) CreatePlan(p subscription.Plan) (int64, error) {
+	retries, err := json.Marshal(p.RetrySchedule)
+	if err != nil {
+		return 0, fmt.Errorf("CreatePlan -> %w", err)
+	}
+
+	var id int64
+	err = t.queryRow(`INSERT INTO plans (name, price, frequency, interval_count, grace_period, retry_schedule, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+		p.Name, p.Price, p.Period.Frequency, p.Period.Interval, p.GracePeriod, string(retries), p.Created).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("CreatePlan -> %w", err)
+	}
+	return id, nil
+}
+
+func (t *Transaction) GetPlan(id int64) (*subscription.Plan, error) {
+	p, err := scanPlan(t.queryRow(`SELECT `+planColumns+` FROM plans WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, PlanDoesNotExistErr
+		}
+		return nil, fmt.Errorf("GetPlan -> %w", err)
+	}
+	return &p, nil
+}
+
+// ListPlans returns the plans, newest first
+func (t *Transaction) ListPlans(limit int) ([]subscription.Plan, error) {
+	rows, err := t.query(`SELECT `+planColumns+` FROM plans ORDER BY id DESC LIMIT $1`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ListPlans -> %w", err)
+	}
+	defer rows.Close()
+
+	plans := make([]subscription.Plan, 0)
+	for rows.Next() {
+		p, err := scanPlan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ListPlans -> %w", err)
+		}
+		plans = append(plans, p)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListPlans -> %w", err)
+	}
+	return plans, nil
+}
+
+func (t *Transaction) CreateSubscription(s subscription.Subscription) (int64, error) {
+	var id int64
+	err := t.queryRow(`INSERT INTO subscriptions (plan_id, wallet_id, status, start_at, period_start, period_end, due_at, next_charge_at, attempts, grace_until, last_error,
+		actor, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING id`,
+		s.PlanID, s.WalletID, s.Status, s.StartAt, s.PeriodStart, s.PeriodEnd, s.DueAt, s.NextChargeAt, s.Attempts, s.GraceUntil, s.LastError,
+		s.Actor, s.Created, s.Updated).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("CreateSubscription -> %w", err)
+	}
+	return id, nil
+}
+
+func (t *Transaction) GetSubscription(id int64) (*subscription.Subscription, error) {
+	s, err := scanSubscription(t.queryRow(`SELECT `+subscriptionColumns+` FROM subscriptions WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, SubscriptionDoesNotExistErr
+		}
+		return nil, fmt.Errorf("GetSubscription -> %w", err)
+	}
+	return &s, nil
+}
+
+// ListSubscriptions returns the subscriptions of the wallet created with the API key of the client with the status, newest first
+func (t *Transaction) ListSubscriptions(client string, walletID int, status subscription.Status, limit int) ([]subscription.Subscription, error) {
+	rows, err := t.query(`SELECT `+subscriptionColumns+` FROM subscriptions WHERE wallet_id = $1 AND status = $2 AND split_part(actor, '/', 1) = $3
+		ORDER BY id DESC LIMIT $4`,
+		walletID, status, client, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ListSubscriptions -> %w", err)
+	}
+	defer rows.Close()
+
+	subs := make([]subscription.Subscription, 0)
+	for rows.Next() {
+		s, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ListSubscriptions -> %w", err)
+		}
+		subs = append(subs, s)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListSubscriptions -> %w", err)
+	}
+	return subs, nil
+}
+
+// LockSubscription returns the active or past due subscription locked until the end of the transaction,
+// nil if it is not charged anymore or is being charged by another transaction
+func (t *Transaction) LockSubscription(id int64) (*subscription.Subscription, error) {
+	s, err := scanSubscription(t.queryRow(`SELECT `+subscriptionColumns+` FROM subscriptions WHERE id = $1 AND status IN ('active', 'past_due') FOR UPDATE SKIP LOCKED`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("LockSubscription -> %w", err)
+	}
+	return &s, nil
+}
+
+// SaveCharge saves the result of the charge attempt
+func (t *Transaction) SaveCharge(s subscription.Subscription) error {
+	_, err := t.exec(`UPDATE subscriptions SET status = $1, period_start = $2, period_end = $3, due_at = $4, next_charge_at = $5, attempts = $6, grace_until = $7,
+		last_error = $8, updated_at = $9 WHERE id = $10`,
+		s.Status, s.PeriodStart, s.PeriodEnd, s.DueAt, s.NextChargeAt, s.Attempts, s.GraceUntil, s.LastError, s.Updated, s.ID)
+	if err != nil {
+		return fmt.Errorf("SaveCharge -> %w", err)
+	}
+	return nil
+}
+
+// CancelSubscription waits for the charge in progress, the paid period is kept
+func (t *Transaction) CancelSubscription(id int64, at int64) (*subscription.Subscription, error) {
+	s, err := scanSubscription(t.queryRow(`SELECT `+subscriptionColumns+` FROM subscriptions WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, SubscriptionDoesNotExistErr
+		}
+		return nil, fmt.Errorf("CancelSubscription -> %w", err)
+	}
+	if s.Status == subscription.Canceled {
+		return nil, subscription.CanceledErr
+	}
+
+	s.Status, s.NextChargeAt, s.Updated = subscription.Canceled, 0, at
+	if _, err = t.exec(`UPDATE subscriptions SET status = $1, next_charge_at = 0, updated_at = $2 WHERE id = $3`, s.Status, at, id); err != nil {
+		return nil, fmt.Errorf("CancelSubscription -> %w", err)
+	}
+	return &s, nil
+}
+
+// ClaimSubscriptions returns the subscriptions to charge and postpones them until lease, the locked ones are skipped
+func (db *DB) ClaimSubscriptions(now, lease time.Time, limit int) ([]int64, error) {
+	rows, err := db.db.Query(`WITH claimed AS (UPDATE subscriptions SET claimed_until = $2
+			WHERE id IN (SELECT id FROM subscriptions WHERE status IN ('active', 'past_due') AND next_charge_at <= $1 AND claimed_until <= $1
+				ORDER BY next_charge_at, id LIMIT $3 FOR UPDATE SKIP LOCKED)
+			RETURNING id, next_charge_at)
+		SELECT id FROM claimed ORDER BY next_charge_at, id`,
+		now.Unix(), lease.Unix(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("ClaimSubscriptions -> %w", err)
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("ClaimSubscriptions -> %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ClaimSubscriptions -> %w", err)
+	}
+	return ids, nil
+}
+
+func scanPlan(row scanner) (subscription.Plan, error) {
+	var p subscription.Plan
+	var frequency, retries string
+	err := row.Scan(&p.ID, &p.Name, &p.Price, &frequency, &p.Period.Interval, &p.GracePeriod, &retries, &p.Created)
+	if err != nil {
+		return subscription.Plan{}, err
+	}
+	p.Period.Frequency = recurring.Frequency(frequency)
+	if err = json.Unmarshal([]byte(retries), &p.RetrySchedule); err != nil {
+		return subscription.Plan{}, err
+	}
+	return p, nil
+}
+
+func scanSubscription(row scanner) (subscription.Subscription, error) {
+	var s subscription.Subscription
+	var status string
+	err := row.Scan(&s.ID, &s.PlanID, &s.WalletID, &status, &s.StartAt, &s.PeriodStart, &s.PeriodEnd, &s.DueAt, &s.NextChargeAt, &s.Attempts, &s.GraceUntil, &s.LastError,
+		&s.Actor, &s.Created, &s.Updated)
+	if err != nil {
+		return subscription.Subscription{}, err
+	}
+	s.Status = subscription.Status(status)
+	return s, nil
+}
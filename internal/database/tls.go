@@ -0,0 +1,98 @@
+package database
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/jackc/pgx"
+
+	"github.com/KseniiaSalmina/Balance/internal/config"
+)
+
+// configureTLS sets the TLS settings of the connection by sslmode the same way libpq does
+func configureTLS(cfg config.Postgres, conn *pgx.ConnConfig) error {
+	var tlsConfig *tls.Config
+	switch cfg.SSLMode {
+	case "disable":
+		return nil
+	case "allow":
+		conn.UseFallbackTLS = true
+		conn.FallbackTLSConfig = &tls.Config{InsecureSkipVerify: true}
+		tlsConfig = conn.FallbackTLSConfig
+	case "prefer", "":
+		conn.TLSConfig = &tls.Config{InsecureSkipVerify: true}
+		conn.UseFallbackTLS = true
+		tlsConfig = conn.TLSConfig
+	case "require":
+		conn.TLSConfig = &tls.Config{InsecureSkipVerify: true}
+		tlsConfig = conn.TLSConfig
+	case "verify-ca", "verify-full":
+		roots, err := loadCertPool(cfg.SSLRootCert)
+		if err != nil {
+			return err
+		}
+
+		conn.TLSConfig = &tls.Config{RootCAs: roots, ServerName: conn.Host}
+		if cfg.SSLMode == "verify-ca" {
+			//the chain is verified without the host name
+			conn.TLSConfig.InsecureSkipVerify = true
+			conn.TLSConfig.VerifyPeerCertificate = verifyChain(roots)
+		}
+		tlsConfig = conn.TLSConfig
+	default:
+		return fmt.Errorf("unknown sslmode %q", cfg.SSLMode)
+	}
+
+	if cfg.SSLCert != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.SSLCert, cfg.SSLKey)
+		if err != nil {
+			return fmt.Errorf("configureTLS -> %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return nil
+}
+
+func loadCertPool(file string) (*x509.CertPool, error) {
+	if file == "" {
+		return x509.SystemCertPool()
+	}
+
+	pem, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("loadCertPool -> %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", file)
+	}
+	return pool, nil
+}
+
+func verifyChain(roots *x509.CertPool) func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("server did not present a certificate")
+		}
+
+		certs := make([]*x509.Certificate, len(rawCerts))
+		for i, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return fmt.Errorf("verifyChain -> %w", err)
+			}
+			certs[i] = cert
+		}
+
+		intermediates := x509.NewCertPool()
+		for _, cert := range certs[1:] {
+			intermediates.AddCert(cert)
+		}
+		_, err := certs[0].Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates})
+		return err
+	}
+}
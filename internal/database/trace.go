@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/KseniiaSalmina/Balance/internal/tracing"
+)
+
+// context returns the context of the transaction span
+func (t *Transaction) context() context.Context {
+	if t.ctx == nil {
+		return context.Background()
+	}
+	return t.ctx
+}
+
+// tracedQuery is a span and a debug log record of one SQL statement
+type tracedQuery struct {
+	ctx       context.Context
+	span      trace.Span
+	operation string
+	start     time.Time
+}
+
+// startQuery starts a span for the SQL statement named by its operation, e.g. SELECT
+func (t *Transaction) startQuery(sql string) *tracedQuery {
+	operation, _, _ := strings.Cut(strings.TrimSpace(sql), " ")
+	ctx, span := tracing.Start(t.context(), operation,
+		semconv.DBSystemPostgreSQL,
+		semconv.DBOperation(operation),
+		attribute.String("db.statement", sql),
+	)
+	return &tracedQuery{ctx: ctx, span: span, operation: operation, start: time.Now()}
+}
+
+func (q *tracedQuery) end(err error) {
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		q.span.RecordError(err)
+		q.span.SetStatus(codes.Error, err.Error())
+		slog.DebugContext(q.ctx, "database query failed", "operation", q.operation, "duration", time.Since(q.start), "error", err)
+	} else {
+		slog.DebugContext(q.ctx, "database query", "operation", q.operation, "duration", time.Since(q.start))
+	}
+	q.span.End()
+}
+
+func (t *Transaction) exec(sql string, args ...interface{}) (pgx.CommandTag, error) {
+	q := t.startQuery(sql)
+	tag, err := t.tx.ExecEx(q.ctx, sql, nil, args...)
+	q.end(err)
+	return tag, err
+}
+
+// tracedRow ends the query span after the row is scanned
+type tracedRow struct {
+	row   *pgx.Row
+	query *tracedQuery
+}
+
+func (r *tracedRow) Scan(dest ...interface{}) error {
+	err := r.row.Scan(dest...)
+	r.query.end(err)
+	return err
+}
+
+func (t *Transaction) queryRow(sql string, args ...interface{}) *tracedRow {
+	q := t.startQuery(sql)
+	return &tracedRow{row: t.tx.QueryRowEx(q.ctx, sql, nil, args...), query: q}
+}
+
+// tracedRows ends the query span when the rows are closed
+type tracedRows struct {
+	*pgx.Rows
+	query *tracedQuery
+}
+
+func (r *tracedRows) Close() {
+	r.Rows.Close()
+	r.query.end(r.Rows.Err())
+}
+
+func (t *Transaction) query(sql string, args ...interface{}) (*tracedRows, error) {
+	q := t.startQuery(sql)
+	rows, err := t.tx.QueryEx(q.ctx, sql, nil, args...)
+	if err != nil {
+		q.end(err)
+		return nil, err
+	}
+	return &tracedRows{Rows: rows, query: q}, nil
+}
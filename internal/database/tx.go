@@ -1,57 +1,97 @@
 package database
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/jackc/pgx"
 	"github.com/jackc/pgx/pgtype"
 	"github.com/shopspring/decimal"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"strconv"
+	"time"
 
+	"github.com/KseniiaSalmina/Balance/internal/audit"
+	"github.com/KseniiaSalmina/Balance/internal/metrics"
+	"github.com/KseniiaSalmina/Balance/internal/notify"
+	"github.com/KseniiaSalmina/Balance/internal/tracing"
 	"github.com/KseniiaSalmina/Balance/internal/wallet"
 )
 
 type Transaction struct {
-	tx *pgx.Tx
+	tx      *pgx.Tx
+	ctx     context.Context //carries the transaction span, parent of the query spans
+	span    trace.Span
+	started time.Time
+	done    bool
 }
 
+// Rollback aborts the transaction, it does nothing if the transaction is already finished
 func (t *Transaction) Rollback() {
+	if t.done {
+		return
+	}
+	t.done = true
+
 	t.tx.Rollback()
+	metrics.DBTransaction("rollback", time.Since(t.started))
+	t.endSpan("rollback", nil)
 }
 
 func (t *Transaction) Commit() error {
-	return t.tx.Commit()
+	t.done = true
+
+	if err := t.tx.Commit(); err != nil {
+		metrics.DBError("commit")
+		metrics.DBTransaction("rollback", time.Since(t.started))
+		t.endSpan("rollback", err)
+		return err
+	}
+
+	metrics.DBTransaction("commit", time.Since(t.started))
+	t.endSpan("commit", nil)
+	return nil
+}
+
+func (t *Transaction) endSpan(result string, err error) {
+	if t.span == nil {
+		return
+	}
+	t.span.SetAttributes(attribute.String("db.transaction.result", result))
+	tracing.End(t.span, &err)
 }
 
 func (t *Transaction) GetBalance(id int) (*wallet.Wallet, error) {
-	var balance decimal.Decimal
-	if err := t.tx.QueryRow(`SELECT balance FROM balances WHERE id = $1`, id).Scan(&balance); err != nil {
+	var balance, creditLimit decimal.Decimal
+	if err := t.queryRow(`SELECT balance, credit_limit FROM balances WHERE id = $1`, id).Scan(&balance, &creditLimit); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, UserDoesNotExistErr
 		}
 		return nil, fmt.Errorf("GetBalance -> %w", err)
 	}
-	return &wallet.Wallet{ID: id, Balance: balance}, nil
+	return &wallet.Wallet{ID: id, Balance: balance, CreditLimit: creditLimit}, nil
 }
 
 func (t *Transaction) GetHistory(walletID int, orderBy OrderBy, order Order, limit int) (*wallet.Wallet, error) {
-	query := `SELECT date, option, amount, description FROM history WHERE wallet_id = $1` + ` ORDER BY ` + string(orderBy) + ` ` + string(order) + ` LIMIT ` + strconv.Itoa(limit)
-	rows, err := t.tx.Query(query, walletID)
+	query := `SELECT date, option, amount, description, actor, COALESCE(counterparty, 0) FROM history WHERE wallet_id = $1` + ` ORDER BY ` + string(orderBy) + ` ` + string(order) + ` LIMIT ` + strconv.Itoa(limit)
+	rows, err := t.query(query, walletID)
 	if err != nil {
 		return nil, fmt.Errorf("getHistory -> %w", err)
 	}
+	defer rows.Close()
 
 	w := &wallet.Wallet{ID: walletID, History: make([]wallet.HistoryChange, 0, limit+1)}
 	for rows.Next() {
 		var c wallet.HistoryChange
 		var date pgtype.Int8
 		var amount decimal.Decimal
-		var operation, description pgtype.Text
-		if err = rows.Scan(&date, &operation, &amount, &description); err != nil {
+		var operation, description, actor pgtype.Text
+		if err = rows.Scan(&date, &operation, &amount, &description, &actor, &c.Counterparty); err != nil {
 			return nil, fmt.Errorf("GetHistory -> %w", err)
 		}
 
-		c.Date, c.Operation, c.Amount, c.Description = date.Int, wallet.Operation(operation.String), amount, description.String
+		c.Date, c.Operation, c.Amount, c.Description, c.Actor = date.Int, wallet.Operation(operation.String), amount, description.String, actor.String
 		w.History = append(w.History, c)
 	}
 
@@ -62,22 +102,73 @@ func (t *Transaction) GetHistory(walletID int, orderBy OrderBy, order Order, lim
 	return w, nil
 }
 
-func (t *Transaction) CommitChanges(id int, balance decimal.Decimal, ch wallet.HistoryChange) error {
-	_, err := t.tx.Exec(`UPDATE balances SET balance = $1 WHERE id = $2`, balance, id)
+// CommitChanges updates the balance, appends the change to the wallet history hash chain and to the outbox of webhooks,
+// returns ID of the history record
+func (t *Transaction) CommitChanges(id int, balance decimal.Decimal, ch wallet.HistoryChange) (int64, error) {
+	var prevHash string
+	if err := t.queryRow(`SELECT history_hash FROM balances WHERE id = $1 FOR UPDATE`, id).Scan(&prevHash); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, UserDoesNotExistErr
+		}
+		return 0, fmt.Errorf("ChangeBalance -> %w", err)
+	}
+
+	hash := audit.Hash(prevHash, id, ch)
+
+	_, err := t.exec(`UPDATE balances SET balance = $1, history_hash = $2 WHERE id = $3`, balance, hash, id)
 	if err != nil {
-		return fmt.Errorf("ChangeBalance -> %w", err)
+		return 0, fmt.Errorf("ChangeBalance -> %w", err)
 	}
 
-	_, err = t.tx.Exec(`INSERT INTO history (wallet_id, date, option, amount, description) VALUES ($1, $2, $3, $4, $5)`, id, ch.Date, ch.Operation, ch.Amount, ch.Description)
+	var historyID int64
+	err = t.queryRow(`INSERT INTO history (wallet_id, date, option, amount, description, actor, prev_hash, hash, counterparty) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, 0)) RETURNING id`,
+		id, ch.Date, ch.Operation, ch.Amount, ch.Description, ch.Actor, prevHash, hash, ch.Counterparty).Scan(&historyID)
 	if err != nil {
-		return fmt.Errorf("ChangeBalance -> %w", err)
+		return 0, fmt.Errorf("ChangeBalance -> %w", err)
 	}
 
-	return nil
+	if err = t.addToOutbox(notify.Event{ID: historyID, WalletID: id, Balance: balance, Change: ch}); err != nil {
+		return 0, fmt.Errorf("ChangeBalance -> %w", err)
+	}
+
+	return historyID, nil
+}
+
+// GetHistoryChain returns all wallet history records in insertion order and the hash of the last one stored with the balance
+func (t *Transaction) GetHistoryChain(walletID int) ([]audit.Record, string, error) {
+	var head string
+	if err := t.queryRow(`SELECT history_hash FROM balances WHERE id = $1`, walletID).Scan(&head); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, "", UserDoesNotExistErr
+		}
+		return nil, "", fmt.Errorf("GetHistoryChain -> %w", err)
+	}
+
+	rows, err := t.query(`SELECT id, date, option, amount, description, actor, COALESCE(counterparty, 0), prev_hash, hash FROM history WHERE wallet_id = $1 ORDER BY id`, walletID)
+	if err != nil {
+		return nil, "", fmt.Errorf("GetHistoryChain -> %w", err)
+	}
+	defer rows.Close()
+
+	records := make([]audit.Record, 0)
+	for rows.Next() {
+		var r audit.Record
+		var operation string
+		if err = rows.Scan(&r.ID, &r.Change.Date, &operation, &r.Change.Amount, &r.Change.Description, &r.Change.Actor, &r.Change.Counterparty, &r.PrevHash, &r.Hash); err != nil {
+			return nil, "", fmt.Errorf("GetHistoryChain -> %w", err)
+		}
+		r.Change.Operation = wallet.Operation(operation)
+		records = append(records, r)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("GetHistoryChain -> %w", err)
+	}
+
+	return records, head, nil
 }
 
 func (t *Transaction) NewUser(id int) error {
-	_, err := t.tx.Exec(`INSERT INTO balances (id) VALUES ($1)`, id)
+	_, err := t.exec(`INSERT INTO balances (id) VALUES ($1)`, id)
 	if err != nil {
 		return fmt.Errorf("NewUser -> %w", err)
 	}
@@ -0,0 +1,185 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/KseniiaSalmina/Balance/internal/notify"
+	"github.com/KseniiaSalmina/Balance/internal/webhook"
+)
+
+// addToOutbox saves the event of the balance change and queues its delivery to every webhook in the same transaction
+func (t *Transaction) addToOutbox(e notify.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("addToOutbox -> %w", err)
+	}
+
+	now := time.Now().Unix()
+	var eventID int64
+	err = t.queryRow(`INSERT INTO outbox (type, wallet_id, data, created_at) VALUES ($1, $2, $3, $4) RETURNING id`,
+		webhook.BalanceChanged, e.WalletID, string(data), now).Scan(&eventID)
+	if err != nil {
+		return fmt.Errorf("addToOutbox -> %w", err)
+	}
+
+	_, err = t.exec(`INSERT INTO webhook_deliveries (webhook_id, event_id, next_attempt_at, updated_at) SELECT id, $1, $2, $2 FROM webhooks`, eventID, now)
+	if err != nil {
+		return fmt.Errorf("addToOutbox -> %w", err)
+	}
+	return nil
+}
+
+func (t *Transaction) CreateWebhook(w webhook.Webhook) (int64, error) {
+	var id int64
+	err := t.queryRow(`INSERT INTO webhooks (url, secret, created_at) VALUES ($1, $2, $3) RETURNING id`, w.URL, w.Secret, w.Created).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("CreateWebhook -> %w", err)
+	}
+	return id, nil
+}
+
+// ListWebhooks returns the webhooks without secrets
+func (t *Transaction) ListWebhooks() ([]webhook.Webhook, error) {
+	rows, err := t.query(`SELECT id, url, created_at FROM webhooks ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("ListWebhooks -> %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := make([]webhook.Webhook, 0)
+	for rows.Next() {
+		var w webhook.Webhook
+		if err = rows.Scan(&w.ID, &w.URL, &w.Created); err != nil {
+			return nil, fmt.Errorf("ListWebhooks -> %w", err)
+		}
+		webhooks = append(webhooks, w)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListWebhooks -> %w", err)
+	}
+	return webhooks, nil
+}
+
+// DeleteWebhook deletes the webhook with its deliveries
+func (t *Transaction) DeleteWebhook(id int64) error {
+	tag, err := t.exec(`DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("DeleteWebhook -> %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return WebhookDoesNotExistErr
+	}
+	return nil
+}
+
+const deliveryColumns = `d.id, d.webhook_id, o.id, o.type, o.data, o.created_at, d.status, d.attempts, d.next_attempt_at, d.last_error, d.updated_at`
+
+func (t *Transaction) ListDeliveries(webhookID int64, status webhook.Status, limit int) ([]webhook.Delivery, error) {
+	var exists bool
+	if err := t.queryRow(`SELECT EXISTS (SELECT 1 FROM webhooks WHERE id = $1)`, webhookID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("ListDeliveries -> %w", err)
+	}
+	if !exists {
+		return nil, WebhookDoesNotExistErr
+	}
+
+	rows, err := t.query(`SELECT `+deliveryColumns+` FROM webhook_deliveries d JOIN outbox o ON o.id = d.event_id
+		WHERE d.webhook_id = $1 AND d.status = $2 ORDER BY d.id DESC LIMIT $3`, webhookID, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ListDeliveries -> %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]webhook.Delivery, 0)
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ListDeliveries -> %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListDeliveries -> %w", err)
+	}
+	return deliveries, nil
+}
+
+// ReplayDelivery queues the delivery to be sent again at with a new set of attempts
+func (t *Transaction) ReplayDelivery(id int64, at int64) error {
+	tag, err := t.exec(`UPDATE webhook_deliveries SET status = 'pending', attempts = 0, next_attempt_at = $1, last_error = '', updated_at = $1 WHERE id = $2`, at, id)
+	if err != nil {
+		return fmt.Errorf("ReplayDelivery -> %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return DeliveryDoesNotExistErr
+	}
+	return nil
+}
+
+// ReplayDeadDeliveries queues all the dead deliveries of the webhook to be sent again, returns their number
+func (t *Transaction) ReplayDeadDeliveries(webhookID int64, at int64) (int64, error) {
+	var exists bool
+	if err := t.queryRow(`SELECT EXISTS (SELECT 1 FROM webhooks WHERE id = $1)`, webhookID).Scan(&exists); err != nil {
+		return 0, fmt.Errorf("ReplayDeadDeliveries -> %w", err)
+	}
+	if !exists {
+		return 0, WebhookDoesNotExistErr
+	}
+
+	tag, err := t.exec(`UPDATE webhook_deliveries SET status = 'pending', attempts = 0, next_attempt_at = $1, last_error = '', updated_at = $1 WHERE webhook_id = $2 AND status = 'dead'`, at, webhookID)
+	if err != nil {
+		return 0, fmt.Errorf("ReplayDeadDeliveries -> %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// ClaimDeliveries returns the pending deliveries due at now and postpones them until lease, the locked ones are skipped,
+// so every delivery is taken by one instance
+func (db *DB) ClaimDeliveries(now, lease time.Time, limit int) ([]webhook.Delivery, error) {
+	rows, err := db.db.Query(`UPDATE webhook_deliveries d SET next_attempt_at = $2 FROM outbox o, webhooks w
+		WHERE d.id IN (SELECT id FROM webhook_deliveries WHERE status = 'pending' AND next_attempt_at <= $1 ORDER BY next_attempt_at, id LIMIT $3 FOR UPDATE SKIP LOCKED)
+			AND o.id = d.event_id AND w.id = d.webhook_id
+		RETURNING `+deliveryColumns+`, w.url, w.secret`, now.Unix(), lease.Unix(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("ClaimDeliveries -> %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]webhook.Delivery, 0)
+	for rows.Next() {
+		var url, secret string
+		d, err := scanDelivery(rows, &url, &secret)
+		if err != nil {
+			return nil, fmt.Errorf("ClaimDeliveries -> %w", err)
+		}
+		d.URL, d.Secret = url, secret
+		deliveries = append(deliveries, d)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("ClaimDeliveries -> %w", err)
+	}
+	return deliveries, nil
+}
+
+// SaveDelivery saves the result of the delivery attempt
+func (db *DB) SaveDelivery(d webhook.Delivery) error {
+	_, err := db.db.Exec(`UPDATE webhook_deliveries SET status = $1, attempts = $2, next_attempt_at = $3, last_error = $4, updated_at = $5 WHERE id = $6`,
+		d.Status, d.Attempts, d.NextAttempt, d.LastError, d.Updated, d.ID)
+	if err != nil {
+		return fmt.Errorf("SaveDelivery -> %w", err)
+	}
+	return nil
+}
+
+func scanDelivery(row scanner, extra ...interface{}) (webhook.Delivery, error) {
+	var d webhook.Delivery
+	var data, status string
+	dest := append([]interface{}{&d.ID, &d.WebhookID, &d.Event.ID, &d.Event.Type, &data, &d.Event.Created, &status, &d.Attempts, &d.NextAttempt, &d.LastError, &d.Updated}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return webhook.Delivery{}, err
+	}
+	d.Event.Data, d.Status = json.RawMessage(data), webhook.Status(status)
+	return d, nil
+}
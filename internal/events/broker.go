@@ -0,0 +1,78 @@
+package events
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"sync"
+)
+
+const structuredContentType = "application/cloudevents+json"
+
+// LocalBroker is a stand-in for the message broker in tests and local development. It accepts the events over HTTP
+// in the structured and batch modes and keeps them in memory
+type LocalBroker struct {
+	mu     sync.Mutex
+	events []Event
+	notify chan struct{}
+}
+
+func NewLocalBroker() *LocalBroker {
+	return &LocalBroker{notify: make(chan struct{}, 1)}
+}
+
+func (b *LocalBroker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var events []Event
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch contentType {
+	case batchContentType:
+		if err := json.NewDecoder(r.Body).Decode(&events); err != nil {
+			http.Error(w, "incorrect batch: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	case structuredContentType:
+		var e Event
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			http.Error(w, "incorrect event: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		events = append(events, e)
+	default:
+		http.Error(w, "unsupported content type", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	for _, e := range events {
+		if e.SpecVersion != specVersion || e.ID == "" || e.Source == "" || e.Type == "" {
+			http.Error(w, "specversion, id, source and type are required", http.StatusBadRequest)
+			return
+		}
+	}
+
+	b.mu.Lock()
+	b.events = append(b.events, events...)
+	b.mu.Unlock()
+
+	select {
+	case b.notify <- struct{}{}:
+	default:
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// Events returns the received events in order of receipt
+func (b *LocalBroker) Events() []Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Event(nil), b.events...)
+}
+
+// Received is signalled when new events are received
+func (b *LocalBroker) Received() <-chan struct{} {
+	return b.notify
+}
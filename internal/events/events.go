@@ -0,0 +1,97 @@
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/KseniiaSalmina/Balance/internal/metrics"
+)
+
+const specVersion = "1.0"
+
+// Event is a domain event in the CloudEvents 1.0 JSON format. The type ends with the version of the data schema,
+// incompatible changes of the data get a new type
+type Event struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data" swaggertype:"object"`
+}
+
+// Data is the payload of a domain event
+type Data interface {
+	EventType() string
+	EventSubject() string
+}
+
+// Publisher delivers the events to the consumers
+type Publisher interface {
+	Publish(ctx context.Context, events ...Event) error
+}
+
+func New(source string, data Data, at time.Time) (Event, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return Event{}, fmt.Errorf("events.New -> %w", err)
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, fmt.Errorf("events.New -> %w", err)
+	}
+
+	return Event{
+		SpecVersion:     specVersion,
+		ID:              hex.EncodeToString(id),
+		Source:          source,
+		Type:            data.EventType(),
+		Subject:         data.EventSubject(),
+		Time:            at.UTC(),
+		DataContentType: "application/json",
+		Data:            raw,
+	}, nil
+}
+
+// Emitter turns the data into events of the source and publishes them. Nil emitter drops the events
+type Emitter struct {
+	source    string
+	publisher Publisher
+}
+
+func NewEmitter(source string, publisher Publisher) *Emitter {
+	return &Emitter{source: source, publisher: publisher}
+}
+
+// Emit publishes the events, the operation is already committed, so the errors are only logged
+func (e *Emitter) Emit(ctx context.Context, data ...Data) {
+	if e == nil || len(data) == 0 {
+		return
+	}
+
+	now := time.Now()
+	events := make([]Event, 0, len(data))
+	for _, d := range data {
+		ev, err := New(e.source, d, now)
+		if err != nil {
+			slog.ErrorContext(ctx, "event is not created", "type", d.EventType(), "error", err)
+			continue
+		}
+		events = append(events, ev)
+	}
+
+	if err := e.publisher.Publish(ctx, events...); err != nil {
+		metrics.EventsPublished("failed", len(events))
+		slog.ErrorContext(ctx, "events are not published", "count", len(events), "error", err)
+		return
+	}
+	metrics.EventsPublished("published", len(events))
+}
@@ -0,0 +1,126 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testEvents(t *testing.T) []Event {
+	created, err := New("/balance", WalletCreated{WalletID: 1}, time.Now())
+	assert.NoError(t, err)
+	replenished, err := New("/balance", Replenished{WalletID: 1, HistoryID: 1, Amount: decimal.NewFromInt(10), Balance: decimal.NewFromInt(10)}, time.Now())
+	assert.NoError(t, err)
+	return []Event{created, replenished}
+}
+
+func TestNew(t *testing.T) {
+	e, err := New("/balance", Withdrawn{WalletID: 7, Amount: decimal.NewFromInt(5)}, time.Unix(100, 0))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "1.0", e.SpecVersion)
+	assert.Len(t, e.ID, 32)
+	assert.Equal(t, "/balance", e.Source)
+	assert.Equal(t, "balance.wallet.withdrawn.v1", e.Type)
+	assert.Equal(t, "wallets/7", e.Subject)
+	assert.Equal(t, "application/json", e.DataContentType)
+	assert.True(t, e.Time.Equal(time.Unix(100, 0)))
+
+	var data Withdrawn
+	assert.NoError(t, json.Unmarshal(e.Data, &data))
+	assert.Equal(t, 7, data.WalletID)
+
+	other, err := New("/balance", Withdrawn{WalletID: 7}, time.Unix(100, 0))
+	assert.NoError(t, err)
+	assert.NotEqual(t, e.ID, other.ID)
+}
+
+func TestInProcess(t *testing.T) {
+	p := NewInProcess()
+	var got []string
+	p.Subscribe(func(ctx context.Context, e Event) { got = append(got, e.Type) })
+
+	assert.NoError(t, p.Publish(context.Background(), testEvents(t)...))
+	assert.Equal(t, []string{WalletCreatedType, ReplenishedType}, got)
+}
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	sent := testEvents(t)
+
+	p, err := NewFile(path)
+	assert.NoError(t, err)
+	assert.NoError(t, p.Publish(context.Background(), sent[0]))
+	assert.NoError(t, p.Publish(context.Background(), sent[1]))
+	assert.NoError(t, p.Close())
+
+	got, err := ReadFile(path)
+	assert.NoError(t, err)
+	assert.Len(t, got, 2)
+	for i := range sent {
+		assert.Equal(t, sent[i].ID, got[i].ID)
+		assert.JSONEq(t, string(sent[i].Data), string(got[i].Data))
+	}
+}
+
+func TestHTTP(t *testing.T) {
+	broker := NewLocalBroker()
+	srv := httptest.NewServer(broker)
+	defer srv.Close()
+
+	sent := testEvents(t)
+	assert.NoError(t, NewHTTP(srv.URL, time.Second).Publish(context.Background(), sent...))
+
+	got := broker.Events()
+	assert.Len(t, got, 2)
+	assert.Equal(t, sent[0].ID, got[0].ID)
+	assert.Equal(t, sent[1].Type, got[1].Type)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	assert.Error(t, NewHTTP(failing.URL, time.Second).Publish(context.Background(), sent...))
+}
+
+func TestLocalBroker(t *testing.T) {
+	sent := testEvents(t)
+	structured, err := json.Marshal(sent[0])
+	assert.NoError(t, err)
+	invalid, err := json.Marshal(Event{ID: "1", Type: WalletCreatedType})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		wantStatus  int
+	}{
+		{name: "structured mode", contentType: "application/cloudevents+json; charset=utf-8", body: structured, wantStatus: http.StatusAccepted},
+		{name: "required attributes", contentType: structuredContentType, body: invalid, wantStatus: http.StatusBadRequest},
+		{name: "binary mode is not supported", contentType: "application/json", body: sent[0].Data, wantStatus: http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broker := NewLocalBroker()
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+
+			broker.ServeHTTP(rec, req)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus == http.StatusAccepted {
+				<-broker.Received()
+				assert.Len(t, broker.Events(), 1)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const batchContentType = "application/cloudevents-batch+json"
+
+// InProcess passes the events to the handlers subscribed in the same process, synchronously and in order
+type InProcess struct {
+	mu       sync.RWMutex
+	handlers []func(ctx context.Context, e Event)
+}
+
+func NewInProcess() *InProcess {
+	return &InProcess{}
+}
+
+func (p *InProcess) Subscribe(handler func(ctx context.Context, e Event)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.handlers = append(p.handlers, handler)
+}
+
+func (p *InProcess) Publish(ctx context.Context, events ...Event) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, e := range events {
+		for _, h := range p.handlers {
+			h(ctx, e)
+		}
+	}
+	return nil
+}
+
+// File appends the events to the file as newline delimited JSON, one event per line
+type File struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFile(path string) (*File, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("events.NewFile -> %w", err)
+	}
+	return &File{file: f}, nil
+}
+
+// Publish writes the events with one write call, so the lines of concurrent publishers are not mixed
+func (p *File) Publish(ctx context.Context, events ...Event) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return fmt.Errorf("File.Publish -> %w", err)
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, err := p.file.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("File.Publish -> %w", err)
+	}
+	return nil
+}
+
+func (p *File) Close() error {
+	return p.file.Close()
+}
+
+// HTTP sends the events to a broker in the CloudEvents HTTP batch mode
+type HTTP struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTP(url string, timeout time.Duration) *HTTP {
+	return &HTTP{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (p *HTTP) Publish(ctx context.Context, events ...Event) error {
+	body, err := json.Marshal(events)
+	if err != nil {
+		return fmt.Errorf("HTTP.Publish -> %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(context.WithoutCancel(ctx), http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("HTTP.Publish -> %w", err)
+	}
+	req.Header.Set("Content-Type", batchContentType)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("HTTP.Publish -> %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("HTTP.Publish -> unexpected response status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// ReadFile reads the events written by File
+func ReadFile(path string) ([]Event, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("events.ReadFile -> %w", err)
+	}
+	defer f.Close()
+
+	events := make([]Event, 0)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e Event
+		if err = json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("events.ReadFile -> %w", err)
+		}
+		events = append(events, e)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("events.ReadFile -> %w", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,166 @@
+package events
+
+import (
+	"strconv"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	WalletCreatedType     = "balance.wallet.created.v1"
+	ReplenishedType       = "balance.wallet.replenished.v1"
+	WithdrawnType         = "balance.wallet.withdrawn.v1"
+	InterestChargedType   = "balance.wallet.interest_charged.v1"
+	TransferCompletedType = "balance.transfer.completed.v1"
+	ReviewRequestedType   = "balance.review.requested.v1"
+	ReviewResolvedType    = "balance.review.resolved.v1"
+	ScheduledExecutedType = "balance.scheduled.executed.v1"
+	ScheduledFailedType   = "balance.scheduled.failed.v1"
+
+	SubscriptionChargedType   = "balance.subscription.charged.v1"
+	SubscriptionPastDueType   = "balance.subscription.past_due.v1"
+	SubscriptionSuspendedType = "balance.subscription.suspended.v1"
+)
+
+type WalletCreated struct {
+	WalletID int `json:"wallet_id"`
+}
+
+func (e WalletCreated) EventType() string    { return WalletCreatedType }
+func (e WalletCreated) EventSubject() string { return walletSubject(e.WalletID) }
+
+// BalanceChanged is the data of Replenished, Withdrawn and InterestCharged
+type BalanceChanged struct {
+	WalletID     int             `json:"wallet_id"`
+	HistoryID    int64           `json:"history_id"`
+	Amount       decimal.Decimal `json:"amount"`
+	Balance      decimal.Decimal `json:"balance"` //balance after the change
+	Description  string          `json:"description"`
+	Actor        string          `json:"actor"`
+	Counterparty int             `json:"counterparty,omitempty"` //other wallet of a transfer
+}
+
+type Replenished BalanceChanged
+
+func (e Replenished) EventType() string    { return ReplenishedType }
+func (e Replenished) EventSubject() string { return walletSubject(e.WalletID) }
+
+type Withdrawn BalanceChanged
+
+func (e Withdrawn) EventType() string    { return WithdrawnType }
+func (e Withdrawn) EventSubject() string { return walletSubject(e.WalletID) }
+
+// InterestCharged is emitted when the overdraft interest is withdrawn, it is not a withdrawal of the client
+type InterestCharged BalanceChanged
+
+func (e InterestCharged) EventType() string    { return InterestChargedType }
+func (e InterestCharged) EventSubject() string { return walletSubject(e.WalletID) }
+
+// TransferCompleted follows Withdrawn and Replenished of the transfer wallets
+type TransferCompleted struct {
+	From   int             `json:"from"`
+	To     int             `json:"to"`
+	Amount decimal.Decimal `json:"amount"`
+	Actor  string          `json:"actor"`
+}
+
+func (e TransferCompleted) EventType() string    { return TransferCompletedType }
+func (e TransferCompleted) EventSubject() string { return walletSubject(e.From) }
+
+// ReviewRequested is emitted when the operation is held by the risk rules until a manual decision
+type ReviewRequested struct {
+	ReviewID int64           `json:"review_id"`
+	Kind     string          `json:"kind"`
+	WalletID int             `json:"wallet_id"`
+	To       int             `json:"to,omitempty"`
+	Amount   decimal.Decimal `json:"amount"`
+	Actor    string          `json:"actor"`
+}
+
+func (e ReviewRequested) EventType() string    { return ReviewRequestedType }
+func (e ReviewRequested) EventSubject() string { return reviewSubject(e.ReviewID) }
+
+type ReviewResolved struct {
+	ReviewID   int64  `json:"review_id"`
+	Status     string `json:"status"` //approved or rejected
+	ResolvedBy string `json:"resolved_by"`
+}
+
+func (e ReviewResolved) EventType() string    { return ReviewResolvedType }
+func (e ReviewResolved) EventSubject() string { return reviewSubject(e.ReviewID) }
+
+// ScheduledFinished is the data of ScheduledExecuted and ScheduledFailed
+type ScheduledFinished struct {
+	ScheduledID int64           `json:"scheduled_id"`
+	Kind        string          `json:"kind"`
+	WalletID    int             `json:"wallet_id"`
+	To          int             `json:"to,omitempty"`
+	Amount      decimal.Decimal `json:"amount"`
+	Status      string          `json:"status"`              //completed, pending_review or failed
+	ReviewID    int64           `json:"review_id,omitempty"` //review holding the operation
+	Error       string          `json:"error,omitempty"`     //reason of the failure
+	RecurringID int64           `json:"recurring_id,omitempty"`
+	Actor       string          `json:"actor"`
+}
+
+// ScheduledExecuted is emitted when the scheduled operation is committed or held for review at its execution time
+type ScheduledExecuted ScheduledFinished
+
+func (e ScheduledExecuted) EventType() string    { return ScheduledExecutedType }
+func (e ScheduledExecuted) EventSubject() string { return walletSubject(e.WalletID) }
+
+// ScheduledFailed is emitted when the scheduled operation is rejected at its execution time
+type ScheduledFailed ScheduledFinished
+
+func (e ScheduledFailed) EventType() string    { return ScheduledFailedType }
+func (e ScheduledFailed) EventSubject() string { return walletSubject(e.WalletID) }
+
+// SubscriptionCharged is emitted when the period of the subscription is paid, it follows Withdrawn with the price
+type SubscriptionCharged struct {
+	SubscriptionID int64 `json:"subscription_id"`
+	PlanID         int64 `json:"plan_id"`
+	WalletID       int   `json:"wallet_id"`
+	PeriodStart    int64 `json:"period_start"`
+	PeriodEnd      int64 `json:"period_end"`
+}
+
+func (e SubscriptionCharged) EventType() string    { return SubscriptionChargedType }
+func (e SubscriptionCharged) EventSubject() string { return subscriptionSubject(e.SubscriptionID) }
+
+// SubscriptionPastDue is emitted when the charge fails and is retried later
+type SubscriptionPastDue struct {
+	SubscriptionID int64  `json:"subscription_id"`
+	PlanID         int64  `json:"plan_id"`
+	WalletID       int    `json:"wallet_id"`
+	Attempt        int    `json:"attempt"`
+	Error          string `json:"error"`
+	NextChargeAt   int64  `json:"next_charge_at"`
+	GraceUntil     int64  `json:"grace_until"`
+}
+
+func (e SubscriptionPastDue) EventType() string    { return SubscriptionPastDueType }
+func (e SubscriptionPastDue) EventSubject() string { return subscriptionSubject(e.SubscriptionID) }
+
+// SubscriptionSuspended is emitted when the last charge attempt fails
+type SubscriptionSuspended struct {
+	SubscriptionID int64  `json:"subscription_id"`
+	PlanID         int64  `json:"plan_id"`
+	WalletID       int    `json:"wallet_id"`
+	Attempts       int    `json:"attempts"`
+	Error          string `json:"error"`
+}
+
+func (e SubscriptionSuspended) EventType() string    { return SubscriptionSuspendedType }
+func (e SubscriptionSuspended) EventSubject() string { return subscriptionSubject(e.SubscriptionID) }
+
+func walletSubject(id int) string {
+	return "wallets/" + strconv.Itoa(id)
+}
+
+func reviewSubject(id int64) string {
+	return "reviews/" + strconv.FormatInt(id, 10)
+}
+
+func subscriptionSubject(id int64) string {
+	return "subscriptions/" + strconv.FormatInt(id, 10)
+}
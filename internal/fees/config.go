@@ -0,0 +1,54 @@
+package fees
+
+import (
+	"fmt"
+	"github.com/shopspring/decimal"
+	"gopkg.in/yaml.v3"
+	"os"
+
+	"github.com/KseniiaSalmina/Balance/internal/risk"
+)
+
+type fileConfig struct {
+	RevenueWallet int          `yaml:"revenue_wallet"`
+	Rules         []ruleConfig `yaml:"rules"`
+}
+
+type ruleConfig struct {
+	Name      string              `yaml:"name"`
+	Operation risk.Kind           `yaml:"operation"`
+	Tier      string              `yaml:"tier"`
+	From      decimal.Decimal     `yaml:"from"`
+	Fixed     decimal.Decimal     `yaml:"fixed"`
+	Percent   decimal.Decimal     `yaml:"percent"`
+	Min       decimal.NullDecimal `yaml:"min"`
+	Max       decimal.NullDecimal `yaml:"max"`
+}
+
+// Load reads the fee schedule from the YAML file
+func Load(path string) (*Schedule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("fees.Load -> %w", err)
+	}
+
+	var cfg fileConfig
+	if err = yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("fees.Load -> %w", err)
+	}
+
+	rules := make([]Rule, 0, len(cfg.Rules))
+	for i, rc := range cfg.Rules {
+		name := rc.Name
+		if name == "" {
+			name = fmt.Sprintf("%s %d", rc.Operation, i)
+		}
+		rules = append(rules, Rule{Name: name, Kind: rc.Operation, Tier: rc.Tier, From: rc.From, Fixed: rc.Fixed, Percent: rc.Percent, Min: rc.Min, Max: rc.Max})
+	}
+
+	s, err := NewSchedule(cfg.RevenueWallet, rules...)
+	if err != nil {
+		return nil, fmt.Errorf("fees.Load -> %w", err)
+	}
+	return s, nil
+}
@@ -0,0 +1,108 @@
+package fees
+
+import (
+	"errors"
+	"fmt"
+	"github.com/shopspring/decimal"
+
+	"github.com/KseniiaSalmina/Balance/internal/risk"
+)
+
+// Rule sets the fee of the operation of the kind from the amount, the fee is fixed + percent of the amount within min and max
+type Rule struct {
+	Name    string
+	Kind    risk.Kind       //withdrawal or transfer
+	Tier    string          //tier of the wallet, empty value matches any tier
+	From    decimal.Decimal //smallest amount the rule is applied to
+	Fixed   decimal.Decimal
+	Percent decimal.Decimal     //percent of the amount
+	Min     decimal.NullDecimal //null value means no minimum
+	Max     decimal.NullDecimal //null value means no maximum
+}
+
+func (r Rule) validate() error {
+	switch {
+	case r.Kind != risk.KindWithdrawal && r.Kind != risk.KindTransfer:
+		return fmt.Errorf("operation must be %s or %s, got %q", risk.KindWithdrawal, risk.KindTransfer, r.Kind)
+	case r.From.IsNegative() || r.Fixed.IsNegative() || r.Percent.IsNegative():
+		return errors.New("from, fixed and percent must not be negative")
+	case r.Min.Valid && r.Min.Decimal.IsNegative() || r.Max.Valid && r.Max.Decimal.IsNegative():
+		return errors.New("min and max must not be negative")
+	case r.Min.Valid && r.Max.Valid && r.Min.Decimal.GreaterThan(r.Max.Decimal):
+		return errors.New("min must not be greater than max")
+	}
+	return nil
+}
+
+// fee returns the fee of the amount rounded to hundredths
+func (r Rule) fee(amount decimal.Decimal) decimal.Decimal {
+	fee := r.Fixed.Add(amount.Mul(r.Percent).Div(decimal.NewFromInt(100)))
+	if r.Min.Valid {
+		fee = decimal.Max(fee, r.Min.Decimal)
+	}
+	if r.Max.Valid {
+		fee = decimal.Min(fee, r.Max.Decimal)
+	}
+	return fee.Round(2)
+}
+
+// Schedule is the set of fee rules, the fees are posted to the revenue wallet
+type Schedule struct {
+	wallet int
+	rules  []Rule
+}
+
+// NewSchedule validates the rules, the fees are posted to the revenue wallet
+func NewSchedule(revenueWallet int, rules ...Rule) (*Schedule, error) {
+	if revenueWallet <= 0 {
+		return nil, errors.New("revenue wallet is required")
+	}
+	for i, r := range rules {
+		if err := r.validate(); err != nil {
+			return nil, fmt.Errorf("rule %d %q: %w", i, r.Name, err)
+		}
+	}
+	return &Schedule{wallet: revenueWallet, rules: rules}, nil
+}
+
+// Wallet returns the revenue wallet
+func (s *Schedule) Wallet() int {
+	return s.wallet
+}
+
+// Quote is the fee of the operation
+type Quote struct {
+	Operation risk.Kind       `json:"operation"`
+	Tier      string          `json:"tier"`           //tier of the wallet
+	Amount    decimal.Decimal `json:"amount"`         //amount of the operation
+	Fee       decimal.Decimal `json:"fee"`            //withdrawn from the wallet in addition to the amount
+	Total     decimal.Decimal `json:"total"`          //amount and fee
+	Rule      string          `json:"rule,omitempty"` //rule that set the fee, empty if the operation has no fee
+}
+
+// Quote returns the fee of the operation of the wallet of the tier. The rule for the tier is preferred over the rule
+// for any tier, then the rule with the greatest from not exceeding the amount is applied. Nil schedule charges no fees
+func (s *Schedule) Quote(kind risk.Kind, tier string, amount decimal.Decimal) Quote {
+	q := Quote{Operation: kind, Tier: tier, Amount: amount, Fee: decimal.Zero, Total: amount}
+	if s == nil {
+		return q
+	}
+
+	var best *Rule
+	for i := range s.rules {
+		r := &s.rules[i]
+		if r.Kind != kind || r.Tier != "" && r.Tier != tier || r.From.GreaterThan(amount) {
+			continue
+		}
+		if best == nil || r.Tier != "" && best.Tier == "" || r.Tier == best.Tier && r.From.GreaterThan(best.From) {
+			best = r
+		}
+	}
+	if best == nil {
+		return q
+	}
+
+	q.Fee, q.Rule = best.fee(amount), best.Name
+	q.Total = amount.Add(q.Fee)
+	return q
+}
@@ -0,0 +1,102 @@
+package fees
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/KseniiaSalmina/Balance/internal/risk"
+)
+
+func dec(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+func TestSchedule_Quote(t *testing.T) {
+	s, err := NewSchedule(1,
+		Rule{Name: "withdrawal", Kind: risk.KindWithdrawal, Fixed: dec("1"), Percent: dec("1.5"), Min: decimal.NewNullDecimal(dec("2")), Max: decimal.NewNullDecimal(dec("50"))},
+		Rule{Name: "transfer", Kind: risk.KindTransfer, Percent: dec("1")},
+		Rule{Name: "large transfer", Kind: risk.KindTransfer, From: dec("10000"), Percent: dec("0.5")},
+		Rule{Name: "business transfer", Kind: risk.KindTransfer, Tier: "business", Fixed: dec("5")},
+	)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		kind     risk.Kind
+		tier     string
+		amount   string
+		wantFee  string
+		wantRule string
+	}{
+		{name: "fixed and percent", kind: risk.KindWithdrawal, amount: "1000", wantFee: "16", wantRule: "withdrawal"},
+		{name: "minimum", kind: risk.KindWithdrawal, amount: "10", wantFee: "2", wantRule: "withdrawal"},
+		{name: "maximum", kind: risk.KindWithdrawal, amount: "100000", wantFee: "50", wantRule: "withdrawal"},
+		{name: "rounded to hundredths", kind: risk.KindTransfer, amount: "10.555", wantFee: "0.11", wantRule: "transfer"},
+		{name: "amount tier", kind: risk.KindTransfer, amount: "10000", wantFee: "50", wantRule: "large transfer"},
+		{name: "wallet tier is preferred", kind: risk.KindTransfer, tier: "business", amount: "20000", wantFee: "5", wantRule: "business transfer"},
+		{name: "other wallet tier", kind: risk.KindTransfer, tier: "private", amount: "100", wantFee: "1", wantRule: "transfer"},
+		{name: "no rule", kind: risk.KindReplenishment, amount: "100", wantFee: "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := s.Quote(tt.kind, tt.tier, dec(tt.amount))
+
+			assert.Equal(t, tt.wantFee, q.Fee.String())
+			assert.Equal(t, tt.wantRule, q.Rule)
+			assert.Equal(t, dec(tt.amount).Add(q.Fee).String(), q.Total.String())
+		})
+	}
+
+	var disabled *Schedule
+	assert.True(t, disabled.Quote(risk.KindWithdrawal, "", dec("100")).Fee.IsZero(), "nil schedule must charge no fees")
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "fees.yaml")
+	os.WriteFile(valid, []byte(`
+revenue_wallet: 1
+rules:
+  - name: withdrawal
+    operation: withdrawal
+    fixed: 1
+    percent: 1.5
+    min: 2
+  - operation: transfer
+    tier: business
+    from: 1000
+    percent: 0.5
+    max: 100
+`), 0o600)
+
+	s, err := Load(valid)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, s.Wallet())
+	if assert.Len(t, s.rules, 2) {
+		assert.Equal(t, "withdrawal", s.rules[0].Name)
+		assert.Equal(t, "1.5", s.rules[0].Percent.String())
+		assert.Equal(t, "2", s.rules[0].Min.Decimal.String())
+		assert.False(t, s.rules[0].Max.Valid)
+		assert.Equal(t, "transfer 1", s.rules[1].Name)
+		assert.Equal(t, "business", s.rules[1].Tier)
+		assert.Equal(t, "1000", s.rules[1].From.String())
+	}
+
+	invalid := []string{
+		"rules:\n  - operation: withdrawal\n    fixed: 1\n",
+		"revenue_wallet: 1\nrules:\n  - operation: replenishment\n    fixed: 1\n",
+		"revenue_wallet: 1\nrules:\n  - operation: transfer\n    percent: -1\n",
+		"revenue_wallet: 1\nrules:\n  - operation: transfer\n    min: 10\n    max: 5\n",
+	}
+	for i, data := range invalid {
+		path := filepath.Join(dir, "invalid.yaml")
+		os.WriteFile(path, []byte(data), 0o600)
+		_, err = Load(path)
+		assert.Error(t, err, "config %d", i)
+	}
+}
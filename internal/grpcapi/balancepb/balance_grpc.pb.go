@@ -0,0 +1,252 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v4.25.3
+// source: balance.proto
+
+package balancepb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Balance_GetBalance_FullMethodName       = "/balance.v1.Balance/GetBalance"
+	Balance_GetHistory_FullMethodName       = "/balance.v1.Balance/GetHistory"
+	Balance_MoneyTransaction_FullMethodName = "/balance.v1.Balance/MoneyTransaction"
+	Balance_Transfer_FullMethodName         = "/balance.v1.Balance/Transfer"
+)
+
+// BalanceClient is the client API for Balance service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type BalanceClient interface {
+	GetBalance(ctx context.Context, in *GetBalanceRequest, opts ...grpc.CallOption) (*GetBalanceResponse, error)
+	// GetHistory streams the wallet operations
+	GetHistory(ctx context.Context, in *GetHistoryRequest, opts ...grpc.CallOption) (Balance_GetHistoryClient, error)
+	// MoneyTransaction replenishes the wallet with a positive amount or withdraws a negative one
+	MoneyTransaction(ctx context.Context, in *MoneyTransactionRequest, opts ...grpc.CallOption) (*TransactionResponse, error)
+	Transfer(ctx context.Context, in *TransferRequest, opts ...grpc.CallOption) (*TransactionResponse, error)
+}
+
+type balanceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBalanceClient(cc grpc.ClientConnInterface) BalanceClient {
+	return &balanceClient{cc}
+}
+
+func (c *balanceClient) GetBalance(ctx context.Context, in *GetBalanceRequest, opts ...grpc.CallOption) (*GetBalanceResponse, error) {
+	out := new(GetBalanceResponse)
+	err := c.cc.Invoke(ctx, Balance_GetBalance_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *balanceClient) GetHistory(ctx context.Context, in *GetHistoryRequest, opts ...grpc.CallOption) (Balance_GetHistoryClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Balance_ServiceDesc.Streams[0], Balance_GetHistory_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &balanceGetHistoryClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Balance_GetHistoryClient interface {
+	Recv() (*HistoryEntry, error)
+	grpc.ClientStream
+}
+
+type balanceGetHistoryClient struct {
+	grpc.ClientStream
+}
+
+func (x *balanceGetHistoryClient) Recv() (*HistoryEntry, error) {
+	m := new(HistoryEntry)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *balanceClient) MoneyTransaction(ctx context.Context, in *MoneyTransactionRequest, opts ...grpc.CallOption) (*TransactionResponse, error) {
+	out := new(TransactionResponse)
+	err := c.cc.Invoke(ctx, Balance_MoneyTransaction_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *balanceClient) Transfer(ctx context.Context, in *TransferRequest, opts ...grpc.CallOption) (*TransactionResponse, error) {
+	out := new(TransactionResponse)
+	err := c.cc.Invoke(ctx, Balance_Transfer_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// BalanceServer is the server API for Balance service.
+// All implementations must embed UnimplementedBalanceServer
+// for forward compatibility
+type BalanceServer interface {
+	GetBalance(context.Context, *GetBalanceRequest) (*GetBalanceResponse, error)
+	// GetHistory streams the wallet operations
+	GetHistory(*GetHistoryRequest, Balance_GetHistoryServer) error
+	// MoneyTransaction replenishes the wallet with a positive amount or withdraws a negative one
+	MoneyTransaction(context.Context, *MoneyTransactionRequest) (*TransactionResponse, error)
+	Transfer(context.Context, *TransferRequest) (*TransactionResponse, error)
+	mustEmbedUnimplementedBalanceServer()
+}
+
+// UnimplementedBalanceServer must be embedded to have forward compatible implementations.
+type UnimplementedBalanceServer struct {
+}
+
+func (UnimplementedBalanceServer) GetBalance(context.Context, *GetBalanceRequest) (*GetBalanceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBalance not implemented")
+}
+func (UnimplementedBalanceServer) GetHistory(*GetHistoryRequest, Balance_GetHistoryServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetHistory not implemented")
+}
+func (UnimplementedBalanceServer) MoneyTransaction(context.Context, *MoneyTransactionRequest) (*TransactionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MoneyTransaction not implemented")
+}
+func (UnimplementedBalanceServer) Transfer(context.Context, *TransferRequest) (*TransactionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Transfer not implemented")
+}
+func (UnimplementedBalanceServer) mustEmbedUnimplementedBalanceServer() {}
+
+// UnsafeBalanceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BalanceServer will
+// result in compilation errors.
+type UnsafeBalanceServer interface {
+	mustEmbedUnimplementedBalanceServer()
+}
+
+func RegisterBalanceServer(s grpc.ServiceRegistrar, srv BalanceServer) {
+	s.RegisterService(&Balance_ServiceDesc, srv)
+}
+
+func _Balance_GetBalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBalanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BalanceServer).GetBalance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Balance_GetBalance_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BalanceServer).GetBalance(ctx, req.(*GetBalanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Balance_GetHistory_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetHistoryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BalanceServer).GetHistory(m, &balanceGetHistoryServer{stream})
+}
+
+type Balance_GetHistoryServer interface {
+	Send(*HistoryEntry) error
+	grpc.ServerStream
+}
+
+type balanceGetHistoryServer struct {
+	grpc.ServerStream
+}
+
+func (x *balanceGetHistoryServer) Send(m *HistoryEntry) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Balance_MoneyTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MoneyTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BalanceServer).MoneyTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Balance_MoneyTransaction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BalanceServer).MoneyTransaction(ctx, req.(*MoneyTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Balance_Transfer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransferRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BalanceServer).Transfer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Balance_Transfer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BalanceServer).Transfer(ctx, req.(*TransferRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Balance_ServiceDesc is the grpc.ServiceDesc for Balance service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Balance_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "balance.v1.Balance",
+	HandlerType: (*BalanceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetBalance",
+			Handler:    _Balance_GetBalance_Handler,
+		},
+		{
+			MethodName: "MoneyTransaction",
+			Handler:    _Balance_MoneyTransaction_Handler,
+		},
+		{
+			MethodName: "Transfer",
+			Handler:    _Balance_Transfer_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GetHistory",
+			Handler:       _Balance_GetHistory_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "balance.proto",
+}
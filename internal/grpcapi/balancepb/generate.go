@@ -0,0 +1,4 @@
+// Package balancepb contains the gRPC service definition and the code generated from it
+package balancepb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative balance.proto
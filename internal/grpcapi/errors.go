@@ -0,0 +1,53 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/jackc/pgx"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/KseniiaSalmina/Balance/internal/database"
+	"github.com/KseniiaSalmina/Balance/internal/grpcapi/balancepb"
+	"github.com/KseniiaSalmina/Balance/internal/limits"
+	"github.com/KseniiaSalmina/Balance/internal/risk"
+	"github.com/KseniiaSalmina/Balance/internal/wallet"
+)
+
+// statusError maps billing errors to gRPC codes the same way the HTTP API maps them to statuses
+func statusError(ctx context.Context, err error) error {
+	var exceeded *limits.ExceededError
+
+	switch {
+	case errors.As(err, &exceeded):
+		return status.Error(codes.InvalidArgument, fmt.Sprintf("%s: %s, max %s, remaining %s", limits.LimitExceededErr, exceeded.Limit, exceeded.Max, exceeded.Remaining))
+	case errors.Is(err, risk.DeniedErr):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, pgx.ErrNoRows):
+		return status.Error(codes.InvalidArgument, database.UserDoesNotExistErr.Error())
+	case errors.Is(err, database.UserDoesNotExistErr) || errors.Is(err, wallet.InsufficientFundsErr):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		slog.ErrorContext(ctx, "request failed", "error", err)
+		return status.Error(codes.Internal, "internal server error, try again")
+	}
+}
+
+// transactionResponse reports the operation held for manual review as pending instead of an error
+func transactionResponse(ctx context.Context, err error) (*balancepb.TransactionResponse, error) {
+	var review *risk.ReviewRequiredError
+	switch {
+	case err == nil:
+		return completed(), nil
+	case errors.As(err, &review):
+		return &balancepb.TransactionResponse{
+			Status:   balancepb.TransactionStatus_TRANSACTION_STATUS_PENDING_REVIEW,
+			ReviewId: review.ReviewID,
+		}, nil
+	default:
+		return nil, statusError(ctx, err)
+	}
+}
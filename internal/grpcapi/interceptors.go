@@ -0,0 +1,169 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/KseniiaSalmina/Balance/internal/api"
+	"github.com/KseniiaSalmina/Balance/internal/audit"
+	"github.com/KseniiaSalmina/Balance/internal/logger"
+	"github.com/KseniiaSalmina/Balance/internal/ratelimit"
+)
+
+// metadata keys are the lower-cased HTTP headers
+const (
+	apiKeyMetadata    = "x-api-key"
+	userIDMetadata    = "x-user-id"
+	requestIDMetadata = "x-request-id"
+
+	maxRequestIDLength = 64
+)
+
+// walletRequest is implemented by the requests addressed to a wallet, the generated getters are used
+type walletRequest interface {
+	GetWalletId() int64
+}
+
+type transferRequest interface {
+	GetFrom() int64
+}
+
+func (s *Server) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	ctx, err := s.authorize(ctx, req)
+	var resp interface{}
+	if err == nil {
+		resp, err = handler(ctx, req)
+	}
+	accessLog(ctx, info.FullMethod, start, err)
+	return resp, err
+}
+
+func (s *Server) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	ctx, err := s.authenticate(ss.Context())
+	if err == nil {
+		err = handler(srv, &authorizedStream{ServerStream: ss, ctx: ctx, server: s})
+	}
+	accessLog(ctx, info.FullMethod, start, err)
+	return err
+}
+
+// authorizedStream checks the rate limit of the wallet when the request is received
+type authorizedStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	server *Server
+}
+
+func (s *authorizedStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *authorizedStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return s.server.allowWallet(m)
+}
+
+// authorize authenticates the client and checks the rate limits of the client and the wallet
+func (s *Server) authorize(ctx context.Context, req interface{}) (context.Context, error) {
+	ctx, err := s.authenticate(ctx)
+	if err != nil {
+		return ctx, err
+	}
+	return ctx, s.allowWallet(req)
+}
+
+// authenticate sets the request ID and the actor to the context and checks the rate limit of the client
+func (s *Server) authenticate(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	requestID := first(md, requestIDMetadata)
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		requestID = logger.NewRequestID()
+	}
+	ctx = logger.WithRequestID(ctx, requestID)
+
+	var remoteAddr string
+	if p, ok := peer.FromContext(ctx); ok {
+		remoteAddr = p.Addr.String()
+	}
+
+	//the calls without a valid key are limited by address before the key is checked, so the keys can not be guessed at full speed
+	cfg := s.cfg.Load()
+	if !api.KnownKey(cfg.Auth, first(md, apiKeyMetadata)) {
+		addressLimit := ratelimit.Limit{Rate: cfg.RateLimit.AddressRate, Burst: cfg.RateLimit.AddressBurst}
+		if err := s.allow(ctx, "address:"+api.RemoteIP(remoteAddr), addressLimit); err != nil {
+			return ctx, err
+		}
+	}
+
+	actor, err := api.Authenticate(cfg.Auth, first(md, apiKeyMetadata), first(md, userIDMetadata))
+	if err != nil {
+		return ctx, status.Error(codes.Unauthenticated, err.Error())
+	}
+	ctx = audit.WithActor(ctx, actor)
+
+	limit := ratelimit.Limit{Rate: cfg.RateLimit.ClientRate, Burst: cfg.RateLimit.ClientBurst}
+	return ctx, s.allow(ctx, "client:"+api.ClientKey(ctx, remoteAddr), limit)
+}
+
+func (s *Server) allowWallet(req interface{}) error {
+	var id int64
+	switch r := req.(type) {
+	case walletRequest:
+		id = r.GetWalletId()
+	case transferRequest:
+		id = r.GetFrom()
+	default:
+		return nil
+	}
+
+	cfg := s.cfg.Load()
+	limit := ratelimit.Limit{Rate: cfg.RateLimit.WalletRate, Burst: cfg.RateLimit.WalletBurst}
+	return s.allow(context.Background(), "wallet:"+strconv.FormatInt(id, 10), limit)
+}
+
+// allow takes a token from the bucket by key, the keys are shared with the HTTP API. If the backend is unavailable the call is allowed
+func (s *Server) allow(ctx context.Context, key string, limit ratelimit.Limit) error {
+	if limit.Disabled() {
+		return nil
+	}
+
+	ok, wait, err := s.limiter.Take(key, limit)
+	if err != nil {
+		slog.WarnContext(ctx, "rate limiter is unavailable", "error", err)
+		return nil
+	}
+	if ok {
+		return nil
+	}
+	return status.Error(codes.ResourceExhausted, fmt.Sprintf("too many requests, retry after %ds", int(math.Ceil(wait.Seconds()))))
+}
+
+func accessLog(ctx context.Context, method string, start time.Time, err error) {
+	slog.InfoContext(ctx, "grpc request",
+		"method", method,
+		"code", status.Code(err).String(),
+		"duration", time.Since(start),
+	)
+}
+
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) != 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -0,0 +1,225 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync/atomic"
+
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+
+	"github.com/KseniiaSalmina/Balance/internal/api"
+	"github.com/KseniiaSalmina/Balance/internal/config"
+	"github.com/KseniiaSalmina/Balance/internal/database"
+	"github.com/KseniiaSalmina/Balance/internal/grpcapi/balancepb"
+	"github.com/KseniiaSalmina/Balance/internal/ratelimit"
+	"github.com/KseniiaSalmina/Balance/internal/wallet"
+)
+
+const defaultHistoryLimit = 100
+
+// Server serves the Balance gRPC service on top of the same billing as the HTTP API
+type Server struct {
+	balancepb.UnimplementedBalanceServer
+
+	bill       api.BillingManager
+	limiter    ratelimit.Backend
+	cfg        atomic.Pointer[config.Server]
+	listen     string
+	grpcServer *grpc.Server
+}
+
+// NewServer creates the gRPC server. Authentication, rate limits and TLS are configured by the HTTP server settings
+func NewServer(cfg config.GRPC, server config.Server, bill api.BillingManager, limiter ratelimit.Backend) (*Server, error) {
+	s := &Server{
+		bill:    bill,
+		limiter: limiter,
+		listen:  cfg.Listen,
+	}
+	s.cfg.Store(&server)
+
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(s.unaryInterceptor),
+		grpc.ChainStreamInterceptor(s.streamInterceptor),
+	}
+	if server.TLS.Enabled() {
+		tlsConfig, err := api.TLSConfig(server.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("NewServer -> %w", err)
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	s.grpcServer = grpc.NewServer(opts...)
+	balancepb.RegisterBalanceServer(s.grpcServer, s)
+	return s, nil
+}
+
+// Reconfigure replaces the API keys and the rate limits
+func (s *Server) Reconfigure(server config.Server) {
+	s.cfg.Store(&server)
+}
+
+// Run starts listening in the background. The returned channel receives an error if the server stops unexpectedly
+func (s *Server) Run() <-chan error {
+	errs := make(chan error, 1)
+
+	lis, err := net.Listen("tcp", s.listen)
+	if err != nil {
+		errs <- fmt.Errorf("grpcapi.Server.Run -> %w", err)
+		return errs
+	}
+	slog.Info("gRPC server started", "listen", lis.Addr().String())
+
+	go func() {
+		if err := s.grpcServer.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			errs <- fmt.Errorf("grpcapi.Server.Run -> %w", err)
+		}
+	}()
+	return errs
+}
+
+// Shutdown stops accepting connections and waits for the calls in progress until ctx is done, then closes them
+func (s *Server) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+		return fmt.Errorf("grpcapi.Server.Shutdown -> %w", ctx.Err())
+	}
+}
+
+func (s *Server) GetBalance(ctx context.Context, req *balancepb.GetBalanceRequest) (*balancepb.GetBalanceResponse, error) {
+	id, err := walletID(req.GetWalletId())
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := s.bill.CheckBalance(ctx, id)
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return &balancepb.GetBalanceResponse{Balance: balance}, nil
+}
+
+func (s *Server) GetHistory(req *balancepb.GetHistoryRequest, stream balancepb.Balance_GetHistoryServer) error {
+	id, err := walletID(req.GetWalletId())
+	if err != nil {
+		return err
+	}
+
+	orderBy := database.OrderByDate
+	if req.GetOrderBy() == balancepb.OrderBy_ORDER_BY_AMOUNT {
+		orderBy = database.OrderByAmount
+	}
+	order := database.Desc
+	if req.GetOrder() == balancepb.Order_ORDER_ASC {
+		order = database.Asc
+	}
+	limit := int(req.GetLimit())
+	if limit < 0 {
+		return status.Error(codes.InvalidArgument, "incorrect limit")
+	}
+	if limit == 0 {
+		limit = defaultHistoryLimit
+	}
+
+	history, err := s.bill.CheckHistory(stream.Context(), id, orderBy, order, limit)
+	if err != nil {
+		return statusError(stream.Context(), err)
+	}
+
+	for _, ch := range history {
+		err = stream.Send(&balancepb.HistoryEntry{
+			Date:         ch.Date,
+			Operation:    string(ch.Operation),
+			Amount:       ch.Amount.String(),
+			Description:  ch.Description,
+			Actor:        ch.Actor,
+			Counterparty: int64(ch.Counterparty),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) MoneyTransaction(ctx context.Context, req *balancepb.MoneyTransactionRequest) (*balancepb.TransactionResponse, error) {
+	id, err := walletID(req.GetWalletId())
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := parseAmount(req.GetAmount())
+	if err != nil {
+		return nil, err
+	}
+	if amount.IsZero() {
+		return completed(), nil
+	}
+	if req.GetDescription() == "" {
+		return nil, status.Error(codes.InvalidArgument, "required description")
+	}
+
+	operation := wallet.Replenishment
+	if amount.IsNegative() {
+		operation = wallet.Withdrawal
+		amount = amount.Neg()
+	}
+
+	return transactionResponse(ctx, s.bill.MoneyTransaction(ctx, id, operation, amount, req.GetDescription()))
+}
+
+func (s *Server) Transfer(ctx context.Context, req *balancepb.TransferRequest) (*balancepb.TransactionResponse, error) {
+	from, err := walletID(req.GetFrom())
+	if err != nil {
+		return nil, err
+	}
+	to, err := walletID(req.GetTo())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "required recipient")
+	}
+
+	amount, err := parseAmount(req.GetAmount())
+	if err != nil {
+		return nil, err
+	}
+	if !amount.IsPositive() {
+		return nil, status.Error(codes.InvalidArgument, "amount of a transfer must be positive")
+	}
+
+	return transactionResponse(ctx, s.bill.Transfer(ctx, from, to, amount))
+}
+
+func walletID(id int64) (int, error) {
+	if id <= 0 {
+		return 0, status.Error(codes.InvalidArgument, "incorrect wallet ID: invalid ID")
+	}
+	return int(id), nil
+}
+
+func parseAmount(amount string) (decimal.Decimal, error) {
+	d, err := decimal.NewFromString(amount)
+	if err != nil {
+		return decimal.Decimal{}, status.Error(codes.InvalidArgument, "incorrect amount: "+err.Error())
+	}
+	return d, nil
+}
+
+func completed() *balancepb.TransactionResponse {
+	return &balancepb.TransactionResponse{Status: balancepb.TransactionStatus_TRANSACTION_STATUS_COMPLETED}
+}
@@ -0,0 +1,149 @@
+package grpcapi
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/KseniiaSalmina/Balance/internal/api"
+	"github.com/KseniiaSalmina/Balance/internal/audit"
+	"github.com/KseniiaSalmina/Balance/internal/config"
+	"github.com/KseniiaSalmina/Balance/internal/database"
+	"github.com/KseniiaSalmina/Balance/internal/grpcapi/balancepb"
+	"github.com/KseniiaSalmina/Balance/internal/ratelimit"
+	"github.com/KseniiaSalmina/Balance/internal/risk"
+	"github.com/KseniiaSalmina/Balance/internal/wallet"
+)
+
+type fakeBilling struct {
+	api.BillingManager
+
+	actor   string
+	history []wallet.HistoryChange
+	err     error
+}
+
+func (b *fakeBilling) CheckBalance(ctx context.Context, id int) (string, error) {
+	b.actor = audit.Actor(ctx)
+	return "10.5", b.err
+}
+
+func (b *fakeBilling) CheckHistory(ctx context.Context, id int, orderBy database.OrderBy, order database.Order, limit int) ([]wallet.HistoryChange, error) {
+	return b.history, b.err
+}
+
+func (b *fakeBilling) MoneyTransaction(ctx context.Context, id int, opt wallet.Operation, amount decimal.Decimal, desc string) error {
+	return b.err
+}
+
+func (b *fakeBilling) Transfer(ctx context.Context, from, to int, amount decimal.Decimal) error {
+	return b.err
+}
+
+func newTestClient(t *testing.T, cfg config.Server, bill api.BillingManager) balancepb.BalanceClient {
+	s, err := NewServer(config.GRPC{}, cfg, bill, ratelimit.NewMemory())
+	assert.NoError(t, err)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	go s.grpcServer.Serve(lis)
+	t.Cleanup(s.grpcServer.Stop)
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return balancepb.NewBalanceClient(conn)
+}
+
+func TestServer_Authentication(t *testing.T) {
+	bill := &fakeBilling{}
+	client := newTestClient(t, config.Server{Auth: config.Auth{APIKeys: config.APIKeys{"secret": "shop"}}}, bill)
+	req := &balancepb.GetBalanceRequest{WalletId: 1}
+
+	_, err := client.GetBalance(context.Background(), req)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), apiKeyMetadata, "secret", userIDMetadata, "42")
+	resp, err := client.GetBalance(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, "10.5", resp.Balance)
+	assert.Equal(t, "shop/42", bill.actor)
+}
+
+func TestServer_RateLimit(t *testing.T) {
+	cfg := config.Server{RateLimit: config.RateLimit{WalletRate: 1, WalletBurst: 1}}
+	client := newTestClient(t, cfg, &fakeBilling{})
+	req := &balancepb.GetBalanceRequest{WalletId: 1}
+
+	_, err := client.GetBalance(context.Background(), req)
+	assert.NoError(t, err)
+	_, err = client.GetBalance(context.Background(), req)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestServer_MoneyTransaction(t *testing.T) {
+	tests := []struct {
+		name       string
+		req        *balancepb.MoneyTransactionRequest
+		err        error
+		wantCode   codes.Code
+		wantStatus balancepb.TransactionStatus
+	}{
+		{name: "completed", req: &balancepb.MoneyTransactionRequest{WalletId: 1, Amount: "-5", Description: "test"}, wantStatus: balancepb.TransactionStatus_TRANSACTION_STATUS_COMPLETED},
+		{name: "pending review", req: &balancepb.MoneyTransactionRequest{WalletId: 1, Amount: "5", Description: "test"}, err: &risk.ReviewRequiredError{ReviewID: 7}, wantStatus: balancepb.TransactionStatus_TRANSACTION_STATUS_PENDING_REVIEW},
+		{name: "invalid wallet", req: &balancepb.MoneyTransactionRequest{Amount: "5", Description: "test"}, wantCode: codes.InvalidArgument},
+		{name: "invalid amount", req: &balancepb.MoneyTransactionRequest{WalletId: 1, Amount: "five", Description: "test"}, wantCode: codes.InvalidArgument},
+		{name: "without description", req: &balancepb.MoneyTransactionRequest{WalletId: 1, Amount: "5"}, wantCode: codes.InvalidArgument},
+		{name: "insufficient funds", req: &balancepb.MoneyTransactionRequest{WalletId: 1, Amount: "-5", Description: "test"}, err: wallet.InsufficientFundsErr, wantCode: codes.InvalidArgument},
+		{name: "denied", req: &balancepb.MoneyTransactionRequest{WalletId: 1, Amount: "-5", Description: "test"}, err: risk.DeniedErr, wantCode: codes.PermissionDenied},
+		{name: "internal", req: &balancepb.MoneyTransactionRequest{WalletId: 1, Amount: "-5", Description: "test"}, err: io.ErrUnexpectedEOF, wantCode: codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, config.Server{}, &fakeBilling{err: tt.err})
+
+			resp, err := client.MoneyTransaction(context.Background(), tt.req)
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			if tt.wantCode == codes.OK {
+				assert.Equal(t, tt.wantStatus, resp.Status)
+			}
+			if tt.wantStatus == balancepb.TransactionStatus_TRANSACTION_STATUS_PENDING_REVIEW {
+				assert.Equal(t, int64(7), resp.ReviewId)
+			}
+		})
+	}
+}
+
+func TestServer_GetHistory(t *testing.T) {
+	bill := &fakeBilling{history: []wallet.HistoryChange{
+		{Operation: wallet.Replenishment, Amount: decimal.NewFromInt(10), Description: "first"},
+		{Operation: wallet.Withdrawal, Amount: decimal.NewFromInt(3), Description: "second"},
+	}}
+	client := newTestClient(t, config.Server{}, bill)
+
+	stream, err := client.GetHistory(context.Background(), &balancepb.GetHistoryRequest{WalletId: 1})
+	assert.NoError(t, err)
+
+	var got []string
+	for {
+		entry, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if !assert.NoError(t, err) {
+			return
+		}
+		got = append(got, entry.Description+":"+entry.Amount)
+	}
+	assert.Equal(t, []string{"first:10", "second:3"}, got)
+}
@@ -0,0 +1,77 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var (
+	FinishedErr  = errors.New("job is already finished")
+	PermanentErr = errors.New("job can not succeed") //wrapped by handlers to fail the job without retries
+)
+
+type Status string
+
+const (
+	Queued    Status = "queued"
+	Running   Status = "running"
+	Succeeded Status = "succeeded"
+	Failed    Status = "failed"   //all attempts failed
+	Canceled  Status = "canceled" //canceled before it was finished, the artifact can hold the partial result
+)
+
+// Job is a long-running operation executed by the workers of any instance
+type Job struct {
+	ID              int64           `json:"id"`
+	Type            string          `json:"type"`
+	Status          Status          `json:"status"`
+	Payload         json.RawMessage `json:"-"`
+	Attempts        int             `json:"attempts"`
+	MaxAttempts     int             `json:"max_attempts"`
+	RunAt           int64           `json:"run_at"`                     //time of the next attempt of the queued job
+	CancelRequested bool            `json:"cancel_requested,omitempty"` //running job is stopping
+	LastError       string          `json:"last_error,omitempty"`
+	ArtifactType    string          `json:"artifact_type,omitempty"` //content type of the artifact, empty if there is none
+	Actor           string          `json:"actor"`
+	Created         int64           `json:"created"`
+	Updated         int64           `json:"updated"`
+}
+
+// Artifact is the result of the job
+type Artifact struct {
+	ContentType string
+	Data        []byte
+}
+
+// New creates the queued job, payload is encoded to JSON
+func New(typ string, payload any, maxAttempts int, actor string, now int64) (Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Job{}, fmt.Errorf("jobs.New -> %w", err)
+	}
+
+	return Job{
+		Type:        typ,
+		Status:      Queued,
+		Payload:     data,
+		MaxAttempts: maxAttempts,
+		RunAt:       now,
+		Actor:       actor,
+		Created:     now,
+		Updated:     now,
+	}, nil
+}
+
+// JSONArtifact encodes v to the JSON artifact
+func JSONArtifact(v any) (*Artifact, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("JSONArtifact -> %w", err)
+	}
+	return &Artifact{ContentType: "application/json", Data: data}, nil
+}
+
+func (j Job) Finished() bool {
+	return j.Status == Succeeded || j.Status == Failed || j.Status == Canceled
+}
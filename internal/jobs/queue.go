@@ -0,0 +1,231 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/KseniiaSalmina/Balance/internal/audit"
+	"github.com/KseniiaSalmina/Balance/internal/config"
+	"github.com/KseniiaSalmina/Balance/internal/metrics"
+)
+
+// Handler executes the job. The context is canceled when the job is canceled, the returned artifact is saved
+// even if the job fails, so it can hold the partial result
+type Handler func(ctx context.Context, job Job) (*Artifact, error)
+
+// Store keeps the jobs
+type Store interface {
+	// ClaimJobs returns up to limit jobs due at now as running and locks them until lease,
+	// so the other instances do not run them at the same time
+	ClaimJobs(now, lease time.Time, limit int) ([]Job, error)
+	// ExtendJobLease locks the running job until lease, reports whether the job must stop
+	ExtendJobLease(id int64, lease time.Time) (bool, error)
+	SaveJob(j Job, artifact *Artifact) error
+}
+
+// Queue runs the jobs with JOBS_WORKERS workers. If the instance stops while running a job,
+// the job is run again by any instance after the lease expires, if it has attempts left
+type Queue struct {
+	store    Store
+	cfg      config.Jobs
+	handlers map[string]Handler
+	now      func() time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewQueue(store Store, cfg config.Jobs) *Queue {
+	return &Queue{
+		store:    store,
+		cfg:      cfg,
+		handlers: make(map[string]Handler),
+		now:      time.Now,
+	}
+}
+
+// Register sets the handler of the job type, must be called before Run
+func (q *Queue) Register(typ string, h Handler) {
+	q.handlers[typ] = h
+}
+
+// Run starts the workers in the background
+func (q *Queue) Run() {
+	ctx, cancel := context.WithCancel(context.Background())
+	q.cancel, q.done = cancel, make(chan struct{})
+
+	go func() {
+		var wg sync.WaitGroup
+		defer close(q.done)
+		defer wg.Wait()
+
+		slots := make(chan struct{}, q.cfg.Workers)
+		freed := make(chan struct{}, 1) //wakes the loop when a worker is free, so the queue is not waited for
+		timer := time.NewTimer(0)
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+			case <-freed:
+				timer.Stop()
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+
+			free := q.cfg.Workers - len(slots)
+			if free == 0 {
+				timer.Reset(q.cfg.PollInterval)
+				continue
+			}
+
+			now := q.now()
+			jobs, err := q.store.ClaimJobs(now, now.Add(q.cfg.Lease), free)
+			if err != nil {
+				slog.Error("jobs are not claimed", "error", err)
+			}
+			for _, j := range jobs {
+				slots <- struct{}{}
+				wg.Add(1)
+				go func(j Job) {
+					defer wg.Done()
+					q.process(j)
+
+					<-slots
+					select {
+					case freed <- struct{}{}:
+					default:
+					}
+				}(j)
+			}
+
+			if len(jobs) == free {
+				timer.Reset(0)
+			} else {
+				timer.Reset(q.cfg.PollInterval)
+			}
+		}
+	}()
+}
+
+// Shutdown stops claiming jobs and waits for the running ones until ctx is done
+func (q *Queue) Shutdown(ctx context.Context) error {
+	q.cancel()
+	select {
+	case <-q.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("jobs.Queue.Shutdown -> %w", ctx.Err())
+	}
+}
+
+// process runs the claimed job and saves its result
+func (q *Queue) process(j Job) {
+	log := slog.With("job_id", j.ID, "job_type", j.Type, "attempt", j.Attempts)
+
+	//the job is not bound to the request that created it, only the actor is kept for the history
+	ctx, cancel := context.WithCancel(audit.WithActor(context.Background(), j.Actor))
+	defer cancel()
+
+	var canceled atomic.Bool
+	leased := make(chan struct{})
+	go func() {
+		defer close(leased)
+		q.keepLease(ctx, j.ID, func() {
+			canceled.Store(true)
+			cancel()
+		})
+	}()
+
+	artifact, err := q.run(ctx, j)
+	cancel()
+	<-leased
+
+	j = q.result(j, err, canceled.Load())
+	if artifact != nil {
+		j.ArtifactType = artifact.ContentType
+	}
+	metrics.Job(j.Type, string(j.Status))
+
+	if err = q.store.SaveJob(j, artifact); err != nil {
+		log.Error("job result is not saved", "error", err)
+		return
+	}
+	log.Info("job finished", "status", j.Status, "error", j.LastError)
+}
+
+func (q *Queue) run(ctx context.Context, j Job) (_ *Artifact, err error) {
+	h, ok := q.handlers[j.Type]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown job type %q", PermanentErr, j.Type)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: panic: %v", PermanentErr, r)
+		}
+	}()
+	return h(ctx, j)
+}
+
+// keepLease extends the lease of the running job until ctx is done, stop is called if the job is canceled
+// or its lease is lost
+func (q *Queue) keepLease(ctx context.Context, id int64, stop func()) {
+	ticker := time.NewTicker(q.cfg.Lease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		canceled, err := q.store.ExtendJobLease(id, q.now().Add(q.cfg.Lease))
+		if err != nil {
+			slog.Error("job lease is not extended", "job_id", id, "error", err)
+			continue
+		}
+		if canceled {
+			stop()
+			return
+		}
+	}
+}
+
+// result returns the job with the status set by the result of the attempt
+func (q *Queue) result(j Job, err error, canceled bool) Job {
+	now := q.now()
+	j.Updated = now.Unix()
+	switch {
+	case err == nil:
+		j.Status, j.LastError = Succeeded, ""
+	case canceled:
+		j.Status, j.LastError = Canceled, err.Error()
+	case errors.Is(err, PermanentErr) || j.Attempts >= j.MaxAttempts:
+		j.Status, j.LastError = Failed, err.Error()
+	default:
+		j.Status, j.LastError = Queued, err.Error()
+		j.RunAt = now.Add(q.backoff(j.Attempts)).Unix()
+	}
+	return j
+}
+
+// backoff returns the delay before the next attempt: JOBS_RETRY_MIN doubled with every failed attempt up to JOBS_RETRY_MAX
+func (q *Queue) backoff(attempts int) time.Duration {
+	delay := q.cfg.RetryMin
+	for i := 1; i < attempts && delay < q.cfg.RetryMax; i++ {
+		delay *= 2
+	}
+	if delay > q.cfg.RetryMax {
+		delay = q.cfg.RetryMax
+	}
+	return delay
+}
@@ -0,0 +1,172 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/KseniiaSalmina/Balance/internal/config"
+)
+
+type fakeStore struct {
+	mu       sync.Mutex
+	queued   []Job
+	canceled bool
+	saved    []Job
+	artifact *Artifact
+}
+
+func (s *fakeStore) ClaimJobs(now, lease time.Time, limit int) ([]Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.queued) < limit {
+		limit = len(s.queued)
+	}
+	claimed := s.queued[:limit]
+	s.queued = s.queued[limit:]
+	for i := range claimed {
+		claimed[i].Status = Running
+		claimed[i].Attempts++
+	}
+	return claimed, nil
+}
+
+func (s *fakeStore) ExtendJobLease(id int64, lease time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.canceled, nil
+}
+
+func (s *fakeStore) SaveJob(j Job, artifact *Artifact) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.saved = append(s.saved, j)
+	s.artifact = artifact
+	return nil
+}
+
+var testConfig = config.Jobs{Workers: 2, PollInterval: time.Second, Lease: 30 * time.Millisecond, RetryMin: 10 * time.Second, RetryMax: 30 * time.Second}
+
+func TestQueue_process(t *testing.T) {
+	failure := errors.New("temporary failure")
+	now := time.Unix(1000, 0)
+
+	tests := []struct {
+		name        string
+		job         Job
+		handler     Handler
+		canceled    bool
+		wantStatus  Status
+		wantRunAt   int64
+		wantContent string
+	}{
+		{
+			name:        "succeeded",
+			job:         Job{Type: "test", Attempts: 1, MaxAttempts: 3},
+			handler:     func(ctx context.Context, j Job) (*Artifact, error) { return JSONArtifact("done") },
+			wantStatus:  Succeeded,
+			wantContent: `"done"`,
+		},
+		{
+			name:       "first retry",
+			job:        Job{Type: "test", Attempts: 1, MaxAttempts: 3},
+			handler:    func(ctx context.Context, j Job) (*Artifact, error) { return nil, failure },
+			wantStatus: Queued,
+			wantRunAt:  1010,
+		},
+		{
+			name:       "retry delay is doubled",
+			job:        Job{Type: "test", Attempts: 2, MaxAttempts: 3},
+			handler:    func(ctx context.Context, j Job) (*Artifact, error) { return nil, failure },
+			wantStatus: Queued,
+			wantRunAt:  1020,
+		},
+		{
+			name:       "failed after the last attempt",
+			job:        Job{Type: "test", Attempts: 3, MaxAttempts: 3},
+			handler:    func(ctx context.Context, j Job) (*Artifact, error) { return nil, failure },
+			wantStatus: Failed,
+		},
+		{
+			name:       "permanent error is not retried",
+			job:        Job{Type: "test", Attempts: 1, MaxAttempts: 3},
+			handler:    func(ctx context.Context, j Job) (*Artifact, error) { return nil, PermanentErr },
+			wantStatus: Failed,
+		},
+		{
+			name:       "panic is not retried",
+			job:        Job{Type: "test", Attempts: 1, MaxAttempts: 3},
+			handler:    func(ctx context.Context, j Job) (*Artifact, error) { panic("bug") },
+			wantStatus: Failed,
+		},
+		{
+			name:       "unknown type",
+			job:        Job{Type: "unknown", Attempts: 1, MaxAttempts: 3},
+			wantStatus: Failed,
+		},
+		{
+			name: "canceled with the partial result",
+			job:  Job{Type: "test", Attempts: 1, MaxAttempts: 3},
+			handler: func(ctx context.Context, j Job) (*Artifact, error) {
+				<-ctx.Done()
+				return &Artifact{ContentType: "text/plain", Data: []byte("partial")}, ctx.Err()
+			},
+			canceled:    true,
+			wantStatus:  Canceled,
+			wantContent: "partial",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeStore{canceled: tt.canceled}
+			q := NewQueue(store, testConfig)
+			q.now = func() time.Time { return now }
+			if tt.handler != nil {
+				q.Register("test", tt.handler)
+			}
+
+			q.process(tt.job)
+
+			assert.Len(t, store.saved, 1)
+			assert.Equal(t, tt.wantStatus, store.saved[0].Status)
+			if tt.wantStatus == Queued {
+				assert.Equal(t, tt.wantRunAt, store.saved[0].RunAt)
+			}
+			if tt.wantContent != "" {
+				assert.Equal(t, tt.wantContent, string(store.artifact.Data))
+				assert.Equal(t, store.artifact.ContentType, store.saved[0].ArtifactType)
+			}
+		})
+	}
+}
+
+func TestQueue_Run(t *testing.T) {
+	store := &fakeStore{queued: []Job{{ID: 1, Type: "test", MaxAttempts: 1}, {ID: 2, Type: "test", MaxAttempts: 1}, {ID: 3, Type: "test", MaxAttempts: 1}}}
+	q := NewQueue(store, testConfig)
+
+	var mu sync.Mutex
+	ran := make(map[int64]bool)
+	q.Register("test", func(ctx context.Context, j Job) (*Artifact, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		ran[j.ID] = true
+		return nil, nil
+	})
+
+	q.Run()
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(ran) == 3
+	}, time.Second, 10*time.Millisecond)
+	assert.NoError(t, q.Shutdown(context.Background()))
+
+	assert.Len(t, store.saved, 3)
+	for _, j := range store.saved {
+		assert.Equal(t, Succeeded, j.Status)
+	}
+}
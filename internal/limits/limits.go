@@ -0,0 +1,107 @@
+package limits
+
+import (
+	"errors"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+var LimitExceededErr = errors.New("limit_exceeded")
+
+// Limits restrict withdrawals and transfers of a wallet. Null value means no limit
+type Limits struct {
+	MaxWithdrawal     decimal.NullDecimal `json:"max_withdrawal" swaggertype:"number"`     //single withdrawal
+	MaxTransfer       decimal.NullDecimal `json:"max_transfer" swaggertype:"number"`       //single transfer
+	DailyWithdrawal   decimal.NullDecimal `json:"daily_withdrawal" swaggertype:"number"`   //withdrawals and outgoing transfers per calendar day
+	MonthlyWithdrawal decimal.NullDecimal `json:"monthly_withdrawal" swaggertype:"number"` //withdrawals and outgoing transfers per calendar month
+}
+
+// Override returns limits where the set values of wallet limits replace the global ones
+func (l Limits) Override(wallet Limits) Limits {
+	pick := func(global, own decimal.NullDecimal) decimal.NullDecimal {
+		if own.Valid {
+			return own
+		}
+		return global
+	}
+
+	return Limits{
+		MaxWithdrawal:     pick(l.MaxWithdrawal, wallet.MaxWithdrawal),
+		MaxTransfer:       pick(l.MaxTransfer, wallet.MaxTransfer),
+		DailyWithdrawal:   pick(l.DailyWithdrawal, wallet.DailyWithdrawal),
+		MonthlyWithdrawal: pick(l.MonthlyWithdrawal, wallet.MonthlyWithdrawal),
+	}
+}
+
+// Kind of operation checked against the limits
+type Kind string
+
+const (
+	Withdrawal Kind = "withdrawal"
+	Transfer   Kind = "transfer"
+)
+
+// Usage is the amount already withdrawn from the wallet in the current periods
+type Usage struct {
+	Daily   decimal.Decimal
+	Monthly decimal.Decimal
+}
+
+// ExceededError reports which limit does not allow the operation and how much can still be spent
+type ExceededError struct {
+	Limit     string          `json:"limit"`
+	Max       decimal.Decimal `json:"max"`
+	Remaining decimal.Decimal `json:"remaining"`
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("%s: %s limit is %s, remaining allowance %s", LimitExceededErr, e.Limit, e.Max, e.Remaining)
+}
+
+func (e *ExceededError) Unwrap() error {
+	return LimitExceededErr
+}
+
+// Check returns ExceededError if the operation with amount breaks any of the limits
+func (l Limits) Check(kind Kind, amount decimal.Decimal, usage Usage) error {
+	single := l.MaxWithdrawal
+	singleName := "max_withdrawal"
+	if kind == Transfer {
+		single, singleName = l.MaxTransfer, "max_transfer"
+	}
+
+	checks := []struct {
+		name  string
+		limit decimal.NullDecimal
+		used  decimal.Decimal
+	}{
+		{name: singleName, limit: single, used: decimal.Zero},
+		{name: "daily_withdrawal", limit: l.DailyWithdrawal, used: usage.Daily},
+		{name: "monthly_withdrawal", limit: l.MonthlyWithdrawal, used: usage.Monthly},
+	}
+
+	for _, c := range checks {
+		if !c.limit.Valid {
+			continue
+		}
+		if c.used.Add(amount).GreaterThan(c.limit.Decimal) {
+			remaining := decimal.Max(c.limit.Decimal.Sub(c.used), decimal.Zero)
+			return &ExceededError{Limit: c.name, Max: c.limit.Decimal, Remaining: remaining}
+		}
+	}
+
+	return nil
+}
+
+// DayStart returns the beginning of the calendar day of t in the location of t
+func DayStart(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// MonthStart returns the beginning of the calendar month of t in the location of t
+func MonthStart(t time.Time) time.Time {
+	y, m, _ := t.Date()
+	return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+}
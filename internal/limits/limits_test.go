@@ -0,0 +1,73 @@
+package limits
+
+import (
+	"errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func limit(v int64) decimal.NullDecimal {
+	return decimal.NewNullDecimal(decimal.NewFromInt(v))
+}
+
+func TestLimits_Check(t *testing.T) {
+	l := Limits{MaxWithdrawal: limit(1000), MaxTransfer: limit(500), DailyWithdrawal: limit(2000), MonthlyWithdrawal: limit(10000)}
+
+	tests := []struct {
+		name          string
+		limits        Limits
+		kind          Kind
+		amount        decimal.Decimal
+		usage         Usage
+		wantLimit     string
+		wantRemaining decimal.Decimal
+	}{
+		{name: "no limits", limits: Limits{}, kind: Withdrawal, amount: decimal.NewFromInt(1000000)},
+		{name: "withdrawal within limits", limits: l, kind: Withdrawal, amount: decimal.NewFromInt(1000), usage: Usage{Daily: decimal.NewFromInt(1000), Monthly: decimal.NewFromInt(1000)}},
+		{name: "single withdrawal", limits: l, kind: Withdrawal, amount: decimal.NewFromInt(1001), wantLimit: "max_withdrawal", wantRemaining: decimal.NewFromInt(1000)},
+		{name: "single transfer", limits: l, kind: Transfer, amount: decimal.NewFromInt(501), wantLimit: "max_transfer", wantRemaining: decimal.NewFromInt(500)},
+		{name: "transfer is not limited by max withdrawal", limits: Limits{MaxWithdrawal: limit(10)}, kind: Transfer, amount: decimal.NewFromInt(100)},
+		{name: "daily withdrawal", limits: l, kind: Withdrawal, amount: decimal.NewFromInt(600), usage: Usage{Daily: decimal.NewFromInt(1500)}, wantLimit: "daily_withdrawal", wantRemaining: decimal.NewFromInt(500)},
+		{name: "monthly withdrawal", limits: l, kind: Transfer, amount: decimal.NewFromInt(100), usage: Usage{Monthly: decimal.NewFromInt(9950)}, wantLimit: "monthly_withdrawal", wantRemaining: decimal.NewFromInt(50)},
+		{name: "remaining is not negative", limits: l, kind: Withdrawal, amount: decimal.NewFromInt(1), usage: Usage{Daily: decimal.NewFromInt(2500)}, wantLimit: "daily_withdrawal", wantRemaining: decimal.Zero},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.limits.Check(tt.kind, tt.amount, tt.usage)
+			if tt.wantLimit == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.ErrorIs(t, err, LimitExceededErr)
+			var exceeded *ExceededError
+			if assert.True(t, errors.As(err, &exceeded)) {
+				assert.Equal(t, tt.wantLimit, exceeded.Limit)
+				assert.Equal(t, tt.wantRemaining.String(), exceeded.Remaining.String())
+			}
+		})
+	}
+}
+
+func TestLimits_Override(t *testing.T) {
+	global := Limits{MaxWithdrawal: limit(1000), DailyWithdrawal: limit(2000)}
+	own := Limits{DailyWithdrawal: limit(5000), MaxTransfer: limit(100)}
+
+	got := global.Override(own)
+
+	assert.Equal(t, limit(1000), got.MaxWithdrawal)
+	assert.Equal(t, limit(100), got.MaxTransfer)
+	assert.Equal(t, limit(5000), got.DailyWithdrawal)
+	assert.False(t, got.MonthlyWithdrawal.Valid)
+}
+
+func TestPeriodStart(t *testing.T) {
+	loc := time.FixedZone("MSK", 3*60*60)
+	now := time.Date(2024, time.March, 15, 1, 30, 0, 0, loc)
+
+	assert.Equal(t, time.Date(2024, time.March, 15, 0, 0, 0, 0, loc), DayStart(now))
+	assert.Equal(t, time.Date(2024, time.March, 1, 0, 0, 0, 0, loc), MonthStart(now))
+}
@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"testing"
+
+	"github.com/KseniiaSalmina/Balance/internal/config"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.Log
+		wantErr bool
+		written bool
+	}{
+		{name: "text", cfg: config.Log{Level: slog.LevelInfo, Format: "text"}, written: true},
+		{name: "json", cfg: config.Log{Level: slog.LevelInfo, Format: "json"}, written: true},
+		{name: "level filters records", cfg: config.Log{Level: slog.LevelWarn, Format: "text"}, written: false},
+		{name: "unknown format", cfg: config.Log{Format: "xml"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l, err := New(tt.cfg, &buf)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			l.Info("test")
+			assert.Equal(t, tt.written, buf.Len() > 0)
+		})
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New(config.Log{Level: slog.LevelInfo, Format: "json"}, &buf)
+	assert.NoError(t, err)
+
+	ctx := WithRequestID(context.Background(), "abc")
+	assert.Equal(t, "abc", RequestID(ctx))
+	assert.Equal(t, "", RequestID(context.Background()))
+
+	l.With("component", "test").InfoContext(ctx, "message")
+
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "abc", record["request_id"])
+	assert.Equal(t, "test", record["component"])
+	assert.NotContains(t, record, "trace_id")
+}
@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/shopspring/decimal"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const namespace = "balance"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of HTTP requests by route name, method and status code.",
+	}, []string{"route", "method", "code"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of HTTP requests by route name and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	operations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "operations_total",
+		Help:      "Number of committed operations by type.",
+	}, []string{"operation"})
+
+	operationAmounts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "operation_amount_total",
+		Help:      "Sum of committed operation amounts by type.",
+	}, []string{"operation"})
+
+	insufficientFunds = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "insufficient_funds_total",
+		Help:      "Number of operations rejected because of insufficient funds.",
+	})
+
+	dbTransactions = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_transaction_duration_seconds",
+		Help:      "Duration of database transactions by result: commit or rollback.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"result"})
+
+	dbErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_errors_total",
+		Help:      "Number of database errors by stage: begin or commit.",
+	}, []string{"stage"})
+
+	webhookDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_deliveries_total",
+		Help:      "Number of webhook delivery attempts by result: delivered, failed or dead.",
+	}, []string{"result"})
+
+	jobs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "jobs_total",
+		Help:      "Number of job attempts by job type and resulting status: succeeded, queued for retry, failed or canceled.",
+	}, []string{"type", "status"})
+
+	events = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "events_total",
+		Help:      "Number of domain events by result of publishing: published or failed.",
+	}, []string{"result"})
+)
+
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+func HTTPRequest(route, method string, code int, duration time.Duration) {
+	httpRequests.WithLabelValues(route, method, strconv.Itoa(code)).Inc()
+	httpDuration.WithLabelValues(route, method).Observe(duration.Seconds())
+}
+
+func Operation(operation string, amount decimal.Decimal) {
+	operations.WithLabelValues(operation).Inc()
+	operationAmounts.WithLabelValues(operation).Add(amount.InexactFloat64())
+}
+
+func InsufficientFunds() {
+	insufficientFunds.Inc()
+}
+
+func DBTransaction(result string, duration time.Duration) {
+	dbTransactions.WithLabelValues(result).Observe(duration.Seconds())
+}
+
+func DBError(stage string) {
+	dbErrors.WithLabelValues(stage).Inc()
+}
+
+func WebhookDelivery(result string) {
+	webhookDeliveries.WithLabelValues(result).Inc()
+}
+
+func Job(jobType, status string) {
+	jobs.WithLabelValues(jobType, status).Inc()
+}
+
+func EventsPublished(result string, n int) {
+	events.WithLabelValues(result).Add(float64(n))
+}
@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOperation(t *testing.T) {
+	before := testutil.ToFloat64(operationAmounts.WithLabelValues("transfer"))
+
+	Operation("transfer", decimal.RequireFromString("100.5"))
+	Operation("transfer", decimal.NewFromInt(20))
+
+	assert.Equal(t, 120.5, testutil.ToFloat64(operationAmounts.WithLabelValues("transfer"))-before)
+}
+
+func TestHTTPRequest(t *testing.T) {
+	HTTPRequest("get_balance", "GET", 200, 10*time.Millisecond)
+	HTTPRequest("get_balance", "GET", 400, 10*time.Millisecond)
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(httpRequests.WithLabelValues("get_balance", "GET", "400")))
+	assert.Equal(t, 1, testutil.CollectAndCount(httpDuration, "balance_http_request_duration_seconds"))
+}
+
+func TestPoolCollector(t *testing.T) {
+	c := &poolCollector{
+		stat: func() (int, int, int) { return 10, 4, 1 },
+		desc: prometheus.NewDesc("balance_db_pool_connections", "Number of database pool connections by state: max, current or available.", []string{"state"}, nil),
+	}
+
+	expected := `
+# HELP balance_db_pool_connections Number of database pool connections by state: max, current or available.
+# TYPE balance_db_pool_connections gauge
+balance_db_pool_connections{state="available"} 1
+balance_db_pool_connections{state="current"} 4
+balance_db_pool_connections{state="max"} 10
+`
+	assert.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(expected)))
+}
@@ -0,0 +1,31 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// PoolStat returns the maximum, current and available number of connections of the database pool
+type PoolStat func() (max, current, available int)
+
+type poolCollector struct {
+	stat PoolStat
+	desc *prometheus.Desc
+}
+
+// RegisterPool exports database pool statistics
+func RegisterPool(stat PoolStat) error {
+	return prometheus.Register(&poolCollector{
+		stat: stat,
+		desc: prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", "connections"),
+			"Number of database pool connections by state: max, current or available.", []string{"state"}, nil),
+	})
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	max, current, available := c.stat()
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(max), "max")
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(current), "current")
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(available), "available")
+}
@@ -0,0 +1,93 @@
+package notify
+
+import (
+	"sync"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/KseniiaSalmina/Balance/internal/wallet"
+)
+
+// subscriptionBuffer is the number of events kept for a subscriber that does not read them in time,
+// on overflow the subscription is closed and the client has to reconnect and replay the missed events
+const subscriptionBuffer = 64
+
+// Event is a committed change of the wallet balance
+type Event struct {
+	ID       int64                `json:"id"` //ID of the history record, increases with every change of the wallet
+	WalletID int                  `json:"wallet_id"`
+	Balance  decimal.Decimal      `json:"balance"` //balance after the change
+	Change   wallet.HistoryChange `json:"change"`
+}
+
+// Hub delivers the committed changes to the subscribers of the wallet. Only the changes committed by this instance are delivered
+type Hub struct {
+	mu   sync.Mutex
+	subs map[int]map[*Subscription]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: make(map[int]map[*Subscription]struct{})}
+}
+
+// Subscription receives the events of one wallet, C is closed when the subscription is closed or overflowed
+type Subscription struct {
+	C <-chan Event
+
+	hub      *Hub
+	walletID int
+	events   chan Event
+	closed   bool
+}
+
+func (h *Hub) Subscribe(walletID int) *Subscription {
+	events := make(chan Event, subscriptionBuffer)
+	s := &Subscription{C: events, hub: h, walletID: walletID, events: events}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subs[walletID] == nil {
+		h.subs[walletID] = make(map[*Subscription]struct{})
+	}
+	h.subs[walletID][s] = struct{}{}
+	return s
+}
+
+// Publish sends the events to the subscribers of their wallets, it never blocks. Nil hub ignores the events
+func (h *Hub) Publish(events ...Event) {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, e := range events {
+		for s := range h.subs[e.WalletID] {
+			select {
+			case s.events <- e:
+			default:
+				h.remove(s)
+			}
+		}
+	}
+}
+
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s)
+}
+
+// remove must be called with the lock held
+func (h *Hub) remove(s *Subscription) {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	close(s.events)
+
+	delete(h.subs[s.walletID], s)
+	if len(h.subs[s.walletID]) == 0 {
+		delete(h.subs, s.walletID)
+	}
+}
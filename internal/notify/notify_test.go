@@ -0,0 +1,34 @@
+package notify
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestHub(t *testing.T) {
+	h := NewHub()
+	first, second, other := h.Subscribe(1), h.Subscribe(1), h.Subscribe(2)
+
+	h.Publish(Event{ID: 1, WalletID: 1}, Event{ID: 2, WalletID: 2})
+	assert.Equal(t, int64(1), (<-first.C).ID)
+	assert.Equal(t, int64(1), (<-second.C).ID)
+	assert.Equal(t, int64(2), (<-other.C).ID)
+
+	second.Close()
+	second.Close()
+	_, ok := <-second.C
+	assert.False(t, ok, "closed subscription must not receive events")
+
+	h.Publish(Event{ID: 3, WalletID: 1})
+	assert.Equal(t, int64(3), (<-first.C).ID)
+
+	for i := 0; i <= subscriptionBuffer; i++ {
+		h.Publish(Event{ID: int64(i), WalletID: 2})
+	}
+	for range other.C {
+	}
+	assert.Empty(t, h.subs[2], "overflowed subscription must be removed")
+
+	var nilHub *Hub
+	nilHub.Publish(Event{ID: 4, WalletID: 1})
+}
@@ -0,0 +1,34 @@
+package ratelimit
+
+import (
+	"fmt"
+	"time"
+)
+
+// BucketStore loads the bucket by key, lets fn change it and saves it atomically
+type BucketStore interface {
+	UpdateBucket(key string, fn func(b *Bucket)) error
+}
+
+// Postgres keeps buckets in the database, so limits are shared between service instances
+type Postgres struct {
+	store BucketStore
+}
+
+func NewPostgres(store BucketStore) *Postgres {
+	return &Postgres{store: store}
+}
+
+func (p *Postgres) Take(key string, limit Limit) (bool, time.Duration, error) {
+	var allowed bool
+	var wait time.Duration
+
+	err := p.store.UpdateBucket(key, func(b *Bucket) {
+		allowed, wait = b.Take(limit, time.Now())
+	})
+	if err != nil {
+		return false, 0, fmt.Errorf("ratelimit.Postgres.Take -> %w", err)
+	}
+
+	return allowed, wait, nil
+}
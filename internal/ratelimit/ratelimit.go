@@ -0,0 +1,51 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+// Limit describes a token bucket: Rate tokens are added every second up to Burst tokens
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Disabled reports whether the limit allows any number of requests
+func (l Limit) Disabled() bool {
+	return l.Rate <= 0 || l.Burst <= 0
+}
+
+// Backend stores token buckets by key
+type Backend interface {
+	// Take consumes one token from the bucket by key. If the bucket is empty it returns false and the time after which the token will be available
+	Take(key string, limit Limit) (bool, time.Duration, error)
+}
+
+type Bucket struct {
+	Tokens  float64
+	Updated time.Time
+}
+
+// Take refills the bucket for the time passed since the last update and consumes one token if possible
+func (b *Bucket) Take(l Limit, now time.Time) (bool, time.Duration) {
+	if b.Updated.IsZero() {
+		b.Tokens = float64(l.Burst)
+	} else if elapsed := now.Sub(b.Updated); elapsed > 0 {
+		b.Tokens = math.Min(float64(l.Burst), b.Tokens+elapsed.Seconds()*l.Rate)
+	}
+	b.Updated = now
+
+	if b.Tokens >= 1 {
+		b.Tokens--
+		return true, 0
+	}
+
+	wait := time.Duration((1 - b.Tokens) / l.Rate * float64(time.Second))
+	return false, wait
+}
+
+// full reports whether the bucket would be full at the moment now, so it can be forgotten
+func (b *Bucket) full(l Limit, now time.Time) bool {
+	return b.Tokens+now.Sub(b.Updated).Seconds()*l.Rate >= float64(l.Burst)
+}
@@ -0,0 +1,59 @@
+package ratelimit
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBucket_Take(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	limit := Limit{Rate: 2, Burst: 3}
+
+	b := &Bucket{}
+	for i := 0; i < 3; i++ {
+		ok, _ := b.Take(limit, start)
+		assert.True(t, ok, "burst token %d", i)
+	}
+
+	ok, wait := b.Take(limit, start)
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	ok, _ = b.Take(limit, start.Add(500*time.Millisecond))
+	assert.True(t, ok)
+
+	ok, _ = b.Take(limit, start.Add(time.Hour))
+	assert.True(t, ok)
+	assert.Equal(t, float64(limit.Burst-1), b.Tokens)
+}
+
+func TestMemory_Take(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	m := NewMemory()
+	m.now = func() time.Time { return now }
+	limit := Limit{Rate: 1, Burst: 1}
+
+	ok, _, err := m.Take("client:shop", limit)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, wait, err := m.Take("client:shop", limit)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, time.Second, wait)
+
+	ok, _, _ = m.Take("client:bank", limit)
+	assert.True(t, ok, "keys must not share buckets")
+
+	now = now.Add(2 * sweepInterval)
+	ok, _, _ = m.Take("client:shop", limit)
+	assert.True(t, ok)
+	assert.Len(t, m.buckets, 1, "refilled buckets must be swept")
+}
+
+func TestLimit_Disabled(t *testing.T) {
+	assert.True(t, Limit{}.Disabled())
+	assert.True(t, Limit{Rate: 1}.Disabled())
+	assert.False(t, Limit{Rate: 1, Burst: 1}.Disabled())
+}
@@ -0,0 +1,169 @@
+package recurring
+
+import (
+	"errors"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+var (
+	InvalidPaymentErr = errors.New("invalid recurring payment")
+	NotActiveErr      = errors.New("recurring payment is already completed or canceled")
+)
+
+type Policy string
+
+const (
+	Skip  Policy = "skip"  //failed occurrence is skipped, the payment continues with the next one
+	Retry Policy = "retry" //failed occurrence is retried after the retry interval up to max retries, then skipped
+)
+
+type Status string
+
+const (
+	Active    Status = "active"
+	Completed Status = "completed" //end date or max occurrences is reached
+	Canceled  Status = "canceled"
+)
+
+const DefaultRetryInterval = int64(time.Hour / time.Second)
+
+// Payment is a transfer repeated by the rule. Every occurrence is executed as a scheduled operation,
+// the next one is scheduled when the previous one is finished
+type Payment struct {
+	ID       int64           `json:"id"`
+	WalletID int             `json:"wallet_id"`
+	To       int             `json:"to"`
+	Amount   decimal.Decimal `json:"amount"`
+	Rule
+	StartAt        int64  `json:"start_at"`                  //Unix timestamp of the first occurrence or after it
+	EndAt          int64  `json:"end_at,omitempty"`          //no occurrences after it, 0 means no end date
+	MaxOccurrences int    `json:"max_occurrences,omitempty"` //0 means no limit
+	OnFailure      Policy `json:"on_failure"`                //skip or retry, default skip
+	MaxRetries     int    `json:"max_retries,omitempty"`     //retries of the failed occurrence with the retry policy
+	RetryInterval  int64  `json:"retry_interval,omitempty"`  //seconds between the retries, default 1 hour
+	Status         Status `json:"status"`
+	Occurrences    int    `json:"occurrences"`            //finished occurrences, the failed and skipped ones included
+	Retries        int    `json:"retries,omitempty"`      //retries of the pending occurrence
+	NextAt         int64  `json:"next_at,omitempty"`      //time of the pending occurrence
+	RetryAt        int64  `json:"retry_at,omitempty"`     //time of the retry of the pending occurrence
+	ScheduledID    int64  `json:"scheduled_id,omitempty"` //scheduled operation executing the pending occurrence
+	Actor          string `json:"actor"`                  //client who created the payment, it is the actor of the occurrences
+	Created        int64  `json:"created"`
+	Updated        int64  `json:"updated"`
+}
+
+// New validates the payment and sets the defaults, the first occurrence must be in the future
+func New(p Payment, actor string, now int64) (Payment, error) {
+	if p.Interval == 0 && p.Frequency != Cron {
+		p.Interval = 1
+	}
+	if p.OnFailure == "" {
+		p.OnFailure = Skip
+	}
+	if p.OnFailure == Retry && p.RetryInterval == 0 {
+		p.RetryInterval = DefaultRetryInterval
+	}
+
+	if err := p.validate(now); err != nil {
+		return Payment{}, fmt.Errorf("%w: %v", InvalidPaymentErr, err)
+	}
+
+	p.ID, p.Status, p.Occurrences, p.Retries, p.RetryAt, p.ScheduledID = 0, Active, 0, 0, 0, 0
+	p.NextAt = p.next(time.Unix(p.StartAt, 0).Add(-time.Nanosecond))
+	if p.NextAt == 0 {
+		return Payment{}, fmt.Errorf("%w: no occurrences between the start and the end", InvalidPaymentErr)
+	}
+	p.Actor, p.Created, p.Updated = actor, now, now
+	return p, nil
+}
+
+func (p Payment) validate(now int64) error {
+	switch {
+	case p.WalletID <= 0:
+		return errors.New("invalid wallet ID")
+	case p.To <= 0 || p.To == p.WalletID:
+		return errors.New("invalid recipient")
+	case !p.Amount.IsPositive():
+		return errors.New("amount must be positive")
+	case p.StartAt <= now:
+		return errors.New("start time must be in the future")
+	case p.EndAt != 0 && p.EndAt < p.StartAt:
+		return errors.New("end time must be after the start time")
+	case p.MaxOccurrences < 0:
+		return errors.New("max occurrences must not be negative")
+	case p.OnFailure != Skip && p.OnFailure != Retry:
+		return fmt.Errorf("unknown failure policy %q", p.OnFailure)
+	case p.MaxRetries < 0 || p.RetryInterval < 0:
+		return errors.New("retries must not be negative")
+	case p.OnFailure == Skip && (p.MaxRetries != 0 || p.RetryInterval != 0):
+		return errors.New("retries are allowed only with the retry policy")
+	}
+	return p.Rule.Validate()
+}
+
+// next returns the time of the occurrence after the time within the end date, 0 if there is none
+func (p Payment) next(after time.Time) int64 {
+	t := p.Rule.Next(time.Unix(p.StartAt, 0), after)
+	if t.IsZero() || (p.EndAt != 0 && t.Unix() > p.EndAt) {
+		return 0
+	}
+	return t.Unix()
+}
+
+// Upcoming returns the times of up to n next executions, the pending one first
+func (p Payment) Upcoming(n int) []int64 {
+	times := make([]int64, 0)
+	if p.Status != Active || n <= 0 {
+		return times
+	}
+
+	times = append(times, p.ExecuteAt())
+	for at, occurrences := p.NextAt, p.Occurrences+1; len(times) < n; occurrences++ {
+		if p.MaxOccurrences != 0 && occurrences >= p.MaxOccurrences {
+			break
+		}
+		if at = p.next(time.Unix(at, 0)); at == 0 {
+			break
+		}
+		times = append(times, at)
+	}
+	return times
+}
+
+// Advance finishes the pending occurrence and sets the next one. Occurrences missed while the service was stopped
+// are not executed. Returns false if the payment is completed
+func (p *Payment) Advance(now int64) bool {
+	p.Occurrences++
+	p.Retries, p.RetryAt, p.ScheduledID, p.Updated = 0, 0, 0, now
+
+	if p.MaxOccurrences != 0 && p.Occurrences >= p.MaxOccurrences {
+		p.Status, p.NextAt = Completed, 0
+		return false
+	}
+	if p.NextAt = p.next(time.Unix(max(p.NextAt, now), 0)); p.NextAt == 0 {
+		p.Status = Completed
+		return false
+	}
+	return true
+}
+
+// Retry sets the retry of the failed pending occurrence, returns false if it must be skipped
+func (p *Payment) Retry(now int64) bool {
+	if p.OnFailure != Retry || p.Retries >= p.MaxRetries {
+		return false
+	}
+
+	p.Retries++
+	p.RetryAt, p.ScheduledID, p.Updated = now+p.RetryInterval, 0, now
+	return true
+}
+
+// ExecuteAt returns the time of the pending execution
+func (p Payment) ExecuteAt() int64 {
+	if p.RetryAt != 0 {
+		return p.RetryAt
+	}
+	return p.NextAt
+}
@@ -0,0 +1,137 @@
+package recurring
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestRule_Next(t *testing.T) {
+	start := date("2024-01-31 09:00")
+
+	tests := []struct {
+		name  string
+		rule  Rule
+		after time.Time
+		want  time.Time
+	}{
+		{name: "first occurrence is the start", rule: Rule{Frequency: Daily, Interval: 1}, after: start.Add(-time.Hour), want: start},
+		{name: "every 2 days", rule: Rule{Frequency: Daily, Interval: 2}, after: start, want: date("2024-02-02 09:00")},
+		{name: "weekly between occurrences", rule: Rule{Frequency: Weekly, Interval: 1}, after: date("2024-02-03 00:00"), want: date("2024-02-07 09:00")},
+		{name: "monthly on the last day of a shorter month", rule: Rule{Frequency: Monthly, Interval: 1}, after: start, want: date("2024-02-29 09:00")},
+		{name: "monthly keeps the day", rule: Rule{Frequency: Monthly, Interval: 1}, after: date("2024-02-29 09:00"), want: date("2024-03-31 09:00")},
+		{name: "quarterly", rule: Rule{Frequency: Monthly, Interval: 3}, after: start, want: date("2024-04-30 09:00")},
+		{name: "cron on weekdays", rule: Rule{Frequency: Cron, Cron: "30 8 * * 1-5"}, after: date("2024-02-02 09:00"), want: date("2024-02-05 08:30")},
+		{name: "cron not before the start", rule: Rule{Frequency: Cron, Cron: "0 9 1,15 * *"}, after: time.Time{}, want: date("2024-02-01 09:00")},
+		{name: "cron with step", rule: Rule{Frequency: Cron, Cron: "*/20 * * * *"}, after: date("2024-02-01 10:41"), want: date("2024-02-01 11:00")},
+		{name: "cron on Sunday as 7", rule: Rule{Frequency: Cron, Cron: "0 0 * * 7"}, after: start, want: date("2024-02-04 00:00")},
+		{name: "cron that never matches", rule: Rule{Frequency: Cron, Cron: "0 0 30 2 *"}, after: start, want: time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.NoError(t, tt.rule.Validate())
+			assert.Equal(t, tt.want, tt.rule.Next(start, tt.after))
+		})
+	}
+}
+
+func TestRule_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+	}{
+		{name: "unknown frequency", rule: Rule{Frequency: "yearly", Interval: 1}},
+		{name: "zero interval", rule: Rule{Frequency: Daily}},
+		{name: "cron with daily frequency", rule: Rule{Frequency: Daily, Interval: 1, Cron: "* * * * *"}},
+		{name: "too few cron fields", rule: Rule{Frequency: Cron, Cron: "0 9 * *"}},
+		{name: "cron value out of range", rule: Rule{Frequency: Cron, Cron: "0 24 * * *"}},
+		{name: "invalid cron step", rule: Rule{Frequency: Cron, Cron: "*/0 * * * *"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Error(t, tt.rule.Validate())
+		})
+	}
+}
+
+func TestPayment(t *testing.T) {
+	now := date("2024-01-01 00:00").Unix()
+	valid := Payment{WalletID: 1, To: 2, Amount: decimal.NewFromInt(10), Rule: Rule{Frequency: Daily}, StartAt: date("2024-01-02 09:00").Unix()}
+
+	t.Run("validation", func(t *testing.T) {
+		invalid := []func(p *Payment){
+			func(p *Payment) { p.To = p.WalletID },
+			func(p *Payment) { p.Amount = decimal.Zero },
+			func(p *Payment) { p.StartAt = now },
+			func(p *Payment) { p.EndAt = p.StartAt - 1 },
+			func(p *Payment) { p.MaxRetries = 3 },
+			func(p *Payment) { p.OnFailure = "ignore" },
+			func(p *Payment) { p.Rule = Rule{Frequency: Cron, Cron: "0 0 30 2 *"} },
+		}
+		for _, change := range invalid {
+			p := valid
+			change(&p)
+			_, err := New(p, "test", now)
+			assert.ErrorIs(t, err, InvalidPaymentErr)
+		}
+	})
+
+	t.Run("max occurrences", func(t *testing.T) {
+		p := valid
+		p.MaxOccurrences = 2
+		p, err := New(p, "test", now)
+		assert.NoError(t, err)
+		assert.Equal(t, Active, p.Status)
+		assert.Equal(t, 1, p.Interval)
+		assert.Equal(t, []int64{date("2024-01-02 09:00").Unix(), date("2024-01-03 09:00").Unix()}, p.Upcoming(10))
+
+		assert.True(t, p.Advance(p.NextAt))
+		assert.Equal(t, date("2024-01-03 09:00").Unix(), p.NextAt)
+		assert.False(t, p.Advance(p.NextAt))
+		assert.Equal(t, Completed, p.Status)
+		assert.Empty(t, p.Upcoming(10))
+	})
+
+	t.Run("end date and missed occurrences", func(t *testing.T) {
+		p := valid
+		p.EndAt = date("2024-01-05 09:00").Unix()
+		p, err := New(p, "test", now)
+		assert.NoError(t, err)
+		assert.Len(t, p.Upcoming(10), 4)
+
+		assert.True(t, p.Advance(date("2024-01-03 12:00").Unix()), "missed occurrence must not be executed")
+		assert.Equal(t, date("2024-01-04 09:00").Unix(), p.NextAt)
+		assert.True(t, p.Advance(p.NextAt))
+		assert.False(t, p.Advance(p.NextAt))
+		assert.Equal(t, Completed, p.Status)
+	})
+
+	t.Run("retry policy", func(t *testing.T) {
+		p := valid
+		p.OnFailure, p.MaxRetries = Retry, 1
+		p, err := New(p, "test", now)
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultRetryInterval, p.RetryInterval)
+
+		failed := p.NextAt
+		assert.True(t, p.Retry(failed))
+		assert.Equal(t, failed+DefaultRetryInterval, p.ExecuteAt())
+		assert.Equal(t, []int64{failed + DefaultRetryInterval, date("2024-01-03 09:00").Unix()}, p.Upcoming(2))
+		assert.False(t, p.Retry(p.RetryAt), "retries are exhausted")
+
+		assert.True(t, p.Advance(p.RetryAt))
+		assert.Equal(t, 0, p.Retries)
+		assert.Equal(t, date("2024-01-03 09:00").Unix(), p.ExecuteAt())
+	})
+}
@@ -0,0 +1,92 @@
+package recurring
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "daily"
+	Weekly  Frequency = "weekly"
+	Monthly Frequency = "monthly" //on the day of the start date, on the last day of shorter months
+	Cron    Frequency = "cron"
+)
+
+// Rule is the recurrence of the payment, the times are in UTC
+type Rule struct {
+	Frequency Frequency `json:"frequency"`          //daily, weekly, monthly or cron
+	Interval  int       `json:"interval,omitempty"` //every interval days, weeks or months, default 1
+	Cron      string    `json:"cron,omitempty"`     //"minute hour day-of-month month day-of-week", required for the cron frequency
+}
+
+func (r Rule) Validate() error {
+	switch r.Frequency {
+	case Daily, Weekly, Monthly:
+		if r.Interval <= 0 {
+			return errors.New("interval must be positive")
+		}
+		if r.Cron != "" {
+			return fmt.Errorf("cron expression is not allowed for the %s frequency", r.Frequency)
+		}
+	case Cron:
+		if r.Interval != 0 {
+			return errors.New("interval is not allowed for the cron frequency")
+		}
+		if _, err := parseCron(r.Cron); err != nil {
+			return fmt.Errorf("invalid cron expression: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown frequency %q", r.Frequency)
+	}
+	return nil
+}
+
+// Next returns the first occurrence after the time. Occurrences start at start for the daily, weekly and monthly rules,
+// and at the first matching time not before start for the cron rule. Zero time means there are no more occurrences
+func (r Rule) Next(start, after time.Time) time.Time {
+	start, after = start.UTC(), after.UTC()
+
+	if r.Frequency == Cron {
+		c, err := parseCron(r.Cron)
+		if err != nil {
+			return time.Time{}
+		}
+		if after.Before(start) {
+			after = start.Add(-time.Nanosecond)
+		}
+		return c.next(after)
+	}
+
+	if after.Before(start) {
+		return start
+	}
+
+	var k int
+	switch r.Frequency {
+	case Daily, Weekly:
+		period := 24 * time.Hour * time.Duration(r.Interval)
+		if r.Frequency == Weekly {
+			period *= 7
+		}
+		k = int(after.Sub(start)/period) + 1
+		return start.Add(period * time.Duration(k))
+	case Monthly:
+		months := (after.Year()-start.Year())*12 + int(after.Month()-start.Month())
+		for k = months / r.Interval; ; k++ {
+			if t := addMonths(start, k*r.Interval); t.After(after) {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// addMonths keeps the day of the month, it is the last day of the month if the month is shorter
+func addMonths(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+	lastDay := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(t.Day(), lastDay)-1)
+}
@@ -0,0 +1,82 @@
+package risk
+
+import (
+	"errors"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"gopkg.in/yaml.v3"
+	"os"
+	"time"
+)
+
+type fileConfig struct {
+	Rules []ruleConfig `yaml:"rules"`
+}
+
+type ruleConfig struct {
+	Name       string              `yaml:"name"`
+	Type       string              `yaml:"type"`
+	Action     Decision            `yaml:"action"`
+	Amount     decimal.NullDecimal `yaml:"amount"`
+	Multiplier decimal.NullDecimal `yaml:"multiplier"`
+	Count      int                 `yaml:"count"`
+	Window     time.Duration       `yaml:"window"`
+	WalletAge  time.Duration       `yaml:"wallet_age"`
+}
+
+// Load reads the rules from the YAML file
+func Load(path string) (*Engine, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("risk.Load -> %w", err)
+	}
+
+	var cfg fileConfig
+	if err = yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("risk.Load -> %w", err)
+	}
+
+	rules := make([]Rule, 0, len(cfg.Rules))
+	for i, rc := range cfg.Rules {
+		r, err := rc.rule()
+		if err != nil {
+			return nil, fmt.Errorf("risk.Load: rule %d %q -> %w", i, rc.Name, err)
+		}
+		rules = append(rules, r)
+	}
+
+	return NewEngine(rules...), nil
+}
+
+func (rc ruleConfig) rule() (Rule, error) {
+	if rc.Action != ManualReview && rc.Action != Deny {
+		return nil, fmt.Errorf("action must be %s or %s, got %q", ManualReview, Deny, rc.Action)
+	}
+	base := rule{name: rc.Name, action: rc.Action}
+	if base.name == "" {
+		base.name = rc.Type
+	}
+
+	switch rc.Type {
+	case "small_transfers_to_new_wallets":
+		if !rc.Amount.Valid || rc.Count <= 0 || rc.Window <= 0 || rc.WalletAge <= 0 {
+			return nil, errors.New("amount, count, window and wallet_age are required")
+		}
+		return &SmallTransfersToNewWallets{rule: base, MaxAmount: rc.Amount.Decimal, Count: rc.Count, Window: rc.Window, WalletAge: rc.WalletAge}, nil
+	case "large_withdrawal":
+		if !rc.Amount.Valid && !rc.Multiplier.Valid {
+			return nil, errors.New("amount or multiplier is required")
+		}
+		if rc.Multiplier.Valid && rc.Window <= 0 {
+			return nil, errors.New("window is required with multiplier")
+		}
+		return &LargeWithdrawal{rule: base, Amount: rc.Amount, Multiplier: rc.Multiplier, Window: rc.Window}, nil
+	case "ping_pong":
+		if rc.Window <= 0 {
+			return nil, errors.New("window is required")
+		}
+		return &PingPong{rule: base, Window: rc.Window}, nil
+	}
+
+	return nil, fmt.Errorf("unknown rule type %q", rc.Type)
+}
@@ -0,0 +1,45 @@
+package risk
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ReviewResolvedErr = errors.New("review is already resolved")
+	SelfApprovalErr   = errors.New("operation can not be approved by the client who requested it")
+)
+
+type ReviewStatus string
+
+const (
+	Pending  ReviewStatus = "pending"
+	Approved ReviewStatus = "approved"
+	Rejected ReviewStatus = "rejected"
+)
+
+// Review is an operation held until a manual decision
+type Review struct {
+	ID          int64        `json:"id"`
+	Created     int64        `json:"created"`
+	Operation   Operation    `json:"operation"`
+	Description string       `json:"description"`
+	Actor       string       `json:"actor"` //client who requested the operation
+	Hits        []Hit        `json:"hits"`
+	Status      ReviewStatus `json:"status"`
+	ResolvedBy  string       `json:"resolved_by,omitempty"`
+	Resolved    int64        `json:"resolved,omitempty"`
+}
+
+// ReviewRequiredError is returned when the operation was not committed but saved for manual review
+type ReviewRequiredError struct {
+	ReviewID int64
+}
+
+func (e *ReviewRequiredError) Error() string {
+	return fmt.Sprintf("%s: review %d", ReviewRequiredErr, e.ReviewID)
+}
+
+func (e *ReviewRequiredError) Unwrap() error {
+	return ReviewRequiredErr
+}
@@ -0,0 +1,135 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"log/slog"
+	"time"
+)
+
+var (
+	DeniedErr         = errors.New("operation denied by risk rules")
+	ReviewRequiredErr = errors.New("operation is sent to manual review")
+)
+
+// Decision is the result of the rules evaluation
+type Decision string
+
+const (
+	Allow        Decision = "allow"
+	ManualReview Decision = "review"
+	Deny         Decision = "deny"
+)
+
+// weight orders decisions by severity
+func (d Decision) weight() int {
+	switch d {
+	case Deny:
+		return 2
+	case ManualReview:
+		return 1
+	}
+	return 0
+}
+
+// Kind of the pending operation
+type Kind string
+
+const (
+	KindReplenishment Kind = "replenishment"
+	KindWithdrawal    Kind = "withdrawal"
+	KindTransfer      Kind = "transfer"
+)
+
+// Operation is a pending operation to be assessed before committing
+type Operation struct {
+	Kind     Kind            `json:"kind"`
+	WalletID int             `json:"wallet_id"`
+	To       int             `json:"to,omitempty"` //recipient of a transfer
+	Amount   decimal.Decimal `json:"amount"`
+	Time     time.Time       `json:"-"`
+}
+
+// Transfer is an outgoing transfer from the wallet history
+type Transfer struct {
+	To               int
+	Amount           decimal.Decimal
+	Date             int64
+	RecipientCreated int64 //unix time when the recipient wallet was created
+}
+
+// Facts provides the wallet history needed to evaluate the rules
+type Facts interface {
+	TransfersFrom(id int, since int64) ([]Transfer, error)
+	WalletCreatedAt(id int) (int64, bool, error)
+	AverageWithdrawal(id int, since int64) (decimal.Decimal, int, error)
+}
+
+// Rule checks the operation for a suspicious pattern
+type Rule interface {
+	Name() string
+	Action() Decision
+	Hit(op Operation, facts Facts) (bool, error)
+}
+
+type Hit struct {
+	Rule   string   `json:"rule"`
+	Action Decision `json:"action"`
+}
+
+type Result struct {
+	Decision Decision `json:"decision"`
+	Hits     []Hit    `json:"hits"`
+}
+
+type previewKey struct{}
+
+// WithPreview returns a copy of ctx of the operation that is only previewed: its transaction is rolled back, so the
+// rule hits, the metrics and the logs of the executed operation are not written
+func WithPreview(ctx context.Context) context.Context {
+	return context.WithValue(ctx, previewKey{}, true)
+}
+
+// IsPreview reports whether the operation of ctx is only previewed
+func IsPreview(ctx context.Context) bool {
+	preview, _ := ctx.Value(previewKey{}).(bool)
+	return preview
+}
+
+// Engine evaluates every rule against the operation, the most severe action of the hit rules wins
+type Engine struct {
+	rules []Rule
+}
+
+func NewEngine(rules ...Rule) *Engine {
+	return &Engine{rules: rules}
+}
+
+func (e *Engine) Evaluate(ctx context.Context, op Operation, facts Facts) (Result, error) {
+	res := Result{Decision: Allow}
+	if e == nil {
+		return res, nil
+	}
+
+	for _, rule := range e.rules {
+		hit, err := rule.Hit(op, facts)
+		if err != nil {
+			return Result{}, fmt.Errorf("rule %q -> %w", rule.Name(), err)
+		}
+		if !hit {
+			continue
+		}
+
+		if !IsPreview(ctx) {
+			slog.InfoContext(ctx, "risk rule hit", "rule", rule.Name(), "action", rule.Action(), "kind", op.Kind, "wallet_id", op.WalletID, "to", op.To, "amount", op.Amount)
+		}
+		res.Hits = append(res.Hits, Hit{Rule: rule.Name(), Action: rule.Action()})
+		if rule.Action().weight() > res.Decision.weight() {
+			res.Decision = rule.Action()
+		}
+	}
+
+	return res, nil
+}
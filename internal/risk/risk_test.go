@@ -0,0 +1,199 @@
+package risk
+
+import (
+	"context"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var now = time.Unix(1700000000, 0)
+
+type facts struct {
+	transfers map[int][]Transfer
+	created   map[int]int64
+	avg       decimal.Decimal
+	count     int
+}
+
+func (f *facts) TransfersFrom(id int, since int64) ([]Transfer, error) {
+	res := make([]Transfer, 0)
+	for _, t := range f.transfers[id] {
+		if t.Date >= since {
+			res = append(res, t)
+		}
+	}
+	return res, nil
+}
+
+func (f *facts) WalletCreatedAt(id int) (int64, bool, error) {
+	created, ok := f.created[id]
+	return created, ok, nil
+}
+
+func (f *facts) AverageWithdrawal(id int, since int64) (decimal.Decimal, int, error) {
+	return f.avg, f.count, nil
+}
+
+func TestSmallTransfersToNewWallets(t *testing.T) {
+	rule := &SmallTransfersToNewWallets{rule: rule{name: "mules", action: ManualReview}, MaxAmount: decimal.NewFromInt(100), Count: 3, Window: time.Hour, WalletAge: 24 * time.Hour}
+	fresh := now.Add(-time.Hour).Unix()
+	old := now.Add(-100 * time.Hour).Unix()
+
+	f := &facts{
+		created: map[int]int64{2: fresh, 3: fresh, 4: old, 5: fresh},
+		transfers: map[int][]Transfer{
+			1: {
+				{To: 2, Amount: decimal.NewFromInt(50), Date: now.Add(-10 * time.Minute).Unix(), RecipientCreated: fresh},
+				{To: 3, Amount: decimal.NewFromInt(50), Date: now.Add(-5 * time.Minute).Unix(), RecipientCreated: fresh},
+			},
+			6: {
+				{To: 2, Amount: decimal.NewFromInt(50), Date: now.Add(-2 * time.Hour).Unix(), RecipientCreated: fresh},
+				{To: 3, Amount: decimal.NewFromInt(500), Date: now.Add(-5 * time.Minute).Unix(), RecipientCreated: fresh},
+			},
+		},
+	}
+
+	tests := []struct {
+		name string
+		op   Operation
+		want bool
+	}{
+		{name: "third small transfer to new wallets", op: Operation{Kind: KindTransfer, WalletID: 1, To: 5, Amount: decimal.NewFromInt(10), Time: now}, want: true},
+		{name: "transfer to a new wallet that does not exist yet", op: Operation{Kind: KindTransfer, WalletID: 1, To: 99, Amount: decimal.NewFromInt(10), Time: now}, want: true},
+		{name: "recipient is old", op: Operation{Kind: KindTransfer, WalletID: 1, To: 4, Amount: decimal.NewFromInt(10), Time: now}, want: false},
+		{name: "transfer is not small", op: Operation{Kind: KindTransfer, WalletID: 1, To: 5, Amount: decimal.NewFromInt(101), Time: now}, want: false},
+		{name: "previous transfers are out of the window or not small", op: Operation{Kind: KindTransfer, WalletID: 6, To: 5, Amount: decimal.NewFromInt(10), Time: now}, want: false},
+		{name: "not a transfer", op: Operation{Kind: KindWithdrawal, WalletID: 1, Amount: decimal.NewFromInt(10), Time: now}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rule.Hit(tt.op, f)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLargeWithdrawal(t *testing.T) {
+	f := &facts{avg: decimal.NewFromInt(100), count: 10}
+	absolute := &LargeWithdrawal{Amount: decimal.NewNullDecimal(decimal.NewFromInt(10000))}
+	relative := &LargeWithdrawal{Multiplier: decimal.NewNullDecimal(decimal.NewFromInt(5)), Window: 24 * time.Hour}
+
+	tests := []struct {
+		name  string
+		rule  *LargeWithdrawal
+		op    Operation
+		facts *facts
+		want  bool
+	}{
+		{name: "above absolute amount", rule: absolute, op: Operation{Kind: KindWithdrawal, Amount: decimal.NewFromInt(10000)}, facts: f, want: true},
+		{name: "below absolute amount", rule: absolute, op: Operation{Kind: KindTransfer, Amount: decimal.NewFromInt(9999)}, facts: f, want: false},
+		{name: "replenishment", rule: absolute, op: Operation{Kind: KindReplenishment, Amount: decimal.NewFromInt(100000)}, facts: f, want: false},
+		{name: "sudden withdrawal", rule: relative, op: Operation{Kind: KindWithdrawal, Amount: decimal.NewFromInt(501)}, facts: f, want: true},
+		{name: "usual withdrawal", rule: relative, op: Operation{Kind: KindWithdrawal, Amount: decimal.NewFromInt(500)}, facts: f, want: false},
+		{name: "no previous withdrawals", rule: relative, op: Operation{Kind: KindWithdrawal, Amount: decimal.NewFromInt(5000)}, facts: &facts{}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.op.Time = now
+			got, err := tt.rule.Hit(tt.op, tt.facts)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPingPong(t *testing.T) {
+	rule := &PingPong{Window: 10 * time.Minute}
+	f := &facts{transfers: map[int][]Transfer{
+		2: {{To: 1, Amount: decimal.NewFromInt(100), Date: now.Add(-time.Minute).Unix()}},
+		3: {{To: 1, Amount: decimal.NewFromInt(100), Date: now.Add(-time.Hour).Unix()}},
+	}}
+
+	hit, err := rule.Hit(Operation{Kind: KindTransfer, WalletID: 1, To: 2, Amount: decimal.NewFromInt(100), Time: now}, f)
+	assert.NoError(t, err)
+	assert.True(t, hit)
+
+	hit, err = rule.Hit(Operation{Kind: KindTransfer, WalletID: 1, To: 3, Amount: decimal.NewFromInt(100), Time: now}, f)
+	assert.NoError(t, err)
+	assert.False(t, hit)
+}
+
+func TestEngine_Evaluate(t *testing.T) {
+	f := &facts{avg: decimal.NewFromInt(100), count: 1}
+	review := &LargeWithdrawal{rule: rule{name: "large", action: ManualReview}, Amount: decimal.NewNullDecimal(decimal.NewFromInt(1000))}
+	deny := &LargeWithdrawal{rule: rule{name: "huge", action: Deny}, Amount: decimal.NewNullDecimal(decimal.NewFromInt(100000))}
+	e := NewEngine(review, deny)
+
+	res, err := e.Evaluate(context.Background(), Operation{Kind: KindWithdrawal, Amount: decimal.NewFromInt(10), Time: now}, f)
+	assert.NoError(t, err)
+	assert.Equal(t, Allow, res.Decision)
+	assert.Empty(t, res.Hits)
+
+	res, err = e.Evaluate(context.Background(), Operation{Kind: KindWithdrawal, Amount: decimal.NewFromInt(5000), Time: now}, f)
+	assert.NoError(t, err)
+	assert.Equal(t, ManualReview, res.Decision)
+
+	res, err = e.Evaluate(context.Background(), Operation{Kind: KindWithdrawal, Amount: decimal.NewFromInt(200000), Time: now}, f)
+	assert.NoError(t, err)
+	assert.Equal(t, Deny, res.Decision)
+	assert.Equal(t, []Hit{{Rule: "large", Action: ManualReview}, {Rule: "huge", Action: Deny}}, res.Hits)
+
+	var disabled *Engine
+	res, err = disabled.Evaluate(context.Background(), Operation{Kind: KindWithdrawal, Amount: decimal.NewFromInt(200000), Time: now}, f)
+	assert.NoError(t, err)
+	assert.Equal(t, Allow, res.Decision)
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "rules.yaml")
+	os.WriteFile(valid, []byte(`
+rules:
+  - name: money mules
+    type: small_transfers_to_new_wallets
+    action: review
+    amount: 1000
+    count: 5
+    window: 1h
+    wallet_age: 24h
+  - type: large_withdrawal
+    action: deny
+    amount: 500000.50
+  - type: ping_pong
+    action: review
+    window: 10m
+`), 0o600)
+
+	e, err := Load(valid)
+	assert.NoError(t, err)
+	if assert.Len(t, e.rules, 3) {
+		mules := e.rules[0].(*SmallTransfersToNewWallets)
+		assert.Equal(t, "money mules", mules.Name())
+		assert.Equal(t, "1000", mules.MaxAmount.String())
+		assert.Equal(t, time.Hour, mules.Window)
+		assert.Equal(t, "large_withdrawal", e.rules[1].Name())
+		assert.Equal(t, Deny, e.rules[1].Action())
+		assert.Equal(t, 10*time.Minute, e.rules[2].(*PingPong).Window)
+	}
+
+	invalid := []string{
+		"rules:\n  - type: ping_pong\n    action: allow\n    window: 1m\n",
+		"rules:\n  - type: ping_pong\n    action: deny\n",
+		"rules:\n  - type: unknown\n    action: deny\n",
+		"rules:\n  - type: large_withdrawal\n    action: deny\n    multiplier: 3\n",
+	}
+	for i, data := range invalid {
+		path := filepath.Join(dir, "invalid.yaml")
+		os.WriteFile(path, []byte(data), 0o600)
+		_, err = Load(path)
+		assert.Error(t, err, "config %d", i)
+	}
+}
@@ -0,0 +1,119 @@
+package risk
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+type rule struct {
+	name   string
+	action Decision
+}
+
+func (r rule) Name() string {
+	return r.name
+}
+
+func (r rule) Action() Decision {
+	return r.action
+}
+
+// SmallTransfersToNewWallets hits when the wallet makes Count or more transfers not bigger than MaxAmount
+// to wallets created less than WalletAge ago during the Window
+type SmallTransfersToNewWallets struct {
+	rule
+	MaxAmount decimal.Decimal
+	Count     int
+	Window    time.Duration
+	WalletAge time.Duration
+}
+
+func (r *SmallTransfersToNewWallets) Hit(op Operation, facts Facts) (bool, error) {
+	if op.Kind != KindTransfer || op.Amount.GreaterThan(r.MaxAmount) {
+		return false, nil
+	}
+
+	created, exists, err := facts.WalletCreatedAt(op.To)
+	if err != nil {
+		return false, err
+	}
+	if exists && !r.isNew(created, op.Time) {
+		return false, nil
+	}
+
+	transfers, err := facts.TransfersFrom(op.WalletID, op.Time.Add(-r.Window).Unix())
+	if err != nil {
+		return false, err
+	}
+
+	count := 1
+	for _, t := range transfers {
+		if !t.Amount.GreaterThan(r.MaxAmount) && r.isNew(t.RecipientCreated, time.Unix(t.Date, 0)) {
+			count++
+		}
+	}
+
+	return count >= r.Count, nil
+}
+
+func (r *SmallTransfersToNewWallets) isNew(created int64, at time.Time) bool {
+	return at.Sub(time.Unix(created, 0)) < r.WalletAge
+}
+
+// LargeWithdrawal hits when a withdrawal or an outgoing transfer is not less than Amount
+// or exceeds the average withdrawal of the wallet during the Window by Multiplier times
+type LargeWithdrawal struct {
+	rule
+	Amount     decimal.NullDecimal
+	Multiplier decimal.NullDecimal
+	Window     time.Duration
+}
+
+func (r *LargeWithdrawal) Hit(op Operation, facts Facts) (bool, error) {
+	if op.Kind != KindWithdrawal && op.Kind != KindTransfer {
+		return false, nil
+	}
+
+	if r.Amount.Valid && op.Amount.GreaterThanOrEqual(r.Amount.Decimal) {
+		return true, nil
+	}
+
+	if !r.Multiplier.Valid {
+		return false, nil
+	}
+
+	avg, count, err := facts.AverageWithdrawal(op.WalletID, op.Time.Add(-r.Window).Unix())
+	if err != nil {
+		return false, err
+	}
+	if count == 0 {
+		return false, nil
+	}
+
+	return op.Amount.GreaterThan(avg.Mul(r.Multiplier.Decimal)), nil
+}
+
+// PingPong hits when the recipient has transferred money to the sender during the Window
+type PingPong struct {
+	rule
+	Window time.Duration
+}
+
+func (r *PingPong) Hit(op Operation, facts Facts) (bool, error) {
+	if op.Kind != KindTransfer {
+		return false, nil
+	}
+
+	transfers, err := facts.TransfersFrom(op.To, op.Time.Add(-r.Window).Unix())
+	if err != nil {
+		return false, err
+	}
+
+	for _, t := range transfers {
+		if t.To == op.WalletID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,30 @@
+package savings
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+)
+
+type fileConfig struct {
+	Products []Product `yaml:"products"`
+}
+
+// Load reads the savings products from the YAML file
+func Load(path string) (*Products, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("savings.Load -> %w", err)
+	}
+
+	var cfg fileConfig
+	if err = yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("savings.Load -> %w", err)
+	}
+
+	p, err := NewProducts(cfg.Products...)
+	if err != nil {
+		return nil, fmt.Errorf("savings.Load -> %w", err)
+	}
+	return p, nil
+}
@@ -0,0 +1,158 @@
+package savings
+
+import (
+	"errors"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+var InvalidAccountErr = errors.New("invalid savings account")
+
+// Precision is the number of decimal places the daily interest is kept with, the payouts are rounded down to hundredths
+// and the rest is carried to the next month
+const Precision = 12
+
+// MaxRate is the highest annual percent of a product or a wallet, it guards against a typo paying out the whole balance
+var MaxRate = decimal.NewFromInt(100)
+
+// Product is the savings product, its rate is applied to the wallets of the product
+type Product struct {
+	Name string          `json:"name"`
+	Rate decimal.Decimal `json:"rate"` //annual percent
+}
+
+// Products are the savings products by name
+type Products struct {
+	products map[string]Product
+}
+
+// NewProducts validates the products, the names must be unique
+func NewProducts(products ...Product) (*Products, error) {
+	p := &Products{products: make(map[string]Product, len(products))}
+	for i, pr := range products {
+		switch {
+		case pr.Name == "":
+			return nil, fmt.Errorf("product %d: name is required", i)
+		case pr.Rate.IsNegative():
+			return nil, fmt.Errorf("product %q: rate must not be negative", pr.Name)
+		case pr.Rate.GreaterThan(MaxRate):
+			return nil, fmt.Errorf("product %q: rate must not be greater than %s", pr.Name, MaxRate)
+		}
+		if _, ok := p.products[pr.Name]; ok {
+			return nil, fmt.Errorf("product %q is duplicated", pr.Name)
+		}
+		p.products[pr.Name] = pr
+	}
+	return p, nil
+}
+
+// Get returns the product by name, nil products have no products
+func (p *Products) Get(name string) (Product, bool) {
+	if p == nil {
+		return Product{}, false
+	}
+	pr, ok := p.products[name]
+	return pr, ok
+}
+
+// Account is the savings settings of the wallet, the wallet rate overrides the rate of the product
+type Account struct {
+	Product string              `json:"product"`                   //empty value means no product
+	Rate    decimal.NullDecimal `json:"rate" swaggertype:"number"` //annual percent of the wallet, null value means the rate of the product
+	Since   int64               `json:"since"`                     //start of the first day the interest is accrued for, set when the savings are enabled
+}
+
+// Enabled reports whether the interest is accrued for the wallet
+func (a Account) Enabled() bool {
+	return a.Product != "" || a.Rate.Valid
+}
+
+// Validate checks the rate and that the product exists
+func (a Account) Validate(products *Products) error {
+	if a.Rate.Valid && a.Rate.Decimal.IsNegative() {
+		return fmt.Errorf("%w: rate must not be negative", InvalidAccountErr)
+	}
+	if a.Rate.Valid && a.Rate.Decimal.GreaterThan(MaxRate) {
+		return fmt.Errorf("%w: rate must not be greater than %s", InvalidAccountErr, MaxRate)
+	}
+	if _, ok := products.Get(a.Product); a.Product != "" && !ok {
+		return fmt.Errorf("%w: unknown product %q", InvalidAccountErr, a.Product)
+	}
+	return nil
+}
+
+// AnnualRate returns the rate of the wallet or of its product, zero if the product is not found
+func (a Account) AnnualRate(products *Products) decimal.Decimal {
+	if a.Rate.Valid {
+		return a.Rate.Decimal
+	}
+	pr, _ := products.Get(a.Product)
+	return pr.Rate
+}
+
+// Accrual is the interest accrued for the day, it is not posted to the balance until the capitalization
+type Accrual struct {
+	Day     int64           `json:"day"`     //start of the day
+	Balance decimal.Decimal `json:"balance"` //balance at the end of the day
+	Rate    decimal.Decimal `json:"rate"`    //annual percent
+	Amount  decimal.Decimal `json:"amount"`  //kept with Precision decimal places
+}
+
+// Capitalization is the interest posted to the balance at the end of the month
+type Capitalization struct {
+	Month     string          `json:"month"` //YYYY-MM
+	Amount    decimal.Decimal `json:"amount"`
+	HistoryID int64           `json:"history_id"` //history entry of the replenishment
+	Date      int64           `json:"date"`
+}
+
+// Report is the interest of the wallet for the period
+type Report struct {
+	WalletID        int              `json:"wallet_id"`
+	From            int64            `json:"from"`
+	To              int64            `json:"to"`
+	Accrued         decimal.Decimal  `json:"accrued"`     //interest accrued for the days of the period
+	Capitalized     decimal.Decimal  `json:"capitalized"` //interest posted to the balance for the months of the period
+	Pending         decimal.Decimal  `json:"pending"`     //interest accrued and not posted yet, including the previous periods
+	Accruals        []Accrual        `json:"accruals"`
+	Capitalizations []Capitalization `json:"capitalizations"`
+}
+
+// NewReport sums the accruals and the capitalizations of the period, pending is the total accrued minus the total posted
+func NewReport(walletID int, from, to int64, accruals []Accrual, caps []Capitalization, pending decimal.Decimal) Report {
+	r := Report{WalletID: walletID, From: from, To: to, Pending: pending, Accruals: accruals, Capitalizations: caps}
+	for _, a := range accruals {
+		r.Accrued = r.Accrued.Add(a.Amount)
+	}
+	for _, c := range caps {
+		r.Capitalized = r.Capitalized.Add(c.Amount)
+	}
+	return r
+}
+
+// DailyInterest returns the interest of the day for the balance at the end of the day, the annual rate is divided
+// by the number of days in the year of the day. Negative balance is accrued nothing
+func DailyInterest(balance, rate decimal.Decimal, day time.Time) decimal.Decimal {
+	if !balance.IsPositive() || !rate.IsPositive() {
+		return decimal.Zero
+	}
+
+	days := int64(time.Date(day.Year(), 12, 31, 0, 0, 0, 0, time.UTC).YearDay())
+	return balance.Mul(rate).DivRound(decimal.NewFromInt(100*days), Precision)
+}
+
+// Payout returns the interest to post: the accrued interest not posted yet rounded down to hundredths
+func Payout(accrued, capitalized decimal.Decimal) decimal.Decimal {
+	return decimal.Max(accrued.Sub(capitalized).Truncate(2), decimal.Zero)
+}
+
+// MonthEnd reports whether the day is the last day of its month, the interest is capitalized after it
+func MonthEnd(day time.Time) bool {
+	return day.AddDate(0, 0, 1).Day() == 1
+}
+
+// Month returns the month of the day as YYYY-MM
+func Month(day time.Time) string {
+	return day.Format("2006-01")
+}
@@ -0,0 +1,118 @@
+package savings
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func dec(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+func TestDailyInterest(t *testing.T) {
+	common := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	leap := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		balance string
+		rate    string
+		day     time.Time
+		want    string
+	}{
+		{name: "common year", balance: "36500", rate: "10", day: common, want: "10"},
+		{name: "leap year", balance: "36600", rate: "10", day: leap, want: "10"},
+		{name: "kept with precision", balance: "100", rate: "5", day: common, want: "0.013698630137"},
+		{name: "negative balance", balance: "-100", rate: "5", day: common, want: "0"},
+		{name: "no rate", balance: "100", rate: "0", day: common, want: "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, DailyInterest(dec(tt.balance), dec(tt.rate), tt.day).String())
+		})
+	}
+}
+
+func TestPayout(t *testing.T) {
+	//a month of the daily interest of 100 at 5% is paid without the fractions of hundredths, they are carried to the next month
+	daily := DailyInterest(dec("100"), dec("5"), time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	accrued := daily.Mul(decimal.NewFromInt(31))
+	assert.Equal(t, "0.42", Payout(accrued, decimal.Zero).String())
+	assert.Equal(t, "0", Payout(accrued, dec("0.42")).String())
+	assert.Equal(t, "0.42", Payout(accrued.Mul(decimal.NewFromInt(2)), dec("0.42")).String())
+	assert.Equal(t, "0.43", Payout(accrued.Mul(decimal.NewFromInt(3)), dec("0.84")).String(), "carried fractions must be paid")
+	assert.Equal(t, "0", Payout(dec("1"), dec("2")).String())
+}
+
+func TestMonthEnd(t *testing.T) {
+	assert.True(t, MonthEnd(time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)))
+	assert.False(t, MonthEnd(time.Date(2024, 2, 28, 0, 0, 0, 0, time.UTC)))
+	assert.True(t, MonthEnd(time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "2023-12", Month(time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestAccount(t *testing.T) {
+	products, err := NewProducts(Product{Name: "basic", Rate: dec("3.5")})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		account  Account
+		wantErr  bool
+		wantRate string
+	}{
+		{name: "disabled", account: Account{}, wantRate: "0"},
+		{name: "product", account: Account{Product: "basic"}, wantRate: "3.5"},
+		{name: "wallet rate overrides product", account: Account{Product: "basic", Rate: decimal.NewNullDecimal(dec("5"))}, wantRate: "5"},
+		{name: "unknown product", account: Account{Product: "gold"}, wantErr: true},
+		{name: "negative rate", account: Account{Rate: decimal.NewNullDecimal(dec("-1"))}, wantErr: true},
+		{name: "highest rate", account: Account{Rate: decimal.NewNullDecimal(dec("100"))}, wantRate: "100"},
+		{name: "too high rate", account: Account{Rate: decimal.NewNullDecimal(dec("100.01"))}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.account.Validate(products)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, InvalidAccountErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantRate, tt.account.AnnualRate(products).String())
+		})
+	}
+
+	var none *Products
+	assert.ErrorIs(t, Account{Product: "basic"}.Validate(none), InvalidAccountErr, "nil products must have no products")
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "savings.yaml")
+	os.WriteFile(valid, []byte("products:\n  - name: basic\n    rate: 3.5\n  - name: premium\n    rate: 5\n"), 0o600)
+
+	p, err := Load(valid)
+	assert.NoError(t, err)
+	pr, ok := p.Get("premium")
+	assert.True(t, ok)
+	assert.Equal(t, "5", pr.Rate.String())
+
+	invalid := []string{
+		"products:\n  - rate: 3.5\n",
+		"products:\n  - name: basic\n    rate: -1\n",
+		"products:\n  - name: basic\n    rate: 150\n",
+		"products:\n  - name: basic\n    rate: 1\n  - name: basic\n    rate: 2\n",
+	}
+	for i, data := range invalid {
+		path := filepath.Join(dir, "invalid.yaml")
+		os.WriteFile(path, []byte(data), 0o600)
+		_, err = Load(path)
+		assert.Error(t, err, "config %d", i)
+	}
+}
@@ -0,0 +1,56 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/KseniiaSalmina/Balance/internal/batch"
+)
+
+var (
+	InvalidOperationErr = errors.New("invalid scheduled operation")
+	NotScheduledErr     = errors.New("operation is already executed or canceled")
+)
+
+type Status string
+
+const (
+	Scheduled     Status = "scheduled"
+	Completed     Status = "completed"
+	PendingReview Status = "pending_review" //held for manual review at the execution time
+	Failed        Status = "failed"         //rejected at the execution time, e.g. insufficient funds, the reason is in the error
+	Canceled      Status = "canceled"
+)
+
+// Operation is a replenishment, withdrawal or transfer executed once at the execution time
+type Operation struct {
+	ID int64 `json:"id"`
+	batch.Item
+	ExecuteAt   int64  `json:"execute_at"` //Unix timestamp, the operation is executed at or shortly after it
+	Status      Status `json:"status"`
+	ReviewID    int64  `json:"review_id,omitempty"`
+	Error       string `json:"error,omitempty"`
+	RecurringID int64  `json:"recurring_id,omitempty"` //recurring payment the operation is an occurrence of
+	Actor       string `json:"actor"`                  //client who scheduled the operation, it is the actor of the history records
+	Created     int64  `json:"created"`
+	Updated     int64  `json:"updated"`
+}
+
+// New validates and creates the scheduled operation, the execution time must be in the future
+func New(item batch.Item, executeAt int64, actor string, now int64) (Operation, error) {
+	if err := item.Validate(); err != nil {
+		return Operation{}, fmt.Errorf("%w: %v", InvalidOperationErr, err)
+	}
+	if executeAt <= now {
+		return Operation{}, fmt.Errorf("%w: execution time must be in the future", InvalidOperationErr)
+	}
+
+	return Operation{
+		Item:      item,
+		ExecuteAt: executeAt,
+		Status:    Scheduled,
+		Actor:     actor,
+		Created:   now,
+		Updated:   now,
+	}, nil
+}
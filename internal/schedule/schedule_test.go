@@ -0,0 +1,97 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+
+	"github.com/KseniiaSalmina/Balance/internal/batch"
+	"github.com/KseniiaSalmina/Balance/internal/config"
+	"github.com/KseniiaSalmina/Balance/internal/risk"
+)
+
+func TestNew(t *testing.T) {
+	transfer := batch.Item{Type: risk.KindTransfer, WalletID: 1, To: 2, Amount: decimal.NewFromInt(10)}
+
+	tests := []struct {
+		name      string
+		item      batch.Item
+		executeAt int64
+		wantErr   bool
+	}{
+		{name: "future transfer", item: transfer, executeAt: 11},
+		{name: "execution time is now", item: transfer, executeAt: 10, wantErr: true},
+		{name: "transfer to itself", item: batch.Item{Type: risk.KindTransfer, WalletID: 1, To: 1, Amount: decimal.NewFromInt(10)}, executeAt: 11, wantErr: true},
+		{name: "withdrawal without description", item: batch.Item{Type: risk.KindWithdrawal, WalletID: 1, Amount: decimal.NewFromInt(10)}, executeAt: 11, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op, err := New(tt.item, tt.executeAt, "test", 10)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, InvalidOperationErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, Scheduled, op.Status)
+			assert.Equal(t, tt.executeAt, op.ExecuteAt)
+		})
+	}
+}
+
+// fakeStore takes the due operations in order, the taken ones are not returned again
+type fakeStore struct {
+	due   []int64
+	now   time.Time
+	lease time.Time
+}
+
+func (s *fakeStore) ClaimScheduled(now, lease time.Time, limit int) ([]int64, error) {
+	s.now, s.lease = now, lease
+	n := min(limit, len(s.due))
+	ids := s.due[:n]
+	s.due = s.due[n:]
+	return ids, nil
+}
+
+func TestScheduler_tick(t *testing.T) {
+	now := time.Unix(100, 0)
+	store := &fakeStore{due: []int64{1, 2, 3}}
+	var executed []int64
+	s := NewScheduler(store, func(ctx context.Context, id int64) error {
+		executed = append(executed, id)
+		if id == 2 {
+			return errors.New("database is unavailable")
+		}
+		return nil
+	}, config.Scheduler{PollInterval: time.Second, BatchSize: 2, RetryInterval: time.Minute})
+	s.now = func() time.Time { return now }
+
+	taken, n, err := s.tick(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, taken)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []int64{1, 2}, executed, "failed operation must not stop the batch")
+	assert.Equal(t, now, store.now)
+	assert.Equal(t, now.Add(time.Minute), store.lease)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	executed = nil
+	_, n, err = s.tick(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+	assert.Empty(t, executed, "operations must not be executed after shutdown")
+}
+
+func TestScheduler_delay(t *testing.T) {
+	s := NewScheduler(&fakeStore{}, nil, config.Scheduler{PollInterval: time.Second, BatchSize: 2})
+
+	assert.Equal(t, time.Duration(0), s.delay(2, 1), "more operations may be due")
+	assert.Equal(t, time.Second, s.delay(2, 0), "failing batch must not be repeated at once")
+	assert.Equal(t, time.Second, s.delay(1, 1))
+	assert.Equal(t, time.Second, s.delay(0, 0))
+}
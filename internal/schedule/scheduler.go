@@ -0,0 +1,118 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/KseniiaSalmina/Balance/internal/config"
+)
+
+// Store takes the operations to execute
+type Store interface {
+	// ClaimScheduled returns the IDs of up to limit scheduled operations with the execution time before now and postpones them until lease.
+	// The operations kept scheduled are not taken again before the lease ends, so they do not block the operations after them
+	ClaimScheduled(now, lease time.Time, limit int) ([]int64, error)
+}
+
+// StoreFunc is the function taking the due items as Store, e.g. the due subscriptions
+type StoreFunc func(now, lease time.Time, limit int) ([]int64, error)
+
+func (f StoreFunc) ClaimScheduled(now, lease time.Time, limit int) ([]int64, error) {
+	return f(now, lease, limit)
+}
+
+// Executor executes the scheduled operation if it is still scheduled and no other instance is executing it.
+// The error means the operation is kept scheduled and is executed again after the retry interval
+type Executor func(ctx context.Context, id int64) error
+
+// Scheduler executes the due operations. Every operation is executed exactly once: the change of its status
+// is committed in the transaction of the operation
+type Scheduler struct {
+	store   Store
+	execute Executor
+	cfg     config.Scheduler
+	now     func() time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewScheduler(store Store, execute Executor, cfg config.Scheduler) *Scheduler {
+	return &Scheduler{
+		store:   store,
+		execute: execute,
+		cfg:     cfg,
+		now:     time.Now,
+	}
+}
+
+// Run starts executing in the background
+func (s *Scheduler) Run() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel, s.done = cancel, make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+
+		timer := time.NewTimer(0)
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+			}
+
+			taken, executed, err := s.tick(ctx)
+			if err != nil {
+				slog.Error("scheduled operations are not executed", "error", err)
+			}
+			timer.Reset(s.delay(taken, executed))
+		}
+	}()
+}
+
+// Shutdown waits for the operation in progress until ctx is done
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	s.cancel()
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("schedule.Scheduler.Shutdown -> %w", ctx.Err())
+	}
+}
+
+// delay returns the time before the next batch. The next batch is taken at once if more operations may be due
+// and the last one made progress, so the failing operations do not make the scheduler poll without a pause
+func (s *Scheduler) delay(taken, executed int) time.Duration {
+	if taken == s.cfg.BatchSize && executed > 0 {
+		return 0
+	}
+	return s.cfg.PollInterval
+}
+
+// tick executes one batch of the due operations one by one, returns the number of the taken operations
+// and the number of them executed without an error
+func (s *Scheduler) tick(ctx context.Context) (taken int, executed int, err error) {
+	now := s.now()
+	ids, err := s.store.ClaimScheduled(now, now.Add(s.cfg.RetryInterval), s.cfg.BatchSize)
+	if err != nil {
+		return 0, 0, fmt.Errorf("tick -> %w", err)
+	}
+
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			break
+		}
+		//the operation in progress is finished on shutdown, its transaction is not interrupted
+		if err = s.execute(context.WithoutCancel(ctx), id); err != nil {
+			slog.Error("scheduled operation is not executed, it is retried later", "scheduled_id", id, "error", err)
+			continue
+		}
+		executed++
+	}
+	return len(ids), executed, nil
+}
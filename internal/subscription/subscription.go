@@ -0,0 +1,164 @@
+package subscription
+
+import (
+	"errors"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"time"
+
+	"github.com/KseniiaSalmina/Balance/internal/recurring"
+)
+
+var (
+	InvalidPlanErr         = errors.New("invalid plan")
+	InvalidSubscriptionErr = errors.New("invalid subscription")
+	CanceledErr            = errors.New("subscription is already canceled")
+)
+
+// Plan is the price charged from the subscribed wallets at the start of every period
+type Plan struct {
+	ID            int64           `json:"id"`
+	Name          string          `json:"name"`
+	Price         decimal.Decimal `json:"price"`          //positive number
+	Period        recurring.Rule  `json:"period"`         //daily, weekly or monthly with the interval
+	GracePeriod   int64           `json:"grace_period"`   //seconds after the start of the unpaid period until the last charge attempt, the subscription is past due meanwhile
+	RetrySchedule []int64         `json:"retry_schedule"` //seconds after the start of the unpaid period the failed charge is retried at, within the grace period
+	Created       int64           `json:"created"`
+}
+
+type Status string
+
+const (
+	Active    Status = "active"
+	PastDue   Status = "past_due"  //the charge failed, it is retried within the grace period
+	Suspended Status = "suspended" //the charge failed after all the retries, the subscription is not charged anymore
+	Canceled  Status = "canceled"
+)
+
+// Subscription charges the price of the plan from the wallet at the start of every period
+type Subscription struct {
+	ID           int64  `json:"id"`
+	PlanID       int64  `json:"plan_id"`
+	WalletID     int    `json:"wallet_id"`
+	Status       Status `json:"status"`
+	StartAt      int64  `json:"start_at"`                 //start of the first period, the periods are counted from it
+	PeriodStart  int64  `json:"period_start,omitempty"`   //start of the paid period
+	PeriodEnd    int64  `json:"period_end,omitempty"`     //end of the paid period
+	DueAt        int64  `json:"due_at,omitempty"`         //start of the period to charge
+	NextChargeAt int64  `json:"next_charge_at,omitempty"` //time of the next charge attempt
+	Attempts     int    `json:"attempts,omitempty"`       //failed attempts to charge the due period
+	GraceUntil   int64  `json:"grace_until,omitempty"`    //end of the grace period of the past due subscription
+	LastError    string `json:"last_error,omitempty"`     //reason of the last failed attempt
+	Actor        string `json:"actor"`                    //client who created the subscription, it is the actor of the charges
+	Created      int64  `json:"created"`
+	Updated      int64  `json:"updated"`
+}
+
+// NewPlan validates the plan and sets the defaults
+func NewPlan(p Plan, now int64) (Plan, error) {
+	if p.Period.Interval == 0 {
+		p.Period.Interval = 1
+	}
+	if p.RetrySchedule == nil {
+		p.RetrySchedule = []int64{}
+	}
+
+	if err := p.validate(); err != nil {
+		return Plan{}, fmt.Errorf("%w: %v", InvalidPlanErr, err)
+	}
+
+	p.ID, p.Created = 0, now
+	return p, nil
+}
+
+func (p Plan) validate() error {
+	switch {
+	case p.Name == "":
+		return errors.New("required name")
+	case !p.Price.IsPositive():
+		return errors.New("price must be positive")
+	case p.Period.Frequency == recurring.Cron:
+		return errors.New("period must be daily, weekly or monthly")
+	case p.GracePeriod < 0:
+		return errors.New("grace period must not be negative")
+	}
+	if err := p.Period.Validate(); err != nil {
+		return fmt.Errorf("period: %w", err)
+	}
+
+	var prev int64
+	for _, at := range p.RetrySchedule {
+		if at <= prev {
+			return errors.New("retry schedule must be positive and increasing")
+		}
+		if at > p.GracePeriod {
+			return errors.New("retries must be within the grace period")
+		}
+		prev = at
+	}
+	return nil
+}
+
+// New creates the subscription, the first period is charged at startAt, 0 means now
+func New(plan Plan, walletID int, startAt int64, actor string, now int64) (Subscription, error) {
+	if startAt == 0 {
+		startAt = now
+	}
+	switch {
+	case walletID <= 0:
+		return Subscription{}, fmt.Errorf("%w: invalid wallet ID", InvalidSubscriptionErr)
+	case startAt < now:
+		return Subscription{}, fmt.Errorf("%w: start time must not be in the past", InvalidSubscriptionErr)
+	}
+
+	return Subscription{
+		PlanID:       plan.ID,
+		WalletID:     walletID,
+		Status:       Active,
+		StartAt:      startAt,
+		DueAt:        startAt,
+		NextChargeAt: startAt,
+		Actor:        actor,
+		Created:      now,
+		Updated:      now,
+	}, nil
+}
+
+// Charged marks the due period paid, the next period is charged at its start
+func (s *Subscription) Charged(plan Plan, now int64) {
+	s.PeriodStart = s.DueAt
+	s.PeriodEnd = plan.Period.Next(time.Unix(s.StartAt, 0), time.Unix(s.DueAt, 0)).Unix()
+	s.DueAt, s.NextChargeAt = s.PeriodEnd, s.PeriodEnd
+	s.Status, s.Attempts, s.GraceUntil, s.LastError, s.Updated = Active, 0, 0, "", now
+}
+
+// Failed saves the failed attempt and sets the next one by the retry schedule of the plan, the last attempt is at the end
+// of the grace period. Returns false if the attempts are exhausted and the subscription is suspended
+func (s *Subscription) Failed(plan Plan, reason string, now int64) bool {
+	s.Attempts++
+	s.LastError, s.Updated = reason, now
+
+	retries := plan.retries()
+	if s.Attempts > len(retries) {
+		s.Status, s.NextChargeAt, s.GraceUntil = Suspended, 0, 0
+		return false
+	}
+
+	s.Status = PastDue
+	s.GraceUntil = s.DueAt + plan.GracePeriod
+	s.NextChargeAt = max(s.DueAt+retries[s.Attempts-1], now)
+	return true
+}
+
+// retries returns the retry schedule with the last attempt at the end of the grace period
+func (p Plan) retries() []int64 {
+	if p.GracePeriod > 0 && (len(p.RetrySchedule) == 0 || p.RetrySchedule[len(p.RetrySchedule)-1] < p.GracePeriod) {
+		return append(p.RetrySchedule[:len(p.RetrySchedule):len(p.RetrySchedule)], p.GracePeriod)
+	}
+	return p.RetrySchedule
+}
+
+// Chargeable reports whether the charge attempt is due
+func (s Subscription) Chargeable(now int64) bool {
+	return (s.Status == Active || s.Status == PastDue) && s.NextChargeAt != 0 && s.NextChargeAt <= now
+}
@@ -0,0 +1,86 @@
+package subscription
+
+import (
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+
+	"github.com/KseniiaSalmina/Balance/internal/recurring"
+)
+
+const day = int64(24 * time.Hour / time.Second)
+
+func TestNewPlan(t *testing.T) {
+	valid := Plan{Name: "pro", Price: decimal.NewFromInt(100), Period: recurring.Rule{Frequency: recurring.Monthly}, GracePeriod: 3 * day, RetrySchedule: []int64{day}}
+
+	tests := []struct {
+		name    string
+		change  func(p *Plan)
+		wantErr bool
+	}{
+		{name: "valid plan", change: func(p *Plan) {}},
+		{name: "without retries", change: func(p *Plan) { p.RetrySchedule = nil }},
+		{name: "without name", change: func(p *Plan) { p.Name = "" }, wantErr: true},
+		{name: "zero price", change: func(p *Plan) { p.Price = decimal.Zero }, wantErr: true},
+		{name: "cron period", change: func(p *Plan) { p.Period = recurring.Rule{Frequency: recurring.Cron, Cron: "0 0 1 * *"} }, wantErr: true},
+		{name: "unknown frequency", change: func(p *Plan) { p.Period.Frequency = "yearly" }, wantErr: true},
+		{name: "retries not increasing", change: func(p *Plan) { p.RetrySchedule = []int64{day, day} }, wantErr: true},
+		{name: "retry after the grace period", change: func(p *Plan) { p.RetrySchedule = []int64{4 * day} }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := valid
+			tt.change(&p)
+
+			got, err := NewPlan(p, 1)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, InvalidPlanErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 1, got.Period.Interval)
+			assert.NotNil(t, got.RetrySchedule)
+		})
+	}
+}
+
+func TestSubscription_dunning(t *testing.T) {
+	start := time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC).Unix()
+	plan, err := NewPlan(Plan{ID: 1, Name: "pro", Price: decimal.NewFromInt(100), Period: recurring.Rule{Frequency: recurring.Monthly}, GracePeriod: 3 * day, RetrySchedule: []int64{day}}, start)
+	assert.NoError(t, err)
+
+	_, err = New(plan, 1, start-1, "test", start)
+	assert.ErrorIs(t, err, InvalidSubscriptionErr)
+
+	s, err := New(plan, 1, 0, "test", start)
+	assert.NoError(t, err)
+	assert.True(t, s.Chargeable(start))
+
+	s.Charged(plan, start)
+	assert.Equal(t, start, s.PeriodStart)
+	feb29 := time.Date(2024, 2, 29, 9, 0, 0, 0, time.UTC).Unix()
+	assert.Equal(t, feb29, s.PeriodEnd, "monthly period must end on the last day of a shorter month")
+	assert.False(t, s.Chargeable(feb29-1))
+	assert.True(t, s.Chargeable(feb29))
+
+	assert.True(t, s.Failed(plan, "insufficient funds", feb29))
+	assert.Equal(t, PastDue, s.Status)
+	assert.Equal(t, feb29+day, s.NextChargeAt, "first retry is by the retry schedule")
+	assert.Equal(t, feb29+3*day, s.GraceUntil)
+
+	assert.True(t, s.Failed(plan, "insufficient funds", feb29+day))
+	assert.Equal(t, feb29+3*day, s.NextChargeAt, "last attempt is at the end of the grace period")
+
+	assert.False(t, s.Failed(plan, "insufficient funds", feb29+3*day))
+	assert.Equal(t, Suspended, s.Status)
+	assert.Equal(t, 3, s.Attempts)
+	assert.False(t, s.Chargeable(feb29+4*day))
+
+	s = Subscription{Status: PastDue, StartAt: start, DueAt: start, NextChargeAt: start + day, Attempts: 1}
+	s.Charged(plan, start+day)
+	assert.Equal(t, Active, s.Status, "paid subscription must be active again")
+	assert.Zero(t, s.Attempts)
+	assert.Equal(t, feb29, s.NextChargeAt)
+}
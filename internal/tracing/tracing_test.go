@@ -0,0 +1,101 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/KseniiaSalmina/Balance/internal/config"
+)
+
+func TestInit(t *testing.T) {
+	var received atomic.Int32
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/traces" {
+			received.Add(1)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+
+	tests := []struct {
+		name    string
+		cfg     config.Tracing
+		wantErr bool
+		spans   int32
+	}{
+		{name: "none", cfg: config.Tracing{Exporter: "none"}},
+		{name: "otlp", cfg: config.Tracing{Exporter: "otlp", OTLPEndpoint: collector.URL + "/v1/traces", ServiceName: "balance", SampleRatio: 1}, spans: 1},
+		{name: "unknown exporter", cfg: config.Tracing{Exporter: "jaeger"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received.Store(0)
+			shutdown, err := Init(tt.cfg)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			_, span := Start(context.Background(), "test")
+			span.End()
+
+			assert.NoError(t, shutdown(context.Background()))
+			assert.Equal(t, tt.spans, received.Load())
+		})
+	}
+}
+
+func TestEnd(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	_, span := Start(context.Background(), "ok")
+	var err error
+	End(span, &err)
+
+	_, span = Start(context.Background(), "failed")
+	err = errors.New("boom")
+	End(span, &err)
+
+	ended := recorder.Ended()
+	assert.Len(t, ended, 2)
+	assert.Equal(t, codes.Unset, ended[0].Status().Code)
+	assert.Equal(t, codes.Error, ended[1].Status().Code)
+	assert.Equal(t, "boom", ended[1].Status().Description)
+}
+
+func TestMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	_, err := Init(config.Tracing{Exporter: "none"})
+	assert.NoError(t, err)
+
+	router := mux.NewRouter()
+	router.Use(Middleware)
+	router.Path("/wallets/{id}/balance").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/wallets/1/balance", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	ended := recorder.Ended()
+	assert.Len(t, ended, 1)
+	assert.Equal(t, "GET /wallets/{id}/balance", ended[0].Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", ended[0].SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", ended[0].Parent().SpanID().String())
+	assert.Equal(t, codes.Error, ended[0].Status().Code)
+}
@@ -0,0 +1,11 @@
+package wallet
+
+import "github.com/shopspring/decimal"
+
+// Preview is the would-be result of the operation, nothing is changed by it
+type Preview struct {
+	Balance        decimal.Decimal `json:"balance"`                   //balance of the wallet after the operation and the fee
+	Fee            decimal.Decimal `json:"fee"`                       //withdrawn in addition to the amount
+	FeeRule        string          `json:"fee_rule,omitempty"`        //rule that set the fee
+	ReviewRequired bool            `json:"review_required,omitempty"` //operation would be held for manual review instead of being executed
+}
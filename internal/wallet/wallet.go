@@ -9,38 +9,53 @@ import (
 var InsufficientFundsErr = errors.New("insufficient funds")
 
 type Wallet struct {
-	ID      int
-	Balance decimal.Decimal
-	History []HistoryChange
+	ID          int
+	Balance     decimal.Decimal
+	CreditLimit decimal.Decimal //withdrawals are allowed down to -CreditLimit
+	History     []HistoryChange
 }
 
 type HistoryChange struct {
 	Date int64
 	Operation
-	Amount      decimal.Decimal
-	Description string
+	Amount       decimal.Decimal
+	Description  string
+	Actor        string //client who triggered the operation
+	Counterparty int    //other wallet of a transfer, 0 for replenishment and withdrawal
 }
 
-//Operation can be replenishment or withdrawal
+//Operation can be replenishment, withdrawal, fee or overdraft interest
 type Operation string
 
 const (
-	Replenishment Operation = "replenishment"
-	Withdrawal    Operation = "withdrawal"
+	Replenishment     Operation = "replenishment"
+	Withdrawal        Operation = "withdrawal"
+	Fee               Operation = "fee"                //withdrawal of the fee for an operation, not counted in the limits and risk rules
+	OverdraftInterest Operation = "overdraft_interest" //charge of the interest for the negative balance, not counted in the limits and risk rules
 )
 
 func (w *Wallet) StringBalance() string {
 	return w.Balance.String()
 }
 
+// AvailableCredit returns the part of the credit limit that is not used
+func (w *Wallet) AvailableCredit() decimal.Decimal {
+	return decimal.Max(decimal.Min(w.CreditLimit, w.CreditLimit.Add(w.Balance)), decimal.Zero)
+}
+
+// Available returns the amount that can be withdrawn including the credit
+func (w *Wallet) Available() decimal.Decimal {
+	return w.Balance.Add(w.CreditLimit)
+}
+
 func (w *Wallet) ChangeBalance(amount decimal.Decimal, opt Operation) error {
 	switch opt {
 	case Replenishment:
 		w.Balance = w.Balance.Add(amount)
 		return nil
-	case Withdrawal:
+	case Withdrawal, Fee:
 		test := w.Balance.Sub(amount)
-		if test.GreaterThanOrEqual(decimal.Zero) {
+		if test.GreaterThanOrEqual(w.CreditLimit.Neg()) {
 			w.Balance = test
 			return nil
 		}
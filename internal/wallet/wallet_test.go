@@ -47,6 +47,7 @@ func TestWallet_ChangeBalance(t *testing.T) {
 	tests := []struct {
 		name            string
 		balance         decimal.Decimal
+		creditLimit     decimal.Decimal
 		args            args
 		wantErr         bool
 		expectedErr     error
@@ -55,11 +56,15 @@ func TestWallet_ChangeBalance(t *testing.T) {
 		{name: "insufficient funds", balance: balance2, args: args{amount: amount1, opt: Withdrawal}, wantErr: true, expectedErr: InsufficientFundsErr, expectedBalance: balance2},
 		{name: "successful withdrawal", balance: balance1, args: args{amount: amount2, opt: Withdrawal}, wantErr: false, expectedBalance: balance2},
 		{name: "successful replenishment", balance: balance2, args: args{amount: amount2, opt: Replenishment}, wantErr: false, expectedBalance: balance1},
+		{name: "withdrawal within credit limit", balance: balance2, creditLimit: amount1, args: args{amount: amount1, opt: Withdrawal}, wantErr: false, expectedBalance: decimal.NewFromInt(-7)},
+		{name: "fee", balance: balance1, args: args{amount: amount2, opt: Fee}, wantErr: false, expectedBalance: balance2},
+		{name: "fee beyond balance", balance: balance2, args: args{amount: amount1, opt: Fee}, wantErr: true, expectedErr: InsufficientFundsErr, expectedBalance: balance2},
+		{name: "withdrawal beyond credit limit", balance: balance2, creditLimit: decimal.NewFromInt(5), args: args{amount: amount1, opt: Withdrawal}, wantErr: true, expectedErr: InsufficientFundsErr, expectedBalance: balance2},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			w := &Wallet{Balance: tt.balance}
+			w := &Wallet{Balance: tt.balance, CreditLimit: tt.creditLimit}
 			err := w.ChangeBalance(tt.args.amount, tt.args.opt)
 
 			if tt.wantErr {
@@ -100,3 +105,27 @@ func TestWallet_StringBalance(t *testing.T) {
 		})
 	}
 }
+
+func TestWallet_Available(t *testing.T) {
+	tests := []struct {
+		name                string
+		balance             int64
+		creditLimit         int64
+		wantAvailable       int64
+		wantAvailableCredit int64
+	}{
+		{name: "without credit", balance: 300, wantAvailable: 300, wantAvailableCredit: 0},
+		{name: "positive balance", balance: 300, creditLimit: 100, wantAvailable: 400, wantAvailableCredit: 100},
+		{name: "credit in use", balance: -30, creditLimit: 100, wantAvailable: 70, wantAvailableCredit: 70},
+		{name: "credit exceeded by interest", balance: -101, creditLimit: 100, wantAvailable: -1, wantAvailableCredit: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &Wallet{Balance: decimal.NewFromInt(tt.balance), CreditLimit: decimal.NewFromInt(tt.creditLimit)}
+
+			assert.Equal(t, decimal.NewFromInt(tt.wantAvailable).String(), w.Available().String())
+			assert.Equal(t, decimal.NewFromInt(tt.wantAvailableCredit).String(), w.AvailableCredit().String())
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/KseniiaSalmina/Balance/internal/config"
+	"github.com/KseniiaSalmina/Balance/internal/metrics"
+)
+
+const (
+	idHeader        = "X-Webhook-ID"
+	typeHeader      = "X-Webhook-Event"
+	timestampHeader = "X-Webhook-Timestamp"
+	signatureHeader = "X-Webhook-Signature"
+)
+
+// Store keeps the deliveries in the outbox
+type Store interface {
+	// ClaimDeliveries returns up to limit pending deliveries due at now and postpones them until lease,
+	// so the other instances do not send them at the same time
+	ClaimDeliveries(now, lease time.Time, limit int) ([]Delivery, error)
+	SaveDelivery(d Delivery) error
+}
+
+// Dispatcher sends the deliveries from the outbox. A delivery is sent at least once: if the instance stops
+// while sending, it is sent again after the lease expires
+type Dispatcher struct {
+	store  Store
+	cfg    config.Webhooks
+	client *http.Client
+	now    func() time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewDispatcher(store Store, cfg config.Webhooks) *Dispatcher {
+	return &Dispatcher{
+		store: store,
+		cfg:   cfg,
+		client: &http.Client{
+			Transport: publicTransport(),
+			Timeout:   cfg.Timeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		now: time.Now,
+	}
+}
+
+// publicTransport connects only to the public addresses, the check is done after the host name is resolved,
+// so a name pointing to the private network is rejected as well
+func publicTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+				return PrivateTargetErr
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+// Run starts sending in the background
+func (d *Dispatcher) Run() {
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel, d.done = cancel, make(chan struct{})
+
+	go func() {
+		defer close(d.done)
+
+		timer := time.NewTimer(0)
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+			}
+
+			n, err := d.dispatch()
+			if err != nil {
+				slog.Error("webhook deliveries are not sent", "error", err)
+			}
+			if n == d.cfg.BatchSize {
+				timer.Reset(0)
+			} else {
+				timer.Reset(d.cfg.PollInterval)
+			}
+		}
+	}()
+}
+
+// Shutdown waits for the deliveries in progress until ctx is done
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	d.cancel()
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("webhook.Dispatcher.Shutdown -> %w", ctx.Err())
+	}
+}
+
+// dispatch sends one batch of the due deliveries, returns the number of them
+func (d *Dispatcher) dispatch() (int, error) {
+	now := d.now()
+	deliveries, err := d.store.ClaimDeliveries(now, now.Add(2*d.cfg.Timeout), d.cfg.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("dispatch -> %w", err)
+	}
+
+	var wg sync.WaitGroup
+	for _, del := range deliveries {
+		wg.Add(1)
+		go func(del Delivery) {
+			defer wg.Done()
+
+			del = d.deliver(del)
+			if err := d.store.SaveDelivery(del); err != nil {
+				slog.Error("webhook delivery result is not saved", "delivery_id", del.ID, "error", err)
+			}
+		}(del)
+	}
+	wg.Wait()
+
+	return len(deliveries), nil
+}
+
+// deliver sends the delivery and returns it with the result of the attempt
+func (d *Dispatcher) deliver(del Delivery) Delivery {
+	del.Attempts++
+	err := d.send(del)
+
+	now := d.now()
+	del.Updated = now.Unix()
+	switch {
+	case err == nil:
+		del.Status, del.LastError = Delivered, ""
+		metrics.WebhookDelivery(string(Delivered))
+	case del.Attempts >= d.cfg.MaxAttempts:
+		del.Status, del.LastError = Dead, err.Error()
+		metrics.WebhookDelivery(string(Dead))
+		slog.Warn("webhook delivery moved to the dead letters", "delivery_id", del.ID, "webhook_id", del.WebhookID, "attempts", del.Attempts, "error", err)
+	default:
+		del.Status, del.LastError = Pending, err.Error()
+		del.NextAttempt = now.Add(d.backoff(del.Attempts)).Unix()
+		metrics.WebhookDelivery("failed")
+	}
+	return del
+}
+
+func (d *Dispatcher) send(del Delivery) error {
+	body, err := json.Marshal(del.Event)
+	if err != nil {
+		return fmt.Errorf("send -> %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, del.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("send -> %w", err)
+	}
+	timestamp := d.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(idHeader, strconv.FormatInt(del.Event.ID, 10))
+	req.Header.Set(typeHeader, del.Event.Type)
+	req.Header.Set(timestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(signatureHeader, Sign(del.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send -> %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// backoff returns the delay before the next attempt: WEBHOOK_RETRY_MIN doubled with every failed attempt up to WEBHOOK_RETRY_MAX
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.cfg.RetryMin
+	for i := 1; i < attempts && delay < d.cfg.RetryMax; i++ {
+		delay *= 2
+	}
+	if delay > d.cfg.RetryMax {
+		delay = d.cfg.RetryMax
+	}
+	return delay
+}
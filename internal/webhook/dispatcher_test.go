@@ -0,0 +1,162 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/KseniiaSalmina/Balance/internal/config"
+)
+
+type fakeStore struct {
+	mu      sync.Mutex
+	pending []Delivery
+	saved   []Delivery
+}
+
+func (s *fakeStore) ClaimDeliveries(now, lease time.Time, limit int) ([]Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	claimed := s.pending
+	s.pending = nil
+	return claimed, nil
+}
+
+func (s *fakeStore) SaveDelivery(d Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.saved = append(s.saved, d)
+	return nil
+}
+
+var testConfig = config.Webhooks{PollInterval: time.Second, Timeout: time.Second, MaxAttempts: 3, RetryMin: 10 * time.Second, RetryMax: 30 * time.Second, BatchSize: 10}
+
+// newLocalDispatcher creates the dispatcher that can send to the test servers on the loopback address
+func newLocalDispatcher(store Store) *Dispatcher {
+	d := NewDispatcher(store, testConfig)
+	d.client.Transport = http.DefaultTransport
+	return d
+}
+
+func TestDispatcher_deliver(t *testing.T) {
+	var status int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		timestamp, err := strconv.ParseInt(r.Header.Get(timestampHeader), 10, 64)
+		assert.NoError(t, err)
+		assert.Equal(t, Sign("secret", timestamp, body), r.Header.Get(signatureHeader))
+		assert.Equal(t, "7", r.Header.Get(idHeader))
+		assert.Equal(t, BalanceChanged, r.Header.Get(typeHeader))
+
+		var e Event
+		assert.NoError(t, json.Unmarshal(body, &e))
+		assert.JSONEq(t, `{"wallet_id":1}`, string(e.Data))
+
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	now := time.Unix(1000, 0)
+	d := newLocalDispatcher(&fakeStore{})
+	d.now = func() time.Time { return now }
+
+	tests := []struct {
+		name            string
+		status          int
+		attempts        int
+		wantStatus      Status
+		wantNextAttempt int64
+	}{
+		{name: "delivered", status: http.StatusNoContent, wantStatus: Delivered},
+		{name: "first retry", status: http.StatusInternalServerError, wantStatus: Pending, wantNextAttempt: 1010},
+		{name: "retry delay is doubled", status: http.StatusInternalServerError, attempts: 1, wantStatus: Pending, wantNextAttempt: 1020},
+		{name: "redirect is a failure", status: http.StatusFound, attempts: 1, wantStatus: Pending, wantNextAttempt: 1020},
+		{name: "dead after the last attempt", status: http.StatusBadRequest, attempts: 2, wantStatus: Dead},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status = tt.status
+			del := Delivery{ID: 1, Event: Event{ID: 7, Type: BalanceChanged, Data: json.RawMessage(`{"wallet_id":1}`)}, Attempts: tt.attempts, URL: srv.URL, Secret: "secret"}
+
+			got := d.deliver(del)
+			assert.Equal(t, tt.wantStatus, got.Status)
+			assert.Equal(t, tt.attempts+1, got.Attempts)
+			if tt.wantStatus == Pending {
+				assert.Equal(t, tt.wantNextAttempt, got.NextAttempt)
+			}
+			if tt.wantStatus == Delivered {
+				assert.Empty(t, got.LastError)
+			} else {
+				assert.Contains(t, got.LastError, strconv.Itoa(tt.status))
+			}
+		})
+	}
+}
+
+func TestDispatcher_privateTarget(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request to the loopback address must not be sent")
+	}))
+	defer srv.Close()
+
+	d := NewDispatcher(&fakeStore{}, testConfig)
+	got := d.deliver(Delivery{ID: 1, URL: srv.URL, Secret: "secret"})
+	assert.Equal(t, Pending, got.Status)
+	assert.Contains(t, got.LastError, PrivateTargetErr.Error())
+}
+
+func TestDispatcher_backoff(t *testing.T) {
+	d := NewDispatcher(&fakeStore{}, testConfig)
+	assert.Equal(t, 10*time.Second, d.backoff(1))
+	assert.Equal(t, 20*time.Second, d.backoff(2))
+	assert.Equal(t, 30*time.Second, d.backoff(3))
+	assert.Equal(t, 30*time.Second, d.backoff(100))
+}
+
+func TestDispatcher_Run(t *testing.T) {
+	delivered := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered <- struct{}{}
+	}))
+	defer srv.Close()
+
+	store := &fakeStore{pending: []Delivery{{ID: 1, URL: srv.URL, Secret: "secret"}}}
+	d := newLocalDispatcher(store)
+	d.Run()
+
+	select {
+	case <-delivered:
+	case <-time.After(time.Second):
+		t.Fatal("delivery was not sent")
+	}
+	assert.NoError(t, d.Shutdown(context.Background()))
+
+	assert.Len(t, store.saved, 1)
+	assert.Equal(t, Delivered, store.saved[0].Status)
+}
+
+func TestNewWebhook(t *testing.T) {
+	w, err := NewWebhook("https://example.com/hook", 1)
+	assert.NoError(t, err)
+	assert.Len(t, w.Secret, 64)
+
+	for _, url := range []string{"", "example.com/hook", "ftp://example.com", "https://"} {
+		_, err = NewWebhook(url, 1)
+		assert.ErrorIs(t, err, InvalidURLErr, url)
+	}
+
+	for _, url := range []string{"http://localhost:8080/hook", "http://127.0.0.1/hook", "http://10.0.0.1/hook", "http://192.168.1.1/hook",
+		"http://169.254.169.254/latest/meta-data", "http://[::1]/hook", "http://[fd00::1]/hook", "http://0.0.0.0/hook", "http://[::ffff:127.0.0.1]/hook"} {
+		_, err = NewWebhook(url, 1)
+		assert.ErrorIs(t, err, PrivateTargetErr, url)
+	}
+}
@@ -0,0 +1,99 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var (
+	InvalidURLErr    = errors.New("webhook URL must be an absolute http or https URL")
+	PrivateTargetErr = errors.New("webhook URL must not point to a private, loopback or link-local address")
+)
+
+// BalanceChanged is the type of the event sent for every committed change of a balance, its data is notify.Event
+const BalanceChanged = "balance.changed"
+
+// Webhook is a subscription of a downstream system to the events
+type Webhook struct {
+	ID      int64  `json:"id"`
+	URL     string `json:"url"`
+	Secret  string `json:"secret,omitempty"` //key of the signature, returned only on creation
+	Created int64  `json:"created"`
+}
+
+type Status string
+
+const (
+	Pending   Status = "pending"
+	Delivered Status = "delivered"
+	Dead      Status = "dead" //all attempts failed, the delivery is sent again only on replay
+)
+
+// Delivery is an event queued for a webhook
+type Delivery struct {
+	ID          int64  `json:"id"`
+	WebhookID   int64  `json:"webhook_id"`
+	Event       Event  `json:"event"`
+	Status      Status `json:"status"`
+	Attempts    int    `json:"attempts"`
+	NextAttempt int64  `json:"next_attempt"`
+	LastError   string `json:"last_error,omitempty"`
+	Updated     int64  `json:"updated"`
+
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+// Event is the body of the webhook request, ID is unique, so the receiver can skip the repeated deliveries
+type Event struct {
+	ID      int64           `json:"id"`
+	Type    string          `json:"type"`
+	Created int64           `json:"created"`
+	Data    json.RawMessage `json:"data" swaggertype:"object"`
+}
+
+func NewWebhook(rawURL string, created int64) (Webhook, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Webhook{}, InvalidURLErr
+	}
+	//the host names are resolved again when the delivery is sent, see Dispatcher
+	host := u.Hostname()
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return Webhook{}, PrivateTargetErr
+	}
+	if ip := net.ParseIP(host); ip != nil && !publicIP(ip) {
+		return Webhook{}, PrivateTargetErr
+	}
+
+	secret := make([]byte, 32)
+	if _, err = rand.Read(secret); err != nil {
+		return Webhook{}, err
+	}
+
+	return Webhook{URL: u.String(), Secret: hex.EncodeToString(secret), Created: created}, nil
+}
+
+// Sign returns the value of the signature header: HMAC-SHA256 of the timestamp and the body joined with a dot
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// publicIP reports whether the address is outside of the private network of the service, the webhooks are set by
+// the clients, so they must not be able to send requests to the internal services
+func publicIP(ip net.IP) bool {
+	return !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsUnspecified()
+}
@@ -1,35 +1,63 @@
 package main
 
 import (
-	"github.com/caarlos0/env/v6"
-	"github.com/joho/godotenv"
-	"log"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
 
 	_ "github.com/KseniiaSalmina/Balance/docs"
 	app "github.com/KseniiaSalmina/Balance/internal"
 	"github.com/KseniiaSalmina/Balance/internal/config"
 )
 
-var (
-	cfg config.Application
-)
-
-func init() {
-	_ = godotenv.Load(".env")
-	if err := env.Parse(&cfg); err != nil {
-		panic(err)
-	}
-}
-
 // @title Balance management API
 // @version 1.0.0
 // @description API to manage users balances
 // @host localhost:8088
 // @BasePath /
 func main() {
-	application, err := app.NewApplication(cfg)
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "optional YAML or TOML config file, environment variables override its values")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [-config file] [config print]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	loader := config.Loader{EnvFile: ".env", ConfigFile: *configFile}
+
+	switch args := flag.Args(); {
+	case len(args) == 0:
+	case len(args) == 2 && args[0] == "config" && args[1] == "print":
+		os.Exit(printConfig(loader))
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	application, err := app.NewApplication(loader)
 	if err != nil {
-		log.Fatal(err)
+		slog.Error("application start failed", "error", err)
+		os.Exit(1)
+	}
+	if err = application.Run(); err != nil {
+		slog.Error("application stopped with error", "error", err)
+		os.Exit(1)
+	}
+}
+
+// printConfig prints the effective configuration with redacted secrets and its validation errors, returns the exit code
+func printConfig(loader config.Loader) int {
+	cfg, err := loader.Parse()
+	if printErr := cfg.Print(os.Stdout); printErr != nil {
+		fmt.Fprintln(os.Stderr, printErr)
+		return 1
+	}
+
+	if err = errors.Join(err, cfg.Validate()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
 	}
-	application.Run()
+	return 0
 }
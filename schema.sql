@@ -16,4 +16,248 @@ CREATE TABLE IF NOT EXISTS history (
     FOREIGN KEY (wallet_id) REFERENCES balances(id)
 );
 
-CREATE INDEX IF NOT EXISTS wallet_id_history_idx ON history(wallet_id);
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS wallet_id_history_idx ON history(wallet_id);
+ALTER TABLE balances ADD COLUMN IF NOT EXISTS "history_hash" TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE history ADD COLUMN IF NOT EXISTS "actor" TEXT NOT NULL DEFAULT '';
+ALTER TABLE history ADD COLUMN IF NOT EXISTS "prev_hash" TEXT NOT NULL DEFAULT '';
+ALTER TABLE history ADD COLUMN IF NOT EXISTS "hash" TEXT NOT NULL DEFAULT '';
+
+CREATE OR REPLACE FUNCTION history_immutable() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'history records cannot be changed or deleted';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS history_immutable_trg ON history;
+CREATE TRIGGER history_immutable_trg BEFORE UPDATE OR DELETE ON history
+    FOR EACH ROW EXECUTE FUNCTION history_immutable();
+
+CREATE TABLE IF NOT EXISTS rate_limits (
+    "key" TEXT PRIMARY KEY,
+    "tokens" DOUBLE PRECISION NOT NULL DEFAULT 0,
+    "updated_at" BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS wallet_limits (
+    "wallet_id" INT PRIMARY KEY,
+    "max_withdrawal" DECIMAL,
+    "max_transfer" DECIMAL,
+    "daily_withdrawal" DECIMAL,
+    "monthly_withdrawal" DECIMAL,
+    FOREIGN KEY (wallet_id) REFERENCES balances(id)
+);
+
+CREATE INDEX IF NOT EXISTS wallet_id_date_history_idx ON history(wallet_id, date);
+
+ALTER TABLE balances ADD COLUMN IF NOT EXISTS "created_at" BIGINT NOT NULL DEFAULT EXTRACT(EPOCH FROM NOW())::BIGINT;
+
+ALTER TABLE history ADD COLUMN IF NOT EXISTS "counterparty" INT;
+
+CREATE INDEX IF NOT EXISTS counterparty_history_idx ON history(counterparty) WHERE counterparty IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS risk_reviews (
+    "id" BIGSERIAL PRIMARY KEY,
+    "created_at" BIGINT NOT NULL,
+    "kind" TEXT NOT NULL,
+    "wallet_id" INT NOT NULL,
+    "to_wallet" INT NOT NULL DEFAULT 0,
+    "amount" DECIMAL NOT NULL,
+    "description" TEXT NOT NULL,
+    "actor" TEXT NOT NULL,
+    "hits" TEXT NOT NULL,
+    "status" TEXT NOT NULL,
+    "resolved_by" TEXT NOT NULL DEFAULT '',
+    "resolved_at" BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS status_risk_reviews_idx ON risk_reviews(status);
+
+CREATE TABLE IF NOT EXISTS outbox (
+    "id" BIGSERIAL PRIMARY KEY,
+    "type" TEXT NOT NULL,
+    "wallet_id" INT NOT NULL,
+    "data" TEXT NOT NULL,
+    "created_at" BIGINT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    "id" BIGSERIAL PRIMARY KEY,
+    "url" TEXT NOT NULL,
+    "secret" TEXT NOT NULL,
+    "created_at" BIGINT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    "id" BIGSERIAL PRIMARY KEY,
+    "webhook_id" BIGINT NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    "event_id" BIGINT NOT NULL REFERENCES outbox(id),
+    "status" TEXT NOT NULL DEFAULT 'pending',
+    "attempts" INT NOT NULL DEFAULT 0,
+    "next_attempt_at" BIGINT NOT NULL,
+    "last_error" TEXT NOT NULL DEFAULT '',
+    "updated_at" BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS pending_webhook_deliveries_idx ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS webhook_id_webhook_deliveries_idx ON webhook_deliveries(webhook_id, status);
+
+CREATE TABLE IF NOT EXISTS jobs (
+    "id" BIGSERIAL PRIMARY KEY,
+    "type" TEXT NOT NULL,
+    "status" TEXT NOT NULL,
+    "payload" TEXT NOT NULL,
+    "attempts" INT NOT NULL DEFAULT 0,
+    "max_attempts" INT NOT NULL,
+    "run_at" BIGINT NOT NULL,
+    "locked_until" BIGINT NOT NULL DEFAULT 0,
+    "cancel_requested" BOOLEAN NOT NULL DEFAULT FALSE,
+    "last_error" TEXT NOT NULL DEFAULT '',
+    "artifact_type" TEXT NOT NULL DEFAULT '',
+    "artifact" BYTEA,
+    "actor" TEXT NOT NULL,
+    "created_at" BIGINT NOT NULL,
+    "updated_at" BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS queued_jobs_idx ON jobs(run_at) WHERE status = 'queued';
+CREATE INDEX IF NOT EXISTS running_jobs_idx ON jobs(locked_until) WHERE status = 'running';
+DROP INDEX IF EXISTS status_jobs_idx;
+DROP INDEX IF EXISTS actor_jobs_idx;
+CREATE INDEX IF NOT EXISTS client_jobs_idx ON jobs(split_part(actor, '/', 1), status, id);
+
+CREATE TABLE IF NOT EXISTS scheduled_operations (
+    "id" BIGSERIAL PRIMARY KEY,
+    "kind" TEXT NOT NULL,
+    "wallet_id" INT NOT NULL,
+    "to_wallet" INT NOT NULL DEFAULT 0,
+    "amount" DECIMAL NOT NULL,
+    "description" TEXT NOT NULL,
+    "execute_at" BIGINT NOT NULL,
+    "status" TEXT NOT NULL,
+    "review_id" BIGINT NOT NULL DEFAULT 0,
+    "error" TEXT NOT NULL DEFAULT '',
+    "actor" TEXT NOT NULL,
+    "created_at" BIGINT NOT NULL,
+    "updated_at" BIGINT NOT NULL
+);
+
+ALTER TABLE scheduled_operations ADD COLUMN IF NOT EXISTS "recurring_id" BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE scheduled_operations ADD COLUMN IF NOT EXISTS "claimed_until" BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS due_scheduled_operations_idx ON scheduled_operations(execute_at) WHERE status = 'scheduled';
+CREATE INDEX IF NOT EXISTS wallet_id_scheduled_operations_idx ON scheduled_operations(wallet_id, status);
+
+CREATE INDEX IF NOT EXISTS recurring_id_scheduled_operations_idx ON scheduled_operations(recurring_id) WHERE recurring_id <> 0;
+
+CREATE TABLE IF NOT EXISTS recurring_payments (
+    "id" BIGSERIAL PRIMARY KEY,
+    "wallet_id" INT NOT NULL,
+    "to_wallet" INT NOT NULL,
+    "amount" DECIMAL NOT NULL,
+    "frequency" TEXT NOT NULL,
+    "interval_count" INT NOT NULL DEFAULT 0,
+    "cron" TEXT NOT NULL DEFAULT '',
+    "start_at" BIGINT NOT NULL,
+    "end_at" BIGINT NOT NULL DEFAULT 0,
+    "max_occurrences" INT NOT NULL DEFAULT 0,
+    "on_failure" TEXT NOT NULL,
+    "max_retries" INT NOT NULL DEFAULT 0,
+    "retry_interval" BIGINT NOT NULL DEFAULT 0,
+    "status" TEXT NOT NULL,
+    "occurrences" INT NOT NULL DEFAULT 0,
+    "retries" INT NOT NULL DEFAULT 0,
+    "next_at" BIGINT NOT NULL DEFAULT 0,
+    "retry_at" BIGINT NOT NULL DEFAULT 0,
+    "scheduled_id" BIGINT NOT NULL DEFAULT 0,
+    "actor" TEXT NOT NULL,
+    "created_at" BIGINT NOT NULL,
+    "updated_at" BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS wallet_id_recurring_payments_idx ON recurring_payments(wallet_id, status);
+
+CREATE TABLE IF NOT EXISTS plans (
+    "id" BIGSERIAL PRIMARY KEY,
+    "name" TEXT NOT NULL,
+    "price" DECIMAL NOT NULL,
+    "frequency" TEXT NOT NULL,
+    "interval_count" INT NOT NULL,
+    "grace_period" BIGINT NOT NULL DEFAULT 0,
+    "retry_schedule" TEXT NOT NULL DEFAULT '[]',
+    "created_at" BIGINT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS subscriptions (
+    "id" BIGSERIAL PRIMARY KEY,
+    "plan_id" BIGINT NOT NULL REFERENCES plans(id),
+    "wallet_id" INT NOT NULL,
+    "status" TEXT NOT NULL,
+    "start_at" BIGINT NOT NULL,
+    "period_start" BIGINT NOT NULL DEFAULT 0,
+    "period_end" BIGINT NOT NULL DEFAULT 0,
+    "due_at" BIGINT NOT NULL,
+    "next_charge_at" BIGINT NOT NULL DEFAULT 0,
+    "attempts" INT NOT NULL DEFAULT 0,
+    "grace_until" BIGINT NOT NULL DEFAULT 0,
+    "last_error" TEXT NOT NULL DEFAULT '',
+    "actor" TEXT NOT NULL,
+    "created_at" BIGINT NOT NULL,
+    "updated_at" BIGINT NOT NULL
+);
+
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS "claimed_until" BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS due_subscriptions_idx ON subscriptions(next_charge_at) WHERE status IN ('active', 'past_due');
+CREATE INDEX IF NOT EXISTS wallet_id_subscriptions_idx ON subscriptions(wallet_id, status);
+
+ALTER TABLE balances ADD COLUMN IF NOT EXISTS "tier" TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE balances ADD COLUMN IF NOT EXISTS "credit_limit" DECIMAL NOT NULL DEFAULT 0;
+ALTER TABLE balances ADD COLUMN IF NOT EXISTS "overdraft_rate" DECIMAL NOT NULL DEFAULT 0;
+ALTER TABLE balances ADD COLUMN IF NOT EXISTS "overdraft_claimed_until" BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE balances ADD COLUMN IF NOT EXISTS "overdraft_since" BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS overdraft_charges (
+    "wallet_id" INT NOT NULL,
+    "day" BIGINT NOT NULL,
+    "balance" DECIMAL NOT NULL,
+    "amount" DECIMAL NOT NULL,
+    "created_at" BIGINT NOT NULL,
+    PRIMARY KEY (wallet_id, day),
+    FOREIGN KEY (wallet_id) REFERENCES balances(id)
+);
+
+ALTER TABLE balances ADD COLUMN IF NOT EXISTS "savings_product" TEXT NOT NULL DEFAULT '';
+ALTER TABLE balances ADD COLUMN IF NOT EXISTS "savings_rate" DECIMAL;
+ALTER TABLE balances ADD COLUMN IF NOT EXISTS "savings_since" BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE balances ADD COLUMN IF NOT EXISTS "interest_claimed_until" BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS interest_accruals (
+    "wallet_id" INT NOT NULL,
+    "day" BIGINT NOT NULL,
+    "balance" DECIMAL NOT NULL,
+    "rate" DECIMAL NOT NULL,
+    "amount" DECIMAL NOT NULL,
+    "created_at" BIGINT NOT NULL,
+    PRIMARY KEY (wallet_id, day),
+    FOREIGN KEY (wallet_id) REFERENCES balances(id)
+);
+
+CREATE TABLE IF NOT EXISTS interest_capitalizations (
+    "wallet_id" INT NOT NULL,
+    "month" TEXT NOT NULL,
+    "amount" DECIMAL NOT NULL,
+    "history_id" BIGINT NOT NULL,
+    "created_at" BIGINT NOT NULL,
+    PRIMARY KEY (wallet_id, month),
+    FOREIGN KEY (wallet_id) REFERENCES balances(id)
+);
+
+CREATE TABLE IF NOT EXISTS schema_version (
+    "id" BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    "version" INT NOT NULL
+);
+
+-- must be equal to database.SchemaVersion, increase both when the schema changes
+INSERT INTO schema_version (version) VALUES (14) ON CONFLICT (id) DO UPDATE SET version = EXCLUDED.version;